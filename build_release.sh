@@ -10,6 +10,7 @@ set -euo pipefail
 #   GOOS/GOARCH    - optional target (defaults to host go env)
 #   CGO_ENABLED    - set to 0 only if using a pure-Go SQLite driver; sqlite here requires cgo
 #   CC             - set when cross-compiling with cgo (e.g., x86_64-linux-gnu-gcc)
+#   GO_TAGS        - Go build tags (default: sqlite_fts5, enables SQLite full-text search)
 
 ROOT_DIR="$(cd -- "$(dirname "$0")" && pwd)"
 WEB_DIR="$ROOT_DIR/web"
@@ -21,6 +22,7 @@ VITE_API_URL=${VITE_API_URL:-"/api"}
 TARGET_OS=${GOOS:-$(go env GOOS)}
 TARGET_ARCH=${GOARCH:-$(go env GOARCH)}
 HOST_OS=$(go env GOOS)
+GO_TAGS=${GO_TAGS-"sqlite_fts5"}
 
 echo "[1/4] prepare embedded frontend directory: $UI_DIR"
 rm -rf "$UI_DIR"
@@ -44,6 +46,6 @@ fi
 GOOS="$TARGET_OS" \
 GOARCH="$TARGET_ARCH" \
 CGO_ENABLED="${CGO_ENABLED:-1}" \
-go build -o "$OUTPUT_DIR/$OUTPUT_NAME" .
+go build -tags "$GO_TAGS" -o "$OUTPUT_DIR/$OUTPUT_NAME" .
 
 echo "done: $OUTPUT_DIR/$OUTPUT_NAME"
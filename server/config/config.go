@@ -3,6 +3,10 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -11,15 +15,79 @@ type Config struct {
 	JWTSecret   string
 	UploadDir   string
 	AllowOrigin string
+
+	// UploadTempDir 暂存分片上传的数据，UploadSessionTTL 之后未完成的会话会被清理。
+	UploadTempDir    string
+	UploadSessionTTL time.Duration
+
+	// TrashRetention 为回收站保留时长，软删除超过该时长的文件与目录由后台任务永久清理。
+	TrashRetention time.Duration
+
+	// DefaultQuotaBytes / DefaultQuotaFiles 为未单独设置配额的用户提供默认上限，0 表示不限制。
+	DefaultQuotaBytes int64
+	DefaultQuotaFiles int64
+
+	// UploadAllowedTypes / UploadDeniedTypes 为上传类型的白名单与黑名单，支持 image/* 形式的通配；白名单为空表示不限制。
+	UploadAllowedTypes []string
+	UploadDeniedTypes  []string
+
+	// ScanDriver 选择上传内容的恶意软件扫描器：空或 none 表示不扫描，clamav 通过 clamd 协议连接 ClamdAddress。
+	// ClamdAddress 支持 tcp://host:port、host:port 与 unix:/path/clamd.sock；ScanTimeout 为单次读写的超时。
+	ScanDriver   string
+	ClamdAddress string
+	ScanTimeout  time.Duration
+
+	// ShareNeverExpire 控制谁可以创建永不过期的分享：disabled（默认，任何人都不可以）、admin 或 all。
+	// ShareMaxLifetime 为分享有效期上限，自分享创建时起算（修改有效期不会重新计时），0 表示不限制；设置后不允许永不过期。
+	ShareNeverExpire string
+	ShareMaxLifetime time.Duration
+
+	// StorageDriver 选择文件内容的存储后端：local（默认，写入 UploadDir）或 s3。
+	StorageDriver string
+	S3Endpoint    string
+	S3Region      string
+	S3Bucket      string
+	S3AccessKey   string
+	S3SecretKey   string
+	S3Prefix      string
+	S3PathStyle   bool
 }
 
 func Load() *Config {
+	uploadDir := getenv("UPLOAD_DIR", "uploads")
 	return &Config{
 		Port:        getenv("PORT", "8080"),
 		DBPath:      getenv("DB_PATH", "data/app.db"),
 		JWTSecret:   getenv("JWT_SECRET", "replace-me"),
-		UploadDir:   getenv("UPLOAD_DIR", "uploads"),
+		UploadDir:   uploadDir,
 		AllowOrigin: getenv("ALLOW_ORIGIN", "*"),
+
+		UploadTempDir:    getenv("UPLOAD_TEMP_DIR", filepath.Join(uploadDir, ".sessions")),
+		UploadSessionTTL: getduration("UPLOAD_SESSION_TTL", 24*time.Hour),
+
+		TrashRetention: getduration("TRASH_RETENTION", 30*24*time.Hour),
+
+		DefaultQuotaBytes: getint64("DEFAULT_QUOTA_BYTES", 0),
+		DefaultQuotaFiles: getint64("DEFAULT_QUOTA_FILES", 0),
+
+		UploadAllowedTypes: getlist("UPLOAD_ALLOWED_TYPES"),
+		UploadDeniedTypes:  getlist("UPLOAD_DENIED_TYPES"),
+
+		ScanDriver:   getenv("SCAN_DRIVER", ""),
+		ClamdAddress: getenv("CLAMD_ADDRESS", "tcp://127.0.0.1:3310"),
+		ScanTimeout:  getduration("SCAN_TIMEOUT", time.Minute),
+
+		ShareNeverExpire: strings.ToLower(getenv("SHARE_NEVER_EXPIRE", "disabled")),
+		ShareMaxLifetime: getduration("SHARE_MAX_LIFETIME", 0),
+
+		StorageDriver: getenv("STORAGE_DRIVER", "local"),
+		S3Endpoint:    getenv("S3_ENDPOINT", ""),
+		S3Region:      getenv("S3_REGION", "us-east-1"),
+		S3Bucket:      getenv("S3_BUCKET", ""),
+		S3AccessKey:   getenv("S3_ACCESS_KEY", ""),
+		S3SecretKey:   getenv("S3_SECRET_KEY", ""),
+		S3Prefix:      getenv("S3_PREFIX", ""),
+		S3PathStyle:   getbool("S3_PATH_STYLE", true),
 	}
 }
 
@@ -27,9 +95,70 @@ func (c *Config) Addr() string {
 	return fmt.Sprintf(":%s", c.Port)
 }
 
+// ScanEnabled 判断是否配置了恶意软件扫描；启用后新上传的内容在扫描通过前不可下载。
+func (c *Config) ScanEnabled() bool {
+	d := strings.ToLower(strings.TrimSpace(c.ScanDriver))
+	return d != "" && d != "none"
+}
+
+// NeverExpireAllowed 判断当前用户能否创建永不过期的分享。
+func (c *Config) NeverExpireAllowed(isAdmin bool) bool {
+	if c.ShareMaxLifetime > 0 {
+		return false
+	}
+	switch c.ShareNeverExpire {
+	case "all":
+		return true
+	case "admin":
+		return isAdmin
+	default:
+		return false
+	}
+}
+
 func getenv(key, def string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
 	}
 	return def
 }
+
+func getbool(key string, def bool) bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(key))) {
+	case "1", "true", "yes", "on":
+		return true
+	case "0", "false", "no", "off":
+		return false
+	default:
+		return def
+	}
+}
+
+func getduration(key string, def time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+	}
+	return def
+}
+
+func getint64(key string, def int64) int64 {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n >= 0 {
+			return n
+		}
+	}
+	return def
+}
+
+// getlist 读取逗号分隔的列表，去除空白并转为小写。
+func getlist(key string) []string {
+	var out []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"fmt"
+	"log"
 	"os"
 
 	"content-hub/server/config"
@@ -20,10 +21,14 @@ func Connect(cfg *config.Config) (*gorm.DB, error) {
 		return nil, err
 	}
 
-	if err := db.AutoMigrate(&models.User{}, &models.File{}, &models.Share{}, &models.APIKey{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.File{}, &models.Share{}, &models.APIKey{}, &models.UploadSession{}, &models.Blob{}, &models.Folder{}, &models.FileAccess{}, &models.FileVersion{}, &models.FileText{}, &models.Tag{}, &models.FileTag{}, &models.FilePreview{}, &models.UserGroup{}, &models.UserGroupMember{}, &models.ShareRecipient{}, &models.ShareAccess{}, &models.ShareItem{}, &models.FileDrop{}, &models.RetiredShareAlias{}); err != nil {
 		return nil, fmt.Errorf("auto migrate: %w", err)
 	}
 
+	if !models.SetupFullTextIndex(db) {
+		log.Printf("SQLite FTS5 unavailable (build with -tags sqlite_fts5), full-text search falls back to LIKE")
+	}
+
 	models.SeedAdmin(db)
 
 	return db, nil
@@ -29,7 +29,64 @@ const docTemplate = `{
                     "admin"
                 ],
                 "summary": "API Key 列表",
-                "responses": {}
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "每页数量，默认 50，最大 200",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "上一页返回的 next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段：created_at / name",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "asc 或 desc，默认 desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否返回过滤后的总数",
+                        "name": "include_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "归属用户ID",
+                        "name": "bound_user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否已撤销",
+                        "name": "revoked",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "名称包含的关键字",
+                        "name": "q",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.APIKeyListResponse"
+                        }
+                    }
+                }
             },
             "post": {
                 "security": [
@@ -87,8 +144,8 @@ const docTemplate = `{
                 "responses": {}
             }
         },
-        "/admin/shares": {
-            "get": {
+        "/admin/files/{id}/release": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
@@ -100,32 +157,21 @@ const docTemplate = `{
                 "tags": [
                     "admin"
                 ],
-                "summary": "分享列表（管理员）",
-                "responses": {}
-            }
-        },
-        "/admin/shares/cleanup": {
-            "post": {
-                "security": [
+                "summary": "手动放行文件",
+                "parameters": [
                     {
-                        "BearerAuth": []
+                        "type": "integer",
+                        "description": "文件ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "admin"
-                ],
-                "summary": "清理失效分享",
                 "responses": {}
             }
         },
-        "/admin/shares/{token}": {
-            "delete": {
+        "/admin/files/{id}/rescan": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
@@ -137,12 +183,12 @@ const docTemplate = `{
                 "tags": [
                     "admin"
                 ],
-                "summary": "撤销分享",
+                "summary": "重新扫描文件",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "分享 Token",
-                        "name": "token",
+                        "type": "integer",
+                        "description": "文件ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
@@ -150,22 +196,7 @@ const docTemplate = `{
                 "responses": {}
             }
         },
-        "/admin/users": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "admin"
-                ],
-                "summary": "用户列表",
-                "responses": {}
-            },
+        "/admin/groups": {
             "post": {
                 "security": [
                     {
@@ -181,22 +212,22 @@ const docTemplate = `{
                 "tags": [
                     "admin"
                 ],
-                "summary": "创建用户",
+                "summary": "创建用户组",
                 "parameters": [
                     {
-                        "description": "用户信息",
+                        "description": "用户组",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.CreateUserRequest"
+                            "$ref": "#/definitions/handlers.createGroupRequest"
                         }
                     }
                 ],
                 "responses": {}
             }
         },
-        "/admin/users/{id}": {
+        "/admin/groups/{id}": {
             "delete": {
                 "security": [
                     {
@@ -209,21 +240,19 @@ const docTemplate = `{
                 "tags": [
                     "admin"
                 ],
-                "summary": "删除用户",
+                "summary": "删除用户组",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "用户ID",
+                        "description": "用户组ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {}
-            }
-        },
-        "/admin/users/{id}/reset-password": {
-            "post": {
+            },
+            "patch": {
                 "security": [
                     {
                         "BearerAuth": []
@@ -238,68 +267,109 @@ const docTemplate = `{
                 "tags": [
                     "admin"
                 ],
-                "summary": "重置用户密码",
+                "summary": "修改用户组",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "用户ID",
+                        "description": "用户组ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "可选自定义密码",
+                        "description": "修改内容",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.ResetPasswordRequest"
+                            "$ref": "#/definitions/handlers.updateGroupRequest"
                         }
                     }
                 ],
                 "responses": {}
             }
         },
-        "/admin/users/{id}/role": {
-            "patch": {
+        "/admin/shares": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "consumes": [
-                    "application/json"
-                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "admin"
                 ],
-                "summary": "更新用户角色",
+                "summary": "分享列表（管理员）",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "用户ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "每页数量，默认 50，最大 200",
+                        "name": "limit",
+                        "in": "query"
                     },
                     {
-                        "description": "角色信息",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "上一页返回的 next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段：created_at / view_count",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "asc 或 desc，默认 desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否返回过滤后的总数",
+                        "name": "include_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "创建者用户名",
+                        "name": "creator",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "文件ID",
+                        "name": "file_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "active / pending / expired / exhausted",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.UpdateUserRoleRequest"
+                            "$ref": "#/definitions/handlers.ShareListResponse"
                         }
                     }
-                ],
-                "responses": {}
+                }
             }
         },
-        "/apikeys/verify": {
+        "/admin/shares/cleanup": {
             "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
                 "consumes": [
                     "application/json"
                 ],
@@ -307,30 +377,40 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "apikey"
+                    "admin"
                 ],
-                "summary": "校验 API Key 有效性",
+                "summary": "清理失效分享",
+                "responses": {}
+            }
+        },
+        "/admin/shares/{token}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "撤销分享",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "明文 API Key",
-                        "name": "X-API-Key",
-                        "in": "header"
-                    },
-                    {
-                        "description": "可选：api_key 与 scope，若未提供则默认 files:upload",
-                        "name": "payload",
-                        "in": "body",
-                        "schema": {
-                            "$ref": "#/definitions/handlers.verifyAPIKeyRequest"
-                        }
+                        "description": "分享 Token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {}
             }
         },
-        "/files": {
-            "get": {
+        "/admin/trash/purge": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
@@ -340,54 +420,79 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "files"
+                    "admin"
                 ],
-                "summary": "获取文件列表",
+                "summary": "立即清理过期回收站条目",
                 "responses": {}
-            },
-            "post": {
+            }
+        },
+        "/admin/users": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
-                    },
-                    {
-                        "ApiKeyAuth": []
                     }
                 ],
-                "consumes": [
-                    "multipart/form-data"
-                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "files"
+                    "admin"
                 ],
-                "summary": "上传文件或文字",
+                "summary": "用户列表",
                 "parameters": [
                     {
-                        "type": "file",
-                        "description": "上传文件",
-                        "name": "file",
-                        "in": "formData"
+                        "type": "integer",
+                        "description": "每页数量，默认 50，最大 200",
+                        "name": "limit",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "纯文本内容",
-                        "name": "text",
-                        "in": "formData"
+                        "description": "上一页返回的 next_cursor",
+                        "name": "cursor",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "描述",
-                        "name": "description",
-                        "in": "formData"
+                        "description": "排序字段：created_at / username",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "asc 或 desc，默认 desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否返回过滤后的总数",
+                        "name": "include_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "admin 或 user",
+                        "name": "role",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "用户名包含的关键字",
+                        "name": "q",
+                        "in": "query"
                     }
                 ],
-                "responses": {}
-            }
-        },
-        "/files/{id}/share": {
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UserListResponse"
+                        }
+                    }
+                }
+            },
             "post": {
                 "security": [
                     {
@@ -401,32 +506,56 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "shares"
+                    "admin"
                 ],
-                "summary": "创建分享链接",
+                "summary": "创建用户",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "文件ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "分享配置",
+                        "description": "用户信息",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.shareRequest"
+                            "$ref": "#/definitions/handlers.CreateUserRequest"
                         }
                     }
                 ],
                 "responses": {}
             }
         },
-        "/login": {
-            "post": {
+        "/admin/users/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "删除用户",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/admin/users/{id}/quota": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
                 "consumes": [
                     "application/json"
                 ],
@@ -434,50 +563,138 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "admin"
                 ],
-                "summary": "用户登录",
+                "summary": "设置用户配额",
                 "parameters": [
                     {
-                        "description": "登录参数",
+                        "type": "integer",
+                        "description": "用户ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "配额，null 表示使用默认值，0 表示不限制",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.LoginRequest"
+                            "$ref": "#/definitions/handlers.UpdateUserQuotaRequest"
                         }
                     }
                 ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                "responses": {}
+            }
+        },
+        "/admin/users/{id}/reset-password": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "重置用户密码",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "可选自定义密码",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.ResetPasswordRequest"
                         }
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/admin/users/{id}/role": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "更新用户角色",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    {
+                        "description": "角色信息",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/handlers.UpdateUserRoleRequest"
                         }
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/apikeys/verify": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "apikey"
+                ],
+                "summary": "校验 API Key 有效性",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "明文 API Key",
+                        "name": "X-API-Key",
+                        "in": "header"
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    {
+                        "description": "可选：api_key 与 scope，若未提供则默认 files:upload",
+                        "name": "payload",
+                        "in": "body",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/handlers.verifyAPIKeyRequest"
                         }
                     }
-                }
+                ],
+                "responses": {}
             }
         },
-        "/shares/{token}": {
+        "/drops": {
             "get": {
                 "security": [
                     {
@@ -488,39 +705,124 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "shares"
+                    "drops"
                 ],
-                "summary": "获取分享元信息",
+                "summary": "我的上传链接",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "每页数量，默认 50，最大 200",
+                        "name": "limit",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
-                        "description": "分享 Token",
+                        "description": "上一页返回的 next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段：created_at / upload_count",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "asc 或 desc，默认 desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否返回过滤后的总数",
+                        "name": "include_total",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FileDropListResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "drops"
+                ],
+                "summary": "创建上传链接",
+                "parameters": [
+                    {
+                        "description": "上传链接参数",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.createDropRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.fileDropItem"
+                        }
+                    }
+                }
+            }
+        },
+        "/drops/{token}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "drops"
+                ],
+                "summary": "获取上传链接信息",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "上传链接 Token",
                         "name": "token",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {}
-            }
-        },
-        "/shares/{token}/stream": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
                 "produces": [
-                    "application/octet-stream"
+                    "application/json"
                 ],
                 "tags": [
-                    "shares"
+                    "drops"
                 ],
-                "summary": "预览/下载分享内容",
+                "summary": "撤销上传链接",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "分享 Token",
+                        "description": "上传链接 Token",
                         "name": "token",
                         "in": "path",
                         "required": true
@@ -528,40 +830,2454 @@ const docTemplate = `{
                 ],
                 "responses": {}
             }
-        }
-    },
-    "definitions": {
-        "handlers.CreateUserRequest": {
-            "type": "object",
-            "required": [
-                "password",
-                "role",
-                "username"
-            ],
-            "properties": {
-                "password": {
-                    "type": "string"
+        },
+        "/drops/{token}/files": {
+            "post": {
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "drops"
+                ],
+                "summary": "通过上传链接上传文件",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "上传链接 Token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "文件",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "说明",
+                        "name": "description",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/files": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "获取文件列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "每页数量，默认 50，最大 200",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "上一页返回的 next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段：created_at / updated_at / filename / size",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "asc 或 desc，默认 desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否返回过滤后的总数",
+                        "name": "include_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "上传者用户名",
+                        "name": "owner",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "MIME 类型，如 text/plain 或 image/*",
+                        "name": "mime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "所在目录ID，0 表示根目录",
+                        "name": "folder_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "private / users / public",
+                        "name": "visibility",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "来源上传链接ID",
+                        "name": "drop_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "标签名，可重复，需同时满足",
+                        "name": "tag",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FileListResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "上传文件或文字",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "上传文件",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "纯文本内容",
+                        "name": "text",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "描述",
+                        "name": "description",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "目标目录ID，省略表示根目录",
+                        "name": "folder_id",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/files/search": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "搜索文件",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "关键词：匹配文件名、描述及文本内容（全文检索）",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "文件名包含",
+                        "name": "filename",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "描述包含",
+                        "name": "description",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "MIME 类型，如 text/plain 或 image/*",
+                        "name": "mime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "上传者用户名",
+                        "name": "owner",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "标签名，可重复，需同时满足",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "最小字节数",
+                        "name": "min_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "最大字节数",
+                        "name": "max_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "创建时间起（RFC 3339 或 YYYY-MM-DD）",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "创建时间止（RFC 3339 或 YYYY-MM-DD，按日期时包含当天）",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，默认 50，最大 200",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "上一页返回的 next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段：created_at / updated_at / filename / size",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "asc 或 desc，默认 desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否返回过滤后的总数",
+                        "name": "include_total",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FileListResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/{id}": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "重命名/移动文件",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "文件ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "新文件名、描述或目标目录（0 表示根目录）",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.updateFileRequest"
+                        }
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/files/{id}/share": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shares"
+                ],
+                "summary": "创建分享链接",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "文件ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "分享配置",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.shareRequest"
+                        }
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/files/{id}/share-analytics": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "文件分享访问统计",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "文件ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "按日统计的天数，默认 30，最大 365",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "最近访问明细条数，默认 20，最大 200",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ShareAnalytics"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/{id}/tags": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "添加文件标签",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "文件ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "标签名列表",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AddFileTagsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FileResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/{id}/tags/{tag}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "移除文件标签",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "文件ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "标签名",
+                        "name": "tag",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FileResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/files/{id}/thumbnail": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "图片返回 image/jpeg 缩略图（最长边 320px），文本返回 text/plain 摘录；后台生成中返回 202，不支持预览的类型返回 404。",
+                "produces": [
+                    "image/jpeg",
+                    "text/plain"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "获取文件缩略图/预览",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "文件ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/files/{id}/versions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "版本列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "文件ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "上传新版本",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "文件ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "新版本文件",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "新版本文字内容",
+                        "name": "text",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/files/{id}/versions/{version}/download": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "下载指定版本",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "文件ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "版本号",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/files/{id}/versions/{version}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "恢复历史版本",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "文件ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "版本号",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/files/{id}/visibility": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "files"
+                ],
+                "summary": "设置文件可见性",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "文件ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "可见性与授权用户",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.updateVisibilityRequest"
+                        }
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/folders": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "folders"
+                ],
+                "summary": "浏览目录",
+                "responses": {}
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "folders"
+                ],
+                "summary": "创建目录",
+                "parameters": [
+                    {
+                        "description": "目录名称与父目录",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.createFolderRequest"
+                        }
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/folders/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "folders"
+                ],
+                "summary": "浏览目录",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "目录ID，省略表示根目录",
+                        "name": "id",
+                        "in": "path"
+                    }
+                ],
+                "responses": {}
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "folders"
+                ],
+                "summary": "删除目录",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "目录ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "folders"
+                ],
+                "summary": "重命名/移动目录",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "目录ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "新名称或新父目录（0 表示根目录）",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.updateFolderRequest"
+                        }
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/groups": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "用户组列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "名称关键字",
+                        "name": "q",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/login": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "用户登录",
+                "parameters": [
+                    {
+                        "description": "登录参数",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/shares": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shares"
+                ],
+                "summary": "我的分享",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "每页数量，默认 50，最大 200",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "上一页返回的 next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序字段：created_at / view_count",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "asc 或 desc，默认 desc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否返回过滤后的总数",
+                        "name": "include_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "创建者用户名",
+                        "name": "creator",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "文件ID",
+                        "name": "file_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "active / pending / expired / exhausted",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ShareListResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shares"
+                ],
+                "summary": "创建打包分享",
+                "parameters": [
+                    {
+                        "description": "打包内容与分享配置",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.bundleShareRequest"
+                        }
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/shares/cleanup": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shares"
+                ],
+                "summary": "清理我的失效分享",
+                "responses": {}
+            }
+        },
+        "/shares/{token}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shares"
+                ],
+                "summary": "获取分享元信息",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "分享 Token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shares"
+                ],
+                "summary": "撤销我的分享",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "分享 Token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shares"
+                ],
+                "summary": "修改分享",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "分享 Token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "修改内容",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.updateShareRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.shareListItem"
+                        }
+                    }
+                }
+            }
+        },
+        "/shares/{token}/analytics": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shares"
+                ],
+                "summary": "分享访问统计",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "分享 Token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "按日统计的天数，默认 30，最大 365",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "最近访问明细条数，默认 20，最大 200",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ShareAnalytics"
+                        }
+                    }
+                }
+            }
+        },
+        "/shares/{token}/download": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "计数与 Range/条件请求规则同 /shares/{token}/stream，可用于断点续传。",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "shares"
+                ],
+                "summary": "下载分享内容（计入浏览/下载次数）",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "分享 Token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "字节范围，例如 bytes=0-1023",
+                        "name": "Range",
+                        "in": "header"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/shares/{token}/highlight": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "language 省略时使用上传时识别或指定的语言。访问规则同 /shares/{token}/stream，每次请求计 1 次浏览。",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "shares"
+                ],
+                "summary": "获取文本分享的高亮页面",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "分享 Token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "覆盖语言，如 go、python、json",
+                        "name": "language",
+                        "in": "query"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/shares/{token}/items/{item}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "shares"
+                ],
+                "summary": "预览打包分享中的文件",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "分享 Token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "成员ID",
+                        "name": "item",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否以附件形式下载",
+                        "name": "download",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "字节范围，例如 bytes=0-1023",
+                        "name": "Range",
+                        "in": "header"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/shares/{token}/raw": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "仅文本分享可用，非文本返回 415。计数规则同 /shares/{token}/stream。",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "shares"
+                ],
+                "summary": "获取文本分享的原始内容",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "分享 Token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "字节范围，例如 bytes=0-1023",
+                        "name": "Range",
+                        "in": "header"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/shares/{token}/stream": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "支持 Range（含多段）、If-None-Match / If-Modified-Since 与 HEAD。完整请求或从 0 开始的 Range 计 1 次浏览；同一客户端计数后 30 分钟内起点大于 0 的续读不计数；HEAD 与 304 不计数。",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "shares"
+                ],
+                "summary": "预览/下载分享内容",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "分享 Token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否以附件形式下载，true 时为 attachment",
+                        "name": "download",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "字节范围，例如 bytes=0-1023",
+                        "name": "Range",
+                        "in": "header"
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/shares/{token}/thumbnail": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "访问规则同 /shares/{token}，但不消耗浏览次数；返回格式同 /files/{id}/thumbnail。",
+                "produces": [
+                    "image/jpeg",
+                    "text/plain"
+                ],
+                "tags": [
+                    "shares"
+                ],
+                "summary": "获取分享缩略图/预览",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "分享 Token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/shares/{token}/unlock": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shares"
+                ],
+                "summary": "解锁密码分享",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "分享 Token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "分享密码",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.unlockShareRequest"
+                        }
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/tags": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "标签联想",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "标签名前缀",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "返回数量，默认 10，最大 50",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.TagResponse"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/trash": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "回收站列表",
+                "responses": {}
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "清空回收站",
+                "responses": {}
+            }
+        },
+        "/trash/files/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "永久删除文件",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "文件ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/trash/files/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "恢复文件",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "文件ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/trash/folders/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "永久删除目录",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "目录ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/trash/folders/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "恢复目录",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "目录ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/uploads": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "创建分片上传会话",
+                "parameters": [
+                    {
+                        "description": "文件信息",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.createUploadSessionRequest"
+                        }
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/uploads/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "查询分片上传进度",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "上传会话 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/octet-stream"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "上传分片",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "上传会话 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "分片起始偏移量",
+                        "name": "offset",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "取消分片上传",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "上传会话 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/uploads/{id}/complete": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "完成分片上传",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "上传会话 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        }
+    },
+    "definitions": {
+        "handlers.APIKeyListResponse": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.apiKeyResponse"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.AddFileTagsRequest": {
+            "type": "object",
+            "required": [
+                "tags"
+            ],
+            "properties": {
+                "global": {
+                    "type": "boolean"
+                },
+                "tags": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "handlers.CreateUserRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "role",
+                "username"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "admin",
+                        "user"
+                    ]
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.FileDropListResponse": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.fileDropItem"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.FileListResponse": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.FileResponse"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.FileResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "declared_mime_type": {
+                    "description": "DeclaredMimeType / DetectedMimeType 为上传时客户端声明与按内容识别出的类型，旧文件为空",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "detected_mime_type": {
+                    "type": "string"
+                },
+                "digest": {
+                    "description": "内容的 SHA-256（十六进制），客户端可据此校验完整性",
+                    "type": "string"
+                },
+                "drop_id": {
+                    "description": "通过上传链接收集的文件所属的链接",
+                    "type": "integer"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "folder_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "language": {
+                    "description": "文本内容的语言，用于分享时的语法高亮",
+                    "type": "string"
+                },
+                "mime_type": {
+                    "type": "string"
+                },
+                "owner": {
+                    "type": "string"
+                },
+                "public_link": {
+                    "type": "string"
+                },
+                "scan_status": {
+                    "description": "pending/clean/infected/error，仅 clean 可下载",
+                    "type": "string"
+                },
+                "shared_with": {
+                    "description": "仅对所有者与管理员返回",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "version": {
+                    "type": "integer"
+                },
+                "visibility": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.LoginRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ResetPasswordRequest": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ShareAnalytics": {
+            "type": "object",
+            "properties": {
+                "bytes_sent": {
+                    "type": "integer"
+                },
+                "daily": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.dailyShareAccess"
+                    }
+                },
+                "denied": {
+                    "type": "integer"
+                },
+                "downloads": {
+                    "type": "integer"
+                },
+                "first_access_at": {
+                    "type": "string"
+                },
+                "last_access_at": {
+                    "type": "string"
+                },
+                "previews": {
+                    "type": "integer"
+                },
+                "recent": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.shareAccessItem"
+                    }
+                },
+                "shares": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.shareAccessSummary"
+                    }
+                },
+                "successful": {
+                    "type": "integer"
+                },
+                "total_accesses": {
+                    "type": "integer"
+                },
+                "unique_visitors": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.ShareListResponse": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.shareListItem"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.TagResponse": {
+            "type": "object",
+            "properties": {
+                "file_count": {
+                    "type": "integer"
+                },
+                "global": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.UpdateUserQuotaRequest": {
+            "type": "object",
+            "properties": {
+                "quota_bytes": {
+                    "type": "integer"
+                },
+                "quota_files": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.UpdateUserRoleRequest": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "admin",
+                        "user"
+                    ]
+                }
+            }
+        },
+        "handlers.UserListResponse": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.UserResponse"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.UserResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "custom_quota": {
+                    "type": "boolean"
+                },
+                "file_count": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "quota_bytes": {
+                    "type": "integer"
+                },
+                "quota_files": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "used_bytes": {
+                    "description": "存储占用与生效配额，配额为 0 表示不限制；CustomQuota 表示是否单独设置过配额",
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.apiKeyResponse": {
+            "type": "object",
+            "properties": {
+                "bound_user": {
+                    "$ref": "#/definitions/handlers.apiKeyUser"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "$ref": "#/definitions/handlers.apiKeyUser"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key_preview": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "revoked": {
+                    "type": "boolean"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "handlers.apiKeyUser": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.bundleShareRequest": {
+            "type": "object",
+            "properties": {
+                "allow_groups": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "allow_username": {
+                    "description": "AllowUsername 为早期版本的单一接收人，会并入 AllowUsernames",
+                    "type": "string"
+                },
+                "allow_usernames": {
+                    "description": "AllowUsernames / AllowGroups 限定可访问的用户与用户组，任一非空时分享强制要求登录",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "burn_after_reading": {
+                    "description": "BurnAfterReading 为阅后即焚，max_views 固定为 1",
+                    "type": "boolean"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "expires_in": {
+                    "type": "string"
+                },
+                "expires_in_days": {
+                    "type": "integer"
+                },
+                "file_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "folder_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "max_views": {
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "Name 为下载时的压缩包名称（不含 .zip），默认取唯一目录的名称",
+                    "type": "string"
+                },
+                "never_expires": {
+                    "type": "boolean"
+                },
+                "not_before": {
+                    "type": "string"
+                },
+                "password": {
+                    "description": "Password 为可选的访问密码，供没有账号的外部接收者使用，访问前需先调用 unlock 换取访问令牌",
+                    "type": "string"
+                },
+                "require_login": {
+                    "type": "boolean"
+                },
+                "short_code": {
+                    "type": "boolean"
+                },
+                "slug": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.createAPIKeyRequest": {
+            "type": "object",
+            "required": [
+                "bound_user_id",
+                "name"
+            ],
+            "properties": {
+                "bound_user_id": {
+                    "type": "integer"
+                },
+                "expires_in_days": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "handlers.createDropRequest": {
+            "type": "object",
+            "properties": {
+                "allowed_types": {
+                    "description": "允许的 MIME 类型，支持 image/*",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "expires_in": {
+                    "type": "string"
+                },
+                "expires_in_days": {
+                    "type": "integer"
+                },
+                "folder_id": {
+                    "description": "目标目录，省略或 0 表示根目录",
+                    "type": "integer"
+                },
+                "instructions": {
+                    "type": "string"
+                },
+                "max_file_size": {
+                    "description": "单个文件的字节上限，0 表示仅受配额限制",
+                    "type": "integer"
+                },
+                "max_files": {
+                    "description": "可上传的文件总数，省略表示不限",
+                    "type": "integer"
+                },
+                "never_expires": {
+                    "type": "boolean"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.createFolderRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.createGroupRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "usernames": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "handlers.createUploadSessionRequest": {
+            "type": "object",
+            "required": [
+                "filename"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string"
                 },
-                "role": {
-                    "type": "string",
-                    "enum": [
-                        "admin",
-                        "user"
-                    ]
+                "folder_id": {
+                    "type": "integer"
                 },
-                "username": {
+                "mime_type": {
                     "type": "string"
+                },
+                "size": {
+                    "type": "integer"
                 }
             }
         },
-        "handlers.LoginRequest": {
+        "handlers.dailyShareAccess": {
             "type": "object",
-            "required": [
-                "password",
-                "username"
-            ],
             "properties": {
-                "password": {
+                "accesses": {
+                    "type": "integer"
+                },
+                "bytes_sent": {
+                    "type": "integer"
+                },
+                "date": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.fileDropItem": {
+            "type": "object",
+            "properties": {
+                "allowed_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "expired": {
+                    "type": "boolean"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "file_count": {
+                    "description": "仍在空间中（未删除）的文件数",
+                    "type": "integer"
+                },
+                "folder_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "instructions": {
+                    "type": "string"
+                },
+                "max_file_size": {
+                    "type": "integer"
+                },
+                "max_files": {
+                    "type": "integer"
+                },
+                "owner": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "upload_count": {
+                    "type": "integer"
+                },
+                "upload_path": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.shareAccessItem": {
+            "type": "object",
+            "properties": {
+                "accessed_at": {
+                    "type": "string"
+                },
+                "bytes_sent": {
+                    "type": "integer"
+                },
+                "disposition": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "user_agent": {
                     "type": "string"
                 },
                 "username": {
@@ -569,46 +3285,201 @@ const docTemplate = `{
                 }
             }
         },
-        "handlers.ResetPasswordRequest": {
+        "handlers.shareAccessSummary": {
+            "type": "object",
+            "properties": {
+                "accesses": {
+                    "type": "integer"
+                },
+                "bytes_sent": {
+                    "type": "integer"
+                },
+                "revoked": {
+                    "type": "boolean"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.shareListItem": {
+            "type": "object",
+            "properties": {
+                "allow_groups": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "allow_username": {
+                    "type": "string"
+                },
+                "allow_usernames": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "bundle": {
+                    "type": "boolean"
+                },
+                "burn_after_reading": {
+                    "description": "BurnAfterReading 为阅后即焚的分享",
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "creator": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "file_owner": {
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "has_password": {
+                    "type": "boolean"
+                },
+                "item_count": {
+                    "type": "integer"
+                },
+                "max_views": {
+                    "type": "integer"
+                },
+                "not_before": {
+                    "type": "string"
+                },
+                "remaining_views": {
+                    "type": "integer"
+                },
+                "require_login": {
+                    "type": "boolean"
+                },
+                "short_code": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "view_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.shareRequest": {
             "type": "object",
             "properties": {
+                "allow_groups": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "allow_username": {
+                    "description": "AllowUsername 为早期版本的单一接收人，会并入 AllowUsernames",
+                    "type": "string"
+                },
+                "allow_usernames": {
+                    "description": "AllowUsernames / AllowGroups 限定可访问的用户与用户组，任一非空时分享强制要求登录",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "burn_after_reading": {
+                    "description": "BurnAfterReading 为阅后即焚，max_views 固定为 1",
+                    "type": "boolean"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "expires_in": {
+                    "type": "string"
+                },
+                "expires_in_days": {
+                    "type": "integer"
+                },
+                "max_views": {
+                    "type": "integer"
+                },
+                "never_expires": {
+                    "type": "boolean"
+                },
+                "not_before": {
+                    "type": "string"
+                },
                 "password": {
+                    "description": "Password 为可选的访问密码，供没有账号的外部接收者使用，访问前需先调用 unlock 换取访问令牌",
+                    "type": "string"
+                },
+                "require_login": {
+                    "type": "boolean"
+                },
+                "short_code": {
+                    "type": "boolean"
+                },
+                "slug": {
                     "type": "string"
                 }
             }
         },
-        "handlers.UpdateUserRoleRequest": {
+        "handlers.unlockShareRequest": {
             "type": "object",
             "required": [
-                "role"
+                "password"
             ],
             "properties": {
-                "role": {
-                    "type": "string",
-                    "enum": [
-                        "admin",
-                        "user"
-                    ]
+                "password": {
+                    "type": "string"
                 }
             }
         },
-        "handlers.createAPIKeyRequest": {
+        "handlers.updateFileRequest": {
             "type": "object",
-            "required": [
-                "bound_user_id",
-                "name"
-            ],
             "properties": {
-                "bound_user_id": {
+                "description": {
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "folder_id": {
                     "type": "integer"
                 },
-                "expires_in_days": {
+                "language": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.updateFolderRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "parent_id": {
                     "type": "integer"
+                }
+            }
+        },
+        "handlers.updateGroupRequest": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
                 },
                 "name": {
                     "type": "string"
                 },
-                "scopes": {
+                "usernames": {
                     "type": "array",
                     "items": {
                         "type": "string"
@@ -616,10 +3487,25 @@ const docTemplate = `{
                 }
             }
         },
-        "handlers.shareRequest": {
+        "handlers.updateShareRequest": {
             "type": "object",
             "properties": {
-                "allow_username": {
+                "allow_groups": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "allow_usernames": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "expires_in": {
                     "type": "string"
                 },
                 "expires_in_days": {
@@ -628,8 +3514,44 @@ const docTemplate = `{
                 "max_views": {
                     "type": "integer"
                 },
+                "never_expires": {
+                    "type": "boolean"
+                },
+                "not_before": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
                 "require_login": {
                     "type": "boolean"
+                },
+                "reset_views": {
+                    "type": "boolean"
+                },
+                "short_code": {
+                    "type": "boolean"
+                },
+                "slug": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.updateVisibilityRequest": {
+            "type": "object",
+            "required": [
+                "visibility"
+            ],
+            "properties": {
+                "usernames": {
+                    "description": "visibility 为 users 时可查看的用户",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "visibility": {
+                    "type": "string"
                 }
             }
         },
@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"math/big"
 	"net/http"
+	"strings"
 	"time"
 
+	"content-hub/server/config"
 	"content-hub/server/models"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -25,6 +27,18 @@ type UserResponse struct {
 	Username  string    `json:"username"`
 	Role      string    `json:"role"`
 	CreatedAt time.Time `json:"created_at"`
+	// 存储占用与生效配额，配额为 0 表示不限制；CustomQuota 表示是否单独设置过配额
+	UsedBytes   int64 `json:"used_bytes"`
+	FileCount   int64 `json:"file_count"`
+	QuotaBytes  int64 `json:"quota_bytes"`
+	QuotaFiles  int64 `json:"quota_files"`
+	CustomQuota bool  `json:"custom_quota"`
+}
+
+// UpdateUserQuotaRequest 设置单个用户的配额，字段为 null 时恢复使用全局默认值，0 表示不限制。
+type UpdateUserQuotaRequest struct {
+	QuotaBytes *int64 `json:"quota_bytes"`
+	QuotaFiles *int64 `json:"quota_files"`
 }
 
 type UpdateUserRoleRequest struct {
@@ -63,27 +77,151 @@ func CreateUser(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
-// ListUsers 以创建时间倒序返回所有用户，供管理员界面展示列表。
+// UserListResponse 是用户列表的分页结果。
+type UserListResponse struct {
+	Items []UserResponse `json:"items"`
+	PageInfo
+}
+
+// userListSpec 定义用户列表允许的排序字段。
+var userListSpec = listSpec{
+	Table:   "users",
+	Fields:  map[string]sortKind{"created_at": sortTime, "username": sortString},
+	Default: "created_at",
+}
+
+// ListUsers 分页返回用户及其存储占用，默认按创建时间倒序，供管理员界面展示列表。
 // @Summary 用户列表
 // @Tags admin
 // @Produce json
+// @Param limit query int false "每页数量，默认 50，最大 200"
+// @Param cursor query string false "上一页返回的 next_cursor"
+// @Param sort query string false "排序字段：created_at / username"
+// @Param order query string false "asc 或 desc，默认 desc"
+// @Param include_total query bool false "是否返回过滤后的总数"
+// @Param role query string false "admin 或 user"
+// @Param q query string false "用户名包含的关键字"
+// @Success 200 {object} UserListResponse
 // @Security BearerAuth
 // @Router /admin/users [get]
-func ListUsers(db *gorm.DB) gin.HandlerFunc {
+func ListUsers(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		lq, ok := parseListQuery(c, userListSpec)
+		if !ok {
+			return
+		}
+		query := db.Model(&models.User{})
+		if v := c.Query("role"); v != "" {
+			query = query.Where("users.role = ?", v)
+		}
+		if v := strings.TrimSpace(c.Query("q")); v != "" {
+			query = query.Where(`users.username LIKE ? ESCAPE '\'`, "%"+models.EscapeLike(v)+"%")
+		}
+
+		total, err := lq.total(query)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户列表"})
+			return
+		}
+		query, err = lq.apply(query)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cursor 无效"})
+			return
+		}
 		var users []models.User
-		if err := db.Order("created_at DESC").Find(&users).Error; err != nil {
+		if err := query.Find(&users).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "无法获取用户列表"})
 			return
 		}
-		resp := make([]UserResponse, 0, len(users))
+		users, page := paginate(lq, users, func(u *models.User, sort string) (any, uint) {
+			if sort == "username" {
+				return u.Username, u.ID
+			}
+			return u.CreatedAt, u.ID
+		})
+		page.Total = total
+
+		ids := make([]uint, 0, len(users))
 		for _, u := range users {
-			resp = append(resp, UserResponse{ID: u.ID, Username: u.Username, Role: u.Role, CreatedAt: u.CreatedAt})
+			ids = append(ids, u.ID)
+		}
+		usage := map[uint]models.StorageUsage{}
+		if len(ids) > 0 {
+			if usage, err = models.UsageByOwner(db, ids...); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "无法统计存储占用"})
+				return
+			}
+		}
+		resp := UserListResponse{Items: make([]UserResponse, 0, len(users)), PageInfo: page}
+		for i := range users {
+			resp.Items = append(resp.Items, buildUserResponse(&users[i], usage[users[i].ID], cfg))
 		}
 		c.JSON(http.StatusOK, resp)
 	}
 }
 
+// UpdateUserQuota 设置指定用户的空间与文件数配额，超出后该用户的上传会返回 413。
+// @Summary 设置用户配额
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "用户ID"
+// @Param payload body UpdateUserQuotaRequest true "配额，null 表示使用默认值，0 表示不限制"
+// @Security BearerAuth
+// @Router /admin/users/{id}/quota [put]
+func UpdateUserQuota(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req UpdateUserQuotaRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if (req.QuotaBytes != nil && *req.QuotaBytes < 0) || (req.QuotaFiles != nil && *req.QuotaFiles < 0) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "配额不能为负数"})
+			return
+		}
+
+		var target models.User
+		if err := db.First(&target, c.Param("id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+			return
+		}
+
+		target.QuotaBytes = req.QuotaBytes
+		target.QuotaFiles = req.QuotaFiles
+		if err := db.Model(&target).Updates(map[string]any{
+			"quota_bytes": req.QuotaBytes,
+			"quota_files": req.QuotaFiles,
+		}).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "更新配额失败"})
+			return
+		}
+
+		usage, err := models.UsageByOwner(db, target.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "无法统计存储占用"})
+			return
+		}
+		c.JSON(http.StatusOK, buildUserResponse(&target, usage[target.ID], cfg))
+	}
+}
+
+// buildUserResponse 组装用户信息与存储占用，生效配额按用户设置优先、全局默认兜底。
+func buildUserResponse(u *models.User, usage models.StorageUsage, cfg *config.Config) UserResponse {
+	quotaBytes, quotaFiles := u.EffectiveQuota(cfg.DefaultQuotaBytes, cfg.DefaultQuotaFiles)
+	return UserResponse{
+		ID:          u.ID,
+		Username:    u.Username,
+		Role:        u.Role,
+		CreatedAt:   u.CreatedAt,
+		UsedBytes:   usage.UsedBytes,
+		FileCount:   usage.FileCount,
+		QuotaBytes:  quotaBytes,
+		QuotaFiles:  quotaFiles,
+		CustomQuota: u.QuotaBytes != nil || u.QuotaFiles != nil,
+	}
+}
+
 // DeleteUser 允许管理员删除其他账号，并保护最后一名管理员与当前登录用户。
 // @Summary 删除用户
 // @Tags admin
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"content-hub/server/config"
 	"content-hub/server/models"
 	"github.com/gin-gonic/gin"
 	"gorm.io/driver/sqlite"
@@ -22,7 +23,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	if err != nil {
 		t.Fatalf("open db: %v", err)
 	}
-	if err := db.AutoMigrate(&models.User{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.File{}, &models.FileVersion{}); err != nil {
 		t.Fatalf("migrate: %v", err)
 	}
 	return db
@@ -52,25 +53,27 @@ func TestListUsers(t *testing.T) {
 	c.Set("userID", admin.ID)
 	c.Set("role", models.RoleAdmin)
 
-	ListUsers(db)(c)
+	ListUsers(db, &config.Config{})(c)
 
 	if w.Code != http.StatusOK {
 		t.Fatalf("unexpected status: %d body=%s", w.Code, w.Body.String())
 	}
 
-	var resp []UserResponse
+	var resp UserListResponse
 	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
 		t.Fatalf("decode response: %v", err)
 	}
-	if len(resp) != 2 {
-		t.Fatalf("expect 2 users, got %d", len(resp))
+	if len(resp.Items) != 2 {
+		t.Fatalf("expect 2 users, got %d", len(resp.Items))
 	}
 
-	var raw []map[string]any
+	var raw struct {
+		Items []map[string]any `json:"items"`
+	}
 	if err := json.Unmarshal(w.Body.Bytes(), &raw); err != nil {
 		t.Fatalf("decode raw: %v", err)
 	}
-	if _, exists := raw[0]["password_hash"]; exists {
+	if _, exists := raw.Items[0]["password_hash"]; exists {
 		t.Fatalf("password hash should not be exposed")
 	}
 }
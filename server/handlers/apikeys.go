@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -137,23 +138,87 @@ func CreateAPIKey(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
-// ListAPIKeys 返回所有密钥的元数据，脱敏展示 key 片段。
+// APIKeyListResponse 是 API Key 列表的分页结果。
+type APIKeyListResponse struct {
+	Items []apiKeyResponse `json:"items"`
+	PageInfo
+}
+
+// apiKeyListSpec 定义 API Key 列表允许的排序字段。
+var apiKeyListSpec = listSpec{
+	Table:   "api_keys",
+	Fields:  map[string]sortKind{"created_at": sortTime, "name": sortString},
+	Default: "created_at",
+}
+
+// ListAPIKeys 分页返回密钥的元数据，脱敏展示 key 片段。
 // @Summary API Key 列表
 // @Tags admin
 // @Produce json
+// @Param limit query int false "每页数量，默认 50，最大 200"
+// @Param cursor query string false "上一页返回的 next_cursor"
+// @Param sort query string false "排序字段：created_at / name"
+// @Param order query string false "asc 或 desc，默认 desc"
+// @Param include_total query bool false "是否返回过滤后的总数"
+// @Param bound_user_id query int false "归属用户ID"
+// @Param revoked query bool false "是否已撤销"
+// @Param q query string false "名称包含的关键字"
+// @Success 200 {object} APIKeyListResponse
 // @Security BearerAuth
 // @Router /admin/apikeys [get]
 func ListAPIKeys(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		lq, ok := parseListQuery(c, apiKeyListSpec)
+		if !ok {
+			return
+		}
+		query := db.Model(&models.APIKey{})
+		if v := c.Query("bound_user_id"); v != "" {
+			userID, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "bound_user_id 无效"})
+				return
+			}
+			query = query.Where("api_keys.bound_user_id = ?", userID)
+		}
+		if v := c.Query("revoked"); v != "" {
+			revoked, err := strconv.ParseBool(v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "revoked 需为布尔值"})
+				return
+			}
+			query = query.Where("api_keys.revoked = ?", revoked)
+		}
+		if v := strings.TrimSpace(c.Query("q")); v != "" {
+			query = query.Where(`api_keys.name LIKE ? ESCAPE '\'`, "%"+models.EscapeLike(v)+"%")
+		}
+
+		total, err := lq.total(query)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		query, err = lq.apply(query)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cursor 无效"})
+			return
+		}
 		var keys []models.APIKey
-		if err := db.Preload("BoundUser").Preload("CreatedBy").Order("created_at DESC").Find(&keys).Error; err != nil {
+		if err := query.Preload("BoundUser").Preload("CreatedBy").Find(&keys).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		keys, page := paginate(lq, keys, func(k *models.APIKey, sort string) (any, uint) {
+			if sort == "name" {
+				return k.Name, k.ID
+			}
+			return k.CreatedAt, k.ID
+		})
+		page.Total = total
 
-		resp := make([]apiKeyResponse, 0, len(keys))
+		resp := APIKeyListResponse{Items: make([]apiKeyResponse, 0, len(keys)), PageInfo: page}
 		for _, k := range keys {
-			resp = append(resp, buildAPIKeyResponse(&k, &k.BoundUser, &k.CreatedBy))
+			resp.Items = append(resp.Items, buildAPIKeyResponse(&k, &k.BoundUser, &k.CreatedBy))
 		}
 		c.JSON(http.StatusOK, resp)
 	}
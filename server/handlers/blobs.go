@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"os"
+
+	"content-hub/server/models"
+	"content-hub/server/storage"
+	"gorm.io/gorm"
+)
+
+// hashUpload 读取上传内容并计算 SHA-256。可定位的本地文件（如分片上传的暂存文件）直接原地计算，
+// 其余流先落到 tmpDir 的临时文件，便于在确定摘要后再写入存储驱动。返回的 cleanup 负责删除临时文件。
+// maxBytes 不小于 0 时，内容一旦超过该长度立即停止读取并返回 errQuotaExceeded。
+func hashUpload(r io.Reader, tmpDir string, maxBytes int64) (io.ReadSeeker, string, int64, func(), error) {
+	hasher := sha256.New()
+	if f, ok := r.(*os.File); ok {
+		size, err := io.Copy(hasher, f)
+		if err != nil {
+			return nil, "", 0, nil, err
+		}
+		if maxBytes >= 0 && size > maxBytes {
+			return nil, "", 0, nil, errQuotaExceeded
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, "", 0, nil, err
+		}
+		return f, hex.EncodeToString(hasher.Sum(nil)), size, func() {}, nil
+	}
+
+	if tmpDir != "" {
+		if err := os.MkdirAll(tmpDir, 0o755); err != nil {
+			return nil, "", 0, nil, err
+		}
+	}
+	tmp, err := os.CreateTemp(tmpDir, "upload-*")
+	if err != nil {
+		return nil, "", 0, nil, err
+	}
+	cleanup := func() {
+		tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}
+	src := r
+	if maxBytes >= 0 {
+		src = io.LimitReader(r, maxBytes+1)
+	}
+	size, err := io.Copy(io.MultiWriter(tmp, hasher), src)
+	if err == nil && maxBytes >= 0 && size > maxBytes {
+		err = errQuotaExceeded
+	}
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		cleanup()
+		return nil, "", 0, nil, err
+	}
+	return tmp, hex.EncodeToString(hasher.Sum(nil)), size, cleanup, nil
+}
+
+// ensureBlobStored 保证摘要对应的内容存在于存储驱动中：已有 Blob 且内容完好时直接复用，否则写入。
+// uploaded 表示本次是否实际写入了新对象，供失败时回收。
+func ensureBlobStored(ctx context.Context, db *gorm.DB, store storage.Driver, content io.Reader, digest string, size int64) (string, bool, error) {
+	key := models.BlobKey(digest)
+	var existing models.Blob
+	err := db.Where("digest = ?", digest).First(&existing).Error
+	switch {
+	case err == nil:
+		// 复用前确认对象仍在，防止历史记录指向已丢失的内容
+		if _, statErr := store.Stat(ctx, existing.Path); statErr == nil {
+			return existing.Path, false, nil
+		} else if !errors.Is(statErr, storage.ErrNotExist) {
+			return "", false, statErr
+		}
+		key = existing.Path
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return "", false, err
+	}
+	if err := store.Put(ctx, key, content, size); err != nil {
+		return "", false, err
+	}
+	return key, true, nil
+}
+
+// discardUnreferencedBlob 在入库失败后清理新写入的对象，仅当没有任何 Blob 记录引用它时才删除。
+func discardUnreferencedBlob(ctx context.Context, db *gorm.DB, store storage.Driver, digest, key string) {
+	var count int64
+	if err := db.Model(&models.Blob{}).Where("digest = ?", digest).Count(&count).Error; err != nil || count > 0 {
+		return
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		log.Printf("remove orphan blob %s: %v", key, err)
+	}
+}
+
+// purgeFile 物理删除文件记录及其历史版本，并释放各自的内容引用，最后一个引用释放时才删除存储中的对象。
+// 未记录摘要的旧文件独占其内容，直接删除。返回实际从存储中释放的字节数，内容仍被引用时为 0。
+func purgeFile(ctx context.Context, db *gorm.DB, store storage.Driver, f *models.File) (int64, error) {
+	var removeKeys []string
+	var freed int64
+	release := func(tx *gorm.DB, digest, path string, size int64) error {
+		if digest == "" {
+			removeKeys = append(removeKeys, path)
+			freed += size
+			return nil
+		}
+		blob, last, err := models.ReleaseBlob(tx, digest)
+		if err != nil {
+			return err
+		}
+		if last && blob != nil {
+			removeKeys = append(removeKeys, blob.Path)
+			freed += blob.Size
+		}
+		return nil
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var versions []models.FileVersion
+		if err := tx.Unscoped().Where("file_id = ?", f.ID).Find(&versions).Error; err != nil {
+			return err
+		}
+		for _, v := range versions {
+			if err := release(tx, v.Digest, v.Path, v.Size); err != nil {
+				return err
+			}
+		}
+		if err := tx.Unscoped().Where("file_id = ?", f.ID).Delete(&models.FileVersion{}).Error; err != nil {
+			return err
+		}
+		if err := release(tx, f.Digest, f.Path, f.Size); err != nil {
+			return err
+		}
+		if err := tx.Where("file_id = ?", f.ID).Delete(&models.FileText{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("file_id = ?", f.ID).Delete(&models.FileTag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("file_id = ?", f.ID).Delete(&models.ShareAccess{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("file_id = ?", f.ID).Delete(&models.ShareItem{}).Error; err != nil {
+			return err
+		}
+		var preview models.FilePreview
+		if err := tx.Where("file_id = ?", f.ID).Limit(1).Find(&preview).Error; err != nil {
+			return err
+		}
+		if preview.ThumbnailKey != "" {
+			removeKeys = append(removeKeys, preview.ThumbnailKey)
+		}
+		if err := tx.Where("file_id = ?", f.ID).Delete(&models.FilePreview{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(f).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, key := range removeKeys {
+		if err := store.Delete(ctx, key); err != nil {
+			log.Printf("remove file %s: %v", key, err)
+		}
+	}
+	return freed, nil
+}
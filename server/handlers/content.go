@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"content-hub/server/models"
+	"content-hub/server/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// serveStoredFile 从存储驱动读取文件内容并写回响应；disposition 为空时不设置 Content-Disposition。
+// Range（含多段）、If-None-Match / If-Modified-Since 与 HEAD 交由 http.ServeContent 统一处理。
+// 未通过恶意软件扫描的文件在此被拦截。
+func serveStoredFile(c *gin.Context, store storage.Driver, f *models.File, disposition string) {
+	if !checkScanStatus(c, f) {
+		return
+	}
+	info, err := store.Stat(c.Request.Context(), f.Path)
+	if err != nil {
+		writeStorageError(c, err)
+		return
+	}
+	writeStoredContent(c, store, f, info, disposition)
+}
+
+// writeStoredContent 在已完成权限与存在性校验后输出内容，供文件与分享接口共用。
+func writeStoredContent(c *gin.Context, store storage.Driver, f *models.File, info storage.ObjectInfo, disposition string) {
+	setContentHeaders(c, f, info, disposition)
+	content := storage.NewReadSeeker(c.Request.Context(), store, f.Path, info.Size)
+	defer content.Close()
+	http.ServeContent(c.Writer, c.Request, f.Filename, contentModTime(f, info), content)
+}
+
+// setContentHeaders 写入类型、缓存校验与下载方式相关的响应头。
+// HTML、SVG 等可执行内容无论请求预览还是下载都强制以附件返回，避免在站点域名下执行上传者的脚本。
+func setContentHeaders(c *gin.Context, f *models.File, info storage.ObjectInfo, disposition string) {
+	if f.MimeType != "" {
+		c.Header("Content-Type", f.MimeType)
+	}
+	c.Header("X-Content-Type-Options", "nosniff")
+	if isActiveContent(f.MimeType, f.DeclaredMimeType, f.DetectedMimeType) {
+		disposition = "attachment"
+		c.Header("Content-Security-Policy", "sandbox")
+	}
+	c.Header("ETag", contentETag(f, info))
+	// 内容受鉴权保护，只允许浏览器私有缓存，且每次需向服务端校验
+	c.Header("Cache-Control", "private, no-cache")
+	if disposition != "" {
+		c.Header("Content-Disposition", fmt.Sprintf("%s; filename=\"%s\"", disposition, url.PathEscape(f.Filename)))
+	}
+}
+
+// contentETag 优先使用内容摘要作为强校验值；旧文件没有摘要时由文件 ID、大小与修改时间组成，内容替换后会随之变化。
+func contentETag(f *models.File, info storage.ObjectInfo) string {
+	if f.Digest != "" {
+		return fmt.Sprintf("\"%s\"", f.Digest)
+	}
+	return fmt.Sprintf("\"%x-%x-%x\"", f.ID, info.Size, contentModTime(f, info).UnixNano())
+}
+
+func contentModTime(f *models.File, info storage.ObjectInfo) time.Time {
+	if !info.ModTime.IsZero() {
+		return info.ModTime
+	}
+	return f.CreatedAt
+}
+
+// requestNotModified 判断条件请求是否命中缓存（将返回 304），规则与 http.ServeContent 保持一致：
+// 存在 If-None-Match 时忽略 If-Modified-Since。
+func requestNotModified(r *http.Request, etag string, modTime time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !modTime.IsZero() {
+		t, err := http.ParseTime(ims)
+		if err != nil {
+			return false
+		}
+		return !modTime.Truncate(time.Second).After(t)
+	}
+	return false
+}
+
+// rangeResumes 解析 Range 头，判断请求是否为“续读”：每一段都显式从非 0 偏移开始。
+// 后缀范围（bytes=-N）可能覆盖文件开头，按非续读处理；ok 为 false 表示 Range 头格式非法。
+func rangeResumes(header string) (resume bool, ok bool) {
+	if header == "" {
+		return false, true
+	}
+	const prefix = "bytes="
+	if !strings.HasPrefix(header, prefix) {
+		return false, false
+	}
+	resume = true
+	for _, spec := range strings.Split(strings.TrimPrefix(header, prefix), ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		startStr, endStr, found := strings.Cut(spec, "-")
+		if !found {
+			return false, false
+		}
+		startStr = strings.TrimSpace(startStr)
+		if startStr == "" {
+			resume = false
+			continue
+		}
+		start, err := strconv.ParseInt(startStr, 10, 64)
+		if err != nil || start < 0 {
+			return false, false
+		}
+		if endStr = strings.TrimSpace(endStr); endStr != "" {
+			end, err := strconv.ParseInt(endStr, 10, 64)
+			if err != nil || end < start {
+				return false, false
+			}
+		}
+		if start == 0 {
+			resume = false
+		}
+	}
+	return resume, true
+}
+
+// rangeSatisfiable 判断格式合法的 Range 头是否至少有一段落在 size 字节的内容内；全部从文件末尾之后开始时
+// http.ServeContent 会返回 416，调用方需在计数前拦截。与 ServeContent 一致，空文件忽略 Range。
+func rangeSatisfiable(header string, size int64) bool {
+	if header == "" || size == 0 {
+		return true
+	}
+	for _, spec := range strings.Split(strings.TrimPrefix(header, "bytes="), ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		startStr, _, _ := strings.Cut(spec, "-")
+		if startStr = strings.TrimSpace(startStr); startStr == "" {
+			return true // 后缀范围（bytes=-N）总能覆盖文件末尾
+		}
+		if start, err := strconv.ParseInt(startStr, 10, 64); err != nil || start < size {
+			return true
+		}
+	}
+	return false
+}
+
+// writeStorageError 将存储层错误映射为 HTTP 状态，对象缺失时返回 404 而不是暴露系统错误。
+func writeStorageError(c *gin.Context, err error) {
+	if errors.Is(err, storage.ErrNotExist) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "file content not found or already deleted"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"content-hub/server/models"
+	"content-hub/server/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestStreamFileRangeAndConditional(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "content.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.File{}, &models.FileAccess{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	owner := models.User{Username: "owner", Role: models.RoleUser, PasswordHash: "x"}
+	_ = db.Create(&owner).Error
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "doc.txt"), []byte("abcdefghij"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	file := models.File{OwnerID: owner.ID, Filename: "doc.txt", Path: "doc.txt", Size: 10, MimeType: "text/plain"}
+	_ = db.Create(&file).Error
+	store := storage.NewLocal(dir)
+
+	// 通过路由分发，确保 304 等无响应体的状态码会真正写出
+	router := gin.New()
+	router.GET("/api/files/:id/stream", func(c *gin.Context) {
+		c.Set("userID", owner.ID)
+		c.Set("role", models.RoleUser)
+	}, StreamFile(db, store))
+	call := func(headers map[string]string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/files/%d/stream", file.ID), nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := call(nil)
+	if w.Code != http.StatusOK || w.Body.String() != "abcdefghij" {
+		t.Fatalf("full stream failed: %d %q", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Content-Length") != "10" || w.Header().Get("Last-Modified") == "" {
+		t.Fatalf("missing length/last-modified headers: %v", w.Header())
+	}
+
+	// 多段 Range 以 multipart/byteranges 返回
+	w = call(map[string]string{"Range": "bytes=0-1,8-9"})
+	if w.Code != http.StatusPartialContent {
+		t.Fatalf("expected 206, got %d", w.Code)
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "multipart/byteranges") {
+		t.Fatalf("expected multipart/byteranges, got %q", w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Body.String(), "ab") || !strings.Contains(w.Body.String(), "ij") {
+		t.Fatalf("multipart body missing parts: %q", w.Body.String())
+	}
+
+	// 超出范围返回 416
+	w = call(map[string]string{"Range": "bytes=50-60"})
+	if w.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Fatalf("expected 416, got %d", w.Code)
+	}
+
+	// If-Modified-Since 命中时返回 304
+	w = call(map[string]string{"If-Modified-Since": time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)})
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("expected 304, got %d", w.Code)
+	}
+}
+
+func TestRangeResumes(t *testing.T) {
+	cases := []struct {
+		header string
+		resume bool
+		ok     bool
+	}{
+		{"", false, true},
+		{"bytes=0-", false, true},
+		{"bytes=100-200", true, true},
+		{"bytes=100-200, 0-10", false, true},
+		{"bytes=-500", false, true},
+		{"bytes=abc-", false, false},
+		{"lines=1-2", false, false},
+		{"bytes=200-100", false, false},
+	}
+	for _, tc := range cases {
+		resume, ok := rangeResumes(tc.header)
+		if resume != tc.resume || ok != tc.ok {
+			t.Errorf("rangeResumes(%q) = %v,%v want %v,%v", tc.header, resume, ok, tc.resume, tc.ok)
+		}
+	}
+}
+
+func TestRangeSatisfiable(t *testing.T) {
+	cases := []struct {
+		header string
+		size   int64
+		want   bool
+	}{
+		{"", 10, true},
+		{"bytes=0-", 10, true},
+		{"bytes=9-", 10, true},
+		{"bytes=10-", 10, false},
+		{"bytes=4096-", 10, false},
+		{"bytes=20-30, 5-", 10, true},
+		{"bytes=20-30, 40-", 10, false},
+		{"bytes=-5", 10, true},
+		{"bytes=10-", 0, true},
+	}
+	for _, tc := range cases {
+		if got := rangeSatisfiable(tc.header, tc.size); got != tc.want {
+			t.Errorf("rangeSatisfiable(%q, %d) = %v want %v", tc.header, tc.size, got, tc.want)
+		}
+	}
+}
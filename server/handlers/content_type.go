@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"content-hub/server/config"
+	"content-hub/server/highlight"
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/gin-gonic/gin"
+)
+
+// sniffLen 为识别类型时读取的内容开头字节数，与 mimetype 默认的检测长度一致。
+const sniffLen = 3072
+
+const octetStream = "application/octet-stream"
+
+var errContentTypeDenied = errors.New("不允许上传该类型的文件")
+
+// activeContentTypes 是浏览器会执行脚本或渲染为页面的类型，内联返回会带来 XSS 风险，预览时一律改为附件下载。
+var activeContentTypes = map[string]bool{
+	"text/html":                     true,
+	"application/xhtml+xml":         true,
+	"image/svg+xml":                 true,
+	"text/xml":                      true,
+	"application/xml":               true,
+	"text/javascript":               true,
+	"application/javascript":        true,
+	"application/x-javascript":      true,
+	"application/ecmascript":        true,
+	"text/ecmascript":               true,
+	"application/x-shockwave-flash": true,
+	"text/xsl":                      true,
+	"application/xslt+xml":          true,
+}
+
+// contentTypes 记录客户端声明的类型与按内容识别出的类型，Mime 为最终保存并在响应中使用的类型。
+type contentTypes struct {
+	Mime     string
+	Declared string
+	Detected string
+	// Language 为文本内容的语言（见 highlight 包），非文本为空
+	Language string
+}
+
+// sniffContent 读取内容开头识别真实类型，返回仍可从头读取完整内容的 Reader。
+func sniffContent(r io.Reader, declared string) (contentTypes, io.Reader, error) {
+	head, r, err := peekContent(r, sniffLen)
+	if err != nil {
+		return contentTypes{}, nil, err
+	}
+	types := contentTypes{
+		Declared: baseMime(declared),
+		Detected: baseMime(mimetype.Detect(head).String()),
+	}
+	types.Mime = effectiveMime(types.Declared, types.Detected)
+	return types, r, nil
+}
+
+// peekContent 读取内容开头最多 n 字节，返回仍可从头读取完整内容的 Reader。
+func peekContent(r io.Reader, n int) ([]byte, io.Reader, error) {
+	head := make([]byte, n)
+	read, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, nil, err
+	}
+	head = head[:read]
+	return head, io.MultiReader(bytes.NewReader(head), r), nil
+}
+
+// effectiveMime 以识别结果为准；仅当声明的类型是识别结果的细分（如 text/plain 内容声明为 text/csv）
+// 或内容无法识别且声明的类型不可执行时，才采用客户端声明的类型。
+func effectiveMime(declared, detected string) string {
+	if declared == "" || declared == octetStream || declared == detected {
+		return detected
+	}
+	if activeContentTypes[declared] {
+		return detected
+	}
+	if detected == octetStream {
+		return declared
+	}
+	if detected == "text/plain" && strings.HasPrefix(declared, "text/") {
+		return declared
+	}
+	for m := mimetype.Lookup(declared); m != nil; m = m.Parent() {
+		if m.Is(detected) {
+			return declared
+		}
+	}
+	return detected
+}
+
+// baseMime 去掉参数（如 charset）并转为小写。
+func baseMime(v string) string {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return ""
+	}
+	if mediaType, _, err := mime.ParseMediaType(v); err == nil {
+		return mediaType
+	}
+	return strings.ToLower(strings.TrimSpace(strings.SplitN(v, ";", 2)[0]))
+}
+
+// isActiveContent 判断声明、识别或最终类型中是否有任一属于可执行内容。
+func isActiveContent(types ...string) bool {
+	for _, t := range types {
+		if activeContentTypes[baseMime(t)] {
+			return true
+		}
+	}
+	return false
+}
+
+// checkContentPolicy 按配置校验上传类型：声明或识别出的类型命中黑名单即拒绝；配置了白名单时最终类型必须在其中。
+func checkContentPolicy(cfg *config.Config, types contentTypes) error {
+	for _, t := range []string{types.Declared, types.Detected, types.Mime} {
+		if t != "" && matchMimePatterns(cfg.UploadDeniedTypes, t) {
+			return errContentTypeDenied
+		}
+	}
+	if len(cfg.UploadAllowedTypes) > 0 && !matchMimePatterns(cfg.UploadAllowedTypes, types.Mime) {
+		return errContentTypeDenied
+	}
+	return nil
+}
+
+// matchMimePatterns 支持精确匹配与 image/* 形式的主类型通配。
+func matchMimePatterns(patterns []string, mimeType string) bool {
+	for _, p := range patterns {
+		if p == "*/*" || p == mimeType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(p, "/*"); ok && strings.HasPrefix(mimeType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// prepareUploadContent 识别上传内容的真实类型并执行类型策略，通过后更新 in 的内容与类型。
+// 文本内容未指定语言时按文件名与内容开头识别。失败时已写入响应。
+func prepareUploadContent(c *gin.Context, cfg *config.Config, in *uploadInput) bool {
+	types, content, err := sniffContent(in.Content, in.MimeType)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取上传内容失败: " + err.Error()})
+		return false
+	}
+	if err := checkContentPolicy(cfg, types); err != nil {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error":              err.Error(),
+			"declared_mime_type": types.Declared,
+			"detected_mime_type": types.Detected,
+		})
+		return false
+	}
+	if isTextMime(types.Mime) {
+		types.Language = in.Language
+		if types.Language == "" {
+			head, rest, err := peekContent(content, highlight.DetectLen)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "读取上传内容失败: " + err.Error()})
+				return false
+			}
+			types.Language = highlight.Detect(in.Filename, head)
+			content = rest
+		}
+	}
+	in.Content = content
+	in.Types = types
+	return true
+}
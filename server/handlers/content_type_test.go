@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"content-hub/server/config"
+	"content-hub/server/models"
+	"content-hub/server/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// uploadTyped 以指定的 Content-Type 上传文件分片，模拟客户端声明的类型。
+func uploadTyped(t *testing.T, db *gorm.DB, cfg *config.Config, store storage.Driver, userID uint, filename, mimeType string, content []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename=%q`, filename))
+	header.Set("Content-Type", mimeType)
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		t.Fatalf("create part: %v", err)
+	}
+	_, _ = part.Write(content)
+	_ = mw.Close()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/files", body)
+	c.Request.Header.Set("Content-Type", mw.FormDataContentType())
+	c.Set("userID", userID)
+	c.Set("role", models.RoleUser)
+	UploadFile(db, cfg, store)(c)
+	return w
+}
+
+func TestUploadSniffsContentType(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	owner := createUser(t, db, "owner", models.RoleUser)
+
+	cases := []struct {
+		name, declared, want string
+		content              []byte
+	}{
+		{"evil.png", "image/png", "text/html", []byte("<!DOCTYPE html><html><script>alert(1)</script></html>")},
+		{"photo", "application/octet-stream", "image/png", testPNG(t, 4, 4)},
+		{"table.csv", "text/csv", "text/csv", []byte("a,b\n1,2\n")},
+	}
+	for _, tc := range cases {
+		w := uploadTyped(t, db, cfg, store, owner.ID, tc.name, tc.declared, tc.content)
+		if w.Code != http.StatusOK {
+			t.Fatalf("upload %s status = %d body=%s", tc.name, w.Code, w.Body.String())
+		}
+		var f models.File
+		db.Where("filename = ?", tc.name).First(&f)
+		if f.MimeType != tc.want || f.DeclaredMimeType != tc.declared {
+			t.Fatalf("%s: mime=%q declared=%q detected=%q, want mime %q", tc.name, f.MimeType, f.DeclaredMimeType, f.DetectedMimeType, tc.want)
+		}
+	}
+
+	// 伪装成图片的 HTML 预览时也必须以附件返回
+	var evil models.File
+	db.Where("filename = ?", "evil.png").First(&evil)
+	w := callFileAs(StreamFile(db, store), owner, http.MethodGet, evil.ID, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("stream status = %d", w.Code)
+	}
+	if got := w.Header().Get("Content-Disposition"); !strings.HasPrefix(got, "attachment") {
+		t.Fatalf("active content disposition = %q, want attachment", got)
+	}
+	if w.Header().Get("X-Content-Type-Options") != "nosniff" || w.Header().Get("Content-Security-Policy") != "sandbox" {
+		t.Fatalf("missing protective headers: %v", w.Header())
+	}
+	var photo models.File
+	db.Where("filename = ?", "photo").First(&photo)
+	if w := callFileAs(StreamFile(db, store), owner, http.MethodGet, photo.ID, ""); w.Header().Get("Content-Disposition") != "" {
+		t.Fatalf("image preview should stay inline, got %q", w.Header().Get("Content-Disposition"))
+	}
+}
+
+func TestUploadContentTypePolicy(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	owner := createUser(t, db, "owner", models.RoleUser)
+
+	// 黑名单同时检查声明与识别出的类型
+	cfg.UploadDeniedTypes = []string{"text/html"}
+	w := uploadTyped(t, db, cfg, store, owner.ID, "page.txt", "text/plain", []byte("<html><body>hi</body></html>"))
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("denied upload status = %d, want 415", w.Code)
+	}
+	var resp map[string]string
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp["detected_mime_type"] != "text/html" {
+		t.Fatalf("415 response should report detected type: %v", resp)
+	}
+
+	cfg.UploadDeniedTypes = nil
+	cfg.UploadAllowedTypes = []string{"image/*"}
+	if w := uploadTyped(t, db, cfg, store, owner.ID, "notes.png", "image/png", []byte("just some text")); w.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("allow list should reject text, status = %d", w.Code)
+	}
+	if w := uploadTyped(t, db, cfg, store, owner.ID, "ok.png", "image/png", testPNG(t, 2, 2)); w.Code != http.StatusOK {
+		t.Fatalf("allowed image status = %d body=%s", w.Code, w.Body.String())
+	}
+	var count int64
+	db.Model(&models.File{}).Count(&count)
+	if count != 1 {
+		t.Fatalf("rejected uploads should not be stored, got %d files", count)
+	}
+}
@@ -0,0 +1,472 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"content-hub/server/config"
+	"content-hub/server/models"
+	"content-hub/server/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const maxDropFiles = 1000
+
+var (
+	errDropGone     = errors.New("上传链接已失效")
+	errDropTooLarge = errors.New("文件超过上传链接允许的大小")
+	errDropType     = errors.New("上传链接不接受该文件类型")
+	errDropFileOnly = errors.New("上传链接仅接受文件（multipart 字段 file）")
+)
+
+// createDropRequest 为创建上传链接的参数；有效期参数与分享一致，同样受 SHARE_NEVER_EXPIRE 与 SHARE_MAX_LIFETIME 限制。
+type createDropRequest struct {
+	Title        string   `json:"title"`
+	Instructions string   `json:"instructions"`
+	FolderID     *uint    `json:"folder_id"`     // 目标目录，省略或 0 表示根目录
+	MaxFiles     *uint    `json:"max_files"`     // 可上传的文件总数，省略表示不限
+	MaxFileSize  int64    `json:"max_file_size"` // 单个文件的字节上限，0 表示仅受配额限制
+	AllowedTypes []string `json:"allowed_types"` // 允许的 MIME 类型，支持 image/*
+	expiryInput
+}
+
+// fileDropItem 为上传链接在所有者视角的信息。
+type fileDropItem struct {
+	Token        string     `json:"token"`
+	ID           uint       `json:"id"`
+	Owner        string     `json:"owner"`
+	Title        string     `json:"title"`
+	Instructions string     `json:"instructions"`
+	FolderID     *uint      `json:"folder_id"`
+	MaxFiles     *uint      `json:"max_files"`
+	MaxFileSize  int64      `json:"max_file_size"`
+	AllowedTypes []string   `json:"allowed_types"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	UploadCount  uint       `json:"upload_count"`
+	FileCount    int64      `json:"file_count"` // 仍在空间中（未删除）的文件数
+	Expired      bool       `json:"expired"`
+	UploadPath   string     `json:"upload_path"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// CreateFileDrop 创建上传链接，持有链接的访客无需登录即可上传文件到当前用户的空间。
+// @Summary 创建上传链接
+// @Tags drops
+// @Accept json
+// @Produce json
+// @Param payload body createDropRequest true "上传链接参数"
+// @Success 200 {object} fileDropItem
+// @Security BearerAuth
+// @Router /drops [post]
+func CreateFileDrop(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, role := currentUser(c)
+		var req createDropRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+			return
+		}
+
+		title := strings.TrimSpace(req.Title)
+		if title == "" {
+			title = "文件收集"
+		}
+		if len(title) > 255 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "title 过长"})
+			return
+		}
+		if req.MaxFiles != nil && (*req.MaxFiles == 0 || *req.MaxFiles > maxDropFiles) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("max_files 需为 1-%d", maxDropFiles)})
+			return
+		}
+		if req.MaxFileSize < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "max_file_size 不能为负数"})
+			return
+		}
+		types, err := normalizeDropTypes(req.AllowedTypes)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		folderID := normalizeFolderID(req.FolderID)
+		if err := checkFolderOwner(db, folderID, userID); err != nil {
+			writeFolderError(c, err)
+			return
+		}
+
+		now := time.Now()
+		expiresAt, set, err := req.resolveExpiry(cfg, role == models.RoleAdmin, now)
+		if err != nil {
+			writeExpiryError(c, err)
+			return
+		}
+		if !set {
+			expiresAt = defaultExpiry(cfg, now)
+		}
+
+		drop := models.FileDrop{
+			Token:        uuid.NewString(),
+			OwnerID:      userID,
+			FolderID:     folderID,
+			Title:        title,
+			Instructions: strings.TrimSpace(req.Instructions),
+			MaxFiles:     req.MaxFiles,
+			MaxFileSize:  req.MaxFileSize,
+			AllowedTypes: strings.Join(types, ","),
+			ExpiresAt:    expiresAt,
+		}
+		if err := db.Omit("Owner").Create(&drop).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		db.Preload("Owner").First(&drop, drop.ID)
+		c.JSON(http.StatusOK, buildFileDropItem(&drop, 0, now))
+	}
+}
+
+// FileDropListResponse 是上传链接列表的分页结果。
+type FileDropListResponse struct {
+	Items []fileDropItem `json:"items"`
+	PageInfo
+}
+
+// fileDropListSpec 定义上传链接列表允许的排序字段。
+var fileDropListSpec = listSpec{
+	Table:   "file_drops",
+	Fields:  map[string]sortKind{"created_at": sortTime, "upload_count": sortInt},
+	Default: "created_at",
+}
+
+// ListFileDrops 分页列出当前用户的上传链接（含已过期），管理员可见全部。
+// @Summary 我的上传链接
+// @Tags drops
+// @Produce json
+// @Param limit query int false "每页数量，默认 50，最大 200"
+// @Param cursor query string false "上一页返回的 next_cursor"
+// @Param sort query string false "排序字段：created_at / upload_count"
+// @Param order query string false "asc 或 desc，默认 desc"
+// @Param include_total query bool false "是否返回过滤后的总数"
+// @Success 200 {object} FileDropListResponse
+// @Security BearerAuth
+// @Router /drops [get]
+func ListFileDrops(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lq, ok := parseListQuery(c, fileDropListSpec)
+		if !ok {
+			return
+		}
+		userID, role := currentUser(c)
+		query := db.Model(&models.FileDrop{})
+		if role != models.RoleAdmin {
+			query = query.Where("file_drops.owner_id = ?", userID)
+		}
+
+		total, err := lq.total(query)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		query, err = lq.apply(query)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cursor 无效"})
+			return
+		}
+		var drops []models.FileDrop
+		if err := query.Preload("Owner").Find(&drops).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		drops, page := paginate(lq, drops, func(d *models.FileDrop, sort string) (any, uint) {
+			if sort == "upload_count" {
+				return d.UploadCount, d.ID
+			}
+			return d.CreatedAt, d.ID
+		})
+		page.Total = total
+
+		counts := map[uint]int64{}
+		if len(drops) > 0 {
+			ids := make([]uint, len(drops))
+			for i := range drops {
+				ids[i] = drops[i].ID
+			}
+			var rows []struct {
+				DropID uint
+				Count  int64
+			}
+			if err := db.Model(&models.File{}).Select("drop_id, COUNT(*) AS count").
+				Where("drop_id IN ?", ids).Group("drop_id").Scan(&rows).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			for _, r := range rows {
+				counts[r.DropID] = r.Count
+			}
+		}
+
+		now := time.Now()
+		resp := FileDropListResponse{Items: make([]fileDropItem, 0, len(drops)), PageInfo: page}
+		for i := range drops {
+			resp.Items = append(resp.Items, buildFileDropItem(&drops[i], counts[drops[i].ID], now))
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// RevokeFileDrop 撤销上传链接，已收集的文件保留并继续记录来源链接；仅所有者与管理员可操作。
+// @Summary 撤销上传链接
+// @Tags drops
+// @Produce json
+// @Param token path string true "上传链接 Token"
+// @Security BearerAuth
+// @Router /drops/{token} [delete]
+func RevokeFileDrop(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, role := currentUser(c)
+		query := db.Where("token = ?", c.Param("token"))
+		if role != models.RoleAdmin {
+			query = query.Where("owner_id = ?", userID)
+		}
+		var drop models.FileDrop
+		if err := query.First(&drop).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "drop not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err := db.Delete(&drop).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "drop revoked"})
+	}
+}
+
+// GetFileDropMeta 返回上传页面所需的信息，无需登录；链接不存在返回 404，已过期或已收满返回 410。
+// @Summary 获取上传链接信息
+// @Tags drops
+// @Produce json
+// @Param token path string true "上传链接 Token"
+// @Router /drops/{token} [get]
+func GetFileDropMeta(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		drop, ok := loadOpenDrop(c, db)
+		if !ok {
+			return
+		}
+		var remaining *uint
+		if drop.MaxFiles != nil {
+			n := *drop.MaxFiles - drop.UploadCount
+			remaining = &n
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"title":           drop.Title,
+			"instructions":    drop.Instructions,
+			"owner":           drop.Owner.Username,
+			"max_files":       drop.MaxFiles,
+			"remaining_files": remaining,
+			"max_file_size":   drop.MaxFileSize,
+			"allowed_types":   dropTypes(drop),
+			"expires_at":      drop.ExpiresAt,
+		})
+	}
+}
+
+// UploadToFileDrop 通过上传链接匿名上传单个文件（multipart 字段 file，可附 description），文件归入链接所有者的空间；不接受 text 文本条目。
+// 除链接自身的数量、大小与类型限制外，仍执行全局上传类型策略并计入所有者配额；超限时不返回所有者的用量明细。
+// @Summary 通过上传链接上传文件
+// @Tags drops
+// @Accept mpfd
+// @Produce json
+// @Param token path string true "上传链接 Token"
+// @Param file formData file true "文件"
+// @Param description formData string false "说明"
+// @Router /drops/{token}/files [post]
+func UploadToFileDrop(db *gorm.DB, cfg *config.Config, store storage.Driver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		drop, ok := loadOpenDrop(c, db)
+		if !ok {
+			return
+		}
+		quota, err := loadUserQuota(db, cfg, drop.OwnerID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		quota.Masked = true
+		if !quota.allows(0, 1) {
+			writeQuotaExceeded(c, quota)
+			return
+		}
+		if drop.MaxFileSize > 0 {
+			if c.Request.ContentLength > drop.MaxFileSize+multipartOverhead {
+				writeDropTooLarge(c, drop)
+				return
+			}
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, drop.MaxFileSize+multipartOverhead)
+		}
+		in, cleanup, ok := readUploadForm(c, quota, 1)
+		if !ok {
+			return
+		}
+		defer cleanup()
+		// 上传链接只收集文件：共用的表单解析也接受 text 字段，这里拒绝文本条目
+		if _, err := c.FormFile("file"); err != nil || c.PostForm("text") != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errDropFileOnly.Error()})
+			return
+		}
+		if drop.MaxFileSize > 0 {
+			if in.Size > drop.MaxFileSize {
+				writeDropTooLarge(c, drop)
+				return
+			}
+			if in.MaxBytes < 0 || in.MaxBytes > drop.MaxFileSize {
+				in.MaxBytes = drop.MaxFileSize
+			}
+		}
+		if !prepareUploadContent(c, cfg, in) {
+			return
+		}
+		if types := dropTypes(drop); len(types) > 0 && !matchMimePatterns(types, in.Types.Mime) {
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{
+				"error":         errDropType.Error(),
+				"mime_type":     in.Types.Mime,
+				"allowed_types": types,
+			})
+			return
+		}
+
+		// 先占用名额再写入，并发上传时不会超过 max_files；写入失败时归还
+		res := db.Model(&models.FileDrop{}).
+			Where("id = ? AND (max_files IS NULL OR upload_count < max_files)", drop.ID).
+			UpdateColumn("upload_count", gorm.Expr("upload_count + 1"))
+		if res.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
+			return
+		}
+		if res.RowsAffected == 0 {
+			c.JSON(http.StatusGone, gin.H{"error": errDropGone.Error()})
+			return
+		}
+		release := func() {
+			db.Model(&models.FileDrop{}).Where("id = ?", drop.ID).
+				UpdateColumn("upload_count", gorm.Expr("upload_count - 1"))
+		}
+
+		in.OwnerID = drop.OwnerID
+		in.DropID = &drop.ID
+		// 目标目录已被删除时放入根目录
+		if err := checkFolderOwner(db, drop.FolderID, drop.OwnerID); err == nil {
+			in.FolderID = drop.FolderID
+		} else if !errors.Is(err, errFolderNotFound) {
+			release()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		f, err := persistUpload(c.Request.Context(), db, cfg, store, *in)
+		if err != nil {
+			release()
+			if errors.Is(err, errQuotaExceeded) {
+				if drop.MaxFileSize > 0 && in.MaxBytes == drop.MaxFileSize {
+					writeDropTooLarge(c, drop)
+					return
+				}
+				writeQuotaExceeded(c, quota)
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var remaining *uint
+		if drop.MaxFiles != nil {
+			db.Model(&models.FileDrop{}).Where("id = ?", drop.ID).Pluck("upload_count", &drop.UploadCount)
+			n := uint(0)
+			if drop.UploadCount < *drop.MaxFiles {
+				n = *drop.MaxFiles - drop.UploadCount
+			}
+			remaining = &n
+		}
+		c.JSON(http.StatusOK, gin.H{"filename": f.Filename, "size": f.Size, "remaining_files": remaining})
+	}
+}
+
+// loadOpenDrop 读取路径参数中仍可上传的链接：不存在返回 404，已过期或已收满返回 410。失败时已写入响应。
+func loadOpenDrop(c *gin.Context, db *gorm.DB) (*models.FileDrop, bool) {
+	var drop models.FileDrop
+	if err := db.Preload("Owner").Where("token = ?", c.Param("token")).First(&drop).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "drop not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if drop.Owner.ID == 0 {
+		// 所有者账号已删除
+		c.JSON(http.StatusNotFound, gin.H{"error": "drop not found"})
+		return nil, false
+	}
+	if drop.Expired(time.Now()) || drop.Full() {
+		c.JSON(http.StatusGone, gin.H{"error": errDropGone.Error()})
+		return nil, false
+	}
+	return &drop, true
+}
+
+func writeDropTooLarge(c *gin.Context, drop *models.FileDrop) {
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": errDropTooLarge.Error(), "max_file_size": drop.MaxFileSize})
+}
+
+// normalizeDropTypes 规范化允许的 MIME 类型列表：去除空白、转为小写并去重，拒绝不含 / 的项。
+func normalizeDropTypes(types []string) ([]string, error) {
+	seen := map[string]bool{}
+	out := make([]string, 0, len(types))
+	for _, t := range types {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		if !strings.Contains(t, "/") || strings.Contains(t, ",") {
+			return nil, fmt.Errorf("allowed_types 中的 %q 不是有效的 MIME 类型", t)
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+func dropTypes(drop *models.FileDrop) []string {
+	if drop.AllowedTypes == "" {
+		return []string{}
+	}
+	return strings.Split(drop.AllowedTypes, ",")
+}
+
+func buildFileDropItem(drop *models.FileDrop, fileCount int64, now time.Time) fileDropItem {
+	return fileDropItem{
+		Token:        drop.Token,
+		ID:           drop.ID,
+		Owner:        drop.Owner.Username,
+		Title:        drop.Title,
+		Instructions: drop.Instructions,
+		FolderID:     drop.FolderID,
+		MaxFiles:     drop.MaxFiles,
+		MaxFileSize:  drop.MaxFileSize,
+		AllowedTypes: dropTypes(drop),
+		ExpiresAt:    drop.ExpiresAt,
+		UploadCount:  drop.UploadCount,
+		FileCount:    fileCount,
+		Expired:      drop.Expired(now) || drop.Full(),
+		UploadPath:   fmt.Sprintf("/drop/%s", drop.Token),
+		CreatedAt:    drop.CreatedAt,
+	}
+}
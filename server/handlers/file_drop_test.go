@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"content-hub/server/config"
+	"content-hub/server/models"
+	"content-hub/server/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// uploadToDrop 以匿名访客身份通过上传链接上传文件。
+func uploadToDrop(t *testing.T, db *gorm.DB, cfg *config.Config, store storage.Driver, token, filename string, content []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	part, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("create form file: %v", err)
+	}
+	_, _ = part.Write(content)
+	_ = mw.Close()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/drops/"+token+"/files", body)
+	c.Request.Header.Set("Content-Type", mw.FormDataContentType())
+	c.Params = gin.Params{{Key: "token", Value: token}}
+	UploadToFileDrop(db, cfg, store)(c)
+	return w
+}
+
+func createDrop(t *testing.T, db *gorm.DB, cfg *config.Config, owner models.User, body string) fileDropItem {
+	t.Helper()
+	w := callManagedShare(CreateFileDrop(db, cfg), owner, http.MethodPost, "", body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("create drop status = %d body=%s", w.Code, w.Body.String())
+	}
+	var item fileDropItem
+	_ = json.Unmarshal(w.Body.Bytes(), &item)
+	return item
+}
+
+func TestFileDropUpload(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	owner := createUser(t, db, "owner", models.RoleUser)
+	other := createUser(t, db, "other", models.RoleUser)
+	inbox := models.Folder{OwnerID: owner.ID, Name: "收件箱"}
+	db.Create(&inbox)
+	foreign := models.Folder{OwnerID: other.ID, Name: "x"}
+	db.Create(&foreign)
+
+	for _, body := range []string{
+		`{"max_files":0}`,
+		`{"max_file_size":-1}`,
+		`{"allowed_types":["pdf"]}`,
+	} {
+		if w := callManagedShare(CreateFileDrop(db, cfg), owner, http.MethodPost, "", body); w.Code != http.StatusBadRequest {
+			t.Fatalf("create %s status = %d, want 400", body, w.Code)
+		}
+	}
+	if w := callManagedShare(CreateFileDrop(db, cfg), owner, http.MethodPost, "", `{"folder_id":`+jsonID(foreign.ID)+`}`); w.Code != http.StatusNotFound {
+		t.Fatalf("foreign folder status = %d, want 404", w.Code)
+	}
+
+	// folder_id 为 0 与创建目录一致，表示根目录
+	root := createDrop(t, db, cfg, owner, `{"folder_id":0}`)
+	if root.FolderID != nil {
+		t.Fatalf("root drop folder = %v, want nil", *root.FolderID)
+	}
+	db.Delete(&models.FileDrop{}, root.ID)
+
+	drop := createDrop(t, db, cfg, owner, `{"title":"作业提交","folder_id":`+jsonID(inbox.ID)+`,"max_files":2,"max_file_size":16,"allowed_types":["Text/*"," "],"expires_in":"2h"}`)
+	if drop.UploadPath != "/drop/"+drop.Token || len(drop.AllowedTypes) != 1 || drop.AllowedTypes[0] != "text/*" || drop.ExpiresAt == nil {
+		t.Fatalf("created drop = %+v", drop)
+	}
+
+	w := callShare(GetFileDropMeta(db), http.MethodGet, drop.Token, "", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"owner":"owner"`) || !strings.Contains(w.Body.String(), `"remaining_files":2`) {
+		t.Fatalf("meta status = %d body=%s", w.Code, w.Body.String())
+	}
+
+	if w := uploadToDrop(t, db, cfg, store, drop.Token, "big.txt", bytes.Repeat([]byte("a"), 17)); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized status = %d, want 413", w.Code)
+	}
+	// 文本条目不受链接的类型与大小设置约束，上传链接不接受
+	tw := httptest.NewRecorder()
+	tc, _ := gin.CreateTestContext(tw)
+	tc.Request = httptest.NewRequest(http.MethodPost, "/api/drops/"+drop.Token+"/files", strings.NewReader("text=hello"))
+	tc.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	tc.Params = gin.Params{{Key: "token", Value: drop.Token}}
+	UploadToFileDrop(db, cfg, store)(tc)
+	if tw.Code != http.StatusBadRequest {
+		t.Fatalf("text upload status = %d, want 400", tw.Code)
+	}
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	if w := uploadToDrop(t, db, cfg, store, drop.Token, "p.png", png); w.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("disallowed type status = %d, want 415", w.Code)
+	}
+	w = uploadToDrop(t, db, cfg, store, drop.Token, "report.txt", []byte("homework"))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"remaining_files":1`) {
+		t.Fatalf("upload status = %d body=%s", w.Code, w.Body.String())
+	}
+	var f models.File
+	db.Where("filename = ?", "report.txt").First(&f)
+	if f.OwnerID != owner.ID || f.FolderID == nil || *f.FolderID != inbox.ID || f.DropID == nil || *f.DropID != drop.ID {
+		t.Fatalf("stored file = %+v", f)
+	}
+
+	// 所有者可按来源链接筛选文件
+	lw := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(lw)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/files?drop_id="+jsonID(drop.ID), nil)
+	c.Set("userID", owner.ID)
+	c.Set("role", owner.Role)
+	ListFiles(db)(c)
+	if lw.Code != http.StatusOK || !strings.Contains(lw.Body.String(), `"drop_id":`+jsonID(drop.ID)) {
+		t.Fatalf("list by drop status = %d body=%s", lw.Code, lw.Body.String())
+	}
+
+	// 目标目录删除后落到根目录；收满后链接失效
+	db.Delete(&inbox)
+	if w := uploadToDrop(t, db, cfg, store, drop.Token, "late.txt", []byte("late")); w.Code != http.StatusOK {
+		t.Fatalf("second upload status = %d body=%s", w.Code, w.Body.String())
+	}
+	var late models.File
+	db.Where("filename = ?", "late.txt").First(&late)
+	if late.ID == 0 || late.FolderID != nil {
+		t.Fatalf("fallback file = %+v, want root folder", late)
+	}
+	if w := uploadToDrop(t, db, cfg, store, drop.Token, "extra.txt", []byte("extra")); w.Code != http.StatusGone {
+		t.Fatalf("full drop status = %d, want 410", w.Code)
+	}
+	if w := callShare(GetFileDropMeta(db), http.MethodGet, drop.Token, "", ""); w.Code != http.StatusGone {
+		t.Fatalf("full meta status = %d, want 410", w.Code)
+	}
+
+	lw = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(lw)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/drops", nil)
+	c.Set("userID", owner.ID)
+	c.Set("role", owner.Role)
+	ListFileDrops(db)(c)
+	var listed FileDropListResponse
+	_ = json.Unmarshal(lw.Body.Bytes(), &listed)
+	if len(listed.Items) != 1 || listed.HasMore || listed.Items[0].UploadCount != 2 || listed.Items[0].FileCount != 2 || !listed.Items[0].Expired {
+		t.Fatalf("listed drops = %s", lw.Body.String())
+	}
+
+	// 列表与其他列表接口共用游标分页
+	second := createDrop(t, db, cfg, owner, `{}`)
+	createDrop(t, db, cfg, other, `{}`)
+	listDrops := func(query string) FileDropListResponse {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/drops?"+query, nil)
+		c.Set("userID", owner.ID)
+		c.Set("role", owner.Role)
+		ListFileDrops(db)(c)
+		if w.Code != http.StatusOK {
+			t.Fatalf("list drops %q status = %d body=%s", query, w.Code, w.Body.String())
+		}
+		var page FileDropListResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &page)
+		return page
+	}
+	page := listDrops("limit=1&include_total=true")
+	if len(page.Items) != 1 || page.Items[0].Token != second.Token || !page.HasMore || page.Total == nil || *page.Total != 2 {
+		t.Fatalf("first page = %+v", page)
+	}
+	page = listDrops("limit=1&cursor=" + page.NextCursor)
+	if len(page.Items) != 1 || page.Items[0].Token != drop.Token || page.HasMore {
+		t.Fatalf("second page = %+v", page)
+	}
+}
+
+func TestFileDropQuotaAndRevoke(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	owner := createUser(t, db, "owner", models.RoleUser)
+	other := createUser(t, db, "other", models.RoleUser)
+	db.Model(&owner).Update("quota_bytes", 10)
+
+	drop := createDrop(t, db, cfg, owner, `{}`)
+	w := uploadToDrop(t, db, cfg, store, drop.Token, "big.txt", bytes.Repeat([]byte("a"), 11))
+	// 匿名访客看不到所有者的用量
+	if w.Code != http.StatusRequestEntityTooLarge || strings.Contains(w.Body.String(), "used_bytes") {
+		t.Fatalf("quota status = %d body=%s", w.Code, w.Body.String())
+	}
+	var n int64
+	db.Model(&models.FileDrop{}).Where("id = ?", drop.ID).Pluck("upload_count", &n)
+	if n != 0 {
+		t.Fatalf("upload count after rejection = %d, want 0", n)
+	}
+
+	if w := callManagedShare(RevokeFileDrop(db), other, http.MethodDelete, drop.Token, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("foreign revoke status = %d, want 404", w.Code)
+	}
+	if w := callManagedShare(RevokeFileDrop(db), owner, http.MethodDelete, drop.Token, ""); w.Code != http.StatusOK {
+		t.Fatalf("revoke status = %d", w.Code)
+	}
+	if w := uploadToDrop(t, db, cfg, store, drop.Token, "a.txt", []byte("a")); w.Code != http.StatusNotFound {
+		t.Fatalf("revoked upload status = %d, want 404", w.Code)
+	}
+}
@@ -1,57 +1,169 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
-	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"content-hub/server/config"
+	"content-hub/server/highlight"
 	"content-hub/server/models"
+	"content-hub/server/storage"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+// defaultMultipartMemory 与 gin 默认的 MaxMultipartMemory 一致，超出部分落盘暂存。
+const defaultMultipartMemory = 32 << 20
+
 type FileResponse struct {
-	ID          uint      `json:"id"`
-	Filename    string    `json:"filename"`
-	Size        int64     `json:"size"`
-	MimeType    string    `json:"mime_type"`
-	Description string    `json:"description"`
-	Owner       string    `json:"owner"`
-	PublicLink  string    `json:"public_link"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID       uint   `json:"id"`
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+	MimeType string `json:"mime_type"`
+	// DeclaredMimeType / DetectedMimeType 为上传时客户端声明与按内容识别出的类型，旧文件为空
+	DeclaredMimeType string    `json:"declared_mime_type,omitempty"`
+	DetectedMimeType string    `json:"detected_mime_type,omitempty"`
+	Description      string    `json:"description"`
+	Owner            string    `json:"owner"`
+	PublicLink       string    `json:"public_link"`
+	FolderID         *uint     `json:"folder_id"`
+	Visibility       string    `json:"visibility"`
+	Version          uint      `json:"version"`
+	Tags             []string  `json:"tags,omitempty"`
+	SharedWith       []string  `json:"shared_with,omitempty"` // 仅对所有者与管理员返回
+	Digest           string    `json:"digest"`                // 内容的 SHA-256（十六进制），客户端可据此校验完整性
+	ScanStatus       string    `json:"scan_status"`           // pending/clean/infected/error，仅 clean 可下载
+	DropID           *uint     `json:"drop_id,omitempty"`     // 通过上传链接收集的文件所属的链接
+	Language         string    `json:"language,omitempty"`    // 文本内容的语言，用于分享时的语法高亮
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// fileListSpec 定义文件列表允许的排序字段。
+var fileListSpec = listSpec{
+	Table:   "files",
+	Fields:  map[string]sortKind{"created_at": sortTime, "updated_at": sortTime, "filename": sortString, "size": sortInt},
+	Default: "created_at",
 }
 
-// ListFiles 列出当前用户可见的文件列表。
+// fileSortKey 返回文件在 fileListSpec 排序字段上的值与 ID，用于生成下一页游标。
+func fileSortKey(f *models.File, sort string) (any, uint) {
+	switch sort {
+	case "updated_at":
+		return f.UpdatedAt, f.ID
+	case "filename":
+		return f.Filename, f.ID
+	case "size":
+		return f.Size, f.ID
+	default:
+		return f.CreatedAt, f.ID
+	}
+}
+
+// FileListResponse 是文件列表的分页结果。
+type FileListResponse struct {
+	Items []FileResponse `json:"items"`
+	PageInfo
+}
+
+// ListFiles 分页列出当前用户可见的文件：自己的、公开的以及被单独授权的文件，管理员可见全部。
 // @Summary 获取文件列表
 // @Tags files
 // @Produce json
+// @Param limit query int false "每页数量，默认 50，最大 200"
+// @Param cursor query string false "上一页返回的 next_cursor"
+// @Param sort query string false "排序字段：created_at / updated_at / filename / size"
+// @Param order query string false "asc 或 desc，默认 desc"
+// @Param include_total query bool false "是否返回过滤后的总数"
+// @Param owner query string false "上传者用户名"
+// @Param mime query string false "MIME 类型，如 text/plain 或 image/*"
+// @Param folder_id query int false "所在目录ID，0 表示根目录"
+// @Param visibility query string false "private / users / public"
+// @Param drop_id query int false "来源上传链接ID"
+// @Param tag query []string false "标签名，可重复，需同时满足" collectionFormat(multi)
+// @Success 200 {object} FileListResponse
 // @Security BearerAuth
 // @Router /files [get]
 func ListFiles(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		lq, ok := parseListQuery(c, fileListSpec)
+		if !ok {
+			return
+		}
+		userID, role := currentUser(c)
+		query := db.Model(&models.File{}).Scopes(models.VisibleTo(userID, role))
+		if v := strings.TrimSpace(c.Query("owner")); v != "" {
+			query = query.Where("files.owner_id IN (?)", db.Model(&models.User{}).Select("id").Where("username = ?", v))
+		}
+		if v := strings.TrimSpace(c.Query("mime")); v != "" {
+			if prefix, ok := strings.CutSuffix(v, "*"); ok {
+				query = query.Where(`files.mime_type LIKE ? ESCAPE '\'`, models.EscapeLike(prefix)+"%")
+			} else {
+				query = query.Where("files.mime_type = ?", v)
+			}
+		}
+		if v := c.Query("folder_id"); v != "" {
+			folderID, err := parseFolderID(v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "folder_id 无效"})
+				return
+			}
+			if folderID == nil {
+				query = query.Where("files.folder_id IS NULL")
+			} else {
+				query = query.Where("files.folder_id = ?", *folderID)
+			}
+		}
+		if v := c.Query("visibility"); v != "" {
+			if !models.ValidVisibility(v) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "visibility 仅支持 private / users / public"})
+				return
+			}
+			query = query.Where("files.visibility = ?", v)
+		}
+		if v := c.Query("drop_id"); v != "" {
+			dropID, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "drop_id 无效"})
+				return
+			}
+			query = query.Where("files.drop_id = ?", dropID)
+		}
+		if query, ok = tagFilter(c, db, query); !ok {
+			return
+		}
+
+		total, err := lq.total(query)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		query, err = lq.apply(query)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cursor 无效"})
+			return
+		}
 		var files []models.File
-		if err := db.Preload("Owner").Order("created_at desc").Find(&files).Error; err != nil {
+		if err := query.Preload("Owner").Find(&files).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		resp := make([]FileResponse, 0, len(files))
-		for _, f := range files {
-			resp = append(resp, FileResponse{
-				ID:          f.ID,
-				Filename:    f.Filename,
-				Size:        f.Size,
-				MimeType:    f.MimeType,
-				Description: f.Description,
-				Owner:       f.Owner.Username,
-				PublicLink:  f.PublicLink,
-				CreatedAt:   f.CreatedAt,
-			})
+		files, page := paginate(lq, files, fileSortKey)
+		page.Total = total
+
+		resp := FileListResponse{Items: make([]FileResponse, 0, len(files)), PageInfo: page}
+		for i := range files {
+			resp.Items = append(resp.Items, buildFileResponse(&files[i]))
+		}
+		if err := fillFileTags(db, resp.Items); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
 		}
 		c.JSON(http.StatusOK, resp)
 	}
@@ -65,10 +177,11 @@ func ListFiles(db *gorm.DB) gin.HandlerFunc {
 // @Param file formData file false "上传文件"
 // @Param text formData string false "纯文本内容"
 // @Param description formData string false "描述"
+// @Param folder_id formData int false "目标目录ID，省略表示根目录"
 // @Security BearerAuth
 // @Security ApiKeyAuth
 // @Router /files [post]
-func UploadFile(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+func UploadFile(db *gorm.DB, cfg *config.Config, store storage.Driver) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userIDVal, exists := c.Get("userID")
 		userID, ok := userIDVal.(uint)
@@ -77,59 +190,49 @@ func UploadFile(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
-		description := c.PostForm("description")
-		textContent := c.PostForm("text")
-		fileHeader, err := c.FormFile("file")
-		if err != nil && textContent == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "file or text is required"})
+		quota, err := loadUserQuota(db, cfg, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-
-		if err := os.MkdirAll(cfg.UploadDir, 0o755); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if !quota.allows(0, 1) {
+			writeQuotaExceeded(c, quota)
 			return
 		}
+		in, cleanup, ok := readUploadForm(c, quota, 1)
+		if !ok {
+			return
+		}
+		defer cleanup()
 
-		var savedPath, filename, mime string
-		var size int64
+		folderID, err := parseFolderID(c.PostForm("folder_id"))
+		if err == nil {
+			err = checkFolderOwner(db, folderID, userID)
+		}
+		if err != nil {
+			writeFolderError(c, err)
+			return
+		}
+		in.OwnerID = userID
+		in.FolderID = folderID
+		if !prepareUploadContent(c, cfg, in) {
+			return
+		}
 
-		if fileHeader != nil {
-			filename = fileHeader.Filename
-			mime = fileHeader.Header.Get("Content-Type")
-			savedPath = filepath.Join(cfg.UploadDir, fmt.Sprintf("%d-%s", time.Now().UnixNano(), filename))
-			if err := c.SaveUploadedFile(fileHeader, savedPath); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
-			}
-			size = fileHeader.Size
-		} else {
-			filename = fmt.Sprintf("text-%d.txt", time.Now().UnixNano())
-			mime = "text/plain"
-			savedPath = filepath.Join(cfg.UploadDir, filename)
-			if err := os.WriteFile(savedPath, []byte(textContent), 0o644); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		f, err := persistUpload(c.Request.Context(), db, cfg, store, *in)
+		if err != nil {
+			if errors.Is(err, errQuotaExceeded) {
+				writeQuotaExceeded(c, quota)
 				return
 			}
-			size = int64(len(textContent))
-		}
-
-		f := models.File{
-			OwnerID:     userID,
-			Filename:    filename,
-			Path:        savedPath,
-			Size:        size,
-			MimeType:    mime,
-			Description: description,
-		}
-		if err := db.Create(&f).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"id": f.ID, "filename": f.Filename})
+		c.JSON(http.StatusOK, gin.H{"id": f.ID, "filename": f.Filename, "digest": f.Digest})
 	}
 }
 
-func DownloadFile(db *gorm.DB) gin.HandlerFunc {
+func DownloadFile(db *gorm.DB, store storage.Driver) gin.HandlerFunc {
 	// DownloadFile 通过附件形式下载指定文件。
 	// @Summary 下载文件
 	// @Tags files
@@ -138,12 +241,11 @@ func DownloadFile(db *gorm.DB) gin.HandlerFunc {
 	// @Security BearerAuth
 	// @Router /files/{id}/download [get]
 	return func(c *gin.Context) {
-		var f models.File
-		if err := db.First(&f, c.Param("id")).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		f, ok := loadVisibleFile(c, db)
+		if !ok {
 			return
 		}
-		c.FileAttachment(f.Path, f.Filename)
+		serveStoredFile(c, store, f, "attachment")
 	}
 }
 
@@ -157,27 +259,32 @@ func GetFileInfo(db *gorm.DB) gin.HandlerFunc {
 	// @Security BearerAuth
 	// @Router /files/{id} [get]
 	return func(c *gin.Context) {
-		var f models.File
-		if err := db.Preload("Owner").First(&f, c.Param("id")).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		f, ok := loadVisibleFile(c, db)
+		if !ok {
 			return
 		}
-		c.JSON(http.StatusOK, FileResponse{
-			ID:          f.ID,
-			Filename:    f.Filename,
-			Size:        f.Size,
-			MimeType:    f.MimeType,
-			Description: f.Description,
-			Owner:       f.Owner.Username,
-			PublicLink:  f.PublicLink,
-			CreatedAt:   f.CreatedAt,
-		})
+		resp := buildFileResponse(f)
+		tagged := []FileResponse{resp}
+		if err := fillFileTags(db, tagged); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		resp = tagged[0]
+		if userID, role := currentUser(c); role == models.RoleAdmin || f.OwnerID == userID {
+			names, err := fileGrantees(db, f.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			resp.SharedWith = names
+		}
+		c.JSON(http.StatusOK, resp)
 	}
 }
 
 // StreamFile returns raw content preview (text/image) with MIME.
-func StreamFile(db *gorm.DB) gin.HandlerFunc {
-	// StreamFile 以内联方式预览文件内容。
+func StreamFile(db *gorm.DB, store storage.Driver) gin.HandlerFunc {
+	// StreamFile 以内联方式预览文件内容，可执行类型（HTML、SVG 等）会被强制改为附件下载。
 	// @Summary 预览文件
 	// @Tags files
 	// @Produce octet-stream
@@ -185,26 +292,96 @@ func StreamFile(db *gorm.DB) gin.HandlerFunc {
 	// @Security BearerAuth
 	// @Router /files/{id}/stream [get]
 	return func(c *gin.Context) {
-		var f models.File
-		if err := db.First(&f, c.Param("id")).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		f, ok := loadVisibleFile(c, db)
+		if !ok {
 			return
 		}
-		file, err := os.Open(f.Path)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		serveStoredFile(c, store, f, "")
+	}
+}
+
+// updateFileRequest 用于重命名或移动文件，字段省略表示不修改，folder_id 为 0 表示移动到根目录。
+// language 修改文本文件的语言，空字符串表示按内容重新识别。
+type updateFileRequest struct {
+	Filename    *string `json:"filename"`
+	Description *string `json:"description"`
+	FolderID    *uint   `json:"folder_id"`
+	Language    *string `json:"language"`
+}
+
+// UpdateFile 重命名文件、修改描述、移动到其他目录或修改文本语言，仅文件所有者与管理员可操作。
+// @Summary 重命名/移动文件
+// @Tags files
+// @Accept json
+// @Produce json
+// @Param id path int true "文件ID"
+// @Param payload body updateFileRequest true "新文件名、描述或目标目录（0 表示根目录）"
+// @Security BearerAuth
+// @Router /files/{id} [patch]
+func UpdateFile(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f, ok := loadOwnedFile(c, db)
+		if !ok {
 			return
 		}
-		defer file.Close()
-		c.Header("Content-Type", f.MimeType)
-		c.Status(http.StatusOK)
-		_, _ = io.Copy(c.Writer, file)
+
+		var req updateFileRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updates := map[string]any{}
+		if req.Filename != nil {
+			name := filepath.Base(strings.TrimSpace(*req.Filename))
+			if name == "" || name == "." || name == string(filepath.Separator) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "filename is required"})
+				return
+			}
+			updates["filename"] = name
+			f.Filename = name
+		}
+		if req.Description != nil {
+			updates["description"] = *req.Description
+			f.Description = *req.Description
+		}
+		if req.FolderID != nil {
+			folderID := normalizeFolderID(req.FolderID)
+			if err := checkFolderOwner(db, folderID, f.OwnerID); err != nil {
+				writeFolderError(c, err)
+				return
+			}
+			updates["folder_id"] = folderID
+			f.FolderID = folderID
+		}
+		if req.Language != nil {
+			if !isTextMime(f.MimeType) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "仅文本文件可设置语言"})
+				return
+			}
+			lang := ""
+			if *req.Language != "" {
+				var ok bool
+				if lang, ok = highlight.Normalize(*req.Language); !ok {
+					c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("不支持的语言 %q", *req.Language)})
+					return
+				}
+			}
+			updates["language"] = lang
+			f.Language = lang
+		}
+		if len(updates) > 0 {
+			if err := db.Model(f).Updates(updates).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		c.JSON(http.StatusOK, buildFileResponse(f))
 	}
 }
 
 // DeleteFile performs role-aware deletion. Users soft-delete their own uploads,
 // admins can permanently delete any record (including already soft-deleted ones).
-func DeleteFile(db *gorm.DB) gin.HandlerFunc {
+func DeleteFile(db *gorm.DB, store storage.Driver) gin.HandlerFunc {
 	// DeleteFile 删除文件，管理员为物理删除，普通用户为软删除。
 	// @Summary 删除文件
 	// @Tags files
@@ -237,10 +414,7 @@ func DeleteFile(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		if role == models.RoleAdmin {
-			if err := os.Remove(f.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
-				log.Printf("remove file %s: %v", f.Path, err)
-			}
-			if err := db.Unscoped().Delete(&f).Error; err != nil {
+			if _, err := purgeFile(c.Request.Context(), db, store, &f); err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
 			}
@@ -255,3 +429,188 @@ func DeleteFile(db *gorm.DB) gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{"status": "deleted", "mode": "soft"})
 	}
 }
+
+// uploadInput 描述一次待入库的上传内容，普通上传与分片上传完成时共用同一入库流程。
+type uploadInput struct {
+	OwnerID     uint
+	FolderID    *uint
+	Filename    string
+	MimeType    string       // 客户端声明的类型
+	Types       contentTypes // prepareUploadContent 识别后的类型
+	Description string
+	Content     io.Reader
+	MaxBytes    int64  // 内容长度上限，超出返回 errQuotaExceeded；小于 0 表示不限制
+	Size        int64  // 表单中文件或文字的长度
+	DropID      *uint  // 通过上传链接收集时的来源链接
+	Language    string // 上传者指定的文本语言，为空时自动识别
+}
+
+// persistUpload 将上传内容写入去重存储并创建 models.File 记录。
+func persistUpload(ctx context.Context, db *gorm.DB, cfg *config.Config, store storage.Driver, in uploadInput) (*models.File, error) {
+	types := in.Types
+	if types.Mime == "" {
+		// 调用方未做类型识别时按声明的类型保存
+		types = contentTypes{Mime: in.MimeType, Declared: in.MimeType}
+	}
+	var f models.File
+	err := persistContent(ctx, db, cfg, store, in.Content, in.MaxBytes, func(tx *gorm.DB, digest, key string, size int64) error {
+		f = models.File{
+			OwnerID:          in.OwnerID,
+			FolderID:         in.FolderID,
+			Filename:         in.Filename,
+			Path:             key,
+			Size:             size,
+			MimeType:         types.Mime,
+			DeclaredMimeType: types.Declared,
+			DetectedMimeType: types.Detected,
+			Digest:           digest,
+			Description:      in.Description,
+			ScanStatus:       initialScanStatus(cfg),
+			DropID:           in.DropID,
+			Language:         types.Language,
+		}
+		return tx.Create(&f).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	indexFileText(ctx, db, store, &f)
+	schedulePreview(db, &f)
+	scheduleScan(&f)
+	return &f, nil
+}
+
+// persistContent 边读取边计算 SHA-256，将内容按摘要写入去重存储，并在同一事务中增加 Blob 引用后执行 apply 写入引用记录；
+// 相同内容只保存一份，入库失败时回收本次新写入的对象，避免产生孤儿文件。
+func persistContent(ctx context.Context, db *gorm.DB, cfg *config.Config, store storage.Driver, r io.Reader, maxBytes int64, apply func(tx *gorm.DB, digest, key string, size int64) error) error {
+	content, digest, size, cleanup, err := hashUpload(r, cfg.UploadTempDir, maxBytes)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	key, uploaded, err := ensureBlobStored(ctx, db, store, content, digest, size)
+	if err != nil {
+		return err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if _, err := models.AcquireBlob(tx, digest, key, size); err != nil {
+			return err
+		}
+		return apply(tx, digest, key, size)
+	})
+	if err != nil && uploaded {
+		discardUnreferencedBlob(ctx, db, store, digest, key)
+	}
+	return err
+}
+
+// readUploadForm 在剩余配额内解析 multipart 表单，读取 file 或 text 作为待入库内容；newFiles 为本次新增的文件数，
+// 上传新版本时为 0。失败时已写入响应，成功时调用方需在处理完成后调用 cleanup 关闭上传文件。
+func readUploadForm(c *gin.Context, quota *userQuota, newFiles int64) (*uploadInput, func(), bool) {
+	// 在解析表单前限制请求体，超出剩余配额的上传在传输过程中即被中断，而不是全部接收后再拒绝
+	remaining := quota.remainingBytes()
+	if remaining >= 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, remaining+multipartOverhead)
+	}
+	if err := c.Request.ParseMultipartForm(defaultMultipartMemory); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		if isBodyTooLarge(err) {
+			writeQuotaExceeded(c, quota)
+			return nil, nil, false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid form: " + err.Error()})
+		return nil, nil, false
+	}
+
+	textContent := c.PostForm("text")
+	fileHeader, err := c.FormFile("file")
+	if err != nil && textContent == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file or text is required"})
+		return nil, nil, false
+	}
+
+	in := &uploadInput{Description: c.PostForm("description"), MaxBytes: remaining}
+	if v := c.PostForm("language"); v != "" {
+		lang, ok := highlight.Normalize(v)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("不支持的语言 %q", v)})
+			return nil, nil, false
+		}
+		in.Language = lang
+	}
+	incoming := int64(len(textContent))
+	if fileHeader != nil {
+		incoming = fileHeader.Size
+	}
+	in.Size = incoming
+	if !quota.allows(incoming, newFiles) {
+		writeQuotaExceeded(c, quota)
+		return nil, nil, false
+	}
+	if fileHeader == nil {
+		in.Filename = fmt.Sprintf("text-%d.txt", time.Now().UnixNano())
+		in.MimeType = "text/plain"
+		in.Content = strings.NewReader(textContent)
+		return in, func() {}, true
+	}
+	src, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+	in.Filename = fileHeader.Filename
+	in.MimeType = fileHeader.Header.Get("Content-Type")
+	in.Content = src
+	return in, func() { src.Close() }, true
+}
+
+// loadOwnedFile 读取路径参数中的文件，仅所有者与管理员可修改；其他用户与不存在一样返回 404。失败时已写入响应。
+func loadOwnedFile(c *gin.Context, db *gorm.DB) (*models.File, bool) {
+	userID, role := currentUser(c)
+	var f models.File
+	query := db.Preload("Owner").Where("id = ?", c.Param("id"))
+	if role != models.RoleAdmin {
+		query = query.Where("owner_id = ?", userID)
+	}
+	if err := query.First(&f).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return &f, true
+}
+
+// currentUser 读取鉴权中间件写入的用户 ID 与角色。
+func currentUser(c *gin.Context) (uint, string) {
+	userIDVal, _ := c.Get("userID")
+	userID, _ := userIDVal.(uint)
+	roleVal, _ := c.Get("role")
+	role, _ := roleVal.(string)
+	return userID, role
+}
+
+func buildFileResponse(f *models.File) FileResponse {
+	return FileResponse{
+		ID:               f.ID,
+		Filename:         f.Filename,
+		Size:             f.Size,
+		MimeType:         f.MimeType,
+		DeclaredMimeType: f.DeclaredMimeType,
+		DetectedMimeType: f.DetectedMimeType,
+		Description:      f.Description,
+		Owner:            f.Owner.Username,
+		PublicLink:       f.PublicLink,
+		FolderID:         f.FolderID,
+		Visibility:       f.Visibility,
+		Version:          f.Version,
+		Digest:           f.Digest,
+		ScanStatus:       f.ScanStatus,
+		DropID:           f.DropID,
+		Language:         f.Language,
+		CreatedAt:        f.CreatedAt,
+	}
+}
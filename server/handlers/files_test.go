@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"content-hub/server/config"
+	"content-hub/server/models"
+	"content-hub/server/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupFileTestEnv 构造文件相关测试所需的数据库、配置与本地存储。
+func setupFileTestEnv(t *testing.T) (*gorm.DB, *config.Config, storage.Driver) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "files.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.File{}, &models.Blob{}, &models.Share{}, &models.Folder{}, &models.FileAccess{}, &models.FileVersion{}, &models.FileText{}, &models.Tag{}, &models.FileTag{}, &models.FilePreview{}, &models.UserGroup{}, &models.UserGroupMember{}, &models.ShareRecipient{}, &models.ShareAccess{}, &models.ShareItem{}, &models.FileDrop{}, &models.RetiredShareAlias{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	uploadDir := t.TempDir()
+	cfg := &config.Config{UploadDir: uploadDir, UploadTempDir: filepath.Join(uploadDir, ".tmp")}
+	return db, cfg, storage.NewLocal(uploadDir)
+}
+
+// uploadMultipart 以 multipart 表单调用 UploadFile，返回响应。
+func uploadMultipart(t *testing.T, db *gorm.DB, cfg *config.Config, store storage.Driver, userID uint, filename string, content []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	return uploadMultipartFields(t, db, cfg, store, userID, filename, content, nil)
+}
+
+// uploadMultipartFields 与 uploadMultipart 相同，但可附带额外的表单字段。
+func uploadMultipartFields(t *testing.T, db *gorm.DB, cfg *config.Config, store storage.Driver, userID uint, filename string, content []byte, fields map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	for k, v := range fields {
+		_ = mw.WriteField(k, v)
+	}
+	part, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("create form file: %v", err)
+	}
+	_, _ = part.Write(content)
+	_ = mw.Close()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/files", body)
+	c.Request.Header.Set("Content-Type", mw.FormDataContentType())
+	c.Set("userID", userID)
+	c.Set("role", models.RoleUser)
+	UploadFile(db, cfg, store)(c)
+	return w
+}
+
+func deleteFileAs(db *gorm.DB, store storage.Driver, fileID, userID uint, role string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodDelete, "/api/files/x", nil)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(fileID)}}
+	c.Set("userID", userID)
+	c.Set("role", role)
+	DeleteFile(db, store)(c)
+	return w
+}
+
+// 相同内容只存一份，引用计数随记录增减，最后一个引用被永久删除时才删除实际内容。
+func TestUploadDeduplicatesContent(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	owner := createUser(t, db, "owner", models.RoleUser)
+	admin := createUser(t, db, "root", models.RoleAdmin)
+
+	var ids []uint
+	for _, name := range []string{"a.txt", "b.txt"} {
+		w := uploadMultipart(t, db, cfg, store, owner.ID, name, []byte("same bytes"))
+		if w.Code != http.StatusOK {
+			t.Fatalf("upload %s status = %d body=%s", name, w.Code, w.Body.String())
+		}
+		var resp struct {
+			ID     uint   `json:"id"`
+			Digest string `json:"digest"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		// sha256("same bytes")
+		if resp.Digest != "58100dc8fc06562ce3e578231dc948e083520ee49c4b4ee5a5a28bb4b4003feb" {
+			t.Fatalf("unexpected digest %q", resp.Digest)
+		}
+		ids = append(ids, resp.ID)
+	}
+
+	var files []models.File
+	db.Order("id").Find(&files)
+	if files[0].Path != files[1].Path || files[0].Digest == "" || files[0].Digest != files[1].Digest {
+		t.Fatalf("identical uploads should share a blob: %+v", files)
+	}
+	var blob models.Blob
+	if err := db.Where("digest = ?", files[0].Digest).First(&blob).Error; err != nil {
+		t.Fatalf("load blob: %v", err)
+	}
+	if blob.RefCount != 2 {
+		t.Fatalf("expected ref_count 2, got %d", blob.RefCount)
+	}
+
+	// 普通用户软删除不释放引用
+	if w := deleteFileAs(db, store, ids[0], owner.ID, models.RoleUser); w.Code != http.StatusOK {
+		t.Fatalf("soft delete status = %d", w.Code)
+	}
+	db.First(&blob, blob.ID)
+	if blob.RefCount != 2 {
+		t.Fatalf("soft delete should keep reference, got %d", blob.RefCount)
+	}
+
+	// 管理员永久删除其中一条，内容仍被另一条引用
+	if w := deleteFileAs(db, store, ids[0], admin.ID, models.RoleAdmin); w.Code != http.StatusOK {
+		t.Fatalf("permanent delete status = %d body=%s", w.Code, w.Body.String())
+	}
+	if _, err := store.Stat(context.Background(), files[1].Path); err != nil {
+		t.Fatalf("blob should survive while referenced: %v", err)
+	}
+	db.First(&blob, blob.ID)
+	if blob.RefCount != 1 {
+		t.Fatalf("expected ref_count 1, got %d", blob.RefCount)
+	}
+
+	// 最后一个引用被删除后，实际内容一并移除
+	if w := deleteFileAs(db, store, ids[1], admin.ID, models.RoleAdmin); w.Code != http.StatusOK {
+		t.Fatalf("final delete status = %d", w.Code)
+	}
+	if _, err := store.Stat(context.Background(), files[1].Path); !errors.Is(err, storage.ErrNotExist) {
+		t.Fatalf("blob should be removed after last reference, got %v", err)
+	}
+	var remaining int64
+	db.Model(&models.Blob{}).Count(&remaining)
+	if remaining != 0 {
+		t.Fatalf("blob row should be deleted, got %d", remaining)
+	}
+}
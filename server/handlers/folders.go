@@ -0,0 +1,376 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"content-hub/server/models"
+	"content-hub/server/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	errFolderNotFound = errors.New("目录不存在")
+	errFolderConflict = errors.New("同一目录下已存在同名目录")
+)
+
+type FolderResponse struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	ParentID  *uint     `json:"parent_id"`
+	OwnerID   uint      `json:"owner_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// FolderContentsResponse 描述一个目录下的直接子目录与文件，Folder 为空表示根目录。
+type FolderContentsResponse struct {
+	Folder      *FolderResponse  `json:"folder"`
+	Breadcrumbs []FolderResponse `json:"breadcrumbs"`
+	Folders     []FolderResponse `json:"folders"`
+	Files       []FileResponse   `json:"files"`
+}
+
+type createFolderRequest struct {
+	Name     string `json:"name" binding:"required"`
+	ParentID *uint  `json:"parent_id"`
+}
+
+// updateFolderRequest 用于重命名或移动目录，字段省略表示不修改，parent_id 为 0 表示移动到根目录。
+type updateFolderRequest struct {
+	Name     *string `json:"name"`
+	ParentID *uint   `json:"parent_id"`
+}
+
+// CreateFolder 在根目录或指定父目录下创建子目录。
+// @Summary 创建目录
+// @Tags folders
+// @Accept json
+// @Produce json
+// @Param payload body createFolderRequest true "目录名称与父目录"
+// @Security BearerAuth
+// @Router /folders [post]
+func CreateFolder(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := currentUser(c)
+
+		var req createFolderRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		name, ok := normalizeFolderName(req.Name)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "目录名称无效"})
+			return
+		}
+		parentID := normalizeFolderID(req.ParentID)
+		if err := checkFolderOwner(db, parentID, userID); err != nil {
+			writeFolderError(c, err)
+			return
+		}
+		if err := ensureFolderNameFree(db, userID, parentID, name, 0); err != nil {
+			writeFolderError(c, err)
+			return
+		}
+
+		folder := models.Folder{OwnerID: userID, ParentID: parentID, Name: name}
+		if err := db.Create(&folder).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, buildFolderResponse(&folder))
+	}
+}
+
+// ListFolder 返回目录下的子目录、文件及面包屑；不带 id 时列出当前用户的根目录。
+// @Summary 浏览目录
+// @Tags folders
+// @Produce json
+// @Param id path int false "目录ID，省略表示根目录"
+// @Security BearerAuth
+// @Router /folders [get]
+// @Router /folders/{id} [get]
+func ListFolder(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := currentUser(c)
+		resp := FolderContentsResponse{Breadcrumbs: []FolderResponse{}}
+
+		ownerID := userID
+		folderQuery := db.Where("parent_id IS NULL")
+		fileQuery := db.Where("folder_id IS NULL")
+		if c.Param("id") != "" {
+			folder, ok := loadAccessibleFolder(c, db, false)
+			if !ok {
+				return
+			}
+			path, err := models.FolderPath(db, folder)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			for i := range path {
+				resp.Breadcrumbs = append(resp.Breadcrumbs, buildFolderResponse(&path[i]))
+			}
+			current := buildFolderResponse(folder)
+			resp.Folder = &current
+			ownerID = folder.OwnerID
+			folderQuery = db.Where("parent_id = ?", folder.ID)
+			fileQuery = db.Where("folder_id = ?", folder.ID)
+		}
+
+		var folders []models.Folder
+		if err := folderQuery.Where("owner_id = ?", ownerID).Order("name").Find(&folders).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		var files []models.File
+		if err := fileQuery.Where("owner_id = ?", ownerID).Preload("Owner").Order("created_at desc").Find(&files).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		resp.Folders = make([]FolderResponse, 0, len(folders))
+		for i := range folders {
+			resp.Folders = append(resp.Folders, buildFolderResponse(&folders[i]))
+		}
+		resp.Files = make([]FileResponse, 0, len(files))
+		for i := range files {
+			resp.Files = append(resp.Files, buildFileResponse(&files[i]))
+		}
+		if err := fillFileTags(db, resp.Files); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// UpdateFolder 重命名目录或将其移动到其他父目录，禁止移动到自身或子目录下。
+// @Summary 重命名/移动目录
+// @Tags folders
+// @Accept json
+// @Produce json
+// @Param id path int true "目录ID"
+// @Param payload body updateFolderRequest true "新名称或新父目录（0 表示根目录）"
+// @Security BearerAuth
+// @Router /folders/{id} [patch]
+func UpdateFolder(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		folder, ok := loadAccessibleFolder(c, db, false)
+		if !ok {
+			return
+		}
+
+		var req updateFolderRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		name := folder.Name
+		if req.Name != nil {
+			if name, ok = normalizeFolderName(*req.Name); !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "目录名称无效"})
+				return
+			}
+		}
+		parentID := folder.ParentID
+		if req.ParentID != nil {
+			parentID = normalizeFolderID(req.ParentID)
+			// 目标目录必须与被移动目录属于同一用户，管理员代为整理时也不能跨用户移动
+			if err := checkFolderOwner(db, parentID, folder.OwnerID); err != nil {
+				writeFolderError(c, err)
+				return
+			}
+			if parentID != nil {
+				inside, err := models.IsFolderDescendant(db, folder.ID, *parentID)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return
+				}
+				if inside {
+					c.JSON(http.StatusBadRequest, gin.H{"error": models.ErrFolderCycle.Error()})
+					return
+				}
+			}
+		}
+		if err := ensureFolderNameFree(db, folder.OwnerID, parentID, name, folder.ID); err != nil {
+			writeFolderError(c, err)
+			return
+		}
+
+		if err := db.Model(folder).Updates(map[string]any{"name": name, "parent_id": parentID}).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		folder.Name, folder.ParentID = name, parentID
+		c.JSON(http.StatusOK, buildFolderResponse(folder))
+	}
+}
+
+// DeleteFolder 递归删除目录及其中的文件，语义与 DeleteFile 一致：普通用户软删除自己的目录，管理员永久删除。
+// @Summary 删除目录
+// @Tags folders
+// @Produce json
+// @Param id path int true "目录ID"
+// @Security BearerAuth
+// @Router /folders/{id} [delete]
+func DeleteFolder(db *gorm.DB, store storage.Driver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, role := currentUser(c)
+		permanent := role == models.RoleAdmin
+
+		folder, ok := loadAccessibleFolder(c, db, permanent)
+		if !ok {
+			return
+		}
+
+		if permanent {
+			report, err := purgeFolderTree(c.Request.Context(), db, store, folder.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"status": "deleted", "mode": "permanent", "folders": report.Folders, "files": report.Files})
+			return
+		}
+
+		folderIDs, err := models.DescendantFolderIDs(db, folder.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		// 同一批次记录本次删除的条目，此前已单独删除的文件与子目录不受影响，恢复目录时也不会被一并恢复
+		trashed := map[string]any{"deleted_at": time.Now(), "trash_batch": uuid.NewString()}
+		var fileCount int64
+		err = db.Transaction(func(tx *gorm.DB) error {
+			res := tx.Model(&models.File{}).Where("folder_id IN ?", folderIDs).Updates(trashed)
+			if res.Error != nil {
+				return res.Error
+			}
+			fileCount = res.RowsAffected
+			return tx.Model(&models.Folder{}).Where("id IN ?", folderIDs).Updates(trashed).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "deleted", "mode": "soft", "folders": len(folderIDs), "files": fileCount})
+	}
+}
+
+// loadAccessibleFolder 读取路径参数中的目录，普通用户只能访问自己的目录，管理员可访问全部；
+// includeDeleted 为 true 时一并查找已软删除的目录。失败时已写入响应。
+func loadAccessibleFolder(c *gin.Context, db *gorm.DB, includeDeleted bool) (*models.Folder, bool) {
+	userID, role := currentUser(c)
+	query := db
+	if includeDeleted {
+		query = db.Unscoped()
+	}
+	query = query.Where("id = ?", c.Param("id"))
+	if role != models.RoleAdmin {
+		query = query.Where("owner_id = ?", userID)
+	}
+
+	var folder models.Folder
+	if err := query.First(&folder).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": errFolderNotFound.Error()})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return &folder, true
+}
+
+// checkFolderOwner 校验目标目录存在且属于 ownerID，folderID 为空（根目录）时总是通过。
+func checkFolderOwner(db *gorm.DB, folderID *uint, ownerID uint) error {
+	if folderID == nil {
+		return nil
+	}
+	var count int64
+	if err := db.Model(&models.Folder{}).Where("id = ? AND owner_id = ?", *folderID, ownerID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errFolderNotFound
+	}
+	return nil
+}
+
+// ensureFolderNameFree 检查同一父目录下是否已有同名目录，excludeID 用于重命名时排除自身。
+func ensureFolderNameFree(db *gorm.DB, ownerID uint, parentID *uint, name string, excludeID uint) error {
+	query := db.Model(&models.Folder{}).Where("owner_id = ? AND name = ? AND id <> ?", ownerID, name, excludeID)
+	if parentID == nil {
+		query = query.Where("parent_id IS NULL")
+	} else {
+		query = query.Where("parent_id = ?", *parentID)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errFolderConflict
+	}
+	return nil
+}
+
+func writeFolderError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errFolderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, errFolderConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// normalizeFolderName 去除首尾空白并拒绝空名称、路径分隔符与 . / ..。
+func normalizeFolderName(name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == ".." || len(name) > 255 || strings.ContainsAny(name, `/\`) {
+		return "", false
+	}
+	return name, true
+}
+
+// normalizeFolderID 将 0 视为根目录。
+func normalizeFolderID(id *uint) *uint {
+	if id == nil || *id == 0 {
+		return nil
+	}
+	return id
+}
+
+// parseFolderID 解析表单中的 folder_id，空字符串与 0 表示根目录。
+func parseFolderID(raw string) (*uint, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return nil, errFolderNotFound
+	}
+	folderID := uint(id)
+	return normalizeFolderID(&folderID), nil
+}
+
+func buildFolderResponse(f *models.Folder) FolderResponse {
+	return FolderResponse{
+		ID:        f.ID,
+		Name:      f.Name,
+		ParentID:  f.ParentID,
+		OwnerID:   f.OwnerID,
+		CreatedAt: f.CreatedAt,
+		UpdatedAt: f.UpdatedAt,
+	}
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"content-hub/server/models"
+	"content-hub/server/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// callFolder 以指定用户身份调用目录相关 handler。
+func callFolder(h gin.HandlerFunc, user models.User, method string, folderID uint, body any) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	var buf bytes.Buffer
+	if body != nil {
+		_ = json.NewEncoder(&buf).Encode(body)
+	}
+	c.Request = httptest.NewRequest(method, "/api/folders", &buf)
+	c.Request.Header.Set("Content-Type", "application/json")
+	if folderID != 0 {
+		c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(folderID)}}
+	}
+	c.Set("userID", user.ID)
+	c.Set("role", user.Role)
+	h(c)
+	return w
+}
+
+func mustCreateFolder(t *testing.T, db *gorm.DB, user models.User, name string, parentID uint) FolderResponse {
+	t.Helper()
+	body := gin.H{"name": name}
+	if parentID != 0 {
+		body["parent_id"] = parentID
+	}
+	w := callFolder(CreateFolder(db), user, http.MethodPost, 0, body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("create folder %s status = %d body=%s", name, w.Code, w.Body.String())
+	}
+	var resp FolderResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp
+}
+
+func TestFolderHierarchy(t *testing.T) {
+	db, _, _ := setupFileTestEnv(t)
+	owner := createUser(t, db, "owner", models.RoleUser)
+	other := createUser(t, db, "other", models.RoleUser)
+
+	docs := mustCreateFolder(t, db, owner, "docs", 0)
+	reports := mustCreateFolder(t, db, owner, "reports", docs.ID)
+	q1 := mustCreateFolder(t, db, owner, "2024-q1", reports.ID)
+
+	// 同级重名冲突
+	if w := callFolder(CreateFolder(db), owner, http.MethodPost, 0, gin.H{"name": "reports", "parent_id": docs.ID}); w.Code != http.StatusConflict {
+		t.Fatalf("duplicate sibling should conflict, got %d", w.Code)
+	}
+	// 不能在别人的目录下创建
+	if w := callFolder(CreateFolder(db), other, http.MethodPost, 0, gin.H{"name": "x", "parent_id": docs.ID}); w.Code != http.StatusNotFound {
+		t.Fatalf("foreign parent should be hidden, got %d", w.Code)
+	}
+
+	w := callFolder(ListFolder(db), owner, http.MethodGet, q1.ID, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("list status = %d body=%s", w.Code, w.Body.String())
+	}
+	var contents FolderContentsResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &contents)
+	if len(contents.Breadcrumbs) != 3 || contents.Breadcrumbs[0].Name != "docs" || contents.Breadcrumbs[2].Name != "2024-q1" {
+		t.Fatalf("unexpected breadcrumbs %+v", contents.Breadcrumbs)
+	}
+
+	// 不能移动到自己的子目录下
+	if w := callFolder(UpdateFolder(db), owner, http.MethodPatch, docs.ID, gin.H{"parent_id": q1.ID}); w.Code != http.StatusBadRequest {
+		t.Fatalf("cyclic move should be rejected, got %d", w.Code)
+	}
+	// 移动到根目录并重命名
+	w = callFolder(UpdateFolder(db), owner, http.MethodPatch, q1.ID, gin.H{"parent_id": 0, "name": "archive"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("move status = %d body=%s", w.Code, w.Body.String())
+	}
+	w = callFolder(ListFolder(db), owner, http.MethodGet, 0, nil)
+	_ = json.Unmarshal(w.Body.Bytes(), &contents)
+	if contents.Folder != nil || len(contents.Folders) != 2 || contents.Folders[0].Name != "archive" {
+		t.Fatalf("unexpected root listing %+v", contents)
+	}
+	if w := callFolder(ListFolder(db), other, http.MethodGet, docs.ID, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("other user should not browse folder, got %d", w.Code)
+	}
+}
+
+func TestUploadIntoFolderAndRecursiveDelete(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	owner := createUser(t, db, "owner", models.RoleUser)
+	admin := createUser(t, db, "root", models.RoleAdmin)
+
+	parent := mustCreateFolder(t, db, owner, "parent", 0)
+	child := mustCreateFolder(t, db, owner, "child", parent.ID)
+
+	upload := func(name string, folderID uint) uint {
+		w := uploadMultipartFields(t, db, cfg, store, owner.ID, name, []byte(name), map[string]string{"folder_id": fmt.Sprint(folderID)})
+		if w.Code != http.StatusOK {
+			t.Fatalf("upload %s status = %d body=%s", name, w.Code, w.Body.String())
+		}
+		var resp struct {
+			ID uint `json:"id"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.ID
+	}
+	upload("a.txt", parent.ID)
+	inChild := upload("b.txt", child.ID)
+	rootFile := upload("c.txt", 0)
+
+	// 移动文件到子目录
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPatch, "/api/files/x", bytes.NewBufferString(fmt.Sprintf(`{"folder_id":%d,"filename":"moved.txt"}`, child.ID)))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(rootFile)}}
+	c.Set("userID", owner.ID)
+	c.Set("role", owner.Role)
+	UpdateFile(db)(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("move file status = %d body=%s", w.Code, w.Body.String())
+	}
+
+	// 普通用户递归软删除
+	w = callFolder(DeleteFolder(db, store), owner, http.MethodDelete, parent.ID, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("soft delete status = %d body=%s", w.Code, w.Body.String())
+	}
+	var visible int64
+	db.Model(&models.File{}).Count(&visible)
+	if visible != 0 {
+		t.Fatalf("all files should be soft deleted, %d remain", visible)
+	}
+	var deletedFolders int64
+	db.Unscoped().Model(&models.Folder{}).Where("deleted_at IS NOT NULL").Count(&deletedFolders)
+	if deletedFolders != 2 {
+		t.Fatalf("expected 2 soft-deleted folders, got %d", deletedFolders)
+	}
+
+	// 管理员永久删除已软删除的目录树，内容一并清理
+	var f models.File
+	db.Unscoped().First(&f, inChild)
+	w = callFolder(DeleteFolder(db, store), admin, http.MethodDelete, parent.ID, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("permanent delete status = %d body=%s", w.Code, w.Body.String())
+	}
+	var remaining int64
+	db.Unscoped().Model(&models.File{}).Count(&remaining)
+	if remaining != 0 {
+		t.Fatalf("files should be purged, %d remain", remaining)
+	}
+	db.Unscoped().Model(&models.Folder{}).Count(&remaining)
+	if remaining != 0 {
+		t.Fatalf("folders should be purged, %d remain", remaining)
+	}
+	if _, err := store.Stat(t.Context(), f.Path); !errors.Is(err, storage.ErrNotExist) {
+		t.Fatalf("content should be removed, stat err=%v", err)
+	}
+}
@@ -0,0 +1,375 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"content-hub/server/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	maxGroupNameLength = 64
+	maxGroupMembers    = 1000
+)
+
+var errUnknownUsers = errors.New("指定的用户不存在")
+
+// GroupResponse 描述用户组；Members 仅对管理员返回。
+type GroupResponse struct {
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	MemberCount int64     `json:"member_count"`
+	Members     []string  `json:"members,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type createGroupRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description"`
+	Usernames   []string `json:"usernames"`
+}
+
+// updateGroupRequest 字段省略表示不修改，usernames 会整体替换成员列表。
+type updateGroupRequest struct {
+	Name        *string   `json:"name"`
+	Description *string   `json:"description"`
+	Usernames   *[]string `json:"usernames"`
+}
+
+// ListGroups 列出用户组，供创建分享时选择接收范围；管理员额外返回成员列表。
+// @Summary 用户组列表
+// @Tags groups
+// @Produce json
+// @Param q query string false "名称关键字"
+// @Security BearerAuth
+// @Router /groups [get]
+func ListGroups(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, role := currentUser(c)
+		query := db.Model(&models.UserGroup{}).Order("name")
+		if q := strings.TrimSpace(c.Query("q")); q != "" {
+			query = query.Where(`name LIKE ? ESCAPE '\'`, "%"+models.EscapeLike(q)+"%")
+		}
+		var groups []models.UserGroup
+		if err := query.Find(&groups).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		resp, err := buildGroupResponses(db, groups, role == models.RoleAdmin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// CreateGroup 创建用户组并设置初始成员。
+// @Summary 创建用户组
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param payload body createGroupRequest true "用户组"
+// @Security BearerAuth
+// @Router /admin/groups [post]
+func CreateGroup(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req createGroupRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		name, err := normalizeGroupName(req.Name)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		members, ok := resolveGroupMembers(c, db, req.Usernames)
+		if !ok {
+			return
+		}
+		if !groupNameAvailable(c, db, name, 0) {
+			return
+		}
+
+		group := models.UserGroup{Name: name, Description: strings.TrimSpace(req.Description)}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&group).Error; err != nil {
+				return err
+			}
+			return replaceGroupMembers(tx, group.ID, members)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		writeGroup(c, db, &group)
+	}
+}
+
+// UpdateGroup 修改用户组名称、描述或整体替换成员。
+// @Summary 修改用户组
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "用户组ID"
+// @Param payload body updateGroupRequest true "修改内容"
+// @Security BearerAuth
+// @Router /admin/groups/{id} [patch]
+func UpdateGroup(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		group, ok := loadGroup(c, db)
+		if !ok {
+			return
+		}
+		var req updateGroupRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		updates := map[string]any{}
+		if req.Name != nil {
+			name, err := normalizeGroupName(*req.Name)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if !groupNameAvailable(c, db, name, group.ID) {
+				return
+			}
+			updates["name"] = name
+		}
+		if req.Description != nil {
+			updates["description"] = strings.TrimSpace(*req.Description)
+		}
+		var members []models.User
+		if req.Usernames != nil {
+			if members, ok = resolveGroupMembers(c, db, *req.Usernames); !ok {
+				return
+			}
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if len(updates) > 0 {
+				if err := tx.Model(group).Updates(updates).Error; err != nil {
+					return err
+				}
+			}
+			if req.Usernames != nil {
+				return replaceGroupMembers(tx, group.ID, members)
+			}
+			return nil
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		writeGroup(c, db, group)
+	}
+}
+
+// DeleteGroup 删除用户组。以该组为接收范围的分享仍保持受限，组成员将无法再访问。
+// @Summary 删除用户组
+// @Tags admin
+// @Produce json
+// @Param id path int true "用户组ID"
+// @Security BearerAuth
+// @Router /admin/groups/{id} [delete]
+func DeleteGroup(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		group, ok := loadGroup(c, db)
+		if !ok {
+			return
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("group_id = ?", group.ID).Delete(&models.ShareRecipient{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("group_id = ?", group.ID).Delete(&models.UserGroupMember{}).Error; err != nil {
+				return err
+			}
+			return tx.Delete(group).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "group deleted"})
+	}
+}
+
+func loadGroup(c *gin.Context, db *gorm.DB) (*models.UserGroup, bool) {
+	var group models.UserGroup
+	if err := db.First(&group, c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "用户组不存在"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return &group, true
+}
+
+func normalizeGroupName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxGroupNameLength {
+		return "", fmt.Errorf("用户组名称需为 1-%d 个字符", maxGroupNameLength)
+	}
+	return name, nil
+}
+
+// groupNameAvailable 检查名称是否已被其他用户组占用。失败时已写入响应。
+func groupNameAvailable(c *gin.Context, db *gorm.DB, name string, excludeID uint) bool {
+	var count int64
+	if err := db.Model(&models.UserGroup{}).Where("name = ? AND id <> ?", name, excludeID).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "用户组名称已存在"})
+		return false
+	}
+	return true
+}
+
+// resolveGroupMembers 按用户名查找成员。失败时已写入响应。
+func resolveGroupMembers(c *gin.Context, db *gorm.DB, usernames []string) ([]models.User, bool) {
+	names := uniqueTrimmed(usernames)
+	if len(names) > maxGroupMembers {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("用户组成员不能超过 %d 人", maxGroupMembers)})
+		return nil, false
+	}
+	users, err := lookupUsers(db, names)
+	if err != nil {
+		writeLookupError(c, err)
+		return nil, false
+	}
+	return users, true
+}
+
+func replaceGroupMembers(tx *gorm.DB, groupID uint, members []models.User) error {
+	if err := tx.Where("group_id = ?", groupID).Delete(&models.UserGroupMember{}).Error; err != nil {
+		return err
+	}
+	for _, u := range members {
+		if err := tx.Create(&models.UserGroupMember{GroupID: groupID, UserID: u.ID}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeGroup(c *gin.Context, db *gorm.DB, group *models.UserGroup) {
+	if err := db.First(group, group.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	resp, err := buildGroupResponses(db, []models.UserGroup{*group}, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp[0])
+}
+
+// buildGroupResponses 批量统计成员数，withMembers 为 true 时附带成员用户名。
+func buildGroupResponses(db *gorm.DB, groups []models.UserGroup, withMembers bool) ([]GroupResponse, error) {
+	resp := make([]GroupResponse, 0, len(groups))
+	if len(groups) == 0 {
+		return resp, nil
+	}
+	ids := make([]uint, 0, len(groups))
+	for _, g := range groups {
+		ids = append(ids, g.ID)
+	}
+	var rows []struct {
+		GroupID  uint
+		Username string
+	}
+	err := db.Model(&models.UserGroupMember{}).
+		Select("user_group_members.group_id, users.username").
+		Joins("JOIN users ON users.id = user_group_members.user_id AND users.deleted_at IS NULL").
+		Where("user_group_members.group_id IN ?", ids).
+		Order("users.username").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	members := make(map[uint][]string, len(groups))
+	for _, r := range rows {
+		members[r.GroupID] = append(members[r.GroupID], r.Username)
+	}
+	for _, g := range groups {
+		item := GroupResponse{
+			ID:          g.ID,
+			Name:        g.Name,
+			Description: g.Description,
+			MemberCount: int64(len(members[g.ID])),
+			CreatedAt:   g.CreatedAt,
+		}
+		if withMembers {
+			item.Members = members[g.ID]
+			if item.Members == nil {
+				item.Members = []string{}
+			}
+		}
+		resp = append(resp, item)
+	}
+	return resp, nil
+}
+
+// uniqueTrimmed 去除空白与重复项，保留原有顺序。
+func uniqueTrimmed(values []string) []string {
+	out := make([]string, 0, len(values))
+	seen := map[string]bool{}
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		out = append(out, v)
+	}
+	return out
+}
+
+// lookupUsers 按用户名批量查找用户，任一不存在时返回包含这些用户名的 errUnknownUsers。
+func lookupUsers(db *gorm.DB, names []string) ([]models.User, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	var users []models.User
+	if err := db.Where("username IN ?", names).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	if len(users) == len(names) {
+		return users, nil
+	}
+	found := make(map[string]bool, len(users))
+	for _, u := range users {
+		found[u.Username] = true
+	}
+	var missing []string
+	for _, n := range names {
+		if !found[n] {
+			missing = append(missing, n)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", errUnknownUsers, strings.Join(missing, ", "))
+}
+
+func writeLookupError(c *gin.Context, err error) {
+	if errors.Is(err, errUnknownUsers) || errors.Is(err, errUnknownGroups) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
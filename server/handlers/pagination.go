@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+type sortKind int
+
+const (
+	sortTime sortKind = iota
+	sortString
+	sortInt
+)
+
+// listSpec 描述一个列表接口允许的排序字段，Fields 的 key 即查询参数 sort 的取值。
+type listSpec struct {
+	Table   string
+	Fields  map[string]sortKind
+	Default string
+}
+
+// PageInfo 是所有列表接口共用的分页信息：next_cursor 为空表示没有更多数据，total 仅在 include_total=true 时返回。
+type PageInfo struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+	Total      *int64 `json:"total,omitempty"`
+}
+
+// listQuery 是解析后的分页与排序参数。
+type listQuery struct {
+	spec         listSpec
+	limit        int
+	sort         string
+	desc         bool
+	includeTotal bool
+	cursor       *pageCursor
+}
+
+// pageCursor 记录上一页最后一条记录的排序值与 ID，按 (排序值, id) 做键集分页，翻页过程中插入新数据也不会重复或遗漏。
+// 同时记录排序方式，防止客户端更换排序后继续使用旧游标。
+type pageCursor struct {
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d"`
+}
+
+// parseListQuery 解析 limit、cursor、sort、order 与 include_total 参数，参数无效时写入 400 响应。
+func parseListQuery(c *gin.Context, spec listSpec) (*listQuery, bool) {
+	q := &listQuery{spec: spec, limit: defaultPageLimit, sort: spec.Default, desc: true}
+
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit 需为正整数"})
+			return nil, false
+		}
+		q.limit = min(n, maxPageLimit)
+	}
+	if v := c.Query("sort"); v != "" {
+		if _, ok := spec.Fields[v]; !ok {
+			fields := make([]string, 0, len(spec.Fields))
+			for name := range spec.Fields {
+				fields = append(fields, name)
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的排序字段", "allowed": fields})
+			return nil, false
+		}
+		q.sort = v
+	}
+	switch strings.ToLower(c.DefaultQuery("order", "desc")) {
+	case "desc":
+	case "asc":
+		q.desc = false
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order 仅支持 asc / desc"})
+		return nil, false
+	}
+	q.includeTotal, _ = strconv.ParseBool(c.Query("include_total"))
+
+	if v := c.Query("cursor"); v != "" {
+		cursor, err := decodeCursor(v)
+		if err != nil || cursor.Sort != q.sort || cursor.Desc != q.desc {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cursor 无效或与当前排序不匹配"})
+			return nil, false
+		}
+		q.cursor = cursor
+	}
+	return q, true
+}
+
+// total 在请求 include_total 时统计过滤后的总数，需在 apply 之前对同一查询调用。
+func (q *listQuery) total(db *gorm.DB) (*int64, error) {
+	if !q.includeTotal {
+		return nil, nil
+	}
+	var n int64
+	if err := db.Session(&gorm.Session{}).Count(&n).Error; err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// apply 追加游标条件、排序与 limit；多取一条用于判断是否还有下一页。
+func (q *listQuery) apply(db *gorm.DB) (*gorm.DB, error) {
+	column := q.spec.Table + "." + q.sort
+	idColumn := q.spec.Table + ".id"
+	op, order := "<", "DESC"
+	if !q.desc {
+		op, order = ">", "ASC"
+	}
+	if q.cursor != nil {
+		value, err := q.cursorValue()
+		if err != nil {
+			return nil, err
+		}
+		db = db.Where(fmt.Sprintf("((%s %s ?) OR (%s = ? AND %s %s ?))", column, op, column, idColumn, op), value, value, q.cursor.ID)
+	}
+	return db.Order(column + " " + order).Order(idColumn + " " + order).Limit(q.limit + 1), nil
+}
+
+func (q *listQuery) cursorValue() (any, error) {
+	switch q.spec.Fields[q.sort] {
+	case sortTime:
+		t, err := time.Parse(time.RFC3339Nano, q.cursor.Value)
+		if err != nil {
+			return nil, err
+		}
+		// SQLite 以字符串保存时间，需与写入时相同的时区格式才能正确比较
+		return t.In(time.Local), nil
+	case sortInt:
+		return strconv.ParseInt(q.cursor.Value, 10, 64)
+	default:
+		return q.cursor.Value, nil
+	}
+}
+
+// paginate 截掉 apply 多取的一条记录，并根据本页最后一条生成下一页游标。key 返回记录在 sort 字段上的值与 ID。
+func paginate[T any](q *listQuery, rows []T, key func(row *T, sort string) (any, uint)) ([]T, PageInfo) {
+	var info PageInfo
+	if len(rows) <= q.limit {
+		return rows, info
+	}
+	rows = rows[:q.limit]
+	value, id := key(&rows[len(rows)-1], q.sort)
+	cursor := pageCursor{ID: id, Sort: q.sort, Desc: q.desc}
+	switch v := value.(type) {
+	case time.Time:
+		cursor.Value = v.Format(time.RFC3339Nano)
+	default:
+		cursor.Value = fmt.Sprint(v)
+	}
+	info.HasMore = true
+	info.NextCursor = encodeCursor(cursor)
+	return rows, info
+}
+
+func encodeCursor(c pageCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"content-hub/server/config"
+	"content-hub/server/models"
+	"github.com/gin-gonic/gin"
+)
+
+func listFilesPage(t *testing.T, h gin.HandlerFunc, user models.User, query url.Values) (int, FileListResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/files?"+query.Encode(), nil)
+	c.Set("userID", user.ID)
+	c.Set("role", user.Role)
+	h(c)
+	var resp FileListResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode list: %v", err)
+		}
+	}
+	return w.Code, resp
+}
+
+func TestListFilesCursorPagination(t *testing.T) {
+	db, _, _ := setupFileTestEnv(t)
+	owner := createUser(t, db, "owner", models.RoleUser)
+	other := createUser(t, db, "other", models.RoleUser)
+
+	// 部分文件创建时间相同，验证按 id 兜底排序时翻页不重复也不遗漏
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < 7; i++ {
+		f := models.File{
+			Filename: fmt.Sprintf("file-%d.txt", i),
+			OwnerID:  owner.ID,
+			MimeType: "text/plain",
+			Size:     int64(i),
+		}
+		f.CreatedAt = base.Add(time.Duration(i/3) * time.Minute)
+		if err := db.Create(&f).Error; err != nil {
+			t.Fatalf("create file: %v", err)
+		}
+	}
+	if err := db.Create(&models.File{Filename: "other.png", OwnerID: other.ID, MimeType: "image/png", Visibility: models.VisibilityPublic}).Error; err != nil {
+		t.Fatalf("create file: %v", err)
+	}
+
+	h := ListFiles(db)
+	seen := map[string]bool{}
+	var order []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatalf("pagination did not terminate")
+		}
+		q := url.Values{"limit": {"3"}, "owner": {"owner"}}
+		if cursor != "" {
+			q.Set("cursor", cursor)
+		}
+		code, resp := listFilesPage(t, h, owner, q)
+		if code != http.StatusOK {
+			t.Fatalf("list status = %d", code)
+		}
+		if resp.Total != nil {
+			t.Fatalf("total should be omitted unless include_total is set")
+		}
+		for _, f := range resp.Items {
+			if seen[f.Filename] {
+				t.Fatalf("duplicate %s across pages", f.Filename)
+			}
+			seen[f.Filename] = true
+			order = append(order, f.Filename)
+		}
+		if !resp.HasMore {
+			if resp.NextCursor != "" {
+				t.Fatalf("last page should not carry a cursor")
+			}
+			break
+		}
+		cursor = resp.NextCursor
+	}
+	if len(order) != 7 {
+		t.Fatalf("walked %d files, want 7: %v", len(order), order)
+	}
+	if order[0] != "file-6.txt" || order[6] != "file-0.txt" {
+		t.Fatalf("unexpected default order: %v", order)
+	}
+
+	code, resp := listFilesPage(t, h, owner, url.Values{"sort": {"filename"}, "order": {"asc"}, "include_total": {"true"}, "limit": {"2"}})
+	if code != http.StatusOK || resp.Total == nil || *resp.Total != 8 {
+		t.Fatalf("include_total: code=%d total=%v", code, resp.Total)
+	}
+	if resp.Items[0].Filename != "file-0.txt" || resp.Items[1].Filename != "file-1.txt" {
+		t.Fatalf("unexpected filename order: %+v", resp.Items)
+	}
+	// 游标与当前排序不一致时拒绝
+	if code, _ := listFilesPage(t, h, owner, url.Values{"cursor": {resp.NextCursor}}); code != http.StatusBadRequest {
+		t.Fatalf("mismatched cursor status = %d, want 400", code)
+	}
+	if code, _ := listFilesPage(t, h, owner, url.Values{"cursor": {"not-a-cursor"}}); code != http.StatusBadRequest {
+		t.Fatalf("bad cursor status = %d, want 400", code)
+	}
+	if code, _ := listFilesPage(t, h, owner, url.Values{"sort": {"owner_id"}}); code != http.StatusBadRequest {
+		t.Fatalf("unknown sort status = %d, want 400", code)
+	}
+
+	code, resp = listFilesPage(t, h, owner, url.Values{"mime": {"image/*"}, "include_total": {"1"}})
+	if code != http.StatusOK || len(resp.Items) != 1 || resp.Items[0].Filename != "other.png" || *resp.Total != 1 {
+		t.Fatalf("mime filter: code=%d items=%+v", code, resp.Items)
+	}
+}
+
+func TestListUsersFilterAndPaginate(t *testing.T) {
+	db := setupTestDB(t)
+	admin := createUser(t, db, "admin", models.RoleAdmin)
+	for _, name := range []string{"alice", "bob", "carol"} {
+		createUser(t, db, name, models.RoleUser)
+	}
+
+	call := func(query string) UserListResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/admin/users?"+query, nil)
+		c.Set("userID", admin.ID)
+		c.Set("role", models.RoleAdmin)
+		ListUsers(db, &config.Config{})(c)
+		if w.Code != http.StatusOK {
+			t.Fatalf("list users %q status = %d body=%s", query, w.Code, w.Body.String())
+		}
+		var resp UserListResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp
+	}
+
+	first := call("role=user&sort=username&order=asc&limit=2&include_total=true")
+	if first.Total == nil || *first.Total != 3 || len(first.Items) != 2 || first.Items[0].Username != "alice" || !first.HasMore {
+		t.Fatalf("unexpected first page: %+v", first)
+	}
+	second := call("role=user&sort=username&order=asc&limit=2&cursor=" + url.QueryEscape(first.NextCursor))
+	if len(second.Items) != 1 || second.Items[0].Username != "carol" || second.HasMore {
+		t.Fatalf("unexpected second page: %+v", second)
+	}
+	if got := call("q=ar"); len(got.Items) != 1 || got.Items[0].Username != "carol" {
+		t.Fatalf("q filter: %+v", got.Items)
+	}
+}
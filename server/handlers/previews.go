@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // 注册 GIF 解码器，缩略图取第一帧
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"content-hub/server/config"
+	"content-hub/server/models"
+	"content-hub/server/storage"
+	"github.com/gin-gonic/gin"
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"gorm.io/gorm"
+)
+
+const (
+	thumbnailMaxEdge      = 320
+	thumbnailQuality      = 80
+	maxPreviewSourceBytes = 50 << 20   // 超过该大小的图片不生成缩略图，避免后台任务占用过多内存
+	maxPreviewPixels      = 40_000_000 // 解码前按图片头部声明的尺寸拦截超大图片
+	previewExcerptRunes   = 1000
+	previewBatchSize      = 20
+)
+
+var errPreviewTooLarge = errors.New("图片尺寸过大，不生成缩略图")
+
+// previewWake 在有新文件排队时唤醒后台预览任务，容量为 1 即可合并多次通知。
+var previewWake = make(chan struct{}, 1)
+
+// schedulePreview 将文件当前版本加入预览队列并唤醒后台任务；排队失败只记录日志，不影响上传结果。
+func schedulePreview(db *gorm.DB, f *models.File) {
+	if err := models.QueueFilePreview(db, f); err != nil {
+		log.Printf("queue preview for file %d: %v", f.ID, err)
+		return
+	}
+	select {
+	case previewWake <- struct{}{}:
+	default:
+	}
+}
+
+// StartPreviewWorker 启动后台预览生成任务：上传后立即被唤醒，同时按 interval 兜底扫描，启动时为升级前的文件补充排队。
+func StartPreviewWorker(db *gorm.DB, store storage.Driver, interval time.Duration) {
+	go func() {
+		ctx := context.Background()
+		if n, err := QueueMissingPreviews(db); err != nil {
+			log.Printf("queue missing previews: %v", err)
+		} else if n > 0 {
+			log.Printf("queued %d files for preview generation", n)
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			for {
+				n, err := GeneratePendingPreviews(ctx, db, store, previewBatchSize)
+				if err != nil {
+					log.Printf("generate previews: %v", err)
+					break
+				}
+				if n < previewBatchSize {
+					break
+				}
+			}
+			select {
+			case <-previewWake:
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// QueueMissingPreviews 为还没有预览记录的文件补充排队，返回新增的数量。
+func QueueMissingPreviews(db *gorm.DB) (int64, error) {
+	res := db.Exec(
+		"INSERT INTO file_previews (file_id, version, status, updated_at) SELECT id, version, ?, ? FROM files WHERE deleted_at IS NULL AND id NOT IN (SELECT file_id FROM file_previews)",
+		models.PreviewPending, time.Now(),
+	)
+	return res.RowsAffected, res.Error
+}
+
+// GeneratePendingPreviews 处理最多 limit 个待生成的预览，返回处理的数量；回收站中的文件等恢复后再处理。
+func GeneratePendingPreviews(ctx context.Context, db *gorm.DB, store storage.Driver, limit int) (int, error) {
+	var files []models.File
+	err := db.Where("id IN (?)", db.Model(&models.FilePreview{}).Select("file_id").Where("status = ?", models.PreviewPending)).
+		Order("id").Limit(limit).Find(&files).Error
+	if err != nil {
+		return 0, err
+	}
+	for i := range files {
+		if err := generatePreview(ctx, db, store, &files[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(files), nil
+}
+
+// generatePreview 为文件当前版本生成预览并写回记录。生成期间若又上传了新版本，本次结果作废，等待下一轮处理。
+func generatePreview(ctx context.Context, db *gorm.DB, store storage.Driver, f *models.File) error {
+	var previous models.FilePreview
+	if err := db.First(&previous, "file_id = ?", f.ID).Error; err != nil {
+		return err
+	}
+
+	updates := map[string]any{
+		"status":           models.PreviewReady,
+		"thumbnail_key":    "",
+		"thumbnail_size":   0,
+		"thumbnail_width":  0,
+		"thumbnail_height": 0,
+		"excerpt":          "",
+		"error":            "",
+	}
+	var key string
+	switch {
+	case isThumbnailable(f):
+		var thumb *thumbnail
+		var err error
+		if thumb, err = renderThumbnail(ctx, store, f); err == nil {
+			key = fmt.Sprintf("previews/%d/v%d.jpg", f.ID, f.Version)
+			err = store.Put(ctx, key, bytes.NewReader(thumb.data), int64(len(thumb.data)))
+		}
+		if err != nil {
+			key = ""
+			updates["status"] = models.PreviewFailed
+			updates["error"] = err.Error()
+			break
+		}
+		updates["thumbnail_key"] = key
+		updates["thumbnail_size"] = len(thumb.data)
+		updates["thumbnail_width"] = thumb.width
+		updates["thumbnail_height"] = thumb.height
+	case isTextMime(f.MimeType):
+		excerpt, err := readExcerpt(ctx, store, f)
+		if err != nil {
+			updates["status"] = models.PreviewFailed
+			updates["error"] = err.Error()
+			break
+		}
+		if excerpt == "" {
+			updates["status"] = models.PreviewUnsupported
+			break
+		}
+		updates["excerpt"] = excerpt
+	default:
+		updates["status"] = models.PreviewUnsupported
+	}
+
+	res := db.Model(&models.FilePreview{}).
+		Where("file_id = ? AND version = ? AND status = ?", f.ID, f.Version, models.PreviewPending).
+		Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		// 记录已被新版本重新排队，丢弃本次生成的缩略图
+		if key != "" {
+			_ = store.Delete(ctx, key)
+		}
+		return nil
+	}
+	if previous.ThumbnailKey != "" && previous.ThumbnailKey != key {
+		if err := store.Delete(ctx, previous.ThumbnailKey); err != nil {
+			log.Printf("remove old thumbnail %s: %v", previous.ThumbnailKey, err)
+		}
+	}
+	return nil
+}
+
+func isThumbnailable(f *models.File) bool {
+	switch strings.ToLower(f.MimeType) {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+		return true
+	}
+	return false
+}
+
+type thumbnail struct {
+	data          []byte
+	width, height int
+}
+
+// renderThumbnail 解码图片并等比缩放到最长边不超过 thumbnailMaxEdge，透明区域填充白色后编码为 JPEG。
+func renderThumbnail(ctx context.Context, store storage.Driver, f *models.File) (*thumbnail, error) {
+	if f.Size > maxPreviewSourceBytes {
+		return nil, errPreviewTooLarge
+	}
+	rc, err := store.Get(ctx, f.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxPreviewSourceBytes))
+	if err != nil {
+		return nil, err
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+	if cfg.Width*cfg.Height > maxPreviewPixels {
+		return nil, errPreviewTooLarge
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+
+	width, height := fitWithin(src.Bounds().Dx(), src.Bounds().Dy(), thumbnailMaxEdge)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), xdraw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, err
+	}
+	return &thumbnail{data: buf.Bytes(), width: width, height: height}, nil
+}
+
+// fitWithin 返回等比缩放到最长边不超过 maxEdge 的尺寸，小图保持原尺寸。
+func fitWithin(width, height, maxEdge int) (int, int) {
+	if width <= maxEdge && height <= maxEdge {
+		return max(width, 1), max(height, 1)
+	}
+	if width >= height {
+		return maxEdge, max(height*maxEdge/width, 1)
+	}
+	return max(width*maxEdge/height, 1), maxEdge
+}
+
+// readExcerpt 读取文本开头的 previewExcerptRunes 个字符；内容不是合法 UTF-8 时返回空字符串。
+func readExcerpt(ctx context.Context, store storage.Driver, f *models.File) (string, error) {
+	rc, err := store.ReadRange(ctx, f.Path, 0, previewExcerptRunes*utf8.UTFMax)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return "", err
+	}
+	// 按字节截断可能切开最后一个字符，去掉不完整的尾部
+	for i := 0; i < utf8.UTFMax && len(data) > 0 && !utf8.Valid(data); i++ {
+		data = data[:len(data)-1]
+	}
+	if !utf8.Valid(data) {
+		return "", nil
+	}
+	text := []rune(string(data))
+	if len(text) > previewExcerptRunes {
+		text = text[:previewExcerptRunes]
+	}
+	return string(text), nil
+}
+
+// GetFileThumbnail 返回文件的预览：图片为 JPEG 缩略图，文本为开头摘录；预览尚未生成时返回 202。
+// @Summary 获取文件缩略图/预览
+// @Description 图片返回 image/jpeg 缩略图（最长边 320px），文本返回 text/plain 摘录；后台生成中返回 202，不支持预览的类型返回 404。
+// @Tags files
+// @Produce jpeg,plain
+// @Param id path int true "文件ID"
+// @Security BearerAuth
+// @Router /files/{id}/thumbnail [get]
+func GetFileThumbnail(db *gorm.DB, store storage.Driver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f, ok := loadVisibleFile(c, db)
+		if !ok {
+			return
+		}
+		servePreview(c, db, store, f)
+	}
+}
+
+// GetShareThumbnail 在与预览相同的访问校验下返回分享文件的缩略图或摘录，不计入浏览次数。
+// 阅后即焚的分享不提供缩略图，避免不计次数地读到内容。
+// @Summary 获取分享缩略图/预览
+// @Description 访问规则同 /shares/{token}，但不消耗浏览次数；返回格式同 /files/{id}/thumbnail。
+// @Tags shares
+// @Produce jpeg,plain
+// @Param token path string true "分享 Token"
+// @Security BearerAuth
+// @Router /shares/{token}/thumbnail [get]
+func GetShareThumbnail(db *gorm.DB, cfg *config.Config, store storage.Driver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		share, err := loadShare(db, c.Param("token"))
+		// 打包分享没有整体的缩略图
+		if err != nil || share.File.ID == 0 || share.Bundle {
+			c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
+			return
+		}
+		if share.BurnAfterReading {
+			c.JSON(http.StatusNotFound, gin.H{"error": "阅后即焚的分享不提供预览"})
+			return
+		}
+		claims, err := parseOptionalClaims(c, cfg)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+		if !checkShareAccess(c, db, cfg, share, claims, true) {
+			return
+		}
+		servePreview(c, db, store, &share.File)
+	}
+}
+
+// servePreview 按预览状态输出缩略图、摘录或状态说明；还没有预览记录的文件会在此补充排队。
+func servePreview(c *gin.Context, db *gorm.DB, store storage.Driver, f *models.File) {
+	if !checkScanStatus(c, f) {
+		return
+	}
+	var p models.FilePreview
+	err := db.First(&p, "file_id = ?", f.ID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		schedulePreview(db, f)
+		p = models.FilePreview{FileID: f.ID, Version: f.Version, Status: models.PreviewPending}
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch {
+	case p.Status == models.PreviewPending || p.Version != f.Version:
+		c.Header("Retry-After", "2")
+		c.JSON(http.StatusAccepted, gin.H{"status": models.PreviewPending})
+		return
+	case p.Status == models.PreviewUnsupported:
+		c.JSON(http.StatusNotFound, gin.H{"error": "该文件类型不支持预览", "status": p.Status})
+		return
+	case p.Status == models.PreviewFailed:
+		c.JSON(http.StatusNotFound, gin.H{"error": "预览生成失败", "status": p.Status})
+		return
+	}
+
+	etag := fmt.Sprintf(`"preview-%d-%d"`, f.ID, p.Version)
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, max-age=300")
+	if requestNotModified(c.Request, etag, p.UpdatedAt) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	if p.ThumbnailKey == "" {
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(p.Excerpt))
+		return
+	}
+	rc, err := store.Get(c.Request.Context(), p.ThumbnailKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotExist) {
+			// 缩略图对象丢失时重新排队生成
+			schedulePreview(db, f)
+			c.Header("Retry-After", "2")
+			c.JSON(http.StatusAccepted, gin.H{"status": models.PreviewPending})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer rc.Close()
+	c.DataFromReader(http.StatusOK, p.ThumbnailSize, "image/jpeg", rc, nil)
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"content-hub/server/config"
+	"content-hub/server/models"
+	"content-hub/server/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func persistTestFile(t *testing.T, db *gorm.DB, cfg *config.Config, store storage.Driver, ownerID uint, name, mimeType string, content []byte) *models.File {
+	t.Helper()
+	f, err := persistUpload(context.Background(), db, cfg, store, uploadInput{
+		OwnerID:  ownerID,
+		Filename: name,
+		MimeType: mimeType,
+		Content:  bytes.NewReader(content),
+		MaxBytes: -1,
+	})
+	if err != nil {
+		t.Fatalf("persist %s: %v", name, err)
+	}
+	return f
+}
+
+func callThumbnail(db *gorm.DB, store storage.Driver, user models.User, fileID uint) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/files/x/thumbnail", nil)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(fileID)}}
+	c.Set("userID", user.ID)
+	c.Set("role", user.Role)
+	GetFileThumbnail(db, store)(c)
+	return w
+}
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 200, A: 128})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestFilePreviews(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	owner := createUser(t, db, "owner", models.RoleUser)
+	stranger := createUser(t, db, "stranger", models.RoleUser)
+	ctx := context.Background()
+
+	img := persistTestFile(t, db, cfg, store, owner.ID, "wide.png", "image/png", testPNG(t, 800, 400))
+	text := persistTestFile(t, db, cfg, store, owner.ID, "notes.txt", "text/plain", bytes.Repeat([]byte("你好 preview "), 200))
+	bin := persistTestFile(t, db, cfg, store, owner.ID, "blob.bin", "application/octet-stream", []byte{0, 1, 2, 3})
+	broken := persistTestFile(t, db, cfg, store, owner.ID, "broken.jpg", "image/jpeg", []byte("not a jpeg"))
+
+	// 后台任务处理前返回 202
+	if w := callThumbnail(db, store, owner, img.ID); w.Code != http.StatusAccepted {
+		t.Fatalf("pending thumbnail status = %d, want 202", w.Code)
+	}
+	if n, err := GeneratePendingPreviews(ctx, db, store, previewBatchSize); err != nil || n != 4 {
+		t.Fatalf("generate previews: n=%d err=%v", n, err)
+	}
+
+	w := callThumbnail(db, store, owner, img.ID)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/jpeg" {
+		t.Fatalf("thumbnail status = %d type=%s body=%s", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+	thumb, err := jpeg.Decode(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("decode thumbnail: %v", err)
+	}
+	if b := thumb.Bounds(); b.Dx() != thumbnailMaxEdge || b.Dy() != thumbnailMaxEdge/2 {
+		t.Fatalf("thumbnail size = %v, want %dx%d", b, thumbnailMaxEdge, thumbnailMaxEdge/2)
+	}
+
+	w = callThumbnail(db, store, owner, text.ID)
+	if w.Code != http.StatusOK || !bytes.HasPrefix(w.Body.Bytes(), []byte("你好 preview")) {
+		t.Fatalf("text excerpt status = %d body=%q", w.Code, w.Body.String())
+	}
+	if n := len([]rune(w.Body.String())); n != previewExcerptRunes {
+		t.Fatalf("excerpt length = %d runes, want %d", n, previewExcerptRunes)
+	}
+	if w := callThumbnail(db, store, owner, bin.ID); w.Code != http.StatusNotFound {
+		t.Fatalf("unsupported preview status = %d, want 404", w.Code)
+	}
+	if w := callThumbnail(db, store, owner, broken.ID); w.Code != http.StatusNotFound {
+		t.Fatalf("failed preview status = %d, want 404", w.Code)
+	}
+	// 无权查看文件时不暴露缩略图
+	if w := callThumbnail(db, store, stranger, img.ID); w.Code != http.StatusNotFound {
+		t.Fatalf("stranger thumbnail status = %d, want 404", w.Code)
+	}
+
+	// 上传新版本后重新生成，旧缩略图被清理
+	var before models.FilePreview
+	db.First(&before, "file_id = ?", img.ID)
+	if w := uploadVersion(t, db, cfg, store, owner, img.ID, "plain text now"); w.Code != http.StatusOK {
+		t.Fatalf("upload version status = %d", w.Code)
+	}
+	if w := callThumbnail(db, store, owner, img.ID); w.Code != http.StatusAccepted {
+		t.Fatalf("thumbnail after new version status = %d, want 202", w.Code)
+	}
+	if _, err := GeneratePendingPreviews(ctx, db, store, previewBatchSize); err != nil {
+		t.Fatalf("regenerate: %v", err)
+	}
+	if _, err := store.Stat(ctx, before.ThumbnailKey); err == nil {
+		t.Fatalf("old thumbnail %s should be removed", before.ThumbnailKey)
+	}
+}
+
+func TestShareThumbnailDoesNotCountViews(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	cfg.JWTSecret = "secret"
+	owner := createUser(t, db, "owner", models.RoleUser)
+	img := persistTestFile(t, db, cfg, store, owner.ID, "small.png", "image/png", testPNG(t, 40, 20))
+	if _, err := GeneratePendingPreviews(context.Background(), db, store, previewBatchSize); err != nil {
+		t.Fatalf("generate previews: %v", err)
+	}
+	maxViews := uint(1)
+	share := models.Share{Token: "thumb-token", FileID: img.ID, CreatorID: owner.ID, MaxViews: &maxViews}
+	if err := db.Create(&share).Error; err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/shares/thumb-token/thumbnail", nil)
+		c.Params = gin.Params{{Key: "token", Value: share.Token}}
+		GetShareThumbnail(db, cfg, store)(c)
+		if w.Code != http.StatusOK {
+			t.Fatalf("share thumbnail #%d status = %d body=%s", i, w.Code, w.Body.String())
+		}
+	}
+	db.First(&share, share.ID)
+	if share.ViewCount != 0 {
+		t.Fatalf("thumbnail requests should not consume views, got %d", share.ViewCount)
+	}
+}
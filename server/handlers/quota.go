@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"content-hub/server/config"
+	"content-hub/server/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// multipartOverhead 为 multipart 边界与普通表单字段预留的字节数，限制请求体时在剩余配额之上额外放宽。
+const multipartOverhead = 1 << 20
+
+var errQuotaExceeded = errors.New("存储配额不足")
+
+// userQuota 是上传前读取的配额与占用快照，配额为 0 表示不限制。
+type userQuota struct {
+	QuotaBytes int64 `json:"quota_bytes"`
+	QuotaFiles int64 `json:"quota_files"`
+	UsedBytes  int64 `json:"used_bytes"`
+	FileCount  int64 `json:"file_count"`
+	// ReservedBytes 为未完成的分片上传会话按声明大小预留的字节数，不计入 UsedBytes
+	ReservedBytes int64 `json:"reserved_bytes,omitempty"`
+	// Masked 为 true 时超限响应不返回用量明细，用于匿名访客通过上传链接写入他人空间
+	Masked bool `json:"-"`
+}
+
+func loadUserQuota(db *gorm.DB, cfg *config.Config, userID uint) (*userQuota, error) {
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	usage, err := models.UsageByOwner(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	q := &userQuota{UsedBytes: usage[userID].UsedBytes, FileCount: usage[userID].FileCount}
+	q.QuotaBytes, q.QuotaFiles = user.EffectiveQuota(cfg.DefaultQuotaBytes, cfg.DefaultQuotaFiles)
+	return q, nil
+}
+
+// allows 判断再新增 bytes 字节、files 个文件后是否仍在配额内。
+func (q *userQuota) allows(bytes, files int64) bool {
+	if q.QuotaBytes > 0 && q.UsedBytes+bytes > q.QuotaBytes {
+		return false
+	}
+	if q.QuotaFiles > 0 && q.FileCount+files > q.QuotaFiles {
+		return false
+	}
+	return true
+}
+
+// remainingBytes 返回剩余可用字节数，-1 表示不限制。
+func (q *userQuota) remainingBytes() int64 {
+	if q.QuotaBytes <= 0 {
+		return -1
+	}
+	if q.UsedBytes >= q.QuotaBytes {
+		return 0
+	}
+	return q.QuotaBytes - q.UsedBytes
+}
+
+func writeQuotaExceeded(c *gin.Context, q *userQuota) {
+	if q.Masked {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": errQuotaExceeded.Error()})
+		return
+	}
+	resp := gin.H{
+		"error":       errQuotaExceeded.Error(),
+		"quota_bytes": q.QuotaBytes,
+		"quota_files": q.QuotaFiles,
+		"used_bytes":  q.UsedBytes,
+		"file_count":  q.FileCount,
+	}
+	if q.ReservedBytes > 0 {
+		resp["reserved_bytes"] = q.ReservedBytes
+	}
+	c.JSON(http.StatusRequestEntityTooLarge, resp)
+}
+
+// isBodyTooLarge 判断错误是否由 http.MaxBytesReader 的上限触发。
+func isBodyTooLarge(err error) bool {
+	var maxErr *http.MaxBytesError
+	return errors.As(err, &maxErr)
+}
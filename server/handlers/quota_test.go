@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"content-hub/server/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestUploadQuotaEnforced(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	cfg.DefaultQuotaBytes = 10
+	owner := createUser(t, db, "owner", models.RoleUser)
+
+	if w := uploadMultipart(t, db, cfg, store, owner.ID, "a.txt", []byte("123456")); w.Code != http.StatusOK {
+		t.Fatalf("upload within quota status = %d body=%s", w.Code, w.Body.String())
+	}
+	// 累计 12 字节，超出默认配额
+	if w := uploadMultipart(t, db, cfg, store, owner.ID, "b.txt", []byte("abcdef")); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("upload over quota should be rejected, got %d", w.Code)
+	}
+	var count int64
+	db.Model(&models.File{}).Count(&count)
+	if count != 1 {
+		t.Fatalf("rejected upload must not create a record, got %d files", count)
+	}
+
+	// 管理员单独放宽配额后可继续上传，文件数限制同样生效
+	body := `{"quota_bytes":0,"quota_files":2}`
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPut, "/api/admin/users/x/quota", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(owner.ID)}}
+	UpdateUserQuota(db, cfg)(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update quota status = %d body=%s", w.Code, w.Body.String())
+	}
+	var resp UserResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if !resp.CustomQuota || resp.QuotaBytes != 0 || resp.QuotaFiles != 2 || resp.UsedBytes != 6 || resp.FileCount != 1 {
+		t.Fatalf("unexpected quota response %+v", resp)
+	}
+
+	if w := uploadMultipart(t, db, cfg, store, owner.ID, "b.txt", []byte("abcdef")); w.Code != http.StatusOK {
+		t.Fatalf("upload after raising quota status = %d body=%s", w.Code, w.Body.String())
+	}
+	if w := uploadMultipart(t, db, cfg, store, owner.ID, "c.txt", []byte("x")); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("file count quota should be enforced, got %d", w.Code)
+	}
+}
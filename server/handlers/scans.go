@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"content-hub/server/config"
+	"content-hub/server/models"
+	"content-hub/server/scanner"
+	"content-hub/server/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	scanBatchSize  = 10
+	scanRetryDelay = 10 * time.Minute // 扫描出错（如 clamd 不可用）的文件间隔该时长后重试
+	// maxScanAttempts 次连续出错（约 1 小时）后停止自动重试，文件保持 error 等待管理员处理
+	maxScanAttempts = 6
+)
+
+// scanWake 在有新内容待扫描时唤醒后台任务，容量为 1 即可合并多次通知。
+var scanWake = make(chan struct{}, 1)
+
+// initialScanStatus 返回新内容写入时的扫描状态：启用扫描时需等待扫描通过，未启用时直接可用。
+func initialScanStatus(cfg *config.Config) string {
+	if cfg.ScanEnabled() {
+		return models.ScanPending
+	}
+	return models.ScanClean
+}
+
+// scheduleScan 在文件等待扫描时唤醒后台任务。
+func scheduleScan(f *models.File) {
+	if f.ScanStatus != models.ScanPending {
+		return
+	}
+	select {
+	case scanWake <- struct{}{}:
+	default:
+	}
+}
+
+// ReleaseUnscannedFiles 在未启用扫描时将遗留的 pending 文件与历史版本标记为 clean：这些内容在扫描启用期间上传、
+// 尚未扫描，关闭扫描后不会再有后台任务处理。扫描出错的文件往往正是 clamd 无法处理的内容，保持拦截，
+// 需管理员重新扫描或手动放行；返回放行的文件数与仍被拦截的出错文件数。
+func ReleaseUnscannedFiles(db *gorm.DB) (released, blocked int64, err error) {
+	res := db.Unscoped().Model(&models.File{}).Where("scan_status = ?", models.ScanPending).
+		Updates(map[string]any{"scan_status": models.ScanClean, "scan_result": "", "scan_attempts": 0})
+	if res.Error != nil {
+		return 0, 0, res.Error
+	}
+	err = db.Unscoped().Model(&models.FileVersion{}).Where("scan_status = ?", models.ScanPending).
+		Update("scan_status", models.ScanClean).Error
+	if err != nil {
+		return res.RowsAffected, 0, err
+	}
+	err = db.Unscoped().Model(&models.File{}).Where("scan_status = ?", models.ScanError).Count(&blocked).Error
+	return res.RowsAffected, blocked, err
+}
+
+// StartScanWorker 启动后台扫描任务：上传后立即被唤醒，同时按 interval 兜底处理待扫描与待重试的文件。
+func StartScanWorker(db *gorm.DB, store storage.Driver, sc scanner.Scanner, interval time.Duration) {
+	go func() {
+		ctx := context.Background()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			for {
+				n, err := ScanPendingFiles(ctx, db, store, sc, scanBatchSize)
+				if err != nil {
+					log.Printf("scan files: %v", err)
+					break
+				}
+				if n < scanBatchSize {
+					break
+				}
+			}
+			select {
+			case <-scanWake:
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// ScanPendingFiles 扫描最多 limit 个待扫描或到期重试的文件，名额有剩余时再扫描待扫描的历史版本，返回处理的数量。
+func ScanPendingFiles(ctx context.Context, db *gorm.DB, store storage.Driver, sc scanner.Scanner, limit int) (int, error) {
+	var files []models.File
+	err := db.Unscoped().
+		Where("scan_status = ? OR (scan_status = ? AND scan_attempts < ? AND (scanned_at IS NULL OR scanned_at < ?))",
+			models.ScanPending, models.ScanError, maxScanAttempts, time.Now().Add(-scanRetryDelay)).
+		Order("id").Limit(limit).Find(&files).Error
+	if err != nil {
+		return 0, err
+	}
+	for i := range files {
+		if err := scanFile(ctx, db, store, sc, &files[i]); err != nil {
+			return i, err
+		}
+	}
+	if len(files) >= limit {
+		return len(files), nil
+	}
+
+	// 扫描完成前上传了新版本时，旧内容以 pending 转为历史版本，同样需要扫描后才能下载
+	var versions []models.FileVersion
+	err = db.Unscoped().Where("scan_status = ?", models.ScanPending).
+		Order("id").Limit(limit - len(files)).Find(&versions).Error
+	if err != nil {
+		return len(files), err
+	}
+	for i := range versions {
+		if err := scanVersion(ctx, db, store, sc, &versions[i]); err != nil {
+			return len(files) + i, err
+		}
+	}
+	return len(files) + len(versions), nil
+}
+
+// scanContent 扫描存储中的内容，返回扫描结论；err 不为空表示扫描本身失败。
+func scanContent(ctx context.Context, store storage.Driver, sc scanner.Scanner, path string) (status, result string, err error) {
+	rc, err := store.Get(ctx, path)
+	if err != nil {
+		return models.ScanError, err.Error(), err
+	}
+	res, err := sc.Scan(ctx, rc)
+	rc.Close()
+	if err != nil {
+		return models.ScanError, err.Error(), err
+	}
+	if res.Infected {
+		return models.ScanInfected, res.Signature, nil
+	}
+	return models.ScanClean, "", nil
+}
+
+// scanFile 扫描文件当前内容并写回结论。扫描期间若上传了新版本，结论转记到被替换下来的历史版本上，由下一轮处理新内容。
+func scanFile(ctx context.Context, db *gorm.DB, store storage.Driver, sc scanner.Scanner, f *models.File) error {
+	status, result, err := scanContent(ctx, store, sc, f.Path)
+	attempts := uint(0)
+	if err != nil {
+		attempts = f.ScanAttempts + 1
+		log.Printf("scan file %d: %v", f.ID, err)
+		if attempts >= maxScanAttempts {
+			log.Printf("file %d (owner %d) failed scanning %d times, automatic retries stopped until an admin rescans it", f.ID, f.OwnerID, attempts)
+		}
+	} else if status == models.ScanInfected {
+		log.Printf("file %d (owner %d) infected: %s", f.ID, f.OwnerID, result)
+	}
+	now := time.Now()
+	res := db.Unscoped().Model(&models.File{}).
+		Where("id = ? AND version = ? AND scan_status IN ?", f.ID, f.Version, []string{models.ScanPending, models.ScanError}).
+		Updates(map[string]any{"scan_status": status, "scan_result": result, "scanned_at": &now, "scan_attempts": attempts})
+	if res.Error != nil || res.RowsAffected > 0 {
+		return res.Error
+	}
+	return db.Unscoped().Model(&models.FileVersion{}).
+		Where("file_id = ? AND version = ? AND scan_status = ?", f.ID, f.Version, models.ScanPending).
+		Update("scan_status", status).Error
+}
+
+// scanVersion 扫描历史版本的内容。历史版本不自动重试，出错时保持拦截，由管理员对文件发起重新扫描。
+func scanVersion(ctx context.Context, db *gorm.DB, store storage.Driver, sc scanner.Scanner, v *models.FileVersion) error {
+	status, result, err := scanContent(ctx, store, sc, v.Path)
+	if err != nil {
+		log.Printf("scan file %d version %d: %v", v.FileID, v.Version, err)
+	} else if status == models.ScanInfected {
+		log.Printf("file %d version %d infected: %s", v.FileID, v.Version, result)
+	}
+	return db.Unscoped().Model(&models.FileVersion{}).
+		Where("id = ? AND scan_status = ?", v.ID, models.ScanPending).
+		Update("scan_status", status).Error
+}
+
+// RescanFile 将文件当前内容重新加入扫描队列，用于多次扫描出错后停止自动重试的文件，或病毒库更新后复查已拦截的文件；
+// 扫描出错的历史版本一并重新扫描。
+// 未启用扫描时返回 409，可改用 /admin/files/{id}/release 手动放行。
+// @Summary 重新扫描文件
+// @Tags admin
+// @Produce json
+// @Param id path int true "文件ID"
+// @Security BearerAuth
+// @Router /admin/files/{id}/rescan [post]
+func RescanFile(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !cfg.ScanEnabled() {
+			c.JSON(http.StatusConflict, gin.H{"error": "未启用恶意软件扫描"})
+			return
+		}
+		f, ok := loadVisibleFile(c, db)
+		if !ok {
+			return
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			err := tx.Model(&models.File{}).Where("id = ?", f.ID).
+				Updates(map[string]any{"scan_status": models.ScanPending, "scan_result": "", "scanned_at": nil, "scan_attempts": 0}).Error
+			if err != nil {
+				return err
+			}
+			// 扫描出错的历史版本不会自动重试，随文件一起重新排队
+			return tx.Model(&models.FileVersion{}).Where("file_id = ? AND scan_status = ?", f.ID, models.ScanError).
+				Update("scan_status", models.ScanPending).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		f.ScanStatus, f.ScanResult, f.ScannedAt, f.ScanAttempts = models.ScanPending, "", nil, 0
+		scheduleScan(f)
+		c.JSON(http.StatusOK, buildFileResponse(f))
+	}
+}
+
+// ReleaseScannedFile 由管理员确认后放行扫描出错或被判定感染的文件（如误报），不再扫描当前内容，操作会记录日志。
+// @Summary 手动放行文件
+// @Tags admin
+// @Produce json
+// @Param id path int true "文件ID"
+// @Security BearerAuth
+// @Router /admin/files/{id}/release [post]
+func ReleaseScannedFile(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f, ok := loadVisibleFile(c, db)
+		if !ok {
+			return
+		}
+		err := db.Model(&models.File{}).Where("id = ?", f.ID).
+			Updates(map[string]any{"scan_status": models.ScanClean, "scan_attempts": 0}).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		adminID, _ := currentUser(c)
+		log.Printf("admin %d released file %d (owner %d) with scan status %q: %s", adminID, f.ID, f.OwnerID, f.ScanStatus, f.ScanResult)
+		f.ScanStatus, f.ScanAttempts = models.ScanClean, 0
+		c.JSON(http.StatusOK, buildFileResponse(f))
+	}
+}
+
+// checkScanStatus 拦截未通过扫描的文件，下载、预览、缩略图与分享访问均需先经过该校验。失败时已写入响应。
+func checkScanStatus(c *gin.Context, f *models.File) bool {
+	switch f.ScanStatus {
+	case models.ScanClean:
+		return true
+	case models.ScanInfected:
+		c.JSON(http.StatusForbidden, gin.H{"error": "文件未通过安全扫描，已禁止下载", "scan_status": f.ScanStatus})
+	case models.ScanError:
+		if f.ScanAttempts >= maxScanAttempts {
+			c.JSON(http.StatusLocked, gin.H{"error": "文件多次安全扫描失败，请联系管理员重新扫描", "scan_status": f.ScanStatus})
+			return false
+		}
+		c.Header("Retry-After", strconv.Itoa(int(scanRetryDelay.Seconds())))
+		c.JSON(http.StatusLocked, gin.H{"error": "文件安全扫描出错，稍后将自动重试", "scan_status": f.ScanStatus})
+	default:
+		c.Header("Retry-After", "30")
+		c.JSON(http.StatusLocked, gin.H{"error": "文件正在进行安全扫描，请稍后再试", "scan_status": f.ScanStatus})
+	}
+	return false
+}
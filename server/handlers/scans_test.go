@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"content-hub/server/models"
+	"content-hub/server/scanner"
+	"github.com/gin-gonic/gin"
+)
+
+// stubScanner 内容包含 EICAR 时报告感染；err 不为空时模拟扫描服务不可用。
+type stubScanner struct {
+	err error
+}
+
+func (s *stubScanner) Scan(_ context.Context, r io.Reader) (scanner.Result, error) {
+	if s.err != nil {
+		return scanner.Result{}, s.err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return scanner.Result{}, err
+	}
+	if bytes.Contains(data, []byte("EICAR")) {
+		return scanner.Result{Infected: true, Signature: "Eicar-Test-Signature"}, nil
+	}
+	return scanner.Result{}, nil
+}
+
+func TestUploadScanGate(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	cfg.ScanDriver = "clamav"
+	owner := createUser(t, db, "owner", models.RoleUser)
+	ctx := context.Background()
+
+	clean := persistTestFile(t, db, cfg, store, owner.ID, "report.txt", "text/plain", []byte("quarterly report"))
+	infected := persistTestFile(t, db, cfg, store, owner.ID, "eicar.com", "application/octet-stream", []byte("X5O!P%@AP EICAR"))
+	if clean.ScanStatus != models.ScanPending {
+		t.Fatalf("new upload scan status = %q, want pending", clean.ScanStatus)
+	}
+
+	// 扫描完成前下载、预览与分享访问均被拦截，且不消耗分享次数
+	if w := callFileAs(DownloadFile(db, store), owner, http.MethodGet, clean.ID, ""); w.Code != http.StatusLocked {
+		t.Fatalf("download pending status = %d, want 423", w.Code)
+	}
+	if w := callFileAs(StreamFile(db, store), owner, http.MethodGet, clean.ID, ""); w.Code != http.StatusLocked {
+		t.Fatalf("stream pending status = %d, want 423", w.Code)
+	}
+	share := models.Share{Token: "scan-token", FileID: clean.ID, CreatorID: owner.ID}
+	if err := db.Create(&share).Error; err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+	streamShare := func() int {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/shares/scan-token/stream", nil)
+		c.Params = gin.Params{{Key: "token", Value: share.Token}}
+		StreamShare(db, cfg, store)(c)
+		return w.Code
+	}
+	if code := streamShare(); code != http.StatusLocked {
+		t.Fatalf("share stream pending status = %d, want 423", code)
+	}
+	db.First(&share, share.ID)
+	if share.ViewCount != 0 {
+		t.Fatalf("blocked share access should not count views, got %d", share.ViewCount)
+	}
+
+	// 扫描服务不可用时标记为 error，仍保持拦截
+	if n, err := ScanPendingFiles(ctx, db, store, &stubScanner{err: errors.New("connection refused")}, scanBatchSize); err != nil || n != 2 {
+		t.Fatalf("scan with broken scanner: n=%d err=%v", n, err)
+	}
+	db.First(clean, clean.ID)
+	if clean.ScanStatus != models.ScanError || clean.ScanResult == "" {
+		t.Fatalf("scan error not recorded: %+v", clean)
+	}
+	if n, _ := ScanPendingFiles(ctx, db, store, &stubScanner{}, scanBatchSize); n != 0 {
+		t.Fatalf("errored files should wait before retry, scanned %d", n)
+	}
+
+	db.Model(&models.File{}).Where("id IN ?", []uint{clean.ID, infected.ID}).Update("scanned_at", nil)
+	if n, err := ScanPendingFiles(ctx, db, store, &stubScanner{}, scanBatchSize); err != nil || n != 2 {
+		t.Fatalf("retry scan: n=%d err=%v", n, err)
+	}
+	db.First(clean, clean.ID)
+	db.First(infected, infected.ID)
+	if clean.ScanStatus != models.ScanClean || infected.ScanStatus != models.ScanInfected || infected.ScanResult != "Eicar-Test-Signature" {
+		t.Fatalf("scan results: clean=%q infected=%q (%s)", clean.ScanStatus, infected.ScanStatus, infected.ScanResult)
+	}
+	if w := callFileAs(DownloadFile(db, store), owner, http.MethodGet, clean.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf("download clean status = %d", w.Code)
+	}
+	if code := streamShare(); code != http.StatusOK {
+		t.Fatalf("share stream clean status = %d", code)
+	}
+	if w := callFileAs(DownloadFile(db, store), owner, http.MethodGet, infected.ID, ""); w.Code != http.StatusForbidden {
+		t.Fatalf("download infected status = %d, want 403", w.Code)
+	}
+
+	// 新版本需重新扫描
+	if w := uploadVersion(t, db, cfg, store, owner, clean.ID, "revised report"); w.Code != http.StatusOK {
+		t.Fatalf("upload version status = %d", w.Code)
+	}
+	if w := callFileAs(StreamFile(db, store), owner, http.MethodGet, clean.ID, ""); w.Code != http.StatusLocked {
+		t.Fatalf("stream new version status = %d, want 423", w.Code)
+	}
+	if w := callVersion(DownloadFileVersion(db, store), owner, http.MethodGet, clean.ID, 1); w.Code != http.StatusOK {
+		t.Fatalf("download scanned old version status = %d", w.Code)
+	}
+}
+
+func TestScanRetryLimitAndRelease(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	cfg.ScanDriver = "clamav"
+	owner := createUser(t, db, "owner", models.RoleUser)
+	admin := createUser(t, db, "admin", models.RoleAdmin)
+	ctx := context.Background()
+	f := persistTestFile(t, db, cfg, store, owner.ID, "report.txt", "text/plain", []byte("quarterly report"))
+
+	// 连续出错达到上限后不再自动重试，访问时提示联系管理员
+	broken := &stubScanner{err: errors.New("connection refused")}
+	for i := 0; i < maxScanAttempts; i++ {
+		db.Model(&models.File{}).Where("id = ?", f.ID).Update("scanned_at", nil)
+		if n, err := ScanPendingFiles(ctx, db, store, broken, scanBatchSize); err != nil || n != 1 {
+			t.Fatalf("attempt %d: n=%d err=%v", i+1, n, err)
+		}
+	}
+	db.Model(&models.File{}).Where("id = ?", f.ID).Update("scanned_at", nil)
+	if n, _ := ScanPendingFiles(ctx, db, store, &stubScanner{}, scanBatchSize); n != 0 {
+		t.Fatalf("exhausted file should not be retried, scanned %d", n)
+	}
+	w := callFileAs(DownloadFile(db, store), owner, http.MethodGet, f.ID, "")
+	if w.Code != http.StatusLocked || w.Header().Get("Retry-After") != "" || !bytes.Contains(w.Body.Bytes(), []byte("管理员")) {
+		t.Fatalf("exhausted download status = %d headers=%v body=%s", w.Code, w.Header(), w.Body.String())
+	}
+
+	if w := callFileAs(RescanFile(db, cfg), admin, http.MethodPost, f.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf("rescan status = %d body=%s", w.Code, w.Body.String())
+	}
+	if n, err := ScanPendingFiles(ctx, db, store, &stubScanner{}, scanBatchSize); err != nil || n != 1 {
+		t.Fatalf("scan after rescan: n=%d err=%v", n, err)
+	}
+	if w := callFileAs(DownloadFile(db, store), owner, http.MethodGet, f.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf("download after rescan status = %d", w.Code)
+	}
+
+	// 关闭扫描后，未扫描的文件在启动时放行；扫描出错的文件保持拦截，需管理员手动放行
+	pending := persistTestFile(t, db, cfg, store, owner.ID, "pending.txt", "text/plain", []byte("pending"))
+	failed := persistTestFile(t, db, cfg, store, owner.ID, "failed.txt", "text/plain", []byte("failed"))
+	db.Model(failed).Update("scan_status", models.ScanError)
+	cfg.ScanDriver = ""
+	if w := callFileAs(RescanFile(db, cfg), admin, http.MethodPost, f.ID, ""); w.Code != http.StatusConflict {
+		t.Fatalf("rescan with scanning disabled status = %d, want 409", w.Code)
+	}
+	if released, blocked, err := ReleaseUnscannedFiles(db); err != nil || released != 1 || blocked != 1 {
+		t.Fatalf("release: released=%d blocked=%d err=%v", released, blocked, err)
+	}
+	if w := callFileAs(DownloadFile(db, store), owner, http.MethodGet, pending.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf("download released file status = %d", w.Code)
+	}
+	if w := callFileAs(DownloadFile(db, store), owner, http.MethodGet, failed.ID, ""); w.Code != http.StatusLocked {
+		t.Fatalf("download failed file status = %d, want 423", w.Code)
+	}
+	if w := callFileAs(ReleaseScannedFile(db), admin, http.MethodPost, failed.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf("admin release status = %d body=%s", w.Code, w.Body.String())
+	}
+	if w := callFileAs(DownloadFile(db, store), owner, http.MethodGet, failed.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf("download after admin release status = %d", w.Code)
+	}
+}
+
+func TestScanArchivedPendingVersions(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	cfg.ScanDriver = "clamav"
+	owner := createUser(t, db, "owner", models.RoleUser)
+	admin := createUser(t, db, "admin", models.RoleAdmin)
+	ctx := context.Background()
+	f := persistTestFile(t, db, cfg, store, owner.ID, "notes.txt", "text/plain", []byte("draft"))
+	versionStatus := func(version uint) string {
+		var v models.FileVersion
+		db.Where("file_id = ? AND version = ?", f.ID, version).First(&v)
+		return v.ScanStatus
+	}
+
+	// 扫描进行中上传了新版本：本次结论转记到被替换下来的历史版本
+	stale := *f
+	if w := uploadVersion(t, db, cfg, store, owner, f.ID, "second"); w.Code != http.StatusOK {
+		t.Fatalf("upload version status = %d", w.Code)
+	}
+	if err := scanFile(ctx, db, store, &stubScanner{}, &stale); err != nil {
+		t.Fatalf("scan stale file: %v", err)
+	}
+	if got := versionStatus(1); got != models.ScanClean {
+		t.Fatalf("version 1 scan status = %q, want clean", got)
+	}
+
+	// 尚未扫描就被替换的内容由后台任务扫描历史版本；出错时保持拦截，管理员重新扫描文件时一并处理
+	if w := uploadVersion(t, db, cfg, store, owner, f.ID, "third"); w.Code != http.StatusOK {
+		t.Fatalf("upload version status = %d", w.Code)
+	}
+	if w := callVersion(DownloadFileVersion(db, store), owner, http.MethodGet, f.ID, 2); w.Code != http.StatusLocked {
+		t.Fatalf("pending version download status = %d, want 423", w.Code)
+	}
+	if n, err := ScanPendingFiles(ctx, db, store, &stubScanner{err: errors.New("connection refused")}, scanBatchSize); err != nil || n != 2 {
+		t.Fatalf("scan with broken scanner: n=%d err=%v", n, err)
+	}
+	if got := versionStatus(2); got != models.ScanError {
+		t.Fatalf("version 2 scan status = %q, want error", got)
+	}
+	db.Model(&models.File{}).Where("id = ?", f.ID).Update("scanned_at", nil)
+	if n, _ := ScanPendingFiles(ctx, db, store, &stubScanner{}, scanBatchSize); n != 1 || versionStatus(2) != models.ScanError {
+		t.Fatalf("errored version should not be retried automatically, scanned %d", n)
+	}
+	if w := callFileAs(RescanFile(db, cfg), admin, http.MethodPost, f.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf("rescan status = %d", w.Code)
+	}
+	if n, err := ScanPendingFiles(ctx, db, store, &stubScanner{}, scanBatchSize); err != nil || n != 2 {
+		t.Fatalf("scan after rescan: n=%d err=%v", n, err)
+	}
+	if w := callVersion(DownloadFileVersion(db, store), owner, http.MethodGet, f.ID, 2); w.Code != http.StatusOK || w.Body.String() != "second" {
+		t.Fatalf("scanned version download status = %d body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestCheckScanStatusUnset(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	if checkScanStatus(c, &models.File{}) || w.Code != http.StatusLocked {
+		t.Fatalf("unset scan status should be treated as pending, got %d", w.Code)
+	}
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"content-hub/server/models"
+	"content-hub/server/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxIndexedTextBytes 超过该大小的文本文件不建立全文索引。
+const maxIndexedTextBytes = 1 << 20
+
+// SearchFiles 按条件检索当前用户可见的文件，q 同时匹配文件名、描述与文本文件的全文内容；排序与分页参数同文件列表。
+// @Summary 搜索文件
+// @Tags files
+// @Produce json
+// @Param q query string false "关键词：匹配文件名、描述及文本内容（全文检索）"
+// @Param filename query string false "文件名包含"
+// @Param description query string false "描述包含"
+// @Param mime query string false "MIME 类型，如 text/plain 或 image/*"
+// @Param owner query string false "上传者用户名"
+// @Param tag query []string false "标签名，可重复，需同时满足" collectionFormat(multi)
+// @Param min_size query int false "最小字节数"
+// @Param max_size query int false "最大字节数"
+// @Param from query string false "创建时间起（RFC 3339 或 YYYY-MM-DD）"
+// @Param to query string false "创建时间止（RFC 3339 或 YYYY-MM-DD，按日期时包含当天）"
+// @Param limit query int false "每页数量，默认 50，最大 200"
+// @Param cursor query string false "上一页返回的 next_cursor"
+// @Param sort query string false "排序字段：created_at / updated_at / filename / size"
+// @Param order query string false "asc 或 desc，默认 desc"
+// @Param include_total query bool false "是否返回过滤后的总数"
+// @Success 200 {object} FileListResponse
+// @Security BearerAuth
+// @Router /files/search [get]
+func SearchFiles(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lq, ok := parseListQuery(c, fileListSpec)
+		if !ok {
+			return
+		}
+		userID, role := currentUser(c)
+		query := db.Model(&models.File{}).Scopes(models.VisibleTo(userID, role))
+
+		if q := strings.TrimSpace(c.Query("q")); q != "" {
+			pattern := "%" + models.EscapeLike(q) + "%"
+			query = query.Where(
+				`files.filename LIKE ? ESCAPE '\' OR files.description LIKE ? ESCAPE '\' OR files.id IN (?)`,
+				pattern, pattern, models.MatchingFileTexts(db, q),
+			)
+		}
+		if v := strings.TrimSpace(c.Query("filename")); v != "" {
+			query = query.Where(`files.filename LIKE ? ESCAPE '\'`, "%"+models.EscapeLike(v)+"%")
+		}
+		if v := strings.TrimSpace(c.Query("description")); v != "" {
+			query = query.Where(`files.description LIKE ? ESCAPE '\'`, "%"+models.EscapeLike(v)+"%")
+		}
+		if v := strings.TrimSpace(c.Query("mime")); v != "" {
+			if prefix, ok := strings.CutSuffix(v, "*"); ok {
+				query = query.Where(`files.mime_type LIKE ? ESCAPE '\'`, models.EscapeLike(prefix)+"%")
+			} else {
+				query = query.Where("files.mime_type = ?", v)
+			}
+		}
+		if v := strings.TrimSpace(c.Query("owner")); v != "" {
+			query = query.Where("files.owner_id IN (?)", db.Model(&models.User{}).Select("id").Where("username = ?", v))
+		}
+		query, ok = tagFilter(c, db, query)
+		if !ok {
+			return
+		}
+		for param, op := range map[string]string{"min_size": ">=", "max_size": "<="} {
+			v := c.Query(param)
+			if v == "" {
+				continue
+			}
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || n < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": param + " 需为非负整数"})
+				return
+			}
+			query = query.Where("files.size "+op+" ?", n)
+		}
+		if v := c.Query("from"); v != "" {
+			from, _, err := parseSearchTime(v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "from 格式无效"})
+				return
+			}
+			query = query.Where("files.created_at >= ?", from)
+		}
+		if v := c.Query("to"); v != "" {
+			to, dateOnly, err := parseSearchTime(v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "to 格式无效"})
+				return
+			}
+			if dateOnly {
+				query = query.Where("files.created_at < ?", to.AddDate(0, 0, 1))
+			} else {
+				query = query.Where("files.created_at <= ?", to)
+			}
+		}
+
+		total, err := lq.total(query)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		query, err = lq.apply(query)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cursor 无效"})
+			return
+		}
+		var files []models.File
+		if err := query.Preload("Owner").Find(&files).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		files, page := paginate(lq, files, fileSortKey)
+		page.Total = total
+
+		resp := FileListResponse{Items: make([]FileResponse, 0, len(files)), PageInfo: page}
+		for i := range files {
+			resp.Items = append(resp.Items, buildFileResponse(&files[i]))
+		}
+		if err := fillFileTags(db, resp.Items); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// parseSearchTime 解析 RFC 3339 时间或 YYYY-MM-DD 日期，dateOnly 表示输入只包含日期。
+func parseSearchTime(v string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, false, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, v, time.Local)
+	return t, true, err
+}
+
+// isIndexableText 判断文件是否需要建立全文索引：文本类型且不超过 maxIndexedTextBytes。
+func isIndexableText(f *models.File) bool {
+	if f.Size > maxIndexedTextBytes {
+		return false
+	}
+	return isTextMime(f.MimeType)
+}
+
+// isTextMime 判断 MIME 类型是否为可直接按 UTF-8 文本处理的内容。
+func isTextMime(mimeType string) bool {
+	mime := strings.ToLower(mimeType)
+	return strings.HasPrefix(mime, "text/") || mime == "application/json" || mime == "application/xml"
+}
+
+// indexFileText 读取文本文件内容写入全文索引，内容不再是文本（如上传了新的二进制版本）时移除旧索引。
+// 索引失败不影响上传结果，仅记录日志。
+func indexFileText(ctx context.Context, db *gorm.DB, store storage.Driver, f *models.File) {
+	if !isIndexableText(f) {
+		if err := db.Where("file_id = ?", f.ID).Delete(&models.FileText{}).Error; err != nil {
+			log.Printf("remove text index for file %d: %v", f.ID, err)
+		}
+		return
+	}
+	rc, err := store.Get(ctx, f.Path)
+	if err != nil {
+		log.Printf("index file %d: %v", f.ID, err)
+		return
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxIndexedTextBytes))
+	if err != nil {
+		log.Printf("index file %d: %v", f.ID, err)
+		return
+	}
+	if !utf8.Valid(data) {
+		return
+	}
+	if err := db.Save(&models.FileText{FileID: f.ID, Content: string(data)}).Error; err != nil {
+		log.Printf("index file %d: %v", f.ID, err)
+	}
+}
+
+// IndexMissingFileTexts 为尚未建立索引的文本文件补建全文索引，用于升级后处理历史数据，返回处理的文件数。
+func IndexMissingFileTexts(ctx context.Context, db *gorm.DB, store storage.Driver) (int, error) {
+	var files []models.File
+	err := db.Where("size <= ?", maxIndexedTextBytes).
+		Where("mime_type LIKE 'text/%' OR mime_type IN ?", []string{"application/json", "application/xml"}).
+		Where("id NOT IN (?)", db.Model(&models.FileText{}).Select("file_id")).
+		Find(&files).Error
+	if err != nil {
+		return 0, err
+	}
+	for i := range files {
+		indexFileText(ctx, db, store, &files[i])
+	}
+	return len(files), nil
+}
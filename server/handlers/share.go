@@ -5,14 +5,14 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
-	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"content-hub/server/config"
 	"content-hub/server/middleware"
 	"content-hub/server/models"
+	"content-hub/server/storage"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -22,20 +22,24 @@ import (
 
 const maxShareViews uint = 1000
 
-var shareDurations = map[int]time.Duration{
-	1:  24 * time.Hour,
-	7:  7 * 24 * time.Hour,
-	30: 30 * 24 * time.Hour,
-}
-
 type shareRequest struct {
-	RequireLogin  *bool  `json:"require_login"`
+	RequireLogin *bool `json:"require_login"`
+	// AllowUsername 为早期版本的单一接收人，会并入 AllowUsernames
 	AllowUsername string `json:"allow_username"`
-	MaxViews      *uint  `json:"max_views"`
-	ExpiresInDays *int   `json:"expires_in_days"`
+	// AllowUsernames / AllowGroups 限定可访问的用户与用户组，任一非空时分享强制要求登录
+	AllowUsernames []string `json:"allow_usernames"`
+	AllowGroups    []string `json:"allow_groups"`
+	MaxViews       *uint    `json:"max_views"`
+	shareExpiryInput
+	// Password 为可选的访问密码，供没有账号的外部接收者使用，访问前需先调用 unlock 换取访问令牌
+	Password string `json:"password"`
+	// BurnAfterReading 为阅后即焚，max_views 固定为 1
+	BurnAfterReading bool `json:"burn_after_reading"`
+	shareAliasInput
 }
 
-// CreateShare 生成带安全策略的预览链接，默认要求登录且 7 天内有效。
+// CreateShare 生成带安全策略的预览链接，默认要求登录且 7 天内有效。文件所有者可将分享限定给指定用户与用户组。
+// 有效期可用天数、时长或 RFC 3339 截止时间指定，受 SHARE_MAX_LIFETIME 限制；永不过期需 SHARE_NEVER_EXPIRE 策略允许。
 // @Summary 创建分享链接
 // @Tags shares
 // @Accept json
@@ -52,10 +56,7 @@ func CreateShare(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
-		userIDVal, _ := c.Get("userID")
-		roleVal, _ := c.Get("role")
-		userID, _ := userIDVal.(uint)
-		role, _ := roleVal.(string)
+		userID, role := currentUser(c)
 		if role != models.RoleAdmin && f.OwnerID != userID {
 			c.JSON(http.StatusForbidden, gin.H{"error": "no permission to share this file"})
 			return
@@ -67,75 +68,124 @@ func CreateShare(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
-		requireLogin := true
-		if req.RequireLogin != nil {
-			requireLogin = *req.RequireLogin
+		share, ok := newShareFromRequest(c, db, cfg, &req)
+		if !ok {
+			return
 		}
-
-		var allowUserID *uint
-		allowUser := strings.TrimSpace(req.AllowUsername)
-		if allowUser != "" {
-			if role != models.RoleAdmin {
-				c.JSON(http.StatusForbidden, gin.H{"error": "only admin can limit receiver"})
-				return
-			}
-			var u models.User
-			if err := db.Where("username = ?", allowUser).First(&u).Error; err != nil {
-				status := http.StatusBadRequest
-				if errors.Is(err, gorm.ErrRecordNotFound) {
-					status = http.StatusNotFound
-				}
-				c.JSON(status, gin.H{"error": "指定的接收用户不存在"})
-				return
-			}
-			allowUserID = &u.ID
-			// 限定到具体用户时必须登录，否则无法识别身份
-			requireLogin = true
+		share.FileID = f.ID
+		if err := db.Omit("Recipients.User", "Recipients.Group").Create(share).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
 		}
+		c.JSON(http.StatusOK, createdShareResponse(share))
+	}
+}
 
-		var maxViews *uint
-		if req.MaxViews != nil {
-			if *req.MaxViews == 0 || *req.MaxViews > maxShareViews {
-				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("max_views 需为 1-%d", maxShareViews)})
-				return
-			}
-			max := *req.MaxViews
-			maxViews = &max
-		}
+// newShareFromRequest 按请求校验并生成分享的访问策略（登录、接收人、次数、有效期与密码）与短链接，创建普通分享与打包分享共用。
+// 返回的分享尚未保存且未设置文件，失败时已写入响应。
+func newShareFromRequest(c *gin.Context, db *gorm.DB, cfg *config.Config, req *shareRequest) (*models.Share, bool) {
+	userID, role := currentUser(c)
+	requireLogin := true
+	if req.RequireLogin != nil {
+		requireLogin = *req.RequireLogin
+	}
 
-		expiresAt := computeExpiresAt(req.ExpiresInDays)
-		if expiresAt == nil && req.ExpiresInDays != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "expires_in_days 仅支持 1 / 7 / 30"})
-			return
-		}
+	usernames := append([]string{req.AllowUsername}, req.AllowUsernames...)
+	recipients, err := resolveShareRecipients(db, usernames, req.AllowGroups)
+	if err != nil {
+		writeRecipientError(c, err)
+		return nil, false
+	}
+	if len(recipients) > 0 {
+		// 限定接收人时必须登录，否则无法识别身份
+		requireLogin = true
+	}
 
-		share := models.Share{
-			Token:        uuid.NewString(),
-			FileID:       f.ID,
-			CreatorID:    userID,
-			RequireLogin: requireLogin,
-			AllowUserID:  allowUserID,
-			MaxViews:     maxViews,
-			ExpiresAt:    expiresAt,
+	var maxViews *uint
+	if req.MaxViews != nil {
+		if err := validateMaxViews(*req.MaxViews); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		max := *req.MaxViews
+		maxViews = &max
+	}
+	if req.BurnAfterReading {
+		if maxViews != nil && *maxViews != 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errBurnMaxViews.Error()})
+			return nil, false
 		}
+		one := uint(1)
+		maxViews = &one
+	}
 
-		if err := db.Create(&share).Error; err != nil {
+	now := time.Now()
+	expiresAt, set, err := req.resolveExpiry(cfg, role == models.RoleAdmin, now)
+	if err != nil {
+		writeExpiryError(c, err)
+		return nil, false
+	}
+	if !set {
+		expiresAt = defaultExpiry(cfg, now)
+	}
+	notBefore, _, err := req.resolveNotBefore()
+	if err == nil {
+		err = validateShareWindow(notBefore, expiresAt)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	share := &models.Share{
+		Token:        uuid.NewString(),
+		CreatorID:    userID,
+		RequireLogin: requireLogin,
+		MaxViews:     maxViews,
+		ExpiresAt:    expiresAt,
+		NotBefore:    notBefore,
+
+		RestrictRecipients: len(recipients) > 0,
+		Recipients:         recipients,
+		BurnAfterReading:   req.BurnAfterReading,
+	}
+	if req.Password != "" {
+		if err := validateSharePassword(req.Password); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		if err := share.SetPassword(req.Password); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+			return nil, false
 		}
+	}
+	if !applyShareAliases(c, db, share, &req.shareAliasInput) {
+		return nil, false
+	}
+	return share, true
+}
 
-		c.JSON(http.StatusOK, gin.H{
-			"share_token":    share.Token,
-			"preview_path":   fmt.Sprintf("/preview/%s", share.Token),
-			"requires_login": share.RequireLogin,
-			"allow_username": allowUser,
-			"max_views":      maxViews,
-			"expires_at":     expiresAt,
-		})
+func createdShareResponse(share *models.Share) gin.H {
+	allowUsers, allowGroups := shareRecipientNames(share)
+	return gin.H{
+		"share_token":        share.Token,
+		"preview_path":       fmt.Sprintf("/preview/%s", share.Token),
+		"requires_login":     share.RequireLogin,
+		"requires_password":  share.HasPassword(),
+		"allow_usernames":    allowUsers,
+		"allow_groups":       allowGroups,
+		"max_views":          share.MaxViews,
+		"expires_at":         share.ExpiresAt,
+		"not_before":         share.NotBefore,
+		"burn_after_reading": share.BurnAfterReading,
+		"short_code":         share.ShortCode,
+		"slug":               share.Slug,
+		"short_path":         shareShortPath(share),
 	}
 }
 
-// GetShareMeta 返回预览所需的文件元信息，同时做访问权限判断但不增加计数。
+// GetShareMeta 返回预览所需的文件元信息，同时做访问权限判断但不增加计数。打包分享额外返回成员列表 items 与 ZIP 下载地址，
+// 文本分享额外返回语言与 raw / highlight 地址。通过短码或自定义链接访问时，token 与各地址都使用该别名，不暴露 UUID。
 // @Summary 获取分享元信息
 // @Tags shares
 // @Produce json
@@ -144,7 +194,8 @@ func CreateShare(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
 // @Router /shares/{token} [get]
 func GetShareMeta(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		share, err := loadShare(db, c.Param("token"))
+		ref := c.Param("token")
+		share, err := loadShare(db, ref)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
 			return
@@ -156,55 +207,83 @@ func GetShareMeta(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
-		if !checkShareAccess(c, share, claims, true) {
+		if !checkShareAccess(c, db, cfg, share, claims, true) {
 			return
 		}
 
+		allowUsers, allowGroups := shareRecipientNames(share)
 		remaining := remainingViews(share)
-		c.JSON(http.StatusOK, gin.H{
-			"token":             share.Token,
-			"filename":          share.File.Filename,
-			"mime_type":         share.File.MimeType,
-			"size":              share.File.Size,
-			"description":       share.File.Description,
-			"owner":             share.File.Owner.Username,
-			"requires_login":    share.RequireLogin,
-			"allow_username":    optionalUsername(share.AllowUser),
-			"max_views":         share.MaxViews,
-			"remaining_views":   remaining,
-			"expires_at":        share.ExpiresAt,
-			"created_at":        share.CreatedAt,
-			"stream_path":       fmt.Sprintf("/api/shares/%s/stream", share.Token),
-			"preview_available": true,
-		})
+		resp := gin.H{
+			"token":              ref,
+			"filename":           share.File.Filename,
+			"mime_type":          share.File.MimeType,
+			"size":               share.File.Size,
+			"digest":             share.File.Digest,
+			"description":        share.File.Description,
+			"owner":              share.File.Owner.Username,
+			"requires_login":     share.RequireLogin,
+			"requires_password":  share.HasPassword(),
+			"allow_username":     optionalUsername(share.AllowUser),
+			"allow_usernames":    allowUsers,
+			"allow_groups":       allowGroups,
+			"max_views":          share.MaxViews,
+			"remaining_views":    remaining,
+			"expires_at":         share.ExpiresAt,
+			"not_before":         share.NotBefore,
+			"created_at":         share.CreatedAt,
+			"stream_path":        fmt.Sprintf("/api/shares/%s/stream", ref),
+			"thumbnail_path":     fmt.Sprintf("/api/shares/%s/thumbnail", ref),
+			"scan_status":        share.File.ScanStatus,
+			"preview_available":  share.File.ScanStatus == models.ScanClean,
+			"bundle":             false,
+			"burn_after_reading": share.BurnAfterReading,
+		}
+		if isTextMime(share.File.MimeType) {
+			resp["language"] = shareLanguage(&share.File)
+			resp["raw_path"] = fmt.Sprintf("/api/shares/%s/raw", ref)
+			resp["highlight_path"] = fmt.Sprintf("/api/shares/%s/highlight", ref)
+		}
+		if share.Bundle {
+			for k, v := range bundleMeta(share, ref) {
+				resp[k] = v
+			}
+		}
+		c.JSON(http.StatusOK, resp)
 	}
 }
 
 // StreamShare 执行安全校验后以内联方式返回文件内容，不强制下载。
+// 计数规则：完整请求或从 0 开始的 Range 请求计 1 次；同一客户端在计数后 30 分钟内的续读（Range 起点大于 0）不计数，
+// 且额度用尽后仍可继续拖动；HEAD 与返回 304 的条件请求不计数。
 // @Summary 预览/下载分享内容
+// @Description 支持 Range（含多段）、If-None-Match / If-Modified-Since 与 HEAD。完整请求或从 0 开始的 Range 计 1 次浏览；同一客户端计数后 30 分钟内起点大于 0 的续读不计数；HEAD 与 304 不计数。
 // @Tags shares
 // @Produce octet-stream
 // @Param token path string true "分享 Token"
 // @Param download query bool false "是否以附件形式下载，true 时为 attachment"
+// @Param Range header string false "字节范围，例如 bytes=0-1023"
 // @Security BearerAuth
 // @Router /shares/{token}/stream [get]
-func StreamShare(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
-	return streamShareWithDisposition(db, cfg, false)
+func StreamShare(db *gorm.DB, cfg *config.Config, store storage.Driver) gin.HandlerFunc {
+	return streamShareWithDisposition(db, cfg, store, false)
 }
 
 // DownloadShare 在与预览相同的权限与计数规则下，以附件形式返回内容，避免分享页面无限下载绕过浏览次数。
 // @Summary 下载分享内容（计入浏览/下载次数）
+// @Description 计数与 Range/条件请求规则同 /shares/{token}/stream，可用于断点续传。
 // @Tags shares
 // @Produce octet-stream
 // @Param token path string true "分享 Token"
+// @Param Range header string false "字节范围，例如 bytes=0-1023"
 // @Security BearerAuth
 // @Router /shares/{token}/download [get]
-func DownloadShare(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
-	return streamShareWithDisposition(db, cfg, true)
+func DownloadShare(db *gorm.DB, cfg *config.Config, store storage.Driver) gin.HandlerFunc {
+	return streamShareWithDisposition(db, cfg, store, true)
 }
 
 // streamShareWithDisposition 复用核心校验逻辑，根据 download 标志切换 Content-Disposition，确保预览与下载都计入次数。
-func streamShareWithDisposition(db *gorm.DB, cfg *config.Config, download bool) gin.HandlerFunc {
+// 可执行类型的文件即使请求预览也以附件返回，见 setContentHeaders。每次请求（含被拒绝的）都会写入访问记录。
+func streamShareWithDisposition(db *gorm.DB, cfg *config.Config, store storage.Driver, download bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		share, err := loadShare(db, c.Param("token"))
 		if err != nil {
@@ -212,31 +291,90 @@ func streamShareWithDisposition(db *gorm.DB, cfg *config.Config, download bool)
 			return
 		}
 
+		disposition := shareDisposition(c, download)
 		claims, err := parseOptionalClaims(c, cfg)
+		// 打包分享的 ZIP 不属于任何单个成员，按分享整体记录，不计入成员文件的统计
+		fileID := share.FileID
+		if share.Bundle {
+			fileID = 0
+		}
+		defer recordShareAccess(db, c, share, fileID, claims, disposition)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			return
 		}
 
-		if !checkShareAccess(c, share, claims, true) {
-			return
-		}
-
-		// 确认底层文件仍然存在，避免已删除文件导致 open 抛出系统错误
-		if info, err := os.Stat(share.File.Path); err != nil {
-			status := http.StatusInternalServerError
-			msg := err.Error()
-			if errors.Is(err, os.ErrNotExist) {
-				status = http.StatusNotFound
-				msg = "shared file not found or already deleted"
+		if share.Bundle {
+			// 打包分享整体只能下载 ZIP，单个文件通过 /items/{item} 预览
+			if disposition != "attachment" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "打包分享请下载压缩包或预览其中的单个文件"})
+				return
 			}
-			c.JSON(status, gin.H{"error": msg})
-			return
-		} else if info.IsDir() {
-			c.JSON(http.StatusNotFound, gin.H{"error": "shared file path is a directory"})
+			serveShareBundle(c, db, cfg, store, share, claims)
 			return
 		}
+		serveShareFile(c, db, cfg, store, share, claims, &share.File, disposition)
+	}
+}
+
+// shareDisposition 在 download 为 true 或 query 参数 download=true/1 时返回 attachment，否则为 inline。
+func shareDisposition(c *gin.Context, download bool) string {
+	if download || c.Query("download") == "1" || strings.EqualFold(c.Query("download"), "true") {
+		return "attachment"
+	}
+	return "inline"
+}
+
+// serveShareFile 校验分享与文件 f 的访问条件后输出内容并按规则计数，单文件分享与打包分享的成员预览共用。
+func serveShareFile(c *gin.Context, db *gorm.DB, cfg *config.Config, store storage.Driver, share *models.Share, claims *middleware.Claims, f *models.File, disposition string) {
+	if share.BurnAfterReading {
+		// 阅后即焚只能查看一次，忽略 Range 始终返回完整内容，避免分段下载只取到部分内容分享就已焚毁
+		c.Request.Header.Del("Range")
+		c.Request.Header.Del("If-Range")
+	}
+	resume, ok := rangeResumes(c.GetHeader("Range"))
+	if !ok {
+		c.JSON(http.StatusRequestedRangeNotSatisfiable, gin.H{"error": "invalid range"})
+		return
+	}
+	// 续读请求（视频拖动、断点续传）仅在携带计数时下发的续读凭证时免计数，此时即使额度已用尽也放行；阅后即焚的分享没有续读窗口
+	continuation := resume && !share.BurnAfterReading && shareResumeActive(c, cfg, share)
+
+	if !checkShareAccess(c, db, cfg, share, claims, !continuation) {
+		return
+	}
+	// 未通过扫描的文件不计入访问次数
+	if !checkScanStatus(c, f) {
+		return
+	}
+
+	// 确认底层文件仍然存在，避免已删除文件导致读取时抛出系统错误
+	info, err := store.Stat(c.Request.Context(), f.Path)
+	if err != nil {
+		status := http.StatusInternalServerError
+		msg := err.Error()
+		if errors.Is(err, storage.ErrNotExist) {
+			status = http.StatusNotFound
+			msg = "shared file not found or already deleted"
+		}
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+
+	// 起点超出文件末尾的 Range 无法满足，直接返回 416，不消耗浏览次数；If-Range 不匹配时 Range 会被忽略
+	etag := contentETag(f, info)
+	if ifRange := c.GetHeader("If-Range"); (ifRange == "" || ifRange == etag) && !rangeSatisfiable(c.GetHeader("Range"), info.Size) {
+		c.Header("Content-Range", fmt.Sprintf("bytes */%d", info.Size))
+		c.JSON(http.StatusRequestedRangeNotSatisfiable, gin.H{"error": "range not satisfiable"})
+		return
+	}
 
+	// HEAD 与命中缓存的条件请求（304）不传输内容，不计入次数
+	countable := c.Request.Method == http.MethodGet &&
+		!continuation &&
+		!requestNotModified(c.Request, etag, contentModTime(f, info))
+
+	if countable {
 		// 控制访问次数：带上上限的情况下需要在返回前占用 1 次额度，避免并发下超过限制
 		if err := consumeView(db, share); err != nil {
 			status := http.StatusInternalServerError
@@ -246,30 +384,10 @@ func streamShareWithDisposition(db *gorm.DB, cfg *config.Config, download bool)
 			c.JSON(status, gin.H{"error": err.Error()})
 			return
 		}
-
-		file, err := os.Open(share.File.Path)
-		if err != nil {
-			// 再次防御：即使通过 Stat 仍可能在窗口期被删除
-			status := http.StatusInternalServerError
-			if errors.Is(err, os.ErrNotExist) {
-				status = http.StatusNotFound
-			}
-			c.JSON(status, gin.H{"error": err.Error()})
-			return
-		}
-		defer file.Close()
-
-		disposition := "inline"
-		// 当 download 为 true 或 query 参数 download=true/1 时，以附件形式下载
-		if download || c.Query("download") == "1" || strings.EqualFold(c.Query("download"), "true") {
-			disposition = "attachment"
-		}
-
-		c.Header("Content-Type", share.File.MimeType)
-		c.Header("Content-Disposition", fmt.Sprintf("%s; filename=\"%s\"", disposition, url.PathEscape(share.File.Filename)))
-		c.Status(http.StatusOK)
-		_, _ = io.Copy(c.Writer, file)
+		grantShareResume(c, cfg, share, time.Now())
 	}
+
+	writeStoredContent(c, store, f, info, disposition)
 }
 
 // CleanShares 支持管理员一键清理失效分享，避免访问落到过期或缺失文件的链接。
@@ -279,7 +397,12 @@ func streamShareWithDisposition(db *gorm.DB, cfg *config.Config, download bool)
 // @Produce json
 // @Security BearerAuth
 // @Router /admin/shares/cleanup [post]
-func CleanShares(db *gorm.DB) gin.HandlerFunc {
+func CleanShares(db *gorm.DB, store storage.Driver) gin.HandlerFunc {
+	return cleanShares(db, store, false)
+}
+
+// cleanShares 按条件清理分享；mine 为 true 时普通用户仅清理自己可管理的分享，管理员不受限制。
+func cleanShares(db *gorm.DB, store storage.Driver, mine bool) gin.HandlerFunc {
 	type cleanRequest struct {
 		RemoveExpired     bool `json:"remove_expired"`
 		RemoveMissingFile bool `json:"remove_missing_file"`
@@ -304,8 +427,12 @@ func CleanShares(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		query := db.Preload("File")
+		if mine {
+			query = scopeManagedShares(c, db, query)
+		}
 		var shares []models.Share
-		if err := db.Preload("File").Find(&shares).Error; err != nil {
+		if err := query.Find(&shares).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -331,7 +458,7 @@ func CleanShares(db *gorm.DB) gin.HandlerFunc {
 				if s.File.Path == "" {
 					result.MissingFileCount++
 					marked = true
-				} else if info, err := os.Stat(s.File.Path); err != nil || info.IsDir() {
+				} else if _, err := store.Stat(c.Request.Context(), s.File.Path); err != nil {
 					result.MissingFileCount++
 					marked = true
 				}
@@ -364,59 +491,172 @@ func LegacyShareRedirect() gin.HandlerFunc {
 
 type shareListItem struct {
 	Token          string     `json:"token"`
+	ShortCode      string     `json:"short_code,omitempty"`
+	Slug           string     `json:"slug,omitempty"`
 	Filename       string     `json:"filename"`
+	Bundle         bool       `json:"bundle"`
+	ItemCount      int        `json:"item_count,omitempty"`
 	FileOwner      string     `json:"file_owner"`
 	Creator        string     `json:"creator"`
 	RequireLogin   bool       `json:"require_login"`
+	HasPassword    bool       `json:"has_password"`
 	AllowUsername  string     `json:"allow_username"`
+	AllowUsernames []string   `json:"allow_usernames"`
+	AllowGroups    []string   `json:"allow_groups"`
 	MaxViews       *uint      `json:"max_views"`
 	ViewCount      uint       `json:"view_count"`
 	RemainingViews *uint      `json:"remaining_views"`
 	ExpiresAt      *time.Time `json:"expires_at"`
+	NotBefore      *time.Time `json:"not_before"`
 	CreatedAt      time.Time  `json:"created_at"`
+	// BurnAfterReading 为阅后即焚的分享
+	BurnAfterReading bool `json:"burn_after_reading"`
+}
+
+// ShareListResponse 是分享列表的分页结果。
+type ShareListResponse struct {
+	Items []shareListItem `json:"items"`
+	PageInfo
+}
+
+// shareListSpec 定义分享列表允许的排序字段。
+var shareListSpec = listSpec{
+	Table:   "shares",
+	Fields:  map[string]sortKind{"created_at": sortTime, "view_count": sortInt},
+	Default: "created_at",
 }
 
 // ListShares 仅管理员可见，用于后台查看与治理分享链接。
 // @Summary 分享列表（管理员）
 // @Tags admin
 // @Produce json
+// @Param limit query int false "每页数量，默认 50，最大 200"
+// @Param cursor query string false "上一页返回的 next_cursor"
+// @Param sort query string false "排序字段：created_at / view_count"
+// @Param order query string false "asc 或 desc，默认 desc"
+// @Param include_total query bool false "是否返回过滤后的总数"
+// @Param creator query string false "创建者用户名"
+// @Param file_id query int false "文件ID"
+// @Param status query string false "active / pending / expired / exhausted"
+// @Success 200 {object} ShareListResponse
 // @Security BearerAuth
 // @Router /admin/shares [get]
 func ListShares(db *gorm.DB) gin.HandlerFunc {
+	return listShares(db, false)
+}
+
+// listShares 返回分页的分享列表；mine 为 true 时普通用户仅能看到自己创建或针对自己文件的分享，管理员仍可见全部。
+func listShares(db *gorm.DB, mine bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		lq, ok := parseListQuery(c, shareListSpec)
+		if !ok {
+			return
+		}
+		query := db.Model(&models.Share{})
+		if mine {
+			query = scopeManagedShares(c, db, query)
+		}
+		if v := strings.TrimSpace(c.Query("creator")); v != "" {
+			query = query.Where("shares.creator_id IN (?)", db.Model(&models.User{}).Select("id").Where("username = ?", v))
+		}
+		if v := c.Query("file_id"); v != "" {
+			fileID, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "file_id 无效"})
+				return
+			}
+			// 打包分享只要包含该文件即命中
+			query = query.Where("shares.file_id = ? OR shares.id IN (?)", fileID,
+				db.Model(&models.ShareItem{}).Select("share_id").Where("file_id = ?", fileID))
+		}
+		now := time.Now()
+		switch c.Query("status") {
+		case "":
+		case "active":
+			query = query.Where("(shares.expires_at IS NULL OR shares.expires_at > ?) AND (shares.not_before IS NULL OR shares.not_before <= ?) AND (shares.max_views IS NULL OR shares.view_count < shares.max_views)", now, now)
+		case "pending":
+			query = query.Where("shares.not_before > ?", now)
+		case "expired":
+			query = query.Where("shares.expires_at IS NOT NULL AND shares.expires_at <= ?", now)
+		case "exhausted":
+			query = query.Where("shares.max_views IS NOT NULL AND shares.view_count >= shares.max_views")
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status 仅支持 active / pending / expired / exhausted"})
+			return
+		}
+
+		total, err := lq.total(query)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		query, err = lq.apply(query)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cursor 无效"})
+			return
+		}
 		var shares []models.Share
-		if err := db.
+		if err := query.
 			Preload("File").
 			Preload("File.Owner").
 			Preload("Creator").
 			Preload("AllowUser").
-			Order("created_at desc").
+			Preload("Recipients.User").
+			Preload("Recipients.Group").
+			Preload("Items").
 			Find(&shares).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		shares, page := paginate(lq, shares, func(s *models.Share, sort string) (any, uint) {
+			if sort == "view_count" {
+				return s.ViewCount, s.ID
+			}
+			return s.CreatedAt, s.ID
+		})
+		page.Total = total
 
-		resp := make([]shareListItem, 0, len(shares))
-		for _, s := range shares {
-			resp = append(resp, shareListItem{
-				Token:          s.Token,
-				Filename:       s.File.Filename,
-				FileOwner:      s.File.Owner.Username,
-				Creator:        s.Creator.Username,
-				RequireLogin:   s.RequireLogin,
-				AllowUsername:  optionalUsername(s.AllowUser),
-				MaxViews:       s.MaxViews,
-				ViewCount:      s.ViewCount,
-				RemainingViews: remainingViews(&s),
-				ExpiresAt:      s.ExpiresAt,
-				CreatedAt:      s.CreatedAt,
-			})
+		resp := ShareListResponse{Items: make([]shareListItem, 0, len(shares)), PageInfo: page}
+		for i := range shares {
+			resp.Items = append(resp.Items, buildShareListItem(&shares[i]))
 		}
 		c.JSON(http.StatusOK, resp)
 	}
 }
 
-// RevokeShare 允许管理员撤销分享，立即失效。
+// buildShareListItem 需已预加载 File.Owner、Creator、AllowUser、Recipients 与 Items；打包分享以压缩包名作为文件名。
+func buildShareListItem(s *models.Share) shareListItem {
+	allowUsers, allowGroups := shareRecipientNames(s)
+	filename := s.File.Filename
+	if s.Bundle {
+		filename = s.Name + ".zip"
+	}
+	return shareListItem{
+		Token:          s.Token,
+		ShortCode:      optionalString(s.ShortCode),
+		Slug:           optionalString(s.Slug),
+		Filename:       filename,
+		Bundle:         s.Bundle,
+		ItemCount:      len(s.Items),
+		FileOwner:      s.File.Owner.Username,
+		Creator:        s.Creator.Username,
+		RequireLogin:   s.RequireLogin,
+		HasPassword:    s.HasPassword(),
+		AllowUsername:  optionalUsername(s.AllowUser),
+		AllowUsernames: allowUsers,
+		AllowGroups:    allowGroups,
+		MaxViews:       s.MaxViews,
+		ViewCount:      s.ViewCount,
+		RemainingViews: remainingViews(s),
+		ExpiresAt:      s.ExpiresAt,
+		NotBefore:      s.NotBefore,
+		CreatedAt:      s.CreatedAt,
+
+		BurnAfterReading: s.BurnAfterReading,
+	}
+}
+
+// RevokeShare 允许管理员按 token、短码或自定义链接撤销分享，立即失效；未找到时返回 404。
 // @Summary 撤销分享
 // @Tags admin
 // @Produce json
@@ -430,36 +670,47 @@ func RevokeShare(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "missing share token"})
 			return
 		}
-		if err := db.Where("token = ?", token).Delete(&models.Share{}).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		res := whereShareRef(db, token).Delete(&models.Share{})
+		if res.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
+			return
+		}
+		if res.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
 			return
 		}
 		c.JSON(http.StatusOK, gin.H{"message": "share revoked"})
 	}
 }
 
-func computeExpiresAt(days *int) *time.Time {
-	// 默认为 7 天；若传入 nil 则使用默认值，传入不受支持的值返回 nil
-	if days == nil {
-		def := 7
-		return computeExpiresAt(&def)
+// validateMaxViews 校验分享的浏览次数上限，创建与修改分享共用。
+func validateMaxViews(v uint) error {
+	if v == 0 || v > maxShareViews {
+		return fmt.Errorf("max_views 需为 1-%d", maxShareViews)
 	}
-	dur, ok := shareDurations[*days]
-	if !ok {
-		return nil
-	}
-	deadline := time.Now().Add(dur)
-	return &deadline
+	return nil
 }
 
+// loadShare 按 token、短码（区分大小写）或自定义链接（不区分大小写）读取分享，三者互不重复。
 func loadShare(db *gorm.DB, token string) (*models.Share, error) {
+	if token == "" {
+		return nil, gorm.ErrRecordNotFound
+	}
 	var share models.Share
-	if err := db.Preload("File").Preload("File.Owner").Preload("AllowUser").Where("token = ?", token).First(&share).Error; err != nil {
+	query := db.Preload("File").Preload("File.Owner").Preload("Creator").Preload("AllowUser").
+		Preload("Recipients.User").Preload("Recipients.Group").
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).Preload("Items.File")
+	if err := whereShareRef(query, token).First(&share).Error; err != nil {
 		return nil, err
 	}
 	return &share, nil
 }
 
+// whereShareRef 按路径中的分享引用筛选，撤销分享、访问统计等接口与 loadShare 使用同一套解析规则。
+func whereShareRef(db *gorm.DB, ref string) *gorm.DB {
+	return db.Where("token = ? OR short_code = ? OR slug = ?", ref, ref, strings.ToLower(ref))
+}
+
 func optionalUsername(u *models.User) string {
 	if u == nil {
 		return ""
@@ -479,30 +730,51 @@ func remainingViews(share *models.Share) *uint {
 	return &remain
 }
 
-func checkShareAccess(c *gin.Context, share *models.Share, claims *middleware.Claims, requireLimitCheck bool) bool {
+// checkShareAccess 依次校验有效期、次数、登录、接收范围与密码。失败时已写入响应。
+func checkShareAccess(c *gin.Context, db *gorm.DB, cfg *config.Config, share *models.Share, claims *middleware.Claims, requireLimitCheck bool) bool {
 	now := time.Now()
 	if share.Expired(now) {
 		c.JSON(http.StatusGone, gin.H{"error": "分享已过期"})
 		return false
 	}
+	if share.Pending(now) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "分享尚未生效", "not_before": share.NotBefore})
+		return false
+	}
 	if share.MaxViews != nil && share.ViewCount >= *share.MaxViews && requireLimitCheck {
-		c.JSON(http.StatusGone, gin.H{"error": "查看次数已用尽"})
+		msg := "查看次数已用尽"
+		if share.BurnAfterReading {
+			msg = "阅后即焚的分享已被查看"
+		}
+		c.JSON(http.StatusGone, gin.H{"error": msg, "burned": share.BurnAfterReading})
 		return false
 	}
 	if share.RequireLogin && claims == nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "该分享需要登录"})
 		return false
 	}
-	if share.AllowUserID != nil {
+	if share.Restricted() {
 		if claims == nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "该分享仅限指定用户访问"})
 			return false
 		}
-		if claims.UserID != *share.AllowUserID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "无权访问该分享"})
-			return false
+		// 创建者始终可以查看自己创建的受限分享
+		if claims.UserID != share.CreatorID {
+			allowed, err := share.AllowsUser(db, claims.UserID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return false
+			}
+			if !allowed {
+				c.JSON(http.StatusForbidden, gin.H{"error": "无权访问该分享"})
+				return false
+			}
 		}
 	}
+	if !shareUnlocked(c, cfg, share, claims) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": errSharePasswordRequired.Error(), "password_required": true})
+		return false
+	}
 	return true
 }
 
@@ -529,7 +801,61 @@ func parseOptionalClaims(c *gin.Context, cfg *config.Config) (*middleware.Claims
 	return claims, nil
 }
 
-var errShareLimitReached = errors.New("查看次数已用尽")
+var (
+	errShareLimitReached = errors.New("查看次数已用尽")
+	errBurnMaxViews      = errors.New("阅后即焚的分享只能查看 1 次")
+)
+
+// shareRangeWindow 是一次计数访问之后允许同一客户端免计数续读（Range 偏移大于 0）的时长。
+const shareRangeWindow = 30 * time.Minute
+
+// shareResumeClaims 是计数访问时随响应下发的续读凭证，只有收到该响应的客户端才能在窗口内免计数续读；
+// 同一 NAT 或代理后的其他访问者没有凭证，修改密码后凭证随之失效。
+type shareResumeClaims struct {
+	ShareID     uint   `json:"sid"`
+	PasswordTag string `json:"pwt"`
+	jwt.RegisteredClaims
+}
+
+func shareResumeCookie(share *models.Share) string {
+	return fmt.Sprintf("share_resume_%d", share.ID)
+}
+
+// shareResumeKey 与登录及分享访问令牌使用不同的签名密钥，凭证不能互相冒用。
+func shareResumeKey(cfg *config.Config) []byte {
+	return []byte("share-resume|" + cfg.JWTSecret)
+}
+
+// grantShareResume 在计数后以 HttpOnly Cookie 下发续读凭证，仅随 /api/shares 下的请求发送。签发失败时只是失去免计数续读。
+func grantShareResume(c *gin.Context, cfg *config.Config, share *models.Share, now time.Time) {
+	claims := shareResumeClaims{
+		ShareID:     share.ID,
+		PasswordTag: sharePasswordTag(share),
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(shareRangeWindow)),
+		},
+	}
+	value, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(shareResumeKey(cfg))
+	if err != nil {
+		return
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(shareResumeCookie(share), value, int(shareRangeWindow.Seconds()), "/api/shares/", "", c.Request.TLS != nil, true)
+}
+
+// shareResumeActive 判断请求是否携带该分享有效的续读凭证。
+func shareResumeActive(c *gin.Context, cfg *config.Config, share *models.Share) bool {
+	raw, err := c.Cookie(shareResumeCookie(share))
+	if err != nil || raw == "" {
+		return false
+	}
+	var claims shareResumeClaims
+	token, err := jwt.ParseWithClaims(raw, &claims, func(t *jwt.Token) (any, error) {
+		return shareResumeKey(cfg), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	return err == nil && token.Valid && claims.ShareID == share.ID && claims.PasswordTag == sharePasswordTag(share)
+}
 
 func consumeView(db *gorm.DB, share *models.Share) error {
 	if share.MaxViews != nil {
@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"content-hub/server/middleware"
+	"content-hub/server/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAnalyticsDays   = 30
+	maxAnalyticsDays       = 365
+	defaultAnalyticsRecent = 20
+	maxAnalyticsRecent     = 200
+	maxUserAgentLength     = 512
+)
+
+// ShareAccessCounts 是访问记录的汇总计数：Successful 为 2xx 响应，Denied 为 4xx/5xx，Previews / Downloads 按成功响应的 disposition 区分。
+type ShareAccessCounts struct {
+	TotalAccesses  int64 `json:"total_accesses"`
+	Successful     int64 `json:"successful"`
+	Denied         int64 `json:"denied"`
+	Previews       int64 `json:"previews"`
+	Downloads      int64 `json:"downloads"`
+	UniqueVisitors int64 `json:"unique_visitors"`
+	BytesSent      int64 `json:"bytes_sent"`
+}
+
+// ShareAnalytics 汇总分享的访问记录：计数为全部历史，Daily 为最近 days 天（按 UTC 日期），Recent 为最近的访问明细。
+type ShareAnalytics struct {
+	ShareAccessCounts
+	FirstAccessAt *time.Time           `json:"first_access_at"`
+	LastAccessAt  *time.Time           `json:"last_access_at"`
+	Daily         []dailyShareAccess   `json:"daily"`
+	Recent        []shareAccessItem    `json:"recent"`
+	Shares        []shareAccessSummary `json:"shares,omitempty"`
+}
+
+type dailyShareAccess struct {
+	Date      string `json:"date"`
+	Accesses  int64  `json:"accesses"`
+	BytesSent int64  `json:"bytes_sent"`
+}
+
+type shareAccessItem struct {
+	Token       string    `json:"token,omitempty"`
+	Username    string    `json:"username"`
+	IP          string    `json:"ip"`
+	UserAgent   string    `json:"user_agent"`
+	Disposition string    `json:"disposition"`
+	BytesSent   int64     `json:"bytes_sent"`
+	Status      int       `json:"status"`
+	AccessedAt  time.Time `json:"accessed_at"`
+}
+
+// shareAccessSummary 是文件统计中单个分享的访问量，Revoked 表示分享已被撤销。
+type shareAccessSummary struct {
+	Token     string `json:"token"`
+	Accesses  int64  `json:"accesses"`
+	BytesSent int64  `json:"bytes_sent"`
+	Revoked   bool   `json:"revoked"`
+}
+
+// recordShareAccess 在分享内容请求结束后写入访问记录，fileID 为实际访问的文件（打包分享的成员预览为该成员，整体下载 ZIP 为 0）；
+// 仅成功响应记录传输字节数。写入失败只记日志，不影响响应。
+func recordShareAccess(db *gorm.DB, c *gin.Context, share *models.Share, fileID uint, claims *middleware.Claims, disposition string) {
+	status := c.Writer.Status()
+	entry := models.ShareAccess{
+		ShareID:     share.ID,
+		FileID:      fileID,
+		IP:          c.ClientIP(),
+		UserAgent:   c.Request.UserAgent(),
+		Disposition: disposition,
+		Status:      status,
+	}
+	if len(entry.UserAgent) > maxUserAgentLength {
+		entry.UserAgent = entry.UserAgent[:maxUserAgentLength]
+	}
+	if claims != nil {
+		entry.UserID = &claims.UserID
+	}
+	if status < http.StatusMultipleChoices && c.Writer.Size() > 0 {
+		entry.BytesSent = int64(c.Writer.Size())
+	}
+	if err := db.Create(&entry).Error; err != nil {
+		log.Printf("record share access %d: %v", share.ID, err)
+	}
+}
+
+// GetShareAnalytics 返回单个分享的访问统计，分享创建者、文件所有者与管理员可见，已撤销的分享同样可查。
+// @Summary 分享访问统计
+// @Tags shares
+// @Produce json
+// @Param token path string true "分享 Token"
+// @Param days query int false "按日统计的天数，默认 30，最大 365"
+// @Param limit query int false "最近访问明细条数，默认 20，最大 200"
+// @Success 200 {object} ShareAnalytics
+// @Security BearerAuth
+// @Router /shares/{token}/analytics [get]
+func GetShareAnalytics(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var share models.Share
+		ref := c.Param("token")
+		if ref == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
+			return
+		}
+		if err := whereShareRef(db.Unscoped(), ref).First(&share).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		userID, role := currentUser(c)
+		if role != models.RoleAdmin && share.CreatorID != userID {
+			var owner uint
+			if err := db.Unscoped().Model(&models.File{}).Where("id = ?", share.FileID).Pluck("owner_id", &owner).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if owner != userID {
+				// 与不存在一样返回 404，避免泄露分享是否存在
+				c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
+				return
+			}
+		}
+		days, limit, ok := parseAnalyticsQuery(c)
+		if !ok {
+			return
+		}
+		scope := func() *gorm.DB { return db.Model(&models.ShareAccess{}).Where("share_id = ?", share.ID) }
+		resp, err := buildShareAnalytics(db, scope, days, limit, false)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// GetFileShareAnalytics 汇总文件所有分享（含已撤销）的访问统计，并按分享列出访问量，仅文件所有者与管理员可见。
+// @Summary 文件分享访问统计
+// @Tags files
+// @Produce json
+// @Param id path int true "文件ID"
+// @Param days query int false "按日统计的天数，默认 30，最大 365"
+// @Param limit query int false "最近访问明细条数，默认 20，最大 200"
+// @Success 200 {object} ShareAnalytics
+// @Security BearerAuth
+// @Router /files/{id}/share-analytics [get]
+func GetFileShareAnalytics(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f, ok := loadOwnedFile(c, db)
+		if !ok {
+			return
+		}
+		days, limit, ok := parseAnalyticsQuery(c)
+		if !ok {
+			return
+		}
+		scope := func() *gorm.DB { return db.Model(&models.ShareAccess{}).Where("file_id = ?", f.ID) }
+		resp, err := buildShareAnalytics(db, scope, days, limit, true)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+func parseAnalyticsQuery(c *gin.Context) (days, limit int, ok bool) {
+	days, limit = defaultAnalyticsDays, defaultAnalyticsRecent
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxAnalyticsDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days 需为 1-365"})
+			return 0, 0, false
+		}
+		days = n
+	}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > maxAnalyticsRecent {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit 需为 0-200"})
+			return 0, 0, false
+		}
+		limit = n
+	}
+	return days, limit, true
+}
+
+// buildShareAnalytics 按 scope 过滤的访问记录生成统计；scope 每次调用返回新的查询，perShare 为 true 时附带各分享的访问量与明细中的分享 Token。
+func buildShareAnalytics(db *gorm.DB, scope func() *gorm.DB, days, limit int, perShare bool) (*ShareAnalytics, error) {
+	resp := &ShareAnalytics{Daily: []dailyShareAccess{}, Recent: []shareAccessItem{}}
+	err := scope().Select(`COUNT(*) AS total_accesses,
+		COALESCE(SUM(CASE WHEN status < 300 THEN 1 ELSE 0 END), 0) AS successful,
+		COALESCE(SUM(CASE WHEN status >= 400 THEN 1 ELSE 0 END), 0) AS denied,
+		COALESCE(SUM(CASE WHEN status < 300 AND disposition = 'inline' THEN 1 ELSE 0 END), 0) AS previews,
+		COALESCE(SUM(CASE WHEN status < 300 AND disposition = 'attachment' THEN 1 ELSE 0 END), 0) AS downloads,
+		COUNT(DISTINCT COALESCE('u' || user_id, 'ip' || ip)) AS unique_visitors,
+		COALESCE(SUM(bytes_sent), 0) AS bytes_sent`).
+		Scan(&resp.ShareAccessCounts).Error
+	if err != nil {
+		return nil, err
+	}
+	if resp.TotalAccesses == 0 {
+		return resp, nil
+	}
+
+	var first, last models.ShareAccess
+	if err := scope().Order("id").Limit(1).Find(&first).Error; err != nil {
+		return nil, err
+	}
+	if err := scope().Order("id DESC").Limit(1).Find(&last).Error; err != nil {
+		return nil, err
+	}
+	resp.FirstAccessAt, resp.LastAccessAt = &first.CreatedAt, &last.CreatedAt
+
+	// 记录以本地时区写入，比较前将 UTC 零点换回本地时间
+	since := time.Now().UTC().AddDate(0, 0, -days+1).Truncate(24 * time.Hour).Local()
+	err = scope().Select("date(created_at) AS date, COUNT(*) AS accesses, COALESCE(SUM(bytes_sent), 0) AS bytes_sent").
+		Where("created_at >= ?", since).
+		Group("date(created_at)").Order("date").
+		Scan(&resp.Daily).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens map[uint]string
+	if perShare {
+		var summaries []struct {
+			ShareID   uint
+			Accesses  int64
+			BytesSent int64
+		}
+		err := scope().Select("share_id, COUNT(*) AS accesses, COALESCE(SUM(bytes_sent), 0) AS bytes_sent").
+			Group("share_id").Order("accesses DESC").Scan(&summaries).Error
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]uint, 0, len(summaries))
+		for _, s := range summaries {
+			ids = append(ids, s.ShareID)
+		}
+		var shares []models.Share
+		if err := db.Unscoped().Select("id", "token", "deleted_at").Where("id IN ?", ids).Find(&shares).Error; err != nil {
+			return nil, err
+		}
+		tokens = make(map[uint]string, len(shares))
+		revoked := make(map[uint]bool, len(shares))
+		for _, s := range shares {
+			tokens[s.ID] = s.Token
+			revoked[s.ID] = s.DeletedAt.Valid
+		}
+		for _, s := range summaries {
+			resp.Shares = append(resp.Shares, shareAccessSummary{
+				Token:     tokens[s.ShareID],
+				Accesses:  s.Accesses,
+				BytesSent: s.BytesSent,
+				Revoked:   revoked[s.ShareID],
+			})
+		}
+	}
+
+	if limit == 0 {
+		return resp, nil
+	}
+	var recent []models.ShareAccess
+	if err := scope().Order("id DESC").Limit(limit).Find(&recent).Error; err != nil {
+		return nil, err
+	}
+	var userIDs []uint
+	for _, a := range recent {
+		if a.UserID != nil {
+			userIDs = append(userIDs, *a.UserID)
+		}
+	}
+	usernames := map[uint]string{}
+	if len(userIDs) > 0 {
+		var users []models.User
+		if err := db.Unscoped().Select("id", "username").Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			usernames[u.ID] = u.Username
+		}
+	}
+	for _, a := range recent {
+		item := shareAccessItem{
+			Token:       tokens[a.ShareID],
+			IP:          a.IP,
+			UserAgent:   a.UserAgent,
+			Disposition: a.Disposition,
+			BytesSent:   a.BytesSent,
+			Status:      a.Status,
+			AccessedAt:  a.CreatedAt,
+		}
+		if a.UserID != nil {
+			item.Username = usernames[*a.UserID]
+		}
+		resp.Recent = append(resp.Recent, item)
+	}
+	return resp, nil
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"content-hub/server/middleware"
+	"content-hub/server/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestShareAccessAnalytics(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	cfg.JWTSecret = "secret"
+	owner := createUser(t, db, "owner", models.RoleUser)
+	viewer := createUser(t, db, "viewer", models.RoleUser)
+	other := createUser(t, db, "other", models.RoleUser)
+	f := persistTestFile(t, db, cfg, store, owner.ID, "deck.txt", "text/plain", []byte("slides"))
+	share := models.Share{Token: "stats-token", FileID: f.ID, CreatorID: owner.ID}
+	if err := db.Create(&share).Error; err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+
+	access := func(h gin.HandlerFunc, user *models.User, ua string) int {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/shares/"+share.Token+"/stream", nil)
+		c.Request.Header.Set("User-Agent", ua)
+		c.Request.RemoteAddr = "203.0.113.7:5000"
+		if user != nil {
+			token, _ := middleware.GenerateToken(user.ID, user.Role, cfg)
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+		c.Params = gin.Params{{Key: "token", Value: share.Token}}
+		h(c)
+		return w.Code
+	}
+	if code := access(StreamShare(db, cfg, store), &viewer, "preview-agent"); code != http.StatusOK {
+		t.Fatalf("stream status = %d", code)
+	}
+	if code := access(DownloadShare(db, cfg, store), &viewer, "download-agent"); code != http.StatusOK {
+		t.Fatalf("download status = %d", code)
+	}
+	// 被拒绝的访问同样记录
+	db.Model(&share).Update("require_login", true)
+	if code := access(StreamShare(db, cfg, store), nil, "anonymous"); code != http.StatusUnauthorized {
+		t.Fatalf("anonymous status = %d", code)
+	}
+
+	var entries []models.ShareAccess
+	db.Order("id").Find(&entries)
+	if len(entries) != 3 {
+		t.Fatalf("access entries = %d, want 3", len(entries))
+	}
+	if e := entries[1]; e.UserID == nil || *e.UserID != viewer.ID || e.Disposition != "attachment" ||
+		e.BytesSent != int64(len("slides")) || e.IP != "203.0.113.7" || e.UserAgent != "download-agent" {
+		t.Fatalf("download entry = %+v", e)
+	}
+	if e := entries[2]; e.UserID != nil || e.Status != http.StatusUnauthorized || e.BytesSent != 0 {
+		t.Fatalf("denied entry = %+v", e)
+	}
+
+	getShare := func(user models.User) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/shares/"+share.Token+"/analytics", nil)
+		c.Params = gin.Params{{Key: "token", Value: share.Token}}
+		c.Set("userID", user.ID)
+		c.Set("role", user.Role)
+		GetShareAnalytics(db)(c)
+		return w
+	}
+	if w := getShare(other); w.Code != http.StatusNotFound {
+		t.Fatalf("stranger analytics status = %d, want 404", w.Code)
+	}
+	w := getShare(owner)
+	if w.Code != http.StatusOK {
+		t.Fatalf("share analytics status = %d body=%s", w.Code, w.Body.String())
+	}
+	var stats ShareAnalytics
+	_ = json.Unmarshal(w.Body.Bytes(), &stats)
+	if stats.TotalAccesses != 3 || stats.Successful != 2 || stats.Denied != 1 || stats.Previews != 1 ||
+		stats.Downloads != 1 || stats.UniqueVisitors != 2 || stats.BytesSent != 12 {
+		t.Fatalf("share analytics = %s", w.Body.String())
+	}
+	if len(stats.Daily) != 1 || stats.Daily[0].Accesses != 3 || len(stats.Recent) != 3 || stats.Recent[2].Username != "viewer" {
+		t.Fatalf("daily/recent = %s", w.Body.String())
+	}
+
+	// 撤销分享后文件统计仍包含其访问记录
+	db.Delete(&share)
+	w = callFileAs(GetFileShareAnalytics(db), owner, http.MethodGet, f.ID, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("file analytics status = %d body=%s", w.Code, w.Body.String())
+	}
+	stats = ShareAnalytics{}
+	_ = json.Unmarshal(w.Body.Bytes(), &stats)
+	if stats.TotalAccesses != 3 || len(stats.Shares) != 1 || !stats.Shares[0].Revoked || stats.Recent[0].Token != share.Token {
+		t.Fatalf("file analytics = %s", w.Body.String())
+	}
+	if w := callFileAs(GetFileShareAnalytics(db), other, http.MethodGet, f.ID, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("stranger file analytics status = %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,418 @@
+package handlers
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"content-hub/server/config"
+	"content-hub/server/middleware"
+	"content-hub/server/models"
+	"content-hub/server/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxBundleItems 限制单个打包分享包含的文件数（目录展开后合计）。
+const maxBundleItems = 1000
+
+const defaultBundleName = "分享文件"
+
+var (
+	errBundleEmpty    = errors.New("请至少选择一个文件或目录")
+	errBundleTooLarge = fmt.Errorf("打包分享最多包含 %d 个文件", maxBundleItems)
+	errBundleNotFound = errors.New("部分文件或目录不存在或无权分享")
+)
+
+// bundleShareRequest 在普通分享配置之外指定打包的文件与目录；目录在创建时展开为其中（含子目录）的全部文件。
+type bundleShareRequest struct {
+	FileIDs   []uint `json:"file_ids"`
+	FolderIDs []uint `json:"folder_ids"`
+	// Name 为下载时的压缩包名称（不含 .zip），默认取唯一目录的名称
+	Name string `json:"name"`
+	shareRequest
+}
+
+type shareBundleItem struct {
+	ID               uint   `json:"id"`
+	Path             string `json:"path"`
+	Filename         string `json:"filename"`
+	MimeType         string `json:"mime_type"`
+	Size             int64  `json:"size"`
+	ScanStatus       string `json:"scan_status"`
+	PreviewAvailable bool   `json:"preview_available"`
+	StreamPath       string `json:"stream_path"`
+}
+
+// CreateBundleShare 将多个文件与目录打包为一个分享链接，访问策略参数与单文件分享一致。
+// 普通用户只能打包自己的文件与目录，管理员不受限制；成员在创建时确定，之后新增到目录中的文件不会加入分享。
+// @Summary 创建打包分享
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Param payload body bundleShareRequest true "打包内容与分享配置"
+// @Security BearerAuth
+// @Router /shares [post]
+func CreateBundleShare(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req bundleShareRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+			return
+		}
+		userID, role := currentUser(c)
+		items, folderName, err := collectBundleItems(db, userID, role == models.RoleAdmin, req.FileIDs, req.FolderIDs)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, errBundleNotFound):
+				status = http.StatusNotFound
+			case errors.Is(err, errBundleEmpty), errors.Is(err, errBundleTooLarge):
+				status = http.StatusBadRequest
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+
+		share, ok := newShareFromRequest(c, db, cfg, &req.shareRequest)
+		if !ok {
+			return
+		}
+		share.Bundle = true
+		share.Name = bundleName(req.Name, folderName)
+		share.FileID = items[0].FileID
+		share.Items = items
+		if err := db.Omit("Recipients.User", "Recipients.Group", "Items.File").Create(share).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		resp := createdShareResponse(share)
+		resp["bundle"] = true
+		resp["name"] = share.Name
+		resp["item_count"] = len(items)
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// collectBundleItems 校验并展开要打包的文件与目录，返回按 ZIP 内路径去重后的成员；同一文件只收录一次，重名时追加序号。
+// folderName 在只选择了一个目录时返回其名称，用作默认压缩包名。
+func collectBundleItems(db *gorm.DB, userID uint, isAdmin bool, fileIDs, folderIDs []uint) (items []models.ShareItem, folderName string, err error) {
+	fileIDs, folderIDs = uniqueIDs(fileIDs), uniqueIDs(folderIDs)
+	if len(fileIDs) == 0 && len(folderIDs) == 0 {
+		return nil, "", errBundleEmpty
+	}
+	owned := func(q *gorm.DB) *gorm.DB {
+		if isAdmin {
+			return q
+		}
+		return q.Where("owner_id = ?", userID)
+	}
+
+	seenFiles := map[uint]bool{}
+	usedPaths := map[string]bool{}
+	add := func(f models.File, dir string) {
+		if seenFiles[f.ID] {
+			return
+		}
+		seenFiles[f.ID] = true
+		items = append(items, models.ShareItem{FileID: f.ID, Path: uniqueZipPath(usedPaths, dir, f.Filename)})
+	}
+
+	if len(fileIDs) > 0 {
+		var files []models.File
+		if err := owned(db.Where("id IN ?", fileIDs)).Order("id").Find(&files).Error; err != nil {
+			return nil, "", err
+		}
+		if len(files) != len(fileIDs) {
+			return nil, "", errBundleNotFound
+		}
+		for _, f := range files {
+			add(f, "")
+		}
+	}
+
+	for _, rootID := range folderIDs {
+		var root models.Folder
+		if err := owned(db.Where("id = ?", rootID)).First(&root).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, "", errBundleNotFound
+			}
+			return nil, "", err
+		}
+		ids, err := models.DescendantFolderIDs(db, root.ID)
+		if err != nil {
+			return nil, "", err
+		}
+		var folders []models.Folder
+		if err := db.Where("id IN ?", ids).Find(&folders).Error; err != nil {
+			return nil, "", err
+		}
+		dirs := bundleFolderPaths(&root, folders, ids)
+		var files []models.File
+		if err := db.Where("folder_id IN ?", ids).Order("id").Find(&files).Error; err != nil {
+			return nil, "", err
+		}
+		for _, f := range files {
+			add(f, dirs[*f.FolderID])
+		}
+		if len(folderIDs) == 1 && len(fileIDs) == 0 {
+			folderName = root.Name
+		}
+	}
+
+	if len(items) == 0 {
+		return nil, "", errBundleEmpty
+	}
+	if len(items) > maxBundleItems {
+		return nil, "", errBundleTooLarge
+	}
+	return items, folderName, nil
+}
+
+// bundleFolderPaths 计算 root 及其子目录在 ZIP 中的路径（以 root 的名称开头）；ids 为层序排列的目录 ID，父目录总在子目录之前。
+func bundleFolderPaths(root *models.Folder, folders []models.Folder, ids []uint) map[uint]string {
+	byID := make(map[uint]models.Folder, len(folders))
+	for _, f := range folders {
+		byID[f.ID] = f
+	}
+	paths := map[uint]string{root.ID: zipSafeName(root.Name)}
+	for _, id := range ids[1:] {
+		f := byID[id]
+		if f.ParentID != nil {
+			paths[id] = paths[*f.ParentID] + "/" + zipSafeName(f.Name)
+		}
+	}
+	return paths
+}
+
+// uniqueZipPath 返回 dir 下不与已有成员冲突的路径，重名时在扩展名前追加 (2)、(3)……
+func uniqueZipPath(used map[string]bool, dir, filename string) string {
+	name := zipSafeName(filename)
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	candidate := path.Join(dir, name)
+	for i := 2; used[candidate]; i++ {
+		candidate = path.Join(dir, fmt.Sprintf("%s (%d)%s", base, i, ext))
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// zipSafeName 去除名称中的路径分隔符，避免解压时写到目标目录之外。
+func zipSafeName(name string) string {
+	name = strings.NewReplacer("/", "_", "\\", "_").Replace(strings.TrimSpace(name))
+	if name == "" || name == "." || name == ".." {
+		return "_"
+	}
+	return name
+}
+
+func bundleName(requested, folderName string) string {
+	name := strings.TrimSuffix(strings.TrimSpace(requested), ".zip")
+	if name == "" {
+		name = folderName
+	}
+	if name == "" {
+		name = defaultBundleName
+	}
+	name = zipSafeName(name)
+	if len([]rune(name)) > 200 {
+		name = string([]rune(name)[:200])
+	}
+	return name
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	out := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if id != 0 && !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// bundleMembers 返回打包分享中仍可访问的成员，移入回收站的文件不再出现。需已预加载 Items.File。
+func bundleMembers(share *models.Share) []*models.ShareItem {
+	members := make([]*models.ShareItem, 0, len(share.Items))
+	for i := range share.Items {
+		if share.Items[i].File.ID != 0 {
+			members = append(members, &share.Items[i])
+		}
+	}
+	return members
+}
+
+// bundleMeta 生成打包分享的元信息：大小为成员原始大小之和，scan_status 取最不安全的成员状态。
+func bundleMeta(share *models.Share, ref string) gin.H {
+	members := bundleMembers(share)
+	items := make([]shareBundleItem, 0, len(members))
+	var size int64
+	scanStatus := models.ScanClean
+	for _, m := range members {
+		size += m.File.Size
+		switch {
+		case m.File.ScanStatus == models.ScanInfected:
+			scanStatus = models.ScanInfected
+		case m.File.ScanStatus == "" && scanStatus == models.ScanClean:
+			scanStatus = models.ScanPending // 未记录状态的成员按待扫描处理，与 checkScanStatus 一致
+		case m.File.ScanStatus != models.ScanClean && scanStatus == models.ScanClean:
+			scanStatus = m.File.ScanStatus
+		}
+		items = append(items, shareBundleItem{
+			ID:               m.ID,
+			Path:             m.Path,
+			Filename:         m.File.Filename,
+			MimeType:         m.File.MimeType,
+			Size:             m.File.Size,
+			ScanStatus:       m.File.ScanStatus,
+			PreviewAvailable: m.File.ScanStatus == models.ScanClean,
+			StreamPath:       fmt.Sprintf("/api/shares/%s/items/%d", ref, m.ID),
+		})
+	}
+	return gin.H{
+		"bundle":            true,
+		"name":              share.Name,
+		"filename":          share.Name + ".zip",
+		"mime_type":         "application/zip",
+		"size":              size,
+		"digest":            "",
+		"description":       "",
+		"items":             items,
+		"download_path":     fmt.Sprintf("/api/shares/%s/download", ref),
+		"stream_path":       "",
+		"thumbnail_path":    "",
+		"scan_status":       scanStatus,
+		"preview_available": false,
+	}
+}
+
+// StreamShareItem 预览或下载打包分享中的单个文件，访问校验、Range 与计数规则同 /shares/{token}/stream，每次计入分享的 1 次浏览。
+// @Summary 预览打包分享中的文件
+// @Tags shares
+// @Produce octet-stream
+// @Param token path string true "分享 Token"
+// @Param item path int true "成员ID"
+// @Param download query bool false "是否以附件形式下载"
+// @Param Range header string false "字节范围，例如 bytes=0-1023"
+// @Security BearerAuth
+// @Router /shares/{token}/items/{item} [get]
+func StreamShareItem(db *gorm.DB, cfg *config.Config, store storage.Driver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		share, err := loadShare(db, c.Param("token"))
+		if err != nil || !share.Bundle {
+			c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
+			return
+		}
+		itemID, _ := strconv.ParseUint(c.Param("item"), 10, 64)
+		var item *models.ShareItem
+		for _, m := range bundleMembers(share) {
+			if uint64(m.ID) == itemID {
+				item = m
+			}
+		}
+		if item == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "shared file not found or already deleted"})
+			return
+		}
+
+		disposition := shareDisposition(c, false)
+		claims, err := parseOptionalClaims(c, cfg)
+		defer recordShareAccess(db, c, share, item.FileID, claims, disposition)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+		serveShareFile(c, db, cfg, store, share, claims, &item.File, disposition)
+	}
+}
+
+// serveShareBundle 校验全部成员后将其实时打包为 ZIP 输出，不落临时文件；每次完整下载计 1 次，HEAD 不计数。
+// 任一成员未通过扫描或内容缺失时整体拒绝，开始输出后发生的读取错误只能中断响应。
+func serveShareBundle(c *gin.Context, db *gorm.DB, cfg *config.Config, store storage.Driver, share *models.Share, claims *middleware.Claims) {
+	if !checkShareAccess(c, db, cfg, share, claims, true) {
+		return
+	}
+	members := bundleMembers(share)
+	if len(members) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "shared file not found or already deleted"})
+		return
+	}
+	for _, m := range members {
+		if !checkScanStatus(c, &m.File) {
+			return
+		}
+		if _, err := store.Stat(c.Request.Context(), m.File.Path); err != nil {
+			if errors.Is(err, storage.ErrNotExist) {
+				c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("shared file %s not found or already deleted", m.Path)})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", url.PathEscape(share.Name+".zip")))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "private, no-store")
+	if c.Request.Method == http.MethodHead {
+		c.Status(http.StatusOK)
+		return
+	}
+	if err := consumeView(db, share); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, errShareLimitReached) {
+			status = http.StatusGone
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusOK)
+	zw := zip.NewWriter(c.Writer)
+	for _, m := range members {
+		if err := writeZipEntry(c, zw, store, m); err != nil {
+			log.Printf("share %s bundle entry %s: %v", share.Token, m.Path, err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		log.Printf("share %s bundle: %v", share.Token, err)
+	}
+}
+
+func writeZipEntry(c *gin.Context, zw *zip.Writer, store storage.Driver, m *models.ShareItem) error {
+	header := &zip.FileHeader{Name: m.Path, Method: zipMethod(m.File.MimeType), Modified: m.File.UpdatedAt}
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	rc, err := store.Get(c.Request.Context(), m.File.Path)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	_, err = io.Copy(w, rc)
+	return err
+}
+
+// zipMethod 对图片、音视频与压缩包等已压缩的内容直接存储，避免无效的重复压缩。
+func zipMethod(mimeType string) uint16 {
+	switch {
+	case strings.HasPrefix(mimeType, "image/"), strings.HasPrefix(mimeType, "video/"), strings.HasPrefix(mimeType, "audio/"):
+		return zip.Store
+	case strings.Contains(mimeType, "zip"), strings.Contains(mimeType, "compressed"), strings.Contains(mimeType, "gzip"):
+		return zip.Store
+	}
+	return zip.Deflate
+}
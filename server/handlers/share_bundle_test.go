@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"content-hub/server/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestBundleShare(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	owner := createUser(t, db, "owner", models.RoleUser)
+	other := createUser(t, db, "other", models.RoleUser)
+
+	docs := models.Folder{OwnerID: owner.ID, Name: "docs"}
+	db.Create(&docs)
+	img := models.Folder{OwnerID: owner.ID, ParentID: &docs.ID, Name: "img"}
+	db.Create(&img)
+	notes := persistTestFile(t, db, cfg, store, owner.ID, "a.txt", "text/plain", []byte("root notes"))
+	dup := persistTestFile(t, db, cfg, store, owner.ID, "a.txt", "text/plain", []byte("second notes"))
+	readme := persistTestFile(t, db, cfg, store, owner.ID, "readme.txt", "text/plain", []byte("read me"))
+	pic := persistTestFile(t, db, cfg, store, owner.ID, "p.txt", "text/plain", []byte("picture"))
+	db.Model(readme).Update("folder_id", docs.ID)
+	db.Model(pic).Update("folder_id", img.ID)
+	foreign := persistTestFile(t, db, cfg, store, other.ID, "x.txt", "text/plain", []byte("foreign"))
+
+	create := func(user models.User, body string) *httptest.ResponseRecorder {
+		return callManagedShare(CreateBundleShare(db, cfg), user, http.MethodPost, "", body)
+	}
+	if w := create(owner, `{"file_ids":[]}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("empty bundle status = %d, want 400", w.Code)
+	}
+	if w := create(owner, `{"file_ids":[`+jsonID(notes.ID)+`,`+jsonID(foreign.ID)+`]}`); w.Code != http.StatusNotFound {
+		t.Fatalf("foreign file status = %d, want 404", w.Code)
+	}
+	w := create(owner, `{"file_ids":[`+jsonID(notes.ID)+`,`+jsonID(dup.ID)+`,`+jsonID(readme.ID)+`],"folder_ids":[`+jsonID(docs.ID)+`],"require_login":false,"max_views":5}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("create status = %d body=%s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Token     string `json:"share_token"`
+		Name      string `json:"name"`
+		ItemCount int    `json:"item_count"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	// readme 既被单独选择又位于目录中，只收录一次
+	if created.ItemCount != 4 || created.Name != defaultBundleName {
+		t.Fatalf("create response = %s", w.Body.String())
+	}
+
+	w = callShare(GetShareMeta(db, cfg), http.MethodGet, created.Token, "", "")
+	var meta struct {
+		Bundle bool              `json:"bundle"`
+		Items  []shareBundleItem `json:"items"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &meta)
+	if w.Code != http.StatusOK || !meta.Bundle || len(meta.Items) != 4 {
+		t.Fatalf("meta status = %d body=%s", w.Code, w.Body.String())
+	}
+	var paths []string
+	for _, item := range meta.Items {
+		paths = append(paths, item.Path)
+	}
+	if got := strings.Join(paths, ","); got != "a.txt,a (2).txt,readme.txt,docs/img/p.txt" {
+		t.Fatalf("item paths = %s", got)
+	}
+
+	if w := callShare(StreamShare(db, cfg, store), http.MethodGet, created.Token, "", ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("inline bundle status = %d, want 400", w.Code)
+	}
+	w = callShare(DownloadShare(db, cfg, store), http.MethodGet, created.Token, "", "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("download status = %d body=%s", w.Code, w.Body.String())
+	}
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("read zip: %v", err)
+	}
+	contents := map[string]string{}
+	var names []string
+	for _, zf := range zr.File {
+		rc, _ := zf.Open()
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		contents[zf.Name] = string(data)
+		names = append(names, zf.Name)
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "a (2).txt,a.txt,docs/img/p.txt,readme.txt" ||
+		contents["a (2).txt"] != "second notes" || contents["docs/img/p.txt"] != "picture" {
+		t.Fatalf("zip entries = %v", contents)
+	}
+
+	// 单个成员预览同样计 1 次
+	item := func(id uint) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/shares/"+created.Token+"/items/"+jsonID(id), nil)
+		c.Params = gin.Params{{Key: "token", Value: created.Token}, {Key: "item", Value: jsonID(id)}}
+		StreamShareItem(db, cfg, store)(c)
+		return w
+	}
+	if w := item(meta.Items[3].ID); w.Code != http.StatusOK || w.Body.String() != "picture" {
+		t.Fatalf("item status = %d body=%s", w.Code, w.Body.String())
+	}
+	var share models.Share
+	db.First(&share, "token = ?", created.Token)
+	if share.ViewCount != 2 {
+		t.Fatalf("view count = %d, want 2", share.ViewCount)
+	}
+	var accesses []models.ShareAccess
+	db.Order("id").Find(&accesses, "share_id = ?", share.ID)
+	// 整体下载 ZIP 按分享记录，不算到任何成员文件上
+	if len(accesses) != 3 || accesses[1].FileID != 0 || accesses[2].FileID != pic.ID {
+		t.Fatalf("access entries = %+v", accesses)
+	}
+
+	// 任一成员未通过扫描时整体拒绝下载，且不计数
+	db.Model(&models.File{}).Where("id = ?", pic.ID).Update("scan_status", models.ScanPending)
+	if w := callShare(DownloadShare(db, cfg, store), http.MethodGet, created.Token, "", ""); w.Code != http.StatusLocked {
+		t.Fatalf("pending member download status = %d, want 423", w.Code)
+	}
+	if w := item(meta.Items[0].ID); w.Code != http.StatusOK {
+		t.Fatalf("clean member status = %d", w.Code)
+	}
+	db.First(&share, share.ID)
+	if share.ViewCount != 3 {
+		t.Fatalf("view count after scan gate = %d, want 3", share.ViewCount)
+	}
+
+	// 移入回收站的成员不再出现，其预览地址返回 404
+	dupItem := meta.Items[1].ID
+	db.Delete(&models.File{}, dup.ID)
+	w = callShare(GetShareMeta(db, cfg), http.MethodGet, created.Token, "", "")
+	_ = json.Unmarshal(w.Body.Bytes(), &meta)
+	if len(meta.Items) != 3 {
+		t.Fatalf("items after trash = %s", w.Body.String())
+	}
+	if w := item(dupItem); w.Code != http.StatusNotFound {
+		t.Fatalf("trashed member status = %d, want 404", w.Code)
+	}
+}
+
+func TestBundleSharePassword(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	owner := createUser(t, db, "owner", models.RoleUser)
+	folder := models.Folder{OwnerID: owner.ID, Name: "报告"}
+	db.Create(&folder)
+	f := persistTestFile(t, db, cfg, store, owner.ID, "q1.txt", "text/plain", []byte("q1"))
+	db.Model(f).Update("folder_id", folder.ID)
+
+	w := callManagedShare(CreateBundleShare(db, cfg), owner, http.MethodPost, "", `{"folder_ids":[`+jsonID(folder.ID)+`],"require_login":false,"password":"open-sesame"}`)
+	var created struct {
+		Token string `json:"share_token"`
+		Name  string `json:"name"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	if w.Code != http.StatusOK || created.Name != "报告" {
+		t.Fatalf("create status = %d body=%s", w.Code, w.Body.String())
+	}
+	w = callShare(DownloadShare(db, cfg, store), http.MethodGet, created.Token, "", "")
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "password_required") {
+		t.Fatalf("locked download status = %d body=%s", w.Code, w.Body.String())
+	}
+	w = callShare(UnlockShare(db, cfg), http.MethodPost, created.Token, "", `{"password":"open-sesame"}`)
+	var unlocked struct {
+		AccessToken string `json:"access_token"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &unlocked)
+	w = callShare(DownloadShare(db, cfg, store), http.MethodGet, created.Token, unlocked.AccessToken, "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Header().Get("Content-Disposition"), ".zip") {
+		t.Fatalf("unlocked download status = %d body=%s", w.Code, w.Body.String())
+	}
+}
+
+func jsonID(id uint) string {
+	b, _ := json.Marshal(id)
+	return string(b)
+}
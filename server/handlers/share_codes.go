@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"content-hub/server/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// shortCodeLen 个 base62 字符约 47 bit，远低于 UUID，仅用于主动选择短链接的分享
+	shortCodeLen      = 8
+	shortCodeAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	shortCodeAttempts = 5
+	minSlugLen        = 3
+	maxSlugLen        = 64
+)
+
+var (
+	slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+	// reservedSlugs 与前端页面、分享子路径同名的词不能作为自定义链接，避免与路由混淆
+	reservedSlugs = map[string]bool{
+		"admin": true, "analytics": true, "api": true, "apikeys": true, "assets": true, "cleanup": true,
+		"download": true, "drop": true, "drops": true, "files": true, "folders": true, "groups": true,
+		"highlight": true, "items": true, "login": true, "logout": true, "new": true, "preview": true,
+		"raw": true, "s": true, "share": true, "shares": true, "static": true, "stream": true,
+		"swagger": true, "thumbnail": true, "unlock": true, "users": true,
+	}
+
+	errSlugTaken = errors.New("该自定义链接已被占用")
+)
+
+// shareAliasInput 为分享的短链接设置：short_code 为 true 时生成随机短码，slug 为自定义链接。
+// 两者都是 token 之外的访问入口，不设置时分享只能通过 UUID 访问。
+type shareAliasInput struct {
+	ShortCode bool   `json:"short_code"`
+	Slug      string `json:"slug"`
+}
+
+// normalizeSlug 将自定义链接转为小写并校验：3-64 个字母、数字或连字符，不能以连字符开头结尾，不能是保留词或 UUID。
+func normalizeSlug(v string) (string, error) {
+	slug := strings.ToLower(strings.TrimSpace(v))
+	switch {
+	case len(slug) < minSlugLen || len(slug) > maxSlugLen:
+		return "", fmt.Errorf("自定义链接需为 %d-%d 个字符", minSlugLen, maxSlugLen)
+	case !slugPattern.MatchString(slug):
+		return "", errors.New("自定义链接只能包含字母、数字与连字符，且不能以连字符开头或结尾")
+	case reservedSlugs[slug] || uuidPattern.MatchString(slug):
+		return "", fmt.Errorf("%q 为保留名称，请换一个", slug)
+	}
+	return slug, nil
+}
+
+// shareAliasTaken 判断 alias 是否已被其他分享用作 token、短码或自定义链接（不区分大小写）。已撤销分享的别名与修改时
+// 移除的别名同样视为占用，避免旧链接指向新的内容；exceptID 为当前分享，新建时为 0。
+func shareAliasTaken(db *gorm.DB, alias string, exceptID uint) (bool, error) {
+	lower := strings.ToLower(alias)
+	var n int64
+	err := db.Unscoped().Model(&models.Share{}).
+		Where("id <> ? AND (token = ? OR lower(short_code) = ? OR slug = ?)", exceptID, lower, lower, lower).
+		Count(&n).Error
+	if err != nil || n > 0 {
+		return n > 0, err
+	}
+	err = db.Model(&models.RetiredShareAlias{}).Where("alias = ? AND share_id <> ?", lower, exceptID).Count(&n).Error
+	return n > 0, err
+}
+
+// retireShareAliases 保留分享修改时移除的别名；原分享重新启用后再次移除时忽略重复记录。
+func retireShareAliases(tx *gorm.DB, shareID uint, aliases []string) error {
+	if len(aliases) == 0 {
+		return nil
+	}
+	rows := make([]models.RetiredShareAlias, len(aliases))
+	for i, alias := range aliases {
+		rows[i] = models.RetiredShareAlias{Alias: strings.ToLower(alias), ShareID: shareID}
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+// newShortCode 生成未被占用的随机短码。
+func newShortCode(db *gorm.DB, exceptID uint) (string, error) {
+	max := big.NewInt(int64(len(shortCodeAlphabet)))
+	for range shortCodeAttempts {
+		b := make([]byte, shortCodeLen)
+		for i := range b {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return "", err
+			}
+			b[i] = shortCodeAlphabet[n.Int64()]
+		}
+		code := string(b)
+		taken, err := shareAliasTaken(db, code, exceptID)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return code, nil
+		}
+	}
+	return "", errors.New("生成短链接失败，请重试")
+}
+
+// resolveSlug 校验自定义链接并确认未被其他分享占用。失败时已写入响应。
+func resolveSlug(c *gin.Context, db *gorm.DB, v string, exceptID uint) (string, bool) {
+	slug, err := normalizeSlug(v)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", false
+	}
+	taken, err := shareAliasTaken(db, slug, exceptID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return "", false
+	}
+	if taken {
+		c.JSON(http.StatusConflict, gin.H{"error": errSlugTaken.Error()})
+		return "", false
+	}
+	return slug, true
+}
+
+// applyShareAliases 按请求为新建的分享生成短码与自定义链接。失败时已写入响应。
+func applyShareAliases(c *gin.Context, db *gorm.DB, share *models.Share, in *shareAliasInput) bool {
+	if in.ShortCode {
+		code, err := newShortCode(db, 0)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return false
+		}
+		share.ShortCode = &code
+	}
+	if in.Slug != "" {
+		slug, ok := resolveSlug(c, db, in.Slug, 0)
+		if !ok {
+			return false
+		}
+		share.Slug = &slug
+	}
+	return true
+}
+
+// shareShortPath 返回分享的短链接页面地址，自定义链接优先；未设置别名时为空。
+func shareShortPath(share *models.Share) string {
+	switch {
+	case share.Slug != nil:
+		return "/s/" + *share.Slug
+	case share.ShortCode != nil:
+		return "/s/" + *share.ShortCode
+	}
+	return ""
+}
+
+func optionalString(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
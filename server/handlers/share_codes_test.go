@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"content-hub/server/models"
+)
+
+func TestNormalizeSlug(t *testing.T) {
+	for in, want := range map[string]string{"Team-Notes": "team-notes", " q3-report ": "q3-report", "abc": "abc"} {
+		if got, err := normalizeSlug(in); err != nil || got != want {
+			t.Errorf("normalizeSlug(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"ab", "-abc", "abc-", "a--b", "a_b", "中文链接", "admin", "preview", "6f1c2a9e-3b4d-4e5f-8a7b-9c0d1e2f3a4b", strings.Repeat("a", 65)} {
+		if _, err := normalizeSlug(in); err == nil {
+			t.Errorf("normalizeSlug(%q) accepted", in)
+		}
+	}
+}
+
+func TestShareShortCodeAndSlug(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	cfg.JWTSecret = "secret"
+	owner := createUser(t, db, "owner", models.RoleUser)
+	f := persistTestFile(t, db, cfg, store, owner.ID, "notes.txt", "text/plain", []byte("weekly notes"))
+
+	w := createPasswordShare(t, db, cfg, owner, f.ID, `{"require_login":false,"short_code":true,"slug":"Team-Notes"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("create status = %d body=%s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Token     string `json:"share_token"`
+		ShortCode string `json:"short_code"`
+		Slug      string `json:"slug"`
+		ShortPath string `json:"short_path"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(created.ShortCode) != shortCodeLen || created.Slug != "team-notes" || created.ShortPath != "/s/team-notes" {
+		t.Fatalf("created = %+v", created)
+	}
+
+	// 别名走同一套元信息与内容接口，且响应中不暴露 UUID
+	for _, ref := range []string{created.ShortCode, "team-notes", "TEAM-NOTES"} {
+		w := callShare(GetShareMeta(db, cfg), http.MethodGet, ref, "", "")
+		if w.Code != http.StatusOK || strings.Contains(w.Body.String(), created.Token) {
+			t.Fatalf("meta via %q status = %d body=%s", ref, w.Code, w.Body.String())
+		}
+		if w := callShare(StreamShare(db, cfg, store), http.MethodGet, ref, "", ""); w.Body.String() != "weekly notes" {
+			t.Fatalf("stream via %q status = %d body=%s", ref, w.Code, w.Body.String())
+		}
+	}
+	if swapped := swapCase(created.ShortCode); swapped != created.ShortCode {
+		if w := callShare(GetShareMeta(db, cfg), http.MethodGet, swapped, "", ""); w.Code != http.StatusNotFound {
+			t.Fatalf("short code is case sensitive, got %d", w.Code)
+		}
+	}
+
+	if w := createPasswordShare(t, db, cfg, owner, f.ID, `{"slug":"team-notes"}`); w.Code != http.StatusConflict {
+		t.Fatalf("duplicate slug status = %d, want 409", w.Code)
+	}
+	if w := createPasswordShare(t, db, cfg, owner, f.ID, `{"slug":"`+strings.ToLower(created.ShortCode)+`"}`); w.Code != http.StatusConflict {
+		t.Fatalf("slug equal to short code status = %d, want 409", w.Code)
+	}
+	if w := createPasswordShare(t, db, cfg, owner, f.ID, `{"slug":"login"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("reserved slug status = %d, want 400", w.Code)
+	}
+
+	// 未启用别名的分享只能通过 UUID 访问
+	w = createPasswordShare(t, db, cfg, owner, f.ID, `{"require_login":false}`)
+	var plain map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &plain); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if plain["short_code"] != nil || plain["slug"] != nil || plain["short_path"] != "" {
+		t.Fatalf("plain share = %v", plain)
+	}
+
+	// 修改：移除自定义链接与短码后旧地址失效，但别名仍保留，不能被其他分享占用
+	w = callManagedShare(UpdateShare(db, cfg), owner, http.MethodPatch, created.Token, `{"slug":"","short_code":false}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update status = %d body=%s", w.Code, w.Body.String())
+	}
+	for _, ref := range []string{created.ShortCode, "team-notes"} {
+		if w := callShare(GetShareMeta(db, cfg), http.MethodGet, ref, "", ""); w.Code != http.StatusNotFound {
+			t.Fatalf("meta via removed %q status = %d", ref, w.Code)
+		}
+	}
+	for _, slug := range []string{"team-notes", strings.ToLower(created.ShortCode)} {
+		if w := callManagedShare(UpdateShare(db, cfg), owner, http.MethodPatch, plain["share_token"].(string), `{"slug":"`+slug+`"}`); w.Code != http.StatusConflict {
+			t.Fatalf("claim retired alias %q status = %d, want 409", slug, w.Code)
+		}
+	}
+	w = callManagedShare(UpdateShare(db, cfg), owner, http.MethodPatch, plain["share_token"].(string), `{"slug":"other-notes","short_code":true}`)
+	var item shareListItem
+	if err := json.Unmarshal(w.Body.Bytes(), &item); err != nil || item.Slug != "other-notes" || len(item.ShortCode) != shortCodeLen {
+		t.Fatalf("update plain share status = %d body=%s", w.Code, w.Body.String())
+	}
+	// 替换自定义链接同样保留旧值；原分享可以重新启用自己的旧链接
+	w = callManagedShare(UpdateShare(db, cfg), owner, http.MethodPatch, plain["share_token"].(string), `{"slug":"renamed-notes"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("rename slug status = %d body=%s", w.Code, w.Body.String())
+	}
+	if w := createPasswordShare(t, db, cfg, owner, f.ID, `{"slug":"other-notes"}`); w.Code != http.StatusConflict {
+		t.Fatalf("claim replaced slug status = %d, want 409", w.Code)
+	}
+	for range 2 {
+		if w := callManagedShare(UpdateShare(db, cfg), owner, http.MethodPatch, created.Token, `{"slug":"team-notes"}`); w.Code != http.StatusOK {
+			t.Fatalf("reclaim own slug status = %d body=%s", w.Code, w.Body.String())
+		}
+		if w := callManagedShare(UpdateShare(db, cfg), owner, http.MethodPatch, created.Token, `{"slug":""}`); w.Code != http.StatusOK {
+			t.Fatalf("remove reclaimed slug status = %d body=%s", w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestShareAliasAnalyticsAndRevoke(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	cfg.JWTSecret = "secret"
+	owner := createUser(t, db, "owner", models.RoleUser)
+	admin := createUser(t, db, "admin", models.RoleAdmin)
+	f := persistTestFile(t, db, cfg, store, owner.ID, "report.txt", "text/plain", []byte("q3"))
+	w := createPasswordShare(t, db, cfg, owner, f.ID, `{"require_login":false,"short_code":true,"slug":"q3-report"}`)
+	var created struct {
+		Token     string `json:"share_token"`
+		ShortCode string `json:"short_code"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	if w := callManagedShare(GetShareAnalytics(db), owner, http.MethodGet, "Q3-Report", ""); w.Code != http.StatusOK {
+		t.Fatalf("analytics via slug status = %d body=%s", w.Code, w.Body.String())
+	}
+	if w := callManagedShare(RevokeShare(db), admin, http.MethodDelete, "no-such-share", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("revoke unknown share status = %d, want 404", w.Code)
+	}
+	if w := callManagedShare(RevokeShare(db), admin, http.MethodDelete, created.ShortCode, ""); w.Code != http.StatusOK {
+		t.Fatalf("revoke via short code status = %d body=%s", w.Code, w.Body.String())
+	}
+	var live int64
+	db.Model(&models.Share{}).Where("token = ?", created.Token).Count(&live)
+	if live != 0 {
+		t.Fatal("share should be revoked")
+	}
+	if w := callManagedShare(RevokeShare(db), admin, http.MethodDelete, "q3-report", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("revoke twice status = %d, want 404", w.Code)
+	}
+	// 已撤销的分享仍可通过别名查看统计
+	if w := callManagedShare(GetShareAnalytics(db), owner, http.MethodGet, "q3-report", ""); w.Code != http.StatusOK {
+		t.Fatalf("analytics of revoked share via slug status = %d body=%s", w.Code, w.Body.String())
+	}
+}
+
+func swapCase(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"content-hub/server/config"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultShareLifetime = 7 * 24 * time.Hour
+	minShareLifetime     = time.Minute
+	maxShareDays         = 3650
+)
+
+var errNeverExpireForbidden = errors.New("当前策略不允许创建永不过期的分享")
+
+// expiryInput 是分享与上传链接共用的有效期参数，expires_in_days、expires_in、expires_at 与 never_expires 至多指定一个。
+// expires_in 支持 Go duration（如 90m、36h）及以 d 结尾的天数（如 10d）；expires_at 为 RFC 3339 时间。
+type expiryInput struct {
+	ExpiresInDays *int   `json:"expires_in_days"`
+	ExpiresIn     string `json:"expires_in"`
+	ExpiresAt     string `json:"expires_at"`
+	NeverExpires  bool   `json:"never_expires"`
+}
+
+// shareExpiryInput 在有效期之外支持分享的生效时间 not_before（RFC 3339），修改分享时传空字符串表示立即生效。
+type shareExpiryInput struct {
+	expiryInput
+	NotBefore *string `json:"not_before"`
+}
+
+// resolveExpiry 计算过期时间；set 为 false 表示未指定任何有效期参数（创建时会使用默认 7 天，但不超过 SHARE_MAX_LIFETIME）。
+// 返回的 expiresAt 为 nil 且 set 为 true 表示永不过期。
+func (in *expiryInput) resolveExpiry(cfg *config.Config, isAdmin bool, now time.Time) (expiresAt *time.Time, set bool, err error) {
+	given := 0
+	for _, ok := range []bool{in.ExpiresInDays != nil, in.ExpiresIn != "", in.ExpiresAt != "", in.NeverExpires} {
+		if ok {
+			given++
+		}
+	}
+	if given > 1 {
+		return nil, false, errors.New("expires_in_days、expires_in、expires_at 与 never_expires 只能指定一个")
+	}
+
+	var deadline time.Time
+	switch {
+	case given == 0:
+		return nil, false, nil
+	case in.NeverExpires:
+		if !cfg.NeverExpireAllowed(isAdmin) {
+			return nil, true, errNeverExpireForbidden
+		}
+		return nil, true, nil
+	case in.ExpiresInDays != nil:
+		if *in.ExpiresInDays < 1 || *in.ExpiresInDays > maxShareDays {
+			return nil, true, fmt.Errorf("expires_in_days 需为 1-%d", maxShareDays)
+		}
+		deadline = now.AddDate(0, 0, *in.ExpiresInDays)
+	case in.ExpiresIn != "":
+		d, err := parseLifetime(in.ExpiresIn)
+		if err != nil {
+			return nil, true, err
+		}
+		deadline = now.Add(d)
+	default:
+		t, err := time.Parse(time.RFC3339, in.ExpiresAt)
+		if err != nil {
+			return nil, true, errors.New("expires_at 需为 RFC 3339 时间，例如 2024-06-01T18:00:00+08:00")
+		}
+		if t.Before(now.Add(minShareLifetime)) {
+			return nil, true, errors.New("expires_at 需晚于当前时间至少 1 分钟")
+		}
+		deadline = t
+	}
+	if err := checkMaxLifetime(cfg, now, deadline); err != nil {
+		return nil, true, err
+	}
+	return &deadline, true, nil
+}
+
+// defaultExpiry 返回创建分享或上传链接时未指定有效期的默认过期时间。
+func defaultExpiry(cfg *config.Config, now time.Time) *time.Time {
+	lifetime := defaultShareLifetime
+	if cfg.ShareMaxLifetime > 0 && cfg.ShareMaxLifetime < lifetime {
+		lifetime = cfg.ShareMaxLifetime
+	}
+	deadline := now.Add(lifetime)
+	return &deadline
+}
+
+// resolveNotBefore 解析生效时间；set 为 false 表示未传，传空字符串返回 (nil, true)。
+func (in *shareExpiryInput) resolveNotBefore() (notBefore *time.Time, set bool, err error) {
+	if in.NotBefore == nil {
+		return nil, false, nil
+	}
+	if *in.NotBefore == "" {
+		return nil, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, *in.NotBefore)
+	if err != nil {
+		return nil, true, errors.New("not_before 需为 RFC 3339 时间")
+	}
+	return &t, true, nil
+}
+
+// validateShareWindow 校验生效时间早于过期时间。
+func validateShareWindow(notBefore, expiresAt *time.Time) error {
+	if notBefore != nil && expiresAt != nil && !notBefore.Before(*expiresAt) {
+		return errors.New("not_before 需早于过期时间")
+	}
+	return nil
+}
+
+// checkMaxLifetime 校验截止时间距 from 不超过 SHARE_MAX_LIFETIME；修改已有分享时 from 为分享的创建时间，
+// 避免反复延期使分享长期有效。
+func checkMaxLifetime(cfg *config.Config, from, deadline time.Time) error {
+	if cfg.ShareMaxLifetime > 0 && deadline.Sub(from) > cfg.ShareMaxLifetime {
+		return fmt.Errorf("分享有效期不能超过 %s", formatLifetime(cfg.ShareMaxLifetime))
+	}
+	return nil
+}
+
+// parseLifetime 解析 expires_in：整数天（10d）或 Go duration（36h、90m），需至少 1 分钟。
+func parseLifetime(v string) (time.Duration, error) {
+	v = strings.TrimSpace(v)
+	var d time.Duration
+	if days, ok := strings.CutSuffix(v, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 1 || n > maxShareDays {
+			return 0, fmt.Errorf("expires_in 天数需为 1-%d", maxShareDays)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(v); err != nil {
+			return 0, errors.New("expires_in 格式无效，例如 90m、36h 或 10d")
+		}
+	}
+	if d < minShareLifetime {
+		return 0, errors.New("expires_in 至少为 1 分钟")
+	}
+	if d > maxShareDays*24*time.Hour {
+		return 0, fmt.Errorf("expires_in 不能超过 %d 天", maxShareDays)
+	}
+	return d, nil
+}
+
+// formatLifetime 以天或小时描述时长，用于错误提示。
+func formatLifetime(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%d 天", d/(24*time.Hour))
+	}
+	return d.String()
+}
+
+// writeExpiryError 将有效期校验错误写入响应：策略不允许永不过期返回 403，其余为 400。
+func writeExpiryError(c *gin.Context, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, errNeverExpireForbidden) {
+		status = http.StatusForbidden
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"content-hub/server/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestShareExpiryOptions(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	admin := createUser(t, db, "admin", models.RoleAdmin)
+	owner := createUser(t, db, "owner", models.RoleUser)
+	f := persistTestFile(t, db, cfg, store, owner.ID, "a.txt", "text/plain", []byte("a"))
+
+	create := func(user models.User, body string) (int, map[string]any) {
+		w := createPasswordShare(t, db, cfg, user, f.ID, body)
+		var resp map[string]any
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+	expiresIn := func(resp map[string]any) time.Duration {
+		v, _ := resp["expires_at"].(string)
+		at, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			t.Fatalf("expires_at = %v", resp["expires_at"])
+		}
+		return time.Until(at)
+	}
+
+	cases := []struct {
+		body     string
+		min, max time.Duration
+	}{
+		{`{"require_login":false}`, 7*24*time.Hour - time.Minute, 7 * 24 * time.Hour},
+		{`{"expires_in":"36h"}`, 36*time.Hour - time.Minute, 36 * time.Hour},
+		{`{"expires_in":"10d"}`, 10*24*time.Hour - time.Minute, 10 * 24 * time.Hour},
+		{`{"expires_at":"` + time.Now().Add(3*time.Hour).Format(time.RFC3339) + `"}`, 2 * time.Hour, 3 * time.Hour},
+	}
+	for _, tc := range cases {
+		code, resp := create(owner, tc.body)
+		if code != http.StatusOK {
+			t.Fatalf("create %s status = %d resp=%v", tc.body, code, resp)
+		}
+		if d := expiresIn(resp); d < tc.min || d > tc.max {
+			t.Fatalf("create %s expires in %s", tc.body, d)
+		}
+	}
+	past := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	for _, body := range []string{`{"expires_at":"` + past + `"}`, `{"expires_in":"30s"}`, `{"expires_in":"soon"}`, `{"expires_in":"1h","expires_in_days":1}`} {
+		if code, _ := create(owner, body); code != http.StatusBadRequest {
+			t.Fatalf("create %s status = %d, want 400", body, code)
+		}
+	}
+
+	// 永不过期默认禁止；admin 策略下仅管理员可用，all 策略下所有人可用
+	if code, _ := create(admin, `{"never_expires":true}`); code != http.StatusForbidden {
+		t.Fatalf("never expires by default status = %d, want 403", code)
+	}
+	cfg.ShareNeverExpire = "admin"
+	if code, _ := create(owner, `{"never_expires":true}`); code != http.StatusForbidden {
+		t.Fatalf("never expires for user status = %d, want 403", code)
+	}
+	if code, resp := create(admin, `{"never_expires":true}`); code != http.StatusOK || resp["expires_at"] != nil {
+		t.Fatalf("never expires for admin status = %d resp=%v", code, resp)
+	}
+	cfg.ShareNeverExpire = "all"
+	if code, _ := create(owner, `{"never_expires":true}`); code != http.StatusOK {
+		t.Fatalf("never expires for all status = %d", code)
+	}
+
+	// 设置有效期上限后，默认有效期随之缩短且不再允许永不过期
+	cfg.ShareMaxLifetime = 48 * time.Hour
+	if code, _ := create(owner, `{"never_expires":true}`); code != http.StatusForbidden {
+		t.Fatalf("never expires with max lifetime status = %d, want 403", code)
+	}
+	if code, _ := create(owner, `{"expires_in_days":3}`); code != http.StatusBadRequest {
+		t.Fatalf("beyond max lifetime status = %d, want 400", code)
+	}
+	code, resp := create(owner, `{}`)
+	if d := expiresIn(resp); code != http.StatusOK || d > 48*time.Hour || d < 47*time.Hour {
+		t.Fatalf("capped default status = %d expires in %s", code, d)
+	}
+	token, _ := resp["share_token"].(string)
+	w := callManagedShare(UpdateShare(db, cfg), owner, http.MethodPatch, token, `{"expires_in":"72h"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("update beyond max lifetime status = %d, want 400", w.Code)
+	}
+
+	// 上限自创建时间起算：分享创建一天后不能再延长 48 小时
+	db.Model(&models.Share{}).Where("token = ?", token).Update("created_at", time.Now().Add(-24*time.Hour))
+	w = callManagedShare(UpdateShare(db, cfg), owner, http.MethodPatch, token, `{"expires_in":"47h"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("extend past created_at + max lifetime status = %d, want 400", w.Code)
+	}
+	w = callManagedShare(UpdateShare(db, cfg), owner, http.MethodPatch, token, `{"expires_in":"23h"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("extend within max lifetime status = %d body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestShareNotBefore(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	owner := createUser(t, db, "owner", models.RoleUser)
+	f := persistTestFile(t, db, cfg, store, owner.ID, "a.txt", "text/plain", []byte("a"))
+
+	start := time.Now().Add(2 * time.Hour).Format(time.RFC3339)
+	if w := createPasswordShare(t, db, cfg, owner, f.ID, `{"expires_in":"1h","not_before":"`+start+`"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("not_before after expiry status = %d, want 400", w.Code)
+	}
+	w := createPasswordShare(t, db, cfg, owner, f.ID, `{"require_login":false,"not_before":"`+start+`"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("create status = %d body=%s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Token     string     `json:"share_token"`
+		NotBefore *time.Time `json:"not_before"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	if created.NotBefore == nil {
+		t.Fatalf("create response = %s", w.Body.String())
+	}
+
+	if w := callShare(StreamShare(db, cfg, store), http.MethodGet, created.Token, "", ""); w.Code != http.StatusForbidden {
+		t.Fatalf("pending stream status = %d, want 403", w.Code)
+	}
+	listed := func(status string) int {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/shares?status="+status, nil)
+		c.Set("userID", owner.ID)
+		c.Set("role", owner.Role)
+		ListMyShares(db)(c)
+		var resp ShareListResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return len(resp.Items)
+	}
+	if listed("pending") != 1 || listed("active") != 0 {
+		t.Fatalf("pending share listed as pending=%d active=%d", listed("pending"), listed("active"))
+	}
+
+	// 改为立即生效后即可访问
+	w = callManagedShare(UpdateShare(db, cfg), owner, http.MethodPatch, created.Token, `{"not_before":""}`)
+	var item shareListItem
+	_ = json.Unmarshal(w.Body.Bytes(), &item)
+	if w.Code != http.StatusOK || item.NotBefore != nil {
+		t.Fatalf("clear not_before status = %d body=%s", w.Code, w.Body.String())
+	}
+	if w := callShare(StreamShare(db, cfg, store), http.MethodGet, created.Token, "", ""); w.Code != http.StatusOK {
+		t.Fatalf("active stream status = %d body=%s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"content-hub/server/config"
+	"content-hub/server/models"
+	"content-hub/server/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// updateShareRequest 字段省略表示不修改，取值范围与创建分享一致；max_views 为 0 表示取消次数限制，password 为空字符串表示移除密码；
+// 阅后即焚的分享 max_views 只能为 1。short_code 为 true 时生成短码（已有则保留）、false 时移除，slug 为空字符串表示移除自定义链接，
+// 移除或替换掉的别名仍保留给该分享。
+// allow_usernames / allow_groups 任一出现即整体替换接收人（另一项省略时保留原值），两者均为空时取消接收人限制。
+type updateShareRequest struct {
+	RequireLogin *bool `json:"require_login"`
+	MaxViews     *uint `json:"max_views"`
+	ResetViews   bool  `json:"reset_views"`
+	shareExpiryInput
+	Password       *string   `json:"password"`
+	AllowUsernames *[]string `json:"allow_usernames"`
+	AllowGroups    *[]string `json:"allow_groups"`
+	ShortCode      *bool     `json:"short_code"`
+	Slug           *string   `json:"slug"`
+}
+
+// ListMyShares 列出当前用户创建的分享以及针对其文件的分享，管理员可见全部；过滤、排序与分页参数同管理端列表。
+// @Summary 我的分享
+// @Tags shares
+// @Produce json
+// @Param limit query int false "每页数量，默认 50，最大 200"
+// @Param cursor query string false "上一页返回的 next_cursor"
+// @Param sort query string false "排序字段：created_at / view_count"
+// @Param order query string false "asc 或 desc，默认 desc"
+// @Param include_total query bool false "是否返回过滤后的总数"
+// @Param creator query string false "创建者用户名"
+// @Param file_id query int false "文件ID"
+// @Param status query string false "active / pending / expired / exhausted"
+// @Success 200 {object} ShareListResponse
+// @Security BearerAuth
+// @Router /shares [get]
+func ListMyShares(db *gorm.DB) gin.HandlerFunc {
+	return listShares(db, true)
+}
+
+// CleanMyShares 清理当前用户可管理的失效分享，条件与管理端清理一致。
+// @Summary 清理我的失效分享
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Router /shares/cleanup [post]
+func CleanMyShares(db *gorm.DB, store storage.Driver) gin.HandlerFunc {
+	return cleanShares(db, store, true)
+}
+
+// RevokeMyShare 撤销分享，分享创建者、文件所有者与管理员可操作。
+// @Summary 撤销我的分享
+// @Tags shares
+// @Produce json
+// @Param token path string true "分享 Token"
+// @Security BearerAuth
+// @Router /shares/{token} [delete]
+func RevokeMyShare(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		share, ok := loadManagedShare(c, db)
+		if !ok {
+			return
+		}
+		if err := db.Delete(share).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "share revoked"})
+	}
+}
+
+// UpdateShare 修改已有分享的登录要求、次数上限、有效期、访问密码与接收人，分享链接保持不变。
+// expires_in_days / expires_in 从当前时间重新计算有效期，可用于延长或缩短；never_expires 受 SHARE_NEVER_EXPIRE 策略限制；reset_views 将已用次数清零。
+// @Summary 修改分享
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Param token path string true "分享 Token"
+// @Param payload body updateShareRequest true "修改内容"
+// @Success 200 {object} shareListItem
+// @Security BearerAuth
+// @Router /shares/{token} [patch]
+func UpdateShare(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		share, ok := loadManagedShare(c, db)
+		if !ok {
+			return
+		}
+		var req updateShareRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+			return
+		}
+
+		updates := map[string]any{}
+		if req.MaxViews != nil {
+			updates["max_views"] = nil
+			if *req.MaxViews > 0 {
+				if err := validateMaxViews(*req.MaxViews); err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+					return
+				}
+				updates["max_views"] = *req.MaxViews
+			}
+			if share.BurnAfterReading && *req.MaxViews != 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": errBurnMaxViews.Error()})
+				return
+			}
+		}
+		if req.ResetViews {
+			updates["view_count"] = 0
+		}
+		_, role := currentUser(c)
+		expiresAt, set, err := req.resolveExpiry(cfg, role == models.RoleAdmin, time.Now())
+		if err != nil {
+			writeExpiryError(c, err)
+			return
+		}
+		if set && expiresAt != nil {
+			if err := checkMaxLifetime(cfg, share.CreatedAt, *expiresAt); err != nil {
+				writeExpiryError(c, fmt.Errorf("%w（自分享创建时起算）", err))
+				return
+			}
+		}
+		if set {
+			updates["expires_at"] = expiresAt
+		} else {
+			expiresAt = share.ExpiresAt
+		}
+		notBefore, set, err := req.resolveNotBefore()
+		if err == nil {
+			if set {
+				updates["not_before"] = notBefore
+			} else {
+				notBefore = share.NotBefore
+			}
+			err = validateShareWindow(notBefore, expiresAt)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.Password != nil {
+			hash := ""
+			if *req.Password != "" {
+				if err := validateSharePassword(*req.Password); err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+					return
+				}
+				if err := share.SetPassword(*req.Password); err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return
+				}
+				hash = share.PasswordHash
+			}
+			// 修改或移除密码后已签发的访问令牌随即失效，同时清除输错锁定
+			updates["password_hash"] = hash
+			updates["unlock_failures"] = 0
+			updates["unlock_locked_until"] = nil
+		}
+
+		// 移除或替换掉的别名保留给原分享，不再分配给其他分享
+		var retired []string
+		if req.ShortCode != nil {
+			switch {
+			case !*req.ShortCode:
+				updates["short_code"] = nil
+				if share.ShortCode != nil {
+					retired = append(retired, *share.ShortCode)
+				}
+			case share.ShortCode == nil:
+				code, err := newShortCode(db, share.ID)
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return
+				}
+				updates["short_code"] = code
+			}
+		}
+		if req.Slug != nil {
+			slug := ""
+			if *req.Slug != "" {
+				var ok bool
+				if slug, ok = resolveSlug(c, db, *req.Slug, share.ID); !ok {
+					return
+				}
+			}
+			updates["slug"] = nil
+			if slug != "" {
+				updates["slug"] = slug
+			}
+			if share.Slug != nil && *share.Slug != slug {
+				retired = append(retired, *share.Slug)
+			}
+		}
+
+		replaceRecipients := req.AllowUsernames != nil || req.AllowGroups != nil
+		var recipients []models.ShareRecipient
+		restricted := share.Restricted()
+		if replaceRecipients {
+			users, groups := shareRecipientNames(share)
+			if req.AllowUsernames != nil {
+				users = *req.AllowUsernames
+			}
+			if req.AllowGroups != nil {
+				groups = *req.AllowGroups
+			}
+			if recipients, err = resolveShareRecipients(db, users, groups); err != nil {
+				writeRecipientError(c, err)
+				return
+			}
+			restricted = len(recipients) > 0
+			// 早期的单一接收人并入接收人名单
+			updates["allow_user_id"] = nil
+			updates["restrict_recipients"] = restricted
+		}
+		requireLogin := share.RequireLogin
+		if req.RequireLogin != nil {
+			requireLogin = *req.RequireLogin
+		}
+		if restricted {
+			// 与创建时一致：限定接收人时必须登录
+			requireLogin = true
+		}
+		if requireLogin != share.RequireLogin {
+			updates["require_login"] = requireLogin
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if len(updates) > 0 {
+				// 按主键更新，避免预加载的 AllowUser 等关联被回写覆盖外键
+				if err := tx.Model(&models.Share{}).Where("id = ?", share.ID).Updates(updates).Error; err != nil {
+					return err
+				}
+			}
+			if err := retireShareAliases(tx, share.ID, retired); err != nil {
+				return err
+			}
+			if !replaceRecipients {
+				return nil
+			}
+			if err := tx.Where("share_id = ?", share.ID).Delete(&models.ShareRecipient{}).Error; err != nil {
+				return err
+			}
+			for i := range recipients {
+				recipients[i].ShareID = share.ID
+			}
+			if len(recipients) == 0 {
+				return nil
+			}
+			return tx.Omit("Share", "User", "Group").Create(&recipients).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		writeManagedShare(c, db, share.Token)
+	}
+}
+
+// scopeManagedShares 将查询限定为当前用户创建或针对其文件（含回收站中的文件）的分享，管理员不受限制。
+func scopeManagedShares(c *gin.Context, db, query *gorm.DB) *gorm.DB {
+	userID, role := currentUser(c)
+	if role == models.RoleAdmin {
+		return query
+	}
+	owned := db.Unscoped().Model(&models.File{}).Select("id").Where("owner_id = ?", userID)
+	return query.Where("shares.creator_id = ? OR shares.file_id IN (?)", userID, owned)
+}
+
+// loadManagedShare 读取当前用户可管理的分享：分享创建者、文件所有者与管理员；其他用户与不存在一样返回 404。失败时已写入响应。
+func loadManagedShare(c *gin.Context, db *gorm.DB) (*models.Share, bool) {
+	share, err := loadShare(db, c.Param("token"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	userID, role := currentUser(c)
+	if role == models.RoleAdmin || share.CreatorID == userID {
+		return share, true
+	}
+	var owner uint
+	if err := db.Unscoped().Model(&models.File{}).Where("id = ?", share.FileID).Pluck("owner_id", &owner).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if owner != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
+		return nil, false
+	}
+	return share, true
+}
+
+func writeManagedShare(c *gin.Context, db *gorm.DB, token string) {
+	share, err := loadShare(db, token)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, buildShareListItem(share))
+}
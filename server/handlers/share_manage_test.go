@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"content-hub/server/models"
+	"content-hub/server/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// callManagedShare 以指定用户身份调用分享管理接口；token 为空时不设置路径参数。
+func callManagedShare(h gin.HandlerFunc, user models.User, method, token, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, "/api/shares/"+token, strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		c.Params = gin.Params{{Key: "token", Value: token}}
+	}
+	c.Set("userID", user.ID)
+	c.Set("role", user.Role)
+	h(c)
+	return w
+}
+
+func TestOwnerManagesShares(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	admin := createUser(t, db, "admin", models.RoleAdmin)
+	alice := createUser(t, db, "alice", models.RoleUser)
+	bob := createUser(t, db, "bob", models.RoleUser)
+	aliceFile := persistTestFile(t, db, cfg, store, alice.ID, "a.txt", "text/plain", []byte("a"))
+	bobFile := persistTestFile(t, db, cfg, store, bob.ID, "b.txt", "text/plain", []byte("b"))
+
+	past := time.Now().Add(-time.Hour)
+	shares := []models.Share{
+		{Token: "alice-own", FileID: aliceFile.ID, CreatorID: alice.ID},
+		{Token: "admin-on-alice", FileID: aliceFile.ID, CreatorID: admin.ID, ExpiresAt: &past},
+		{Token: "bob-own", FileID: bobFile.ID, CreatorID: bob.ID, ExpiresAt: &past},
+	}
+	for i := range shares {
+		if err := db.Create(&shares[i]).Error; err != nil {
+			t.Fatalf("create share: %v", err)
+		}
+	}
+
+	list := func(user models.User) []string {
+		w := callManagedShare(ListMyShares(db), user, http.MethodGet, "", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("list status = %d body=%s", w.Code, w.Body.String())
+		}
+		var resp ShareListResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		tokens := make([]string, 0, len(resp.Items))
+		for _, item := range resp.Items {
+			tokens = append(tokens, item.Token)
+		}
+		return tokens
+	}
+	// 文件所有者能看到他人针对其文件创建的分享，但看不到无关分享
+	if got := list(alice); len(got) != 2 || strings.Contains(strings.Join(got, ","), "bob-own") {
+		t.Fatalf("alice shares = %v", got)
+	}
+	if got := list(admin); len(got) != 3 {
+		t.Fatalf("admin shares = %v", got)
+	}
+
+	if w := callManagedShare(UpdateShare(db, cfg), bob, http.MethodPatch, "alice-own", `{"max_views":5}`); w.Code != http.StatusNotFound {
+		t.Fatalf("stranger update status = %d, want 404", w.Code)
+	}
+	w := callManagedShare(UpdateShare(db, cfg), alice, http.MethodPatch, "alice-own", `{"require_login":false,"max_views":5,"password":"letmein"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update status = %d body=%s", w.Code, w.Body.String())
+	}
+	var item shareListItem
+	_ = json.Unmarshal(w.Body.Bytes(), &item)
+	if item.RequireLogin || item.MaxViews == nil || *item.MaxViews != 5 || !item.HasPassword {
+		t.Fatalf("updated share = %s", w.Body.String())
+	}
+	w = callManagedShare(UpdateShare(db, cfg), alice, http.MethodPatch, "alice-own", `{"max_views":0,"password":""}`)
+	_ = json.Unmarshal(w.Body.Bytes(), &item)
+	if w.Code != http.StatusOK || item.MaxViews != nil || item.HasPassword {
+		t.Fatalf("clear limits status = %d body=%s", w.Code, w.Body.String())
+	}
+
+	// 清理只作用于自己可管理的分享
+	w = callManagedShare(CleanMyShares(db, storage.NewLocal("")), alice, http.MethodPost, "", `{"remove_expired":true}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "admin-on-alice") || strings.Contains(w.Body.String(), "bob-own") {
+		t.Fatalf("cleanup status = %d body=%s", w.Code, w.Body.String())
+	}
+
+	if w := callManagedShare(RevokeMyShare(db), bob, http.MethodDelete, "alice-own", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("stranger revoke status = %d, want 404", w.Code)
+	}
+	if w := callManagedShare(RevokeMyShare(db), alice, http.MethodDelete, "alice-own", ""); w.Code != http.StatusOK {
+		t.Fatalf("revoke status = %d", w.Code)
+	}
+	if got := list(alice); len(got) != 0 {
+		t.Fatalf("alice shares after revoke = %v", got)
+	}
+	if got := list(bob); len(got) != 1 {
+		t.Fatalf("bob shares = %v", got)
+	}
+}
+
+func TestUpdateSharePolicy(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	owner := createUser(t, db, "owner", models.RoleUser)
+	alice := createUser(t, db, "alice", models.RoleUser)
+	createUser(t, db, "bob", models.RoleUser)
+	f := persistTestFile(t, db, cfg, store, owner.ID, "a.txt", "text/plain", []byte("a"))
+	max := uint(3)
+	share := models.Share{Token: "policy-token", FileID: f.ID, CreatorID: owner.ID, AllowUserID: &alice.ID, RequireLogin: true, MaxViews: &max, ViewCount: 3}
+	if err := db.Create(&share).Error; err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+	update := func(body string) (*httptest.ResponseRecorder, shareListItem) {
+		w := callManagedShare(UpdateShare(db, cfg), owner, http.MethodPatch, share.Token, body)
+		var item shareListItem
+		_ = json.Unmarshal(w.Body.Bytes(), &item)
+		return w, item
+	}
+
+	// 校验规则与创建分享一致
+	for _, body := range []string{`{"max_views":100001}`, `{"expires_in_days":0}`, `{"expires_in":"3h","never_expires":true}`, `{"password":"abc"}`} {
+		if w, _ := update(body); w.Code != http.StatusBadRequest {
+			t.Fatalf("update %s status = %d, want 400", body, w.Code)
+		}
+	}
+	if w, _ := update(`{"allow_groups":["missing"]}`); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown group status = %d, want 404", w.Code)
+	}
+
+	w, item := update(`{"reset_views":true,"max_views":10,"expires_in_days":30}`)
+	if w.Code != http.StatusOK || item.ViewCount != 0 || *item.MaxViews != 10 || item.ExpiresAt == nil ||
+		item.ExpiresAt.Before(time.Now().Add(29*24*time.Hour)) {
+		t.Fatalf("extend status = %d body=%s", w.Code, w.Body.String())
+	}
+
+	// 替换接收人：早期的单一接收人并入名单，限定接收人时仍强制登录
+	w, item = update(`{"allow_usernames":["alice","bob"],"require_login":false}`)
+	if w.Code != http.StatusOK || !item.RequireLogin || item.AllowUsername != "" ||
+		strings.Join(item.AllowUsernames, ",") != "alice,bob" {
+		t.Fatalf("recipients status = %d body=%s", w.Code, w.Body.String())
+	}
+	w, item = update(`{"allow_usernames":[],"require_login":false}`)
+	if w.Code != http.StatusOK || item.RequireLogin || len(item.AllowUsernames) != 0 {
+		t.Fatalf("clear recipients status = %d body=%s", w.Code, w.Body.String())
+	}
+	var reloaded models.Share
+	db.Preload("Recipients").First(&reloaded, share.ID)
+	if reloaded.Restricted() || len(reloaded.Recipients) != 0 {
+		t.Fatalf("share should no longer be restricted: %+v", reloaded)
+	}
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"content-hub/server/config"
+	"content-hub/server/middleware"
+	"content-hub/server/models"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	minSharePasswordLen    = 4
+	maxSharePasswordLen    = 72 // bcrypt 只使用前 72 字节
+	shareAccessTTL         = 30 * time.Minute
+	shareUnlockMaxFailures = 5
+	shareUnlockLockout     = 15 * time.Minute
+	// shareAccessHeader 携带解锁后签发的访问令牌；无法自定义请求头的场景（如 <video src>）依靠解锁时下发的 HttpOnly Cookie。
+	// 不接受查询参数传递令牌，避免凭证出现在访问日志、浏览器历史与 Referer 中
+	shareAccessHeader = "X-Share-Access"
+)
+
+var errSharePasswordRequired = errors.New("该分享需要密码")
+
+// shareAccessClaims 是密码解锁后签发的短期令牌，仅对签发时的分享与密码有效，修改密码后旧令牌随即失效。
+type shareAccessClaims struct {
+	ShareID     uint   `json:"sid"`
+	PasswordTag string `json:"pwt"`
+	jwt.RegisteredClaims
+}
+
+type unlockShareRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// validateSharePassword 校验创建或修改分享时提交的密码长度。
+func validateSharePassword(pw string) error {
+	if len(pw) < minSharePasswordLen || len(pw) > maxSharePasswordLen {
+		return fmt.Errorf("分享密码长度需为 %d-%d 个字节", minSharePasswordLen, maxSharePasswordLen)
+	}
+	return nil
+}
+
+// UnlockShare 校验分享密码并签发短期访问令牌，之后的元信息、预览与下载请求需通过 X-Share-Access 头携带该令牌；
+// 同时以仅限 /api/shares/ 路径的 HttpOnly Cookie 下发同一令牌，供浏览器直接加载媒体地址。
+// 同一分享连续输错 5 次后锁定 15 分钟，锁定期间不再校验密码。
+// @Summary 解锁密码分享
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Param token path string true "分享 Token"
+// @Param payload body unlockShareRequest true "分享密码"
+// @Router /shares/{token}/unlock [post]
+func UnlockShare(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		share, err := loadShare(db, c.Param("token"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
+			return
+		}
+		if share.Expired(time.Now()) {
+			c.JSON(http.StatusGone, gin.H{"error": "分享已过期"})
+			return
+		}
+		if !share.HasPassword() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "该分享未设置密码"})
+			return
+		}
+		var req unlockShareRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "password is required"})
+			return
+		}
+
+		// 先原子地占用一次尝试再比对密码，并发请求也无法在锁定后继续猜测
+		now := time.Now()
+		claimed, err := claimUnlockAttempt(db, share, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !claimed {
+			writeUnlockRefused(c, db, share, now)
+			return
+		}
+		if !share.CheckPassword(req.Password) {
+			lockedUntil, remaining, err := recordUnlockFailure(db, share, now)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if lockedUntil != nil {
+				writeShareLocked(c, *lockedUntil, now)
+				return
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "分享密码错误", "password_required": true, "remaining_attempts": remaining})
+			return
+		}
+
+		// 比对期间同批的错误尝试可能已触发锁定，此时正确的密码同样拒绝
+		res := db.Model(&models.Share{}).Where("id = ? AND unlock_locked_until IS NULL", share.ID).
+			Update("unlock_failures", 0)
+		if res.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": res.Error.Error()})
+			return
+		}
+		if res.RowsAffected == 0 {
+			writeUnlockRefused(c, db, share, now)
+			return
+		}
+		expiresAt := now.Add(shareAccessTTL)
+		token, err := signShareAccess(cfg, share, now, expiresAt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(shareAccessCookie(share), token, int(shareAccessTTL.Seconds()), "/api/shares/", "", c.Request.TLS != nil, true)
+		c.JSON(http.StatusOK, gin.H{"access_token": token, "expires_at": expiresAt})
+	}
+}
+
+// claimUnlockAttempt 以一条条件 UPDATE 占用一次尝试：未锁定且已占用次数未达上限，或锁定已过期（此时计数从 1 重新开始）。
+// 尝试在比对密码前即计入，成功后再清零，因此同一时刻最多只有 5 次比对在进行。
+func claimUnlockAttempt(db *gorm.DB, share *models.Share, now time.Time) (bool, error) {
+	res := db.Model(&models.Share{}).
+		Where("id = ? AND ((unlock_locked_until IS NULL AND unlock_failures < ?) OR unlock_locked_until <= ?)", share.ID, shareUnlockMaxFailures, now).
+		Updates(map[string]any{
+			"unlock_failures":     gorm.Expr("CASE WHEN unlock_locked_until IS NULL THEN unlock_failures + 1 ELSE 1 END"),
+			"unlock_locked_until": nil,
+		})
+	return res.RowsAffected == 1, res.Error
+}
+
+// recordUnlockFailure 在已计入的错误尝试达到上限时锁定分享，计数保留到锁定过期后再重置；
+// 返回锁定截止时间（未锁定为 nil）与剩余可尝试次数。
+func recordUnlockFailure(db *gorm.DB, share *models.Share, now time.Time) (*time.Time, uint, error) {
+	lockedUntil := now.Add(shareUnlockLockout)
+	res := db.Model(&models.Share{}).
+		Where("id = ? AND unlock_locked_until IS NULL AND unlock_failures >= ?", share.ID, shareUnlockMaxFailures).
+		Update("unlock_locked_until", lockedUntil)
+	if res.Error != nil {
+		return nil, 0, res.Error
+	}
+	if res.RowsAffected > 0 {
+		return &lockedUntil, 0, nil
+	}
+	var current models.Share
+	if err := db.Select("unlock_failures", "unlock_locked_until").First(&current, share.ID).Error; err != nil {
+		return nil, 0, err
+	}
+	if current.UnlockLockedUntil != nil {
+		return current.UnlockLockedUntil, 0, nil
+	}
+	if current.UnlockFailures >= shareUnlockMaxFailures {
+		return nil, 0, nil
+	}
+	return nil, shareUnlockMaxFailures - current.UnlockFailures, nil
+}
+
+// writeUnlockRefused 在无法占用尝试时返回 429。尚未锁定说明其余尝试仍在比对中，稍后即可确定结果。
+func writeUnlockRefused(c *gin.Context, db *gorm.DB, share *models.Share, now time.Time) {
+	var current models.Share
+	if err := db.Select("unlock_locked_until").First(&current, share.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	until := now.Add(time.Second)
+	if current.UnlockLockedUntil != nil && current.UnlockLockedUntil.After(now) {
+		until = *current.UnlockLockedUntil
+	}
+	writeShareLocked(c, until, now)
+}
+
+func writeShareLocked(c *gin.Context, until, now time.Time) {
+	c.Header("Retry-After", strconv.Itoa(int(until.Sub(now).Seconds())+1))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "密码错误次数过多，请稍后再试", "locked_until": until})
+}
+
+// shareAccessKey 与登录 JWT 使用不同的签名密钥，避免分享令牌被当作用户身份接受。
+func shareAccessKey(cfg *config.Config) []byte {
+	return []byte("share-access|" + cfg.JWTSecret)
+}
+
+// shareAccessCookie 返回保存分享访问令牌的 Cookie 名称，按分享区分，同一浏览器可同时解锁多个分享。
+func shareAccessCookie(share *models.Share) string {
+	return fmt.Sprintf("share_access_%d", share.ID)
+}
+
+// sharePasswordTag 由密码哈希派生，写入令牌后可在修改密码时使旧令牌失效。
+func sharePasswordTag(share *models.Share) string {
+	sum := sha256.Sum256([]byte(share.PasswordHash))
+	return hex.EncodeToString(sum[:8])
+}
+
+func signShareAccess(cfg *config.Config, share *models.Share, now, expiresAt time.Time) (string, error) {
+	claims := shareAccessClaims{
+		ShareID:     share.ID,
+		PasswordTag: sharePasswordTag(share),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   share.Token,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(shareAccessKey(cfg))
+}
+
+// shareUnlocked 判断请求是否已通过分享密码：未设置密码、分享创建者本人，或通过请求头或 Cookie 携带了有效的访问令牌。
+func shareUnlocked(c *gin.Context, cfg *config.Config, share *models.Share, claims *middleware.Claims) bool {
+	if !share.HasPassword() {
+		return true
+	}
+	if claims != nil && claims.UserID == share.CreatorID {
+		return true
+	}
+	raw := c.GetHeader(shareAccessHeader)
+	if raw == "" {
+		raw, _ = c.Cookie(shareAccessCookie(share))
+	}
+	if raw == "" {
+		return false
+	}
+	var access shareAccessClaims
+	token, err := jwt.ParseWithClaims(raw, &access, func(t *jwt.Token) (any, error) {
+		return shareAccessKey(cfg), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		return false
+	}
+	return access.ShareID == share.ID && access.Subject == share.Token && access.PasswordTag == sharePasswordTag(share)
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"content-hub/server/config"
+	"content-hub/server/middleware"
+	"content-hub/server/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// callShare 调用分享接口；access 不为空时通过 X-Share-Access 头携带访问令牌。
+func callShare(h gin.HandlerFunc, method, token, access, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, "/api/shares/"+token, strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	if access != "" {
+		c.Request.Header.Set(shareAccessHeader, access)
+	}
+	c.Params = gin.Params{{Key: "token", Value: token}}
+	h(c)
+	return w
+}
+
+func createPasswordShare(t *testing.T, db *gorm.DB, cfg *config.Config, owner models.User, fileID uint, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/files/x/share", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(fileID)}}
+	c.Set("userID", owner.ID)
+	c.Set("role", owner.Role)
+	CreateShare(db, cfg)(c)
+	return w
+}
+
+func TestPasswordProtectedShare(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	cfg.JWTSecret = "secret"
+	owner := createUser(t, db, "owner", models.RoleUser)
+	f := persistTestFile(t, db, cfg, store, owner.ID, "plan.txt", "text/plain", []byte("secret plan"))
+
+	if w := createPasswordShare(t, db, cfg, owner, f.ID, `{"require_login":false,"password":"abc"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("short password status = %d, want 400", w.Code)
+	}
+	w := createPasswordShare(t, db, cfg, owner, f.ID, `{"require_login":false,"password":"open sesame"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("create share status = %d body=%s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Token            string `json:"share_token"`
+		RequiresPassword bool   `json:"requires_password"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	if !created.RequiresPassword {
+		t.Fatalf("create response should report password: %s", w.Body.String())
+	}
+	token := created.Token
+
+	// 未解锁时元信息与内容均要求密码，且不计入次数
+	w = callShare(GetShareMeta(db, cfg), http.MethodGet, token, "", "")
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "password_required") {
+		t.Fatalf("locked meta status = %d body=%s", w.Code, w.Body.String())
+	}
+	if w := callShare(StreamShare(db, cfg, store), http.MethodGet, token, "", ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("locked stream status = %d", w.Code)
+	}
+
+	unlock := UnlockShare(db, cfg)
+	w = callShare(unlock, http.MethodPost, token, "", `{"password":"wrong"}`)
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), `"remaining_attempts":4`) {
+		t.Fatalf("wrong password status = %d body=%s", w.Code, w.Body.String())
+	}
+	w = callShare(unlock, http.MethodPost, token, "", `{"password":"open sesame"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unlock status = %d body=%s", w.Code, w.Body.String())
+	}
+	var unlocked struct {
+		AccessToken string `json:"access_token"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &unlocked)
+	var accessCookie *http.Cookie
+	for _, ck := range w.Result().Cookies() {
+		if strings.HasPrefix(ck.Name, "share_access_") {
+			accessCookie = ck
+		}
+	}
+	if accessCookie == nil || !accessCookie.HttpOnly || accessCookie.Path != "/api/shares/" || accessCookie.Value != unlocked.AccessToken {
+		t.Fatalf("unlock should set a scoped HttpOnly access cookie, got %+v", accessCookie)
+	}
+
+	// 令牌只接受请求头或 Cookie，查询参数中的令牌会进入日志与浏览历史，不予接受
+	streamWith := func(target string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+		if cookie != nil {
+			c.Request.AddCookie(cookie)
+		}
+		c.Params = gin.Params{{Key: "token", Value: token}}
+		StreamShare(db, cfg, store)(c)
+		return w
+	}
+	if w := streamWith("/api/shares/"+token+"/stream?access_token="+unlocked.AccessToken, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("query access token status = %d, want 401", w.Code)
+	}
+	if w := streamWith("/api/shares/"+token+"/stream", accessCookie); w.Code != http.StatusOK || w.Body.String() != "secret plan" {
+		t.Fatalf("cookie access status = %d body=%q", w.Code, w.Body.String())
+	}
+
+	if w := callShare(GetShareMeta(db, cfg), http.MethodGet, token, unlocked.AccessToken, ""); w.Code != http.StatusOK {
+		t.Fatalf("unlocked meta status = %d body=%s", w.Code, w.Body.String())
+	}
+	w = callShare(StreamShare(db, cfg, store), http.MethodGet, token, unlocked.AccessToken, "")
+	if w.Code != http.StatusOK || w.Body.String() != "secret plan" {
+		t.Fatalf("unlocked stream status = %d body=%q", w.Code, w.Body.String())
+	}
+	// 访问令牌不能冒充登录身份
+	if _, err := middleware.ParseJWTClaims("Bearer "+unlocked.AccessToken, cfg); err == nil {
+		t.Fatal("share access token must not be accepted as a login token")
+	}
+	// 创建者登录后无需密码
+	login, _ := middleware.GenerateToken(owner.ID, owner.Role, cfg)
+	wr := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(wr)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/shares/"+token, nil)
+	c.Request.Header.Set("Authorization", "Bearer "+login)
+	c.Params = gin.Params{{Key: "token", Value: token}}
+	GetShareMeta(db, cfg)(c)
+	if wr.Code != http.StatusOK {
+		t.Fatalf("creator meta status = %d", wr.Code)
+	}
+
+	// 修改密码后旧令牌失效
+	var share models.Share
+	db.Where("token = ?", token).First(&share)
+	_ = share.SetPassword("new password")
+	db.Model(&share).Update("password_hash", share.PasswordHash)
+	if w := callShare(DownloadShare(db, cfg, store), http.MethodGet, token, unlocked.AccessToken, ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("stale access token status = %d, want 401", w.Code)
+	}
+}
+
+func TestShareUnlockLockout(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	cfg.JWTSecret = "secret"
+	owner := createUser(t, db, "owner", models.RoleUser)
+	f := persistTestFile(t, db, cfg, store, owner.ID, "a.txt", "text/plain", []byte("a"))
+	share := models.Share{Token: "locked-token", FileID: f.ID, CreatorID: owner.ID}
+	_ = share.SetPassword("correct horse")
+	if err := db.Create(&share).Error; err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+
+	unlock := UnlockShare(db, cfg)
+	for i := 1; i < shareUnlockMaxFailures; i++ {
+		if w := callShare(unlock, http.MethodPost, share.Token, "", `{"password":"guess"}`); w.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d status = %d", i, w.Code)
+		}
+	}
+	w := callShare(unlock, http.MethodPost, share.Token, "", `{"password":"guess"}`)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("lockout status = %d headers=%v", w.Code, w.Header())
+	}
+	// 锁定期间即使密码正确也拒绝
+	if w := callShare(unlock, http.MethodPost, share.Token, "", `{"password":"correct horse"}`); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("locked correct password status = %d, want 429", w.Code)
+	}
+
+	db.Model(&share).Update("unlock_locked_until", time.Now().Add(-time.Second))
+	if w := callShare(unlock, http.MethodPost, share.Token, "", `{"password":"correct horse"}`); w.Code != http.StatusOK {
+		t.Fatalf("unlock after lockout status = %d body=%s", w.Code, w.Body.String())
+	}
+	var reloaded models.Share
+	db.First(&reloaded, share.ID)
+	if reloaded.UnlockFailures != 0 || reloaded.UnlockLockedUntil != nil {
+		t.Fatalf("successful unlock should reset lockout state: failures=%d locked=%v", reloaded.UnlockFailures, reloaded.UnlockLockedUntil)
+	}
+	if w := callShare(unlock, http.MethodPost, "missing", "", `{"password":"x"}`); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown share status = %d", w.Code)
+	}
+}
+
+func TestShareUnlockLockoutConcurrent(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	cfg.JWTSecret = "secret"
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	owner := createUser(t, db, "owner", models.RoleUser)
+	f := persistTestFile(t, db, cfg, store, owner.ID, "a.txt", "text/plain", []byte("a"))
+	share := models.Share{Token: "burst-token", FileID: f.ID, CreatorID: owner.ID}
+	_ = share.SetPassword("correct horse")
+	if err := db.Create(&share).Error; err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+
+	// 一批并发请求中最多只有 5 次会进入密码比对，其余直接 429
+	const wrong = 2 * shareUnlockMaxFailures
+	unlock := UnlockShare(db, cfg)
+	codes := make([]int, wrong+1)
+	var wg sync.WaitGroup
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			password := "guess"
+			if i == wrong {
+				password = "correct horse"
+			}
+			codes[i] = callShare(unlock, http.MethodPost, share.Token, "", `{"password":"`+password+`"}`).Code
+		}()
+	}
+	wg.Wait()
+
+	checked := 0
+	for _, code := range codes {
+		if code != http.StatusTooManyRequests {
+			checked++
+		}
+	}
+	if checked > shareUnlockMaxFailures {
+		t.Fatalf("%d attempts were checked, want at most %d: %v", checked, shareUnlockMaxFailures, codes)
+	}
+	if codes[wrong] != http.StatusOK {
+		// 正确密码未能在锁定前通过时，分享必须保持锁定
+		if w := callShare(unlock, http.MethodPost, share.Token, "", `{"password":"correct horse"}`); w.Code != http.StatusTooManyRequests {
+			t.Fatalf("correct password after burst status = %d, want 429 (codes %v)", w.Code, codes)
+		}
+	}
+
+	// 锁定过期后计数重新开始，而不是沿用锁定前的次数
+	db.Model(&share).Updates(map[string]any{"unlock_failures": shareUnlockMaxFailures, "unlock_locked_until": time.Now().Add(-time.Second)})
+	w := callShare(unlock, http.MethodPost, share.Token, "", `{"password":"guess"}`)
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), fmt.Sprintf(`"remaining_attempts":%d`, shareUnlockMaxFailures-1)) {
+		t.Fatalf("attempt after lockout expiry status = %d body=%s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"content-hub/server/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxShareRecipients 限制单个分享的接收人（用户与用户组合计）数量。
+const maxShareRecipients = 100
+
+var (
+	errUnknownGroups     = errors.New("指定的用户组不存在")
+	errTooManyRecipients = errors.New("接收人数量超出上限")
+)
+
+// resolveShareRecipients 将用户名与用户组名转换为接收人记录，任一不存在时返回错误。
+func resolveShareRecipients(db *gorm.DB, usernames, groupNames []string) ([]models.ShareRecipient, error) {
+	usernames, groupNames = uniqueTrimmed(usernames), uniqueTrimmed(groupNames)
+	if len(usernames)+len(groupNames) > maxShareRecipients {
+		return nil, fmt.Errorf("%w：最多 %d 个", errTooManyRecipients, maxShareRecipients)
+	}
+	users, err := lookupUsers(db, usernames)
+	if err != nil {
+		return nil, err
+	}
+	var groups []models.UserGroup
+	if len(groupNames) > 0 {
+		if err := db.Where("name IN ?", groupNames).Find(&groups).Error; err != nil {
+			return nil, err
+		}
+		if len(groups) != len(groupNames) {
+			found := make(map[string]bool, len(groups))
+			for _, g := range groups {
+				found[g.Name] = true
+			}
+			var missing []string
+			for _, n := range groupNames {
+				if !found[n] {
+					missing = append(missing, n)
+				}
+			}
+			return nil, fmt.Errorf("%w: %s", errUnknownGroups, strings.Join(missing, ", "))
+		}
+	}
+
+	recipients := make([]models.ShareRecipient, 0, len(users)+len(groups))
+	for i := range users {
+		recipients = append(recipients, models.ShareRecipient{UserID: &users[i].ID, User: &users[i]})
+	}
+	for i := range groups {
+		recipients = append(recipients, models.ShareRecipient{GroupID: &groups[i].ID, Group: &groups[i]})
+	}
+	return recipients, nil
+}
+
+// shareRecipientNames 返回分享的接收用户名与用户组名，包含早期版本的单一接收人；需已预加载 AllowUser 与 Recipients。
+func shareRecipientNames(share *models.Share) (users, groups []string) {
+	users, groups = []string{}, []string{}
+	if share.AllowUser != nil {
+		users = append(users, share.AllowUser.Username)
+	}
+	for _, r := range share.Recipients {
+		switch {
+		case r.User != nil:
+			users = append(users, r.User.Username)
+		case r.Group != nil:
+			groups = append(groups, r.Group.Name)
+		}
+	}
+	return users, groups
+}
+
+// writeRecipientError 将 resolveShareRecipients 的错误写入响应：数量超限返回 400，接收人不存在返回 404。
+func writeRecipientError(c *gin.Context, err error) {
+	if errors.Is(err, errTooManyRecipients) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	writeLookupError(c, err)
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"content-hub/server/middleware"
+	"content-hub/server/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestShareRecipientsAndGroups(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	cfg.JWTSecret = "secret"
+	admin := createUser(t, db, "admin", models.RoleAdmin)
+	owner := createUser(t, db, "owner", models.RoleUser)
+	alice := createUser(t, db, "alice", models.RoleUser)
+	bob := createUser(t, db, "bob", models.RoleUser)
+	carol := createUser(t, db, "carol", models.RoleUser)
+	f := persistTestFile(t, db, cfg, store, owner.ID, "roadmap.txt", "text/plain", []byte("roadmap"))
+
+	w := callFileAs(CreateGroup(db), admin, http.MethodPost, 0, `{"name":"design","usernames":["bob"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("create group status = %d body=%s", w.Code, w.Body.String())
+	}
+	var group GroupResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &group)
+	if group.MemberCount != 1 {
+		t.Fatalf("group members = %+v", group)
+	}
+	if w := callFileAs(CreateGroup(db), admin, http.MethodPost, 0, `{"name":"design"}`); w.Code != http.StatusConflict {
+		t.Fatalf("duplicate group status = %d, want 409", w.Code)
+	}
+
+	// 普通文件所有者即可限定接收人，未知的用户组返回 404
+	if w := createPasswordShare(t, db, cfg, owner, f.ID, `{"allow_groups":["missing"]}`); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown group status = %d, want 404", w.Code)
+	}
+	w = createPasswordShare(t, db, cfg, owner, f.ID, `{"require_login":false,"allow_usernames":["alice"],"allow_groups":["design"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("create share status = %d body=%s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Token         string   `json:"share_token"`
+		RequiresLogin bool     `json:"requires_login"`
+		Users         []string `json:"allow_usernames"`
+		Groups        []string `json:"allow_groups"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	if !created.RequiresLogin || len(created.Users) != 1 || len(created.Groups) != 1 {
+		t.Fatalf("create response = %s", w.Body.String())
+	}
+
+	metaAs := func(u *models.User) int {
+		wr := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(wr)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/shares/"+created.Token, nil)
+		if u != nil {
+			token, _ := middleware.GenerateToken(u.ID, u.Role, cfg)
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+		c.Params = gin.Params{{Key: "token", Value: created.Token}}
+		GetShareMeta(db, cfg)(c)
+		return wr.Code
+	}
+	for _, tc := range []struct {
+		user *models.User
+		want int
+	}{
+		{nil, http.StatusUnauthorized},
+		{&alice, http.StatusOK},
+		{&bob, http.StatusOK},
+		{&carol, http.StatusForbidden},
+		{&owner, http.StatusOK},
+	} {
+		if code := metaAs(tc.user); code != tc.want {
+			t.Fatalf("meta as %v status = %d, want %d", tc.user, code, tc.want)
+		}
+	}
+
+	// 成员变更即时生效
+	if w := callFileAs(UpdateGroup(db), admin, http.MethodPatch, group.ID, `{"usernames":["carol"]}`); w.Code != http.StatusOK {
+		t.Fatalf("update group status = %d body=%s", w.Code, w.Body.String())
+	}
+	if metaAs(&bob) != http.StatusForbidden || metaAs(&carol) != http.StatusOK {
+		t.Fatal("group membership change not applied")
+	}
+
+	wr := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(wr)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/admin/shares", nil)
+	ListShares(db)(c)
+	var list ShareListResponse
+	_ = json.Unmarshal(wr.Body.Bytes(), &list)
+	if len(list.Items) != 1 || len(list.Items[0].AllowUsernames) != 1 || list.Items[0].AllowUsernames[0] != "alice" ||
+		len(list.Items[0].AllowGroups) != 1 || list.Items[0].AllowGroups[0] != "design" {
+		t.Fatalf("list recipients = %s", wr.Body.String())
+	}
+
+	// 删除用户组后分享仍保持受限，不会退化为所有登录用户可见
+	if w := callFileAs(DeleteGroup(db), admin, http.MethodDelete, group.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf("delete group status = %d", w.Code)
+	}
+	if metaAs(&carol) != http.StatusForbidden || metaAs(&alice) != http.StatusOK {
+		t.Fatal("share must stay restricted after its group is deleted")
+	}
+}
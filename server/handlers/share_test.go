@@ -12,6 +12,7 @@ import (
 
 	"content-hub/server/config"
 	"content-hub/server/models"
+	"content-hub/server/storage"
 	"github.com/gin-gonic/gin"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -26,7 +27,7 @@ func TestStreamShareMissingFile(t *testing.T) {
 	if err != nil {
 		t.Fatalf("open db: %v", err)
 	}
-	if err := db.AutoMigrate(&models.User{}, &models.File{}, &models.Share{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.File{}, &models.Share{}, &models.ShareRecipient{}, &models.ShareAccess{}, &models.ShareItem{}); err != nil {
 		t.Fatalf("migrate: %v", err)
 	}
 
@@ -65,7 +66,7 @@ func TestStreamShareMissingFile(t *testing.T) {
 	c.Request = req
 	c.Params = gin.Params{{Key: "token", Value: share.Token}}
 
-	StreamShare(db, cfg)(c)
+	StreamShare(db, cfg, storage.NewLocal(""))(c)
 
 	if w.Code != http.StatusNotFound {
 		t.Fatalf("expected 404, got %d, body=%s", w.Code, w.Body.String())
@@ -98,7 +99,7 @@ func TestDownloadShareConsumesView(t *testing.T) {
 	if err != nil {
 		t.Fatalf("open db: %v", err)
 	}
-	if err := db.AutoMigrate(&models.User{}, &models.File{}, &models.Share{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.File{}, &models.Share{}, &models.ShareRecipient{}, &models.ShareAccess{}, &models.ShareItem{}); err != nil {
 		t.Fatalf("migrate: %v", err)
 	}
 
@@ -130,7 +131,7 @@ func TestDownloadShareConsumesView(t *testing.T) {
 	req1 := httptest.NewRequest(http.MethodGet, "/api/shares/download-token/download", nil)
 	c1.Request = req1
 	c1.Params = gin.Params{{Key: "token", Value: share.Token}}
-	DownloadShare(db, cfg)(c1)
+	DownloadShare(db, cfg, storage.NewLocal(""))(c1)
 
 	if w1.Code != http.StatusOK {
 		t.Fatalf("first download status = %d, body=%s", w1.Code, w1.Body.String())
@@ -153,7 +154,7 @@ func TestDownloadShareConsumesView(t *testing.T) {
 	req2 := httptest.NewRequest(http.MethodGet, "/api/shares/download-token/download", nil)
 	c2.Request = req2
 	c2.Params = gin.Params{{Key: "token", Value: share.Token}}
-	DownloadShare(db, cfg)(c2)
+	DownloadShare(db, cfg, storage.NewLocal(""))(c2)
 
 	if w2.Code != http.StatusOK {
 		t.Fatalf("second download status = %d", w2.Code)
@@ -172,7 +173,7 @@ func TestDownloadShareConsumesView(t *testing.T) {
 	req3 := httptest.NewRequest(http.MethodGet, "/api/shares/download-token/download", nil)
 	c3.Request = req3
 	c3.Params = gin.Params{{Key: "token", Value: share.Token}}
-	DownloadShare(db, cfg)(c3)
+	DownloadShare(db, cfg, storage.NewLocal(""))(c3)
 
 	if w3.Code != http.StatusGone {
 		t.Fatalf("expected 410 when limit reached, got %d", w3.Code)
@@ -194,7 +195,7 @@ func TestCleanShares(t *testing.T) {
 	if err != nil {
 		t.Fatalf("open db: %v", err)
 	}
-	if err := db.AutoMigrate(&models.User{}, &models.File{}, &models.Share{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.File{}, &models.Share{}, &models.ShareRecipient{}, &models.ShareAccess{}, &models.ShareItem{}); err != nil {
 		t.Fatalf("migrate: %v", err)
 	}
 
@@ -238,7 +239,7 @@ func TestCleanShares(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	c.Request = req
 
-	CleanShares(db)(c)
+	CleanShares(db, storage.NewLocal(""))(c)
 
 	if w.Code != http.StatusOK {
 		t.Fatalf("cleanup status = %d, body=%s", w.Code, w.Body.String())
@@ -262,3 +263,116 @@ func TestCleanShares(t *testing.T) {
 		t.Fatalf("live share removed unexpectedly")
 	}
 }
+
+// 验证 Range 续读策略：首次（含从 0 开始的 Range）计数，同一客户端的后续续读不计数且在额度用尽后仍可拖动；
+// HEAD 与 304 条件请求不计数，其他客户端的续读不能绕过额度。
+func TestStreamShareRangePolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "range.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.File{}, &models.Share{}, &models.ShareRecipient{}, &models.ShareAccess{}, &models.ShareItem{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	owner := models.User{Username: "owner", Role: models.RoleUser, PasswordHash: "x"}
+	_ = db.Create(&owner).Error
+
+	filePath := filepath.Join(t.TempDir(), "video.bin")
+	if err := os.WriteFile(filePath, []byte("0123456789"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	file := models.File{OwnerID: owner.ID, Filename: "video.bin", Path: filePath, Size: 10, MimeType: "video/mp4"}
+	_ = db.Create(&file).Error
+	maxViews := uint(1)
+	share := models.Share{Token: "range-token", FileID: file.ID, CreatorID: owner.ID, MaxViews: &maxViews}
+	_ = db.Create(&share).Error
+
+	cfg := &config.Config{JWTSecret: "test-secret"}
+	store := storage.NewLocal("")
+	// 通过路由分发，确保 304 等无响应体的状态码会真正写出
+	router := gin.New()
+	router.GET("/api/shares/:token/stream", StreamShare(db, cfg, store))
+	router.HEAD("/api/shares/:token/stream", StreamShare(db, cfg, store))
+	call := func(method string, headers map[string]string, remoteAddr string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/api/shares/range-token/stream", nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		if remoteAddr != "" {
+			req.RemoteAddr = remoteAddr
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+	viewCount := func() uint {
+		var s models.Share
+		_ = db.First(&s, share.ID).Error
+		return s.ViewCount
+	}
+
+	// HEAD 只返回头部，不计数
+	w := call(http.MethodHead, nil, "")
+	if w.Code != http.StatusOK || w.Header().Get("Accept-Ranges") != "bytes" || w.Body.Len() != 0 {
+		t.Fatalf("unexpected HEAD response: %d headers=%v body=%q", w.Code, w.Header(), w.Body.String())
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" || viewCount() != 0 {
+		t.Fatalf("HEAD should expose ETag without counting, etag=%q views=%d", etag, viewCount())
+	}
+
+	// 命中缓存的条件请求返回 304，不计数
+	w = call(http.MethodGet, map[string]string{"If-None-Match": etag}, "")
+	if w.Code != http.StatusNotModified || viewCount() != 0 {
+		t.Fatalf("expected 304 without counting, got %d views=%d", w.Code, viewCount())
+	}
+
+	// 起点超出文件末尾的 Range 返回 416，不消耗额度
+	for _, r := range []string{"bytes=10-", "bytes=4096-", "bytes=20-30, 40-"} {
+		w = call(http.MethodGet, map[string]string{"Range": r}, "")
+		if w.Code != http.StatusRequestedRangeNotSatisfiable || w.Header().Get("Content-Range") != "bytes */10" || viewCount() != 0 {
+			t.Fatalf("range %q beyond EOF: status=%d content-range=%q views=%d", r, w.Code, w.Header().Get("Content-Range"), viewCount())
+		}
+	}
+
+	// 从 0 开始的 Range 计为一次访问
+	w = call(http.MethodGet, map[string]string{"Range": "bytes=0-3"}, "")
+	if w.Code != http.StatusPartialContent || w.Body.String() != "0123" {
+		t.Fatalf("expected 206 with first 4 bytes, got %d %q", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Content-Range") != "bytes 0-3/10" || viewCount() != 1 {
+		t.Fatalf("unexpected content-range %q views=%d", w.Header().Get("Content-Range"), viewCount())
+	}
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || !cookies[0].HttpOnly {
+		t.Fatalf("counted view should issue an HttpOnly resume cookie, got %v", cookies)
+	}
+	resumeCookie := cookies[0].Name + "=" + cookies[0].Value
+
+	// 携带续读凭证：额度已用尽仍可继续，且不计数
+	w = call(http.MethodGet, map[string]string{"Range": "bytes=6-", "Cookie": resumeCookie}, "")
+	if w.Code != http.StatusPartialContent || w.Body.String() != "6789" || viewCount() != 1 {
+		t.Fatalf("continuation should be free, got %d %q views=%d", w.Code, w.Body.String(), viewCount())
+	}
+
+	// 没有凭证的续读（同一 IP 或其他客户端）无法绕过已用尽的额度
+	for _, addr := range []string{"", "198.51.100.7:5555"} {
+		w = call(http.MethodGet, map[string]string{"Range": "bytes=1-"}, addr)
+		if w.Code != http.StatusGone || viewCount() != 1 {
+			t.Fatalf("continuation without cookie from %q should be rejected once exhausted, got %d views=%d", addr, w.Code, viewCount())
+		}
+	}
+	// 伪造或属于其他分享的凭证同样无效
+	w = call(http.MethodGet, map[string]string{"Range": "bytes=1-", "Cookie": resumeCookie + "x"}, "")
+	if w.Code != http.StatusGone {
+		t.Fatalf("tampered resume cookie should be rejected, got %d", w.Code)
+	}
+
+	// 非法 Range 头直接拒绝
+	w = call(http.MethodGet, map[string]string{"Range": "items=0-1"}, "")
+	if w.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Fatalf("expected 416 for malformed range, got %d", w.Code)
+	}
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"content-hub/server/config"
+	"content-hub/server/highlight"
+	"content-hub/server/models"
+	"content-hub/server/storage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxHighlightBytes 是服务端渲染高亮的内容上限，更大的文本请使用 /raw。
+const maxHighlightBytes = 1 << 20
+
+// StreamShareRaw 以 text/plain 内联返回文本分享的原始内容，便于在浏览器中直接查看或用 curl 获取；
+// HTML、SVG 等文本同样按纯文本返回，不会被浏览器渲染。计数、Range 与条件请求规则同 /shares/{token}/stream。
+// @Summary 获取文本分享的原始内容
+// @Description 仅文本分享可用，非文本返回 415。计数规则同 /shares/{token}/stream。
+// @Tags shares
+// @Produce plain
+// @Param token path string true "分享 Token"
+// @Param Range header string false "字节范围，例如 bytes=0-1023"
+// @Security BearerAuth
+// @Router /shares/{token}/raw [get]
+func StreamShareRaw(db *gorm.DB, cfg *config.Config, store storage.Driver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		share, err := loadShare(db, c.Param("token"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
+			return
+		}
+		claims, err := parseOptionalClaims(c, cfg)
+		defer recordShareAccess(db, c, share, share.FileID, claims, "inline")
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+		if !textShare(c, share) {
+			return
+		}
+		raw := share.File
+		raw.MimeType, raw.DeclaredMimeType, raw.DetectedMimeType = "text/plain; charset=utf-8", "", ""
+		serveShareFile(c, db, cfg, store, share, claims, &raw, "inline")
+	}
+}
+
+// GetShareHighlight 返回文本分享经语法高亮后的完整 HTML 页面，计入 1 次浏览。页面只含转义后的文本与内联样式，
+// 并以 CSP 禁止脚本与外部资源，可直接打开或嵌入 iframe。超过 1 MB 的文本返回 413，不消耗浏览次数。
+// @Summary 获取文本分享的高亮页面
+// @Description language 省略时使用上传时识别或指定的语言。访问规则同 /shares/{token}/stream，每次请求计 1 次浏览。
+// @Tags shares
+// @Produce html
+// @Param token path string true "分享 Token"
+// @Param language query string false "覆盖语言，如 go、python、json"
+// @Security BearerAuth
+// @Router /shares/{token}/highlight [get]
+func GetShareHighlight(db *gorm.DB, cfg *config.Config, store storage.Driver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		share, err := loadShare(db, c.Param("token"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "share not found"})
+			return
+		}
+		claims, err := parseOptionalClaims(c, cfg)
+		defer recordShareAccess(db, c, share, share.FileID, claims, "inline")
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+		if !textShare(c, share) {
+			return
+		}
+		f := &share.File
+		lang := shareLanguage(f)
+		if v := c.Query("language"); v != "" {
+			var ok bool
+			if lang, ok = highlight.Normalize(v); !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("不支持的语言 %q", v)})
+				return
+			}
+		}
+
+		if !checkShareAccess(c, db, cfg, share, claims, true) || !checkScanStatus(c, f) {
+			return
+		}
+		info, err := store.Stat(c.Request.Context(), f.Path)
+		if err != nil {
+			writeStorageError(c, err)
+			return
+		}
+		if info.Size > maxHighlightBytes {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error":    "文本过大，无法高亮显示，请查看原始内容",
+				"raw_path": fmt.Sprintf("/api/shares/%s/raw", c.Param("token")),
+			})
+			return
+		}
+		if err := consumeView(db, share); err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, errShareLimitReached) {
+				status = http.StatusGone
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		grantShareResume(c, cfg, share, time.Now())
+
+		rc, err := store.Get(c.Request.Context(), f.Path)
+		if err != nil {
+			writeStorageError(c, err)
+			return
+		}
+		defer rc.Close()
+		src, err := io.ReadAll(io.LimitReader(rc, maxHighlightBytes))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+		c.Header("Cache-Control", "private, no-store")
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(highlight.Page(f.Filename, lang, string(src))))
+	}
+}
+
+// textShare 确认分享为单个文本文件，失败时已写入响应。
+func textShare(c *gin.Context, share *models.Share) bool {
+	if share.Bundle {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "打包分享请预览其中的单个文件"})
+		return false
+	}
+	if !isTextMime(share.File.MimeType) {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "该分享不是文本内容"})
+		return false
+	}
+	return true
+}
+
+// shareLanguage 返回文件的语言；语言功能上线前上传的文件没有记录，按文件名识别。
+func shareLanguage(f *models.File) string {
+	if f.Language != "" {
+		return f.Language
+	}
+	return highlight.Detect(f.Filename, nil)
+}
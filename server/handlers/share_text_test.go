@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"content-hub/server/models"
+	"github.com/gin-gonic/gin"
+)
+
+// callShareQuery 以 GET 调用分享接口，可附带查询串与 Range 头。
+func callShareQuery(h gin.HandlerFunc, token, query, rangeHeader string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/shares/"+token+"?"+query, nil)
+	if rangeHeader != "" {
+		c.Request.Header.Set("Range", rangeHeader)
+	}
+	c.Params = gin.Params{{Key: "token", Value: token}}
+	h(c)
+	return w
+}
+
+func TestUploadDetectsLanguage(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	owner := createUser(t, db, "owner", models.RoleUser)
+
+	uploadText(t, db, cfg, store, owner.ID, "package main\n\nfunc main() {}\n", "snippet")
+	var pasted models.File
+	if err := db.Where("description = ?", "snippet").First(&pasted).Error; err != nil {
+		t.Fatalf("load pasted: %v", err)
+	}
+	if pasted.Language != "go" {
+		t.Fatalf("pasted language = %q, want go", pasted.Language)
+	}
+
+	w := uploadMultipartFields(t, db, cfg, store, owner.ID, "notes.txt", []byte("SELECT 1 FROM t;"), map[string]string{"language": "Py"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("upload status = %d body=%s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		ID uint `json:"id"`
+	}
+	var f models.File
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || db.First(&f, resp.ID).Error != nil {
+		t.Fatalf("load upload: %s", w.Body.String())
+	}
+	if f.Language != "python" {
+		t.Fatalf("explicit language = %q, want python", f.Language)
+	}
+	if w := uploadMultipartFields(t, db, cfg, store, owner.ID, "x.txt", []byte("x"), map[string]string{"language": "cobol"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("unsupported language status = %d, want 400", w.Code)
+	}
+
+	// 二进制内容不记录语言，也不能手动设置
+	w = uploadMultipart(t, db, cfg, store, owner.ID, "blob.bin", []byte{0x00, 0x01, 0x02, 0xff})
+	f = models.File{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || db.First(&f, resp.ID).Error != nil {
+		t.Fatalf("load upload: %s", w.Body.String())
+	}
+	if f.Language != "" {
+		t.Fatalf("binary language = %q", f.Language)
+	}
+	if w := callFileAs(UpdateFile(db), owner, http.MethodPatch, resp.ID, `{"language":"go"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("set language on binary status = %d, want 400", w.Code)
+	}
+	if w := callFileAs(UpdateFile(db), owner, http.MethodPatch, pasted.ID, `{"language":"rust"}`); w.Code != http.StatusOK {
+		t.Fatalf("update language status = %d body=%s", w.Code, w.Body.String())
+	}
+	if err := db.First(&pasted, pasted.ID).Error; err != nil || pasted.Language != "rust" {
+		t.Fatalf("language after update = %q, err=%v", pasted.Language, err)
+	}
+}
+
+func TestShareRawAndHighlight(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	cfg.JWTSecret = "secret"
+	owner := createUser(t, db, "owner", models.RoleUser)
+	page := persistTestFile(t, db, cfg, store, owner.ID, "page.html", "text/html", []byte(`<script>alert(1)</script>`))
+	img := persistTestFile(t, db, cfg, store, owner.ID, "a.png", "image/png", testPNG(t, 4, 4))
+
+	var created struct {
+		Token string `json:"share_token"`
+	}
+	w := createPasswordShare(t, db, cfg, owner, page.ID, `{"require_login":false}`)
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode share: %v", err)
+	}
+
+	w = callShare(GetShareMeta(db, cfg), http.MethodGet, created.Token, "", "")
+	var meta map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &meta); err != nil {
+		t.Fatalf("decode meta: %v", err)
+	}
+	if meta["language"] != "html" || meta["highlight_path"] != "/api/shares/"+created.Token+"/highlight" {
+		t.Fatalf("meta = %v", meta)
+	}
+
+	// 原始内容始终为内联纯文本，HTML 不会被浏览器渲染
+	w = callShare(StreamShareRaw(db, cfg, store), http.MethodGet, created.Token, "", "")
+	if w.Code != http.StatusOK || w.Body.String() != `<script>alert(1)</script>` {
+		t.Fatalf("raw status = %d body=%s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Fatalf("raw content type = %q", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, "inline") {
+		t.Fatalf("raw disposition = %q", cd)
+	}
+
+	w = callShare(GetShareHighlight(db, cfg, store), http.MethodGet, created.Token, "", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("highlight status = %d body=%s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if strings.Contains(body, "<script>") || !strings.Contains(body, `<span class="t">&lt;script</span>`) {
+		t.Fatalf("highlight body = %s", body)
+	}
+	if csp := w.Header().Get("Content-Security-Policy"); !strings.Contains(csp, "default-src 'none'") {
+		t.Fatalf("highlight csp = %q", csp)
+	}
+	if w := callShareQuery(GetShareHighlight(db, cfg, store), created.Token, "language=nope", ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("unsupported language status = %d, want 400", w.Code)
+	}
+	w = callShareQuery(GetShareHighlight(db, cfg, store), created.Token, "language=text", "")
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), `class="t"`) {
+		t.Fatalf("plain text highlight status = %d body=%s", w.Code, w.Body.String())
+	}
+
+	var share models.Share
+	if err := db.Where("token = ?", created.Token).First(&share).Error; err != nil {
+		t.Fatalf("load share: %v", err)
+	}
+	if share.ViewCount != 3 {
+		t.Fatalf("view_count = %d, want 3", share.ViewCount)
+	}
+
+	w = createPasswordShare(t, db, cfg, owner, img.ID, `{"require_login":false}`)
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode share: %v", err)
+	}
+	if w := callShare(StreamShareRaw(db, cfg, store), http.MethodGet, created.Token, "", ""); w.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("raw image status = %d, want 415", w.Code)
+	}
+}
+
+func TestBurnAfterReadingShare(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	cfg.JWTSecret = "secret"
+	owner := createUser(t, db, "owner", models.RoleUser)
+	f := persistTestFile(t, db, cfg, store, owner.ID, "secret.txt", "text/plain", []byte("the password is hunter2"))
+
+	if w := createPasswordShare(t, db, cfg, owner, f.ID, `{"require_login":false,"burn_after_reading":true,"max_views":3}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("burn with max_views=3 status = %d, want 400", w.Code)
+	}
+	w := createPasswordShare(t, db, cfg, owner, f.ID, `{"require_login":false,"burn_after_reading":true}`)
+	var created struct {
+		Token    string `json:"share_token"`
+		MaxViews *uint  `json:"max_views"`
+		Burn     bool   `json:"burn_after_reading"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode share: %v", err)
+	}
+	if !created.Burn || created.MaxViews == nil || *created.MaxViews != 1 {
+		t.Fatalf("created = %+v", created)
+	}
+
+	// 元信息与缩略图都不消耗次数，但缩略图会泄露内容，阅后即焚时不提供
+	if w := callShare(GetShareMeta(db, cfg), http.MethodGet, created.Token, "", ""); w.Code != http.StatusOK {
+		t.Fatalf("meta status = %d", w.Code)
+	}
+	if w := callShare(GetShareThumbnail(db, cfg, store), http.MethodGet, created.Token, "", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("thumbnail status = %d, want 404", w.Code)
+	}
+	if w := callManagedShare(UpdateShare(db, cfg), owner, http.MethodPatch, created.Token, `{"max_views":5}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("update max_views status = %d, want 400", w.Code)
+	}
+
+	w = callShare(GetShareHighlight(db, cfg, store), http.MethodGet, created.Token, "", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "hunter2") {
+		t.Fatalf("first read status = %d body=%s", w.Code, w.Body.String())
+	}
+
+	// 读过之后任何入口都不能再取到内容，续读窗口也不放行
+	for name, w := range map[string]*httptest.ResponseRecorder{
+		"meta":      callShare(GetShareMeta(db, cfg), http.MethodGet, created.Token, "", ""),
+		"highlight": callShare(GetShareHighlight(db, cfg, store), http.MethodGet, created.Token, "", ""),
+		"raw":       callShareQuery(StreamShareRaw(db, cfg, store), created.Token, "", "bytes=4-"),
+	} {
+		if w.Code != http.StatusGone {
+			t.Fatalf("%s after burn status = %d, want 410", name, w.Code)
+		}
+		var resp struct {
+			Burned bool `json:"burned"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || !resp.Burned {
+			t.Fatalf("%s after burn body = %s", name, w.Body.String())
+		}
+	}
+
+	// 首次请求带 Range 时仍返回完整内容，不能只消耗掉唯一的一次查看
+	w = createPasswordShare(t, db, cfg, owner, f.ID, `{"require_login":false,"burn_after_reading":true}`)
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode share: %v", err)
+	}
+	w = callShareQuery(StreamShare(db, cfg, store), created.Token, "", "bytes=4-")
+	if w.Code != http.StatusOK || w.Body.String() != "the password is hunter2" {
+		t.Fatalf("ranged burn read status = %d body=%q", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"content-hub/server/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	maxTagsPerFile       = 50
+	defaultTagSuggestNum = 10
+	maxTagSuggestNum     = 50
+)
+
+var errTooManyTags = errors.New("单个文件最多 50 个标签")
+
+// AddFileTagsRequest 为文件添加标签；global 仅管理员可用，表示使用（必要时创建）全局标签。
+type AddFileTagsRequest struct {
+	Tags   []string `json:"tags" binding:"required,min=1"`
+	Global bool     `json:"global"`
+}
+
+// TagResponse 是标签联想的结果，file_count 为当前用户自己的文件中使用该标签的数量。
+type TagResponse struct {
+	Name      string `json:"name"`
+	Global    bool   `json:"global"`
+	FileCount int64  `json:"file_count"`
+}
+
+// AddFileTags 为文件添加一个或多个标签，标签按文件所有者的个人标签创建，已存在同名全局标签时直接复用。
+// @Summary 添加文件标签
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path int true "文件ID"
+// @Param payload body AddFileTagsRequest true "标签名列表"
+// @Success 200 {object} FileResponse
+// @Security BearerAuth
+// @Router /files/{id}/tags [post]
+func AddFileTags(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req AddFileTagsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		_, role := currentUser(c)
+		if req.Global && role != models.RoleAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "仅管理员可使用全局标签"})
+			return
+		}
+		names := make([]string, 0, len(req.Tags))
+		seen := map[string]bool{}
+		for _, raw := range req.Tags {
+			name, err := models.NormalizeTagName(raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "tag": raw})
+				return
+			}
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+
+		f, ok := loadOwnedFile(c, db)
+		if !ok {
+			return
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, name := range names {
+				tag, err := models.ResolveTag(tx, f.OwnerID, name, req.Global)
+				if err != nil {
+					return err
+				}
+				link := models.FileTag{FileID: f.ID, TagID: tag.ID}
+				if err := tx.Where(link).FirstOrCreate(&link).Error; err != nil {
+					return err
+				}
+			}
+			var count int64
+			if err := tx.Model(&models.FileTag{}).Where("file_id = ?", f.ID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > maxTagsPerFile {
+				return errTooManyTags
+			}
+			return nil
+		})
+		if errors.Is(err, errTooManyTags) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		writeTaggedFile(c, db, f)
+	}
+}
+
+// RemoveFileTag 移除文件上的标签；个人标签不再被任何文件使用时一并删除，避免联想列表堆积无用标签。
+// @Summary 移除文件标签
+// @Tags tags
+// @Produce json
+// @Param id path int true "文件ID"
+// @Param tag path string true "标签名"
+// @Success 200 {object} FileResponse
+// @Security BearerAuth
+// @Router /files/{id}/tags/{tag} [delete]
+func RemoveFileTag(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name, err := models.NormalizeTagName(c.Param("tag"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		f, ok := loadOwnedFile(c, db)
+		if !ok {
+			return
+		}
+		var tagIDs []uint
+		if err := db.Model(&models.FileTag{}).
+			Joins("JOIN tags ON tags.id = file_tags.tag_id").
+			Where("file_tags.file_id = ? AND tags.name = ?", f.ID, name).
+			Pluck("file_tags.tag_id", &tagIDs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(tagIDs) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "文件没有该标签"})
+			return
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("file_id = ? AND tag_id IN ?", f.ID, tagIDs).Delete(&models.FileTag{}).Error; err != nil {
+				return err
+			}
+			return tx.Where("id IN ? AND owner_id IS NOT NULL AND id NOT IN (?)", tagIDs,
+				tx.Model(&models.FileTag{}).Select("tag_id")).Delete(&models.Tag{}).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		writeTaggedFile(c, db, f)
+	}
+}
+
+// ListTags 按前缀联想当前用户可用的标签（个人标签与全局标签），按使用次数降序排列。
+// @Summary 标签联想
+// @Tags tags
+// @Produce json
+// @Param q query string false "标签名前缀"
+// @Param limit query int false "返回数量，默认 10，最大 50"
+// @Success 200 {array} TagResponse
+// @Security BearerAuth
+// @Router /tags [get]
+func ListTags(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := defaultTagSuggestNum
+		if v := c.Query("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit 需为正整数"})
+				return
+			}
+			limit = min(n, maxTagSuggestNum)
+		}
+		userID, _ := currentUser(c)
+		query := db.Model(&models.Tag{}).
+			Select("tags.name AS name, MAX(CASE WHEN tags.owner_id IS NULL THEN 1 ELSE 0 END) AS global, COUNT(files.id) AS file_count").
+			Joins("LEFT JOIN file_tags ON file_tags.tag_id = tags.id").
+			Joins("LEFT JOIN files ON files.id = file_tags.file_id AND files.deleted_at IS NULL AND files.owner_id = ?", userID).
+			Scopes(models.TagsUsableBy(userID))
+		if prefix := strings.TrimSpace(c.Query("q")); prefix != "" {
+			query = query.Where(`tags.name LIKE ? ESCAPE '\'`, models.EscapeLike(strings.ToLower(prefix))+"%")
+		}
+		tags := []TagResponse{}
+		if err := query.Group("tags.name").Order("file_count DESC").Order("tags.name").Limit(limit).Scan(&tags).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, tags)
+	}
+}
+
+// writeTaggedFile 返回带最新标签的文件信息。
+func writeTaggedFile(c *gin.Context, db *gorm.DB, f *models.File) {
+	resp := []FileResponse{buildFileResponse(f)}
+	if err := fillFileTags(db, resp); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, resp[0])
+}
+
+// fillFileTags 为一批文件响应批量填充标签，避免逐条查询。
+func fillFileTags(db *gorm.DB, items []FileResponse) error {
+	ids := make([]uint, 0, len(items))
+	for _, it := range items {
+		ids = append(ids, it.ID)
+	}
+	names, err := models.TagNamesByFile(db, ids)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		items[i].Tags = names[items[i].ID]
+	}
+	return nil
+}
+
+// tagFilter 按 tag 参数（可重复，需同时满足）过滤文件；管理员匹配任意用户的同名标签。
+func tagFilter(c *gin.Context, db, query *gorm.DB) (*gorm.DB, bool) {
+	userID, role := currentUser(c)
+	if role == models.RoleAdmin {
+		userID = 0
+	}
+	for _, raw := range c.QueryArray("tag") {
+		name, err := models.NormalizeTagName(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		query = query.Where("files.id IN (?)", models.TaggedFileIDs(db, name, userID))
+	}
+	return query, true
+}
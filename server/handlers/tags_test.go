@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"content-hub/server/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func removeTagAs(db *gorm.DB, user models.User, fileID uint, tag string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodDelete, "/api/files/x/tags/x", nil)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(fileID)}, {Key: "tag", Value: tag}}
+	c.Set("userID", user.ID)
+	c.Set("role", user.Role)
+	RemoveFileTag(db)(c)
+	return w
+}
+
+func suggestTags(t *testing.T, db *gorm.DB, user models.User, prefix string) []TagResponse {
+	t.Helper()
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/tags?q="+url.QueryEscape(prefix), nil)
+	c.Set("userID", user.ID)
+	c.Set("role", user.Role)
+	ListTags(db)(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("list tags status = %d body=%s", w.Code, w.Body.String())
+	}
+	var tags []TagResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &tags)
+	return tags
+}
+
+func TestFileTags(t *testing.T) {
+	db, _, _ := setupFileTestEnv(t)
+	owner := createUser(t, db, "owner", models.RoleUser)
+	other := createUser(t, db, "other", models.RoleUser)
+	admin := createUser(t, db, "root", models.RoleAdmin)
+
+	files := []models.File{
+		{Filename: "spec.md", OwnerID: owner.ID},
+		{Filename: "logo.png", OwnerID: owner.ID},
+		{Filename: "notes.txt", OwnerID: other.ID, Visibility: models.VisibilityPublic},
+	}
+	for i := range files {
+		if err := db.Create(&files[i]).Error; err != nil {
+			t.Fatalf("create file: %v", err)
+		}
+	}
+
+	add := AddFileTags(db)
+	w := callFileAs(add, owner, http.MethodPost, files[0].ID, `{"tags":["Project X","design","project  x"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("add tags status = %d body=%s", w.Code, w.Body.String())
+	}
+	var resp FileResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if fmt.Sprint(resp.Tags) != "[design project x]" {
+		t.Fatalf("tags should be normalized and deduplicated, got %v", resp.Tags)
+	}
+	if w := callFileAs(add, owner, http.MethodPost, files[1].ID, `{"tags":["project x"]}`); w.Code != http.StatusOK {
+		t.Fatalf("add tag to second file status = %d", w.Code)
+	}
+	// 他人的文件、非法标签与普通用户创建全局标签都应被拒绝
+	if w := callFileAs(add, owner, http.MethodPost, files[2].ID, `{"tags":["mine"]}`); w.Code != http.StatusNotFound {
+		t.Fatalf("tagging others' file status = %d, want 404", w.Code)
+	}
+	if w := callFileAs(add, owner, http.MethodPost, files[0].ID, `{"tags":["a,b"]}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid tag status = %d, want 400", w.Code)
+	}
+	if w := callFileAs(add, other, http.MethodPost, files[2].ID, `{"tags":["release"],"global":true}`); w.Code != http.StatusForbidden {
+		t.Fatalf("global tag by user status = %d, want 403", w.Code)
+	}
+	if w := callFileAs(add, admin, http.MethodPost, files[2].ID, `{"tags":["release"],"global":true}`); w.Code != http.StatusOK {
+		t.Fatalf("global tag by admin status = %d body=%s", w.Code, w.Body.String())
+	}
+
+	// 按标签列出文件：个人标签只在所有者的命名空间内匹配
+	code, list := listFilesPage(t, ListFiles(db), owner, url.Values{"tag": {"Project X"}})
+	if code != http.StatusOK || len(list.Items) != 2 {
+		t.Fatalf("owner tag filter: code=%d items=%+v", code, list.Items)
+	}
+	if _, list = listFilesPage(t, ListFiles(db), owner, url.Values{"tag": {"project x", "design"}}); len(list.Items) != 1 || list.Items[0].Filename != "spec.md" {
+		t.Fatalf("multiple tags should intersect, got %+v", list.Items)
+	}
+	if _, list = listFilesPage(t, ListFiles(db), other, url.Values{"tag": {"project x"}}); len(list.Items) != 0 {
+		t.Fatalf("personal tags should not match for other users, got %+v", list.Items)
+	}
+	if _, list = listFilesPage(t, ListFiles(db), owner, url.Values{"tag": {"release"}}); len(list.Items) != 1 || list.Items[0].Filename != "notes.txt" {
+		t.Fatalf("global tag filter, got %+v", list.Items)
+	}
+	if res := searchAs(t, db, owner, "tag=design"); *res.Total != 1 {
+		t.Fatalf("search by tag, got %+v", res)
+	}
+
+	// 联想：个人标签按使用次数排序，全局标签对所有人可见
+	tags := suggestTags(t, db, owner, "")
+	if len(tags) != 3 || tags[0].Name != "project x" || tags[0].FileCount != 2 {
+		t.Fatalf("unexpected suggestions: %+v", tags)
+	}
+	if tags = suggestTags(t, db, other, "RE"); len(tags) != 1 || !tags[0].Global {
+		t.Fatalf("global tag should be suggested to others: %+v", tags)
+	}
+
+	// 移除最后一次使用后个人标签被清理
+	if w := removeTagAs(db, owner, files[0].ID, "design"); w.Code != http.StatusOK {
+		t.Fatalf("remove tag status = %d body=%s", w.Code, w.Body.String())
+	}
+	if w := removeTagAs(db, owner, files[0].ID, "design"); w.Code != http.StatusNotFound {
+		t.Fatalf("removing absent tag status = %d, want 404", w.Code)
+	}
+	if tags = suggestTags(t, db, owner, "des"); len(tags) != 0 {
+		t.Fatalf("unused personal tag should be removed: %+v", tags)
+	}
+}
@@ -0,0 +1,430 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"content-hub/server/config"
+	"content-hub/server/models"
+	"content-hub/server/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type trashFileResponse struct {
+	FileResponse
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"` // 超过保留期后将被后台任务永久删除的时间
+}
+
+type trashFolderResponse struct {
+	FolderResponse
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+// TrashResponse 列出回收站中的顶层条目：已删除目录中的子目录与文件随目录一起恢复或清理，不单独列出。
+type TrashResponse struct {
+	Folders []trashFolderResponse `json:"folders"`
+	Files   []trashFileResponse   `json:"files"`
+}
+
+// TrashPurgeReport 汇总一次永久清理的结果。
+type TrashPurgeReport struct {
+	Files      int   `json:"files"`
+	Folders    int   `json:"folders"`
+	FreedBytes int64 `json:"freed_bytes"` // 实际从存储中释放的字节数，仍被其他文件引用的内容不计入
+}
+
+// ListTrash 列出当前用户回收站中的文件与目录，管理员可见全部用户的条目。
+// @Summary 回收站列表
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Router /trash [get]
+func ListTrash(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, role := currentUser(c)
+		deletedFolders := db.Unscoped().Model(&models.Folder{}).Select("id").Where("deleted_at IS NOT NULL")
+
+		folderQuery := db.Unscoped().Where("deleted_at IS NOT NULL").
+			Where("parent_id IS NULL OR parent_id NOT IN (?)", deletedFolders)
+		fileQuery := db.Unscoped().Preload("Owner").Where("files.deleted_at IS NOT NULL").
+			Where("folder_id IS NULL OR folder_id NOT IN (?)", deletedFolders)
+		if role != models.RoleAdmin {
+			folderQuery = folderQuery.Where("owner_id = ?", userID)
+			fileQuery = fileQuery.Where("owner_id = ?", userID)
+		}
+
+		var folders []models.Folder
+		if err := folderQuery.Order("deleted_at desc").Find(&folders).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		var files []models.File
+		if err := fileQuery.Order("deleted_at desc").Find(&files).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		resp := TrashResponse{
+			Folders: make([]trashFolderResponse, 0, len(folders)),
+			Files:   make([]trashFileResponse, 0, len(files)),
+		}
+		for i := range folders {
+			deletedAt := folders[i].DeletedAt.Time
+			resp.Folders = append(resp.Folders, trashFolderResponse{
+				FolderResponse: buildFolderResponse(&folders[i]),
+				DeletedAt:      deletedAt,
+				PurgeAt:        deletedAt.Add(cfg.TrashRetention),
+			})
+		}
+		for i := range files {
+			deletedAt := files[i].DeletedAt.Time
+			resp.Files = append(resp.Files, trashFileResponse{
+				FileResponse: buildFileResponse(&files[i]),
+				DeletedAt:    deletedAt,
+				PurgeAt:      deletedAt.Add(cfg.TrashRetention),
+			})
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// RestoreTrashFile 从回收站恢复文件；原目录已删除时恢复到根目录。恢复后重新计入配额。
+// @Summary 恢复文件
+// @Tags trash
+// @Produce json
+// @Param id path int true "文件ID"
+// @Security BearerAuth
+// @Router /trash/files/{id}/restore [post]
+func RestoreTrashFile(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var f models.File
+		if !loadTrashed(c, db, &f) {
+			return
+		}
+
+		quota, err := loadUserQuota(db, cfg, f.OwnerID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		versionBytes, err := restoredVersionBytes(db, db.Unscoped().Model(&models.File{}).Where("id = ?", f.ID))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !quota.allows(f.Size+versionBytes, 1) {
+			writeQuotaExceeded(c, quota)
+			return
+		}
+
+		folderID := f.FolderID
+		if err := checkFolderOwner(db, folderID, f.OwnerID); err != nil {
+			if !errors.Is(err, errFolderNotFound) {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			folderID = nil
+		}
+		if err := db.Unscoped().Model(&f).Updates(map[string]any{"deleted_at": nil, "trash_batch": "", "folder_id": folderID}).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		f.FolderID = folderID
+		c.JSON(http.StatusOK, buildFileResponse(&f))
+	}
+}
+
+// restoredVersionBytes 统计 files 查询所选文件的历史版本大小。回收站中文件的历史版本不计入配额，恢复时需一并校验。
+func restoredVersionBytes(db *gorm.DB, files *gorm.DB) (int64, error) {
+	var n int64
+	err := db.Model(&models.FileVersion{}).Select("COALESCE(SUM(size), 0)").
+		Where("file_id IN (?)", files.Select("id")).Scan(&n).Error
+	return n, err
+}
+
+// RestoreTrashFolder 恢复目录及其中的子目录与文件；父目录已删除时恢复到根目录，重名时自动追加序号。
+// @Summary 恢复目录
+// @Tags trash
+// @Produce json
+// @Param id path int true "目录ID"
+// @Security BearerAuth
+// @Router /trash/folders/{id}/restore [post]
+func RestoreTrashFolder(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var folder models.Folder
+		if !loadTrashed(c, db, &folder) {
+			return
+		}
+
+		folderIDs, err := models.DescendantFolderIDs(db.Unscoped(), folder.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		// 只恢复随该目录一起删除的条目；在此之前单独删除的文件与子目录仍留在回收站
+		batch := folder.TrashBatch
+		sameBatch := func(q *gorm.DB) *gorm.DB {
+			q = q.Where("deleted_at IS NOT NULL")
+			if batch == "" {
+				return q // 批次上线前删除的目录无法区分，按原方式全部恢复
+			}
+			return q.Where("trash_batch = ?", batch)
+		}
+		var restoring struct {
+			Count int64
+			Bytes int64
+		}
+		if err := db.Unscoped().Model(&models.File{}).
+			Select("COUNT(*) AS count, COALESCE(SUM(size), 0) AS bytes").
+			Where("folder_id IN ?", folderIDs).Scopes(sameBatch).
+			Scan(&restoring).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		versionBytes, err := restoredVersionBytes(db, db.Unscoped().Model(&models.File{}).
+			Where("folder_id IN ?", folderIDs).Scopes(sameBatch))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		quota, err := loadUserQuota(db, cfg, folder.OwnerID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !quota.allows(restoring.Bytes+versionBytes, restoring.Count) {
+			writeQuotaExceeded(c, quota)
+			return
+		}
+
+		parentID := folder.ParentID
+		if err := checkFolderOwner(db, parentID, folder.OwnerID); err != nil {
+			if !errors.Is(err, errFolderNotFound) {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			parentID = nil
+		}
+		name, err := restoredFolderName(db, folder.OwnerID, parentID, folder.Name, folder.ID)
+		if err != nil {
+			writeFolderError(c, err)
+			return
+		}
+
+		restored := map[string]any{"deleted_at": nil, "trash_batch": ""}
+		var folderCount int64
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Unscoped().Model(&folder).Updates(map[string]any{"deleted_at": nil, "trash_batch": "", "parent_id": parentID, "name": name}).Error; err != nil {
+				return err
+			}
+			res := tx.Unscoped().Model(&models.Folder{}).Where("id IN ?", folderIDs[1:]).Scopes(sameBatch).Updates(restored)
+			if res.Error != nil {
+				return res.Error
+			}
+			folderCount = res.RowsAffected + 1
+			return tx.Unscoped().Model(&models.File{}).Where("folder_id IN ?", folderIDs).Scopes(sameBatch).Updates(restored).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		folder.ParentID, folder.Name = parentID, name
+		c.JSON(http.StatusOK, gin.H{"folder": buildFolderResponse(&folder), "folders": folderCount, "files": restoring.Count})
+	}
+}
+
+// PurgeTrashFile 永久删除回收站中的文件，内容不再被引用时从存储中移除。
+// @Summary 永久删除文件
+// @Tags trash
+// @Produce json
+// @Param id path int true "文件ID"
+// @Security BearerAuth
+// @Router /trash/files/{id} [delete]
+func PurgeTrashFile(db *gorm.DB, store storage.Driver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var f models.File
+		if !loadTrashed(c, db, &f) {
+			return
+		}
+		freed, err := purgeFile(c.Request.Context(), db, store, &f)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, TrashPurgeReport{Files: 1, FreedBytes: freed})
+	}
+}
+
+// PurgeTrashFolder 永久删除回收站中的目录及其全部内容。
+// @Summary 永久删除目录
+// @Tags trash
+// @Produce json
+// @Param id path int true "目录ID"
+// @Security BearerAuth
+// @Router /trash/folders/{id} [delete]
+func PurgeTrashFolder(db *gorm.DB, store storage.Driver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var folder models.Folder
+		if !loadTrashed(c, db, &folder) {
+			return
+		}
+		report, err := purgeFolderTree(c.Request.Context(), db, store, folder.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// EmptyTrash 清空当前用户的回收站。
+// @Summary 清空回收站
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Router /trash [delete]
+func EmptyTrash(db *gorm.DB, store storage.Driver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := currentUser(c)
+		report, err := purgeDeleted(c.Request.Context(), db, store, func(q *gorm.DB) *gorm.DB {
+			return q.Where("owner_id = ?", userID)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// RunTrashPurge 立即执行一次按保留期的清理，供管理员手动触发。
+// @Summary 立即清理过期回收站条目
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Router /admin/trash/purge [post]
+func RunTrashPurge(db *gorm.DB, cfg *config.Config, store storage.Driver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report, err := PurgeExpiredTrash(c.Request.Context(), db, store, time.Now().Add(-cfg.TrashRetention))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// PurgeExpiredTrash 永久删除在 cutoff 之前被软删除的文件与目录。
+func PurgeExpiredTrash(ctx context.Context, db *gorm.DB, store storage.Driver, cutoff time.Time) (TrashPurgeReport, error) {
+	return purgeDeleted(ctx, db, store, func(q *gorm.DB) *gorm.DB {
+		return q.Where("deleted_at < ?", cutoff)
+	})
+}
+
+// StartTrashJanitor 在后台周期性清理超过保留期的回收站条目，并记录清理结果。
+func StartTrashJanitor(db *gorm.DB, cfg *config.Config, store storage.Driver, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			report, err := PurgeExpiredTrash(context.Background(), db, store, time.Now().Add(-cfg.TrashRetention))
+			if err != nil {
+				log.Printf("purge trash: %v", err)
+				continue
+			}
+			if report.Files > 0 || report.Folders > 0 {
+				log.Printf("purged trash: %d files, %d folders, %d bytes freed", report.Files, report.Folders, report.FreedBytes)
+			}
+		}
+	}()
+}
+
+// purgeDeleted 永久删除满足 scope 条件且已软删除的文件与目录。
+func purgeDeleted(ctx context.Context, db *gorm.DB, store storage.Driver, scope func(*gorm.DB) *gorm.DB) (TrashPurgeReport, error) {
+	var report TrashPurgeReport
+
+	var files []models.File
+	if err := db.Unscoped().Scopes(scope).Where("deleted_at IS NOT NULL").Find(&files).Error; err != nil {
+		return report, err
+	}
+	for i := range files {
+		freed, err := purgeFile(ctx, db, store, &files[i])
+		if err != nil {
+			return report, err
+		}
+		report.Files++
+		report.FreedBytes += freed
+	}
+
+	res := db.Unscoped().Scopes(scope).Where("deleted_at IS NOT NULL").Delete(&models.Folder{})
+	if res.Error != nil {
+		return report, res.Error
+	}
+	report.Folders = int(res.RowsAffected)
+	return report, nil
+}
+
+// purgeFolderTree 永久删除目录及其全部子目录与文件（含已软删除的条目）。
+func purgeFolderTree(ctx context.Context, db *gorm.DB, store storage.Driver, folderID uint) (TrashPurgeReport, error) {
+	var report TrashPurgeReport
+	folderIDs, err := models.DescendantFolderIDs(db.Unscoped(), folderID)
+	if err != nil {
+		return report, err
+	}
+
+	var files []models.File
+	if err := db.Unscoped().Where("folder_id IN ?", folderIDs).Find(&files).Error; err != nil {
+		return report, err
+	}
+	for i := range files {
+		freed, err := purgeFile(ctx, db, store, &files[i])
+		if err != nil {
+			return report, err
+		}
+		report.Files++
+		report.FreedBytes += freed
+	}
+	if err := db.Unscoped().Delete(&models.Folder{}, folderIDs).Error; err != nil {
+		return report, err
+	}
+	report.Folders = len(folderIDs)
+	return report, nil
+}
+
+// loadTrashed 读取路径参数指定且已软删除的文件或目录，普通用户只能操作自己的条目。失败时已写入响应。
+func loadTrashed(c *gin.Context, db *gorm.DB, dest any) bool {
+	userID, role := currentUser(c)
+	query := db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", c.Param("id"))
+	if role != models.RoleAdmin {
+		query = query.Where("owner_id = ?", userID)
+	}
+	if err := query.First(dest).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "回收站中不存在该条目"})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// restoredFolderName 在恢复目标位置已有同名目录时追加序号，避免与现有目录冲突。
+func restoredFolderName(db *gorm.DB, ownerID uint, parentID *uint, name string, folderID uint) (string, error) {
+	candidate := name
+	for i := 2; i <= 100; i++ {
+		err := ensureFolderNameFree(db, ownerID, parentID, candidate, folderID)
+		if !errors.Is(err, errFolderConflict) {
+			return candidate, err
+		}
+		candidate = fmt.Sprintf("%s (%d)", name, i)
+	}
+	return "", errFolderConflict
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"content-hub/server/models"
+	"content-hub/server/storage"
+)
+
+func TestTrashRestoreAndPurge(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	cfg.TrashRetention = 24 * time.Hour
+	owner := createUser(t, db, "owner", models.RoleUser)
+	other := createUser(t, db, "other", models.RoleUser)
+
+	folder := mustCreateFolder(t, db, owner, "album", 0)
+	upload := func(name string, folderID uint) uint {
+		fields := map[string]string{}
+		if folderID != 0 {
+			fields["folder_id"] = fmt.Sprint(folderID)
+		}
+		w := uploadMultipartFields(t, db, cfg, store, owner.ID, name, []byte(name), fields)
+		var resp struct {
+			ID uint `json:"id"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.ID
+	}
+	loose := upload("loose.txt", 0)
+	upload("inside.txt", folder.ID)
+
+	if w := deleteFileAs(db, store, loose, owner.ID, models.RoleUser); w.Code != http.StatusOK {
+		t.Fatalf("soft delete file status = %d", w.Code)
+	}
+	if w := callFolder(DeleteFolder(db, store), owner, http.MethodDelete, folder.ID, nil); w.Code != http.StatusOK {
+		t.Fatalf("soft delete folder status = %d", w.Code)
+	}
+
+	// 回收站只列出顶层条目，目录中的文件随目录展示
+	w := callFolder(ListTrash(db, cfg), owner, http.MethodGet, 0, nil)
+	var trash TrashResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &trash)
+	if len(trash.Files) != 1 || trash.Files[0].Filename != "loose.txt" || len(trash.Folders) != 1 {
+		t.Fatalf("unexpected trash listing %+v", trash)
+	}
+	if got := trash.Files[0].PurgeAt.Sub(trash.Files[0].DeletedAt); got != cfg.TrashRetention {
+		t.Fatalf("purge_at should follow retention, got %v", got)
+	}
+	w = callFolder(ListTrash(db, cfg), other, http.MethodGet, 0, nil)
+	_ = json.Unmarshal(w.Body.Bytes(), &trash)
+	if len(trash.Files) != 0 || len(trash.Folders) != 0 {
+		t.Fatalf("other user should see an empty trash: %+v", trash)
+	}
+
+	// 别人无法恢复，所有者恢复后重新可见
+	if w := callFileAs(RestoreTrashFile(db, cfg), other, http.MethodPost, loose, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("foreign restore should be 404, got %d", w.Code)
+	}
+	if w := callFileAs(RestoreTrashFile(db, cfg), owner, http.MethodPost, loose, ""); w.Code != http.StatusOK {
+		t.Fatalf("restore file status = %d body=%s", w.Code, w.Body.String())
+	}
+	if w := callFolder(RestoreTrashFolder(db, cfg), owner, http.MethodPost, folder.ID, nil); w.Code != http.StatusOK {
+		t.Fatalf("restore folder status = %d body=%s", w.Code, w.Body.String())
+	}
+	var visible int64
+	db.Model(&models.File{}).Count(&visible)
+	if visible != 2 {
+		t.Fatalf("both files should be restored, got %d", visible)
+	}
+
+	// 再次删除后，未超过保留期的条目不会被清理
+	deleteFileAs(db, store, loose, owner.ID, models.RoleUser)
+	callFolder(DeleteFolder(db, store), owner, http.MethodDelete, folder.ID, nil)
+	report, err := PurgeExpiredTrash(t.Context(), db, store, time.Now().Add(-cfg.TrashRetention))
+	if err != nil || report.Files != 0 || report.Folders != 0 {
+		t.Fatalf("fresh trash should be kept, report=%+v err=%v", report, err)
+	}
+
+	var files []models.File
+	db.Unscoped().Find(&files)
+	report, err = PurgeExpiredTrash(t.Context(), db, store, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if report.Files != 2 || report.Folders != 1 || report.FreedBytes != int64(len("loose.txt")+len("inside.txt")) {
+		t.Fatalf("unexpected purge report %+v", report)
+	}
+	for _, f := range files {
+		if _, err := store.Stat(t.Context(), f.Path); !errors.Is(err, storage.ErrNotExist) {
+			t.Fatalf("content %s should be removed, stat err=%v", f.Path, err)
+		}
+	}
+	var remaining int64
+	db.Unscoped().Model(&models.File{}).Count(&remaining)
+	if remaining != 0 {
+		t.Fatalf("file rows should be purged, got %d", remaining)
+	}
+}
+
+func TestTrashRestoreCountsVersions(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	owner := createUser(t, db, "owner", models.RoleUser)
+	folder := mustCreateFolder(t, db, owner, "docs", 0)
+	loose := persistTestFile(t, db, cfg, store, owner.ID, "loose.txt", "text/plain", []byte("abcd"))
+	inside := persistTestFile(t, db, cfg, store, owner.ID, "inside.txt", "text/plain", []byte("abcd"))
+	if err := db.Model(inside).Update("folder_id", folder.ID).Error; err != nil {
+		t.Fatalf("move file: %v", err)
+	}
+	for _, f := range []*models.File{loose, inside} {
+		if err := db.Create(&models.FileVersion{FileID: f.ID, Version: 1, Size: 20}).Error; err != nil {
+			t.Fatalf("create version: %v", err)
+		}
+	}
+	deleteFileAs(db, store, loose.ID, owner.ID, models.RoleUser)
+	callFolder(DeleteFolder(db, store), owner, http.MethodDelete, folder.ID, nil)
+
+	// 文件本身只有 4 字节，但历史版本恢复后同样计入配额
+	cfg.DefaultQuotaBytes = 10
+	if w := callFileAs(RestoreTrashFile(db, cfg), owner, http.MethodPost, loose.ID, ""); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("restore file over quota status = %d, want 413", w.Code)
+	}
+	if w := callFolder(RestoreTrashFolder(db, cfg), owner, http.MethodPost, folder.ID, nil); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("restore folder over quota status = %d, want 413", w.Code)
+	}
+	cfg.DefaultQuotaBytes = 48
+	if w := callFileAs(RestoreTrashFile(db, cfg), owner, http.MethodPost, loose.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf("restore file status = %d body=%s", w.Code, w.Body.String())
+	}
+	if w := callFolder(RestoreTrashFolder(db, cfg), owner, http.MethodPost, folder.ID, nil); w.Code != http.StatusOK {
+		t.Fatalf("restore folder status = %d body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestTrashRestoreFolderKeepsEarlierDeletes(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	owner := createUser(t, db, "owner", models.RoleUser)
+	folder := mustCreateFolder(t, db, owner, "album", 0)
+	sub := mustCreateFolder(t, db, owner, "drafts", folder.ID)
+	a := persistTestFile(t, db, cfg, store, owner.ID, "a.txt", "text/plain", []byte("a"))
+	b := persistTestFile(t, db, cfg, store, owner.ID, "b.txt", "text/plain", []byte("b"))
+	c := persistTestFile(t, db, cfg, store, owner.ID, "c.txt", "text/plain", []byte("c"))
+	db.Model(a).Update("folder_id", folder.ID)
+	db.Model(b).Update("folder_id", folder.ID)
+	db.Model(c).Update("folder_id", sub.ID)
+
+	// 先单独删除文件 A 与子目录，再删除上级目录
+	deleteFileAs(db, store, a.ID, owner.ID, models.RoleUser)
+	if w := callFolder(DeleteFolder(db, store), owner, http.MethodDelete, sub.ID, nil); w.Code != http.StatusOK {
+		t.Fatalf("delete subfolder status = %d", w.Code)
+	}
+	if w := callFolder(DeleteFolder(db, store), owner, http.MethodDelete, folder.ID, nil); w.Code != http.StatusOK {
+		t.Fatalf("delete folder status = %d", w.Code)
+	}
+	w := callFolder(RestoreTrashFolder(db, cfg), owner, http.MethodPost, folder.ID, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("restore folder status = %d body=%s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Folders int `json:"folders"`
+		Files   int `json:"files"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Folders != 1 || resp.Files != 1 {
+		t.Fatalf("restore response = %s", w.Body.String())
+	}
+
+	var live []uint
+	db.Model(&models.File{}).Order("id").Pluck("id", &live)
+	if len(live) != 1 || live[0] != b.ID {
+		t.Fatalf("only b should be restored, live files = %v", live)
+	}
+	// 此前单独删除的文件与子目录仍在回收站，作为顶层条目列出
+	w = callFolder(ListTrash(db, cfg), owner, http.MethodGet, 0, nil)
+	var trash TrashResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &trash)
+	if len(trash.Files) != 1 || trash.Files[0].ID != a.ID || len(trash.Folders) != 1 || trash.Folders[0].ID != sub.ID {
+		t.Fatalf("trash after restore = %+v", trash)
+	}
+}
@@ -0,0 +1,439 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"content-hub/server/config"
+	"content-hub/server/models"
+	"content-hub/server/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxOpenUploadSessions 为单个用户同时未完成的分片上传会话数上限。
+const maxOpenUploadSessions = 10
+
+// uploadSessionLocks 串行化同一会话的分片写入，避免并发 PUT 在同一偏移处交错写入暂存文件。
+var uploadSessionLocks sync.Map
+
+type createUploadSessionRequest struct {
+	Filename    string `json:"filename" binding:"required"`
+	Size        int64  `json:"size"`
+	MimeType    string `json:"mime_type"`
+	Description string `json:"description"`
+	FolderID    *uint  `json:"folder_id"`
+}
+
+type uploadSessionResponse struct {
+	UploadID  string    `json:"upload_id"`
+	Filename  string    `json:"filename"`
+	Size      int64     `json:"size"`
+	Offset    int64     `json:"offset"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CreateUploadSession 创建分片上传会话，客户端随后按偏移量依次 PUT 分片，断线后可查询进度继续上传。
+// 未完成会话的声明大小计入配额，每个用户最多同时保留 maxOpenUploadSessions 个会话，超出时返回 429。
+// @Summary 创建分片上传会话
+// @Tags uploads
+// @Accept json
+// @Produce json
+// @Param payload body createUploadSessionRequest true "文件信息"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /uploads [post]
+func CreateUploadSession(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDVal, exists := c.Get("userID")
+		userID, ok := userIDVal.(uint)
+		if !exists || !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "无法识别上传者，请重新登录或检查 API Key"})
+			return
+		}
+
+		var req createUploadSessionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		filename := filepath.Base(strings.TrimSpace(req.Filename))
+		if filename == "" || filename == "." || filename == string(filepath.Separator) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "filename is required"})
+			return
+		}
+		if req.Size <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "size 需大于 0"})
+			return
+		}
+		mime := strings.TrimSpace(req.MimeType)
+		if mime == "" {
+			mime = octetStream
+		}
+		// 声明类型已在黑名单中的无需等到传完再拒绝；内容的真实类型在完成时再识别
+		if matchMimePatterns(cfg.UploadDeniedTypes, baseMime(mime)) {
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": errContentTypeDenied.Error(), "declared_mime_type": baseMime(mime)})
+			return
+		}
+
+		folderID := normalizeFolderID(req.FolderID)
+		if err := checkFolderOwner(db, folderID, userID); err != nil {
+			writeFolderError(c, err)
+			return
+		}
+
+		// 未完成的会话同样占用暂存磁盘：限制同时打开的会话数，并按声明大小预留配额，避免以大量并行会话占满磁盘
+		openCount, openBytes, err := openUploadSessions(db, userID, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if openCount >= maxOpenUploadSessions {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "未完成的分片上传过多，请先完成或取消已有上传", "max_sessions": maxOpenUploadSessions})
+			return
+		}
+
+		// 创建会话时即按声明大小校验配额，避免传完数 GB 后才被拒绝
+		quota, err := loadUserQuota(db, cfg, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		quota.ReservedBytes = openBytes
+		if !quota.allows(openBytes+req.Size, openCount+1) {
+			writeQuotaExceeded(c, quota)
+			return
+		}
+
+		if err := os.MkdirAll(cfg.UploadTempDir, 0o755); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		session := models.UploadSession{
+			UploadID:    uuid.NewString(),
+			OwnerID:     userID,
+			Filename:    filename,
+			MimeType:    mime,
+			Description: req.Description,
+			FolderID:    folderID,
+			TotalSize:   req.Size,
+			ExpiresAt:   time.Now().Add(cfg.UploadSessionTTL),
+		}
+		// 预先创建空的暂存文件，后续分片只做定位写入
+		part, err := os.Create(sessionPartPath(cfg, session.UploadID))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		part.Close()
+
+		if err := db.Create(&session).Error; err != nil {
+			_ = os.Remove(sessionPartPath(cfg, session.UploadID))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, buildUploadSessionResponse(&session))
+	}
+}
+
+// GetUploadSession 返回服务端已接收的字节数，客户端断线重连后据此确定下一个分片的偏移量。
+// @Summary 查询分片上传进度
+// @Tags uploads
+// @Produce json
+// @Param id path string true "上传会话 ID"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /uploads/{id} [get]
+func GetUploadSession(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session, ok := loadOwnedUploadSession(c, db)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, buildUploadSessionResponse(session))
+	}
+}
+
+// PutUploadChunk 以原始请求体追加一个分片，offset 必须等于当前已接收字节数，
+// 否则返回 409 与服务端偏移量，便于客户端纠正后重试。
+// @Summary 上传分片
+// @Tags uploads
+// @Accept octet-stream
+// @Produce json
+// @Param id path string true "上传会话 ID"
+// @Param offset query int true "分片起始偏移量"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /uploads/{id} [put]
+func PutUploadChunk(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		offset, err := strconv.ParseInt(c.Query("offset"), 10, 64)
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset 参数无效"})
+			return
+		}
+
+		lock := lockUploadSession(c.Param("id"))
+		defer lock.Unlock()
+
+		session, ok := loadOwnedUploadSession(c, db)
+		if !ok {
+			return
+		}
+		if offset != session.ReceivedSize {
+			c.JSON(http.StatusConflict, gin.H{"error": "offset 与服务端进度不一致", "offset": session.ReceivedSize})
+			return
+		}
+
+		part, err := os.OpenFile(sessionPartPath(cfg, session.UploadID), os.O_WRONLY|os.O_CREATE, 0o644)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer part.Close()
+		// 截断到已确认的偏移，丢弃上次中断时未记账的残留字节
+		if err := part.Truncate(offset); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if _, err := part.Seek(offset, io.SeekStart); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		remaining := session.TotalSize - offset
+		written, copyErr := io.Copy(part, io.LimitReader(c.Request.Body, remaining+1))
+		if written > remaining {
+			_ = part.Truncate(offset)
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "分片超出声明的文件大小", "offset": offset})
+			return
+		}
+		if err := part.Sync(); err != nil && copyErr == nil {
+			copyErr = err
+		}
+
+		// 即使连接中途断开，也记录已落盘的字节，下次从该位置续传
+		if written > 0 {
+			session.ReceivedSize = offset + written
+			session.ExpiresAt = time.Now().Add(cfg.UploadSessionTTL)
+			if err := db.Model(session).Updates(map[string]any{
+				"received_size": session.ReceivedSize,
+				"expires_at":    session.ExpiresAt,
+			}).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if copyErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": copyErr.Error(), "offset": session.ReceivedSize})
+			return
+		}
+		c.JSON(http.StatusOK, buildUploadSessionResponse(session))
+	}
+}
+
+// CompleteUploadSession 在全部分片到齐后写入存储驱动并生成文件记录，随后清理会话与暂存文件。
+// @Summary 完成分片上传
+// @Tags uploads
+// @Produce json
+// @Param id path string true "上传会话 ID"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /uploads/{id}/complete [post]
+func CompleteUploadSession(db *gorm.DB, cfg *config.Config, store storage.Driver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lock := lockUploadSession(c.Param("id"))
+		defer lock.Unlock()
+
+		session, ok := loadOwnedUploadSession(c, db)
+		if !ok {
+			return
+		}
+		if session.ReceivedSize != session.TotalSize {
+			c.JSON(http.StatusConflict, gin.H{"error": "文件尚未上传完整", "offset": session.ReceivedSize})
+			return
+		}
+
+		// 会话期间可能有其他上传占用了配额，完成前再校验一次
+		quota, err := loadUserQuota(db, cfg, session.OwnerID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !quota.allows(session.TotalSize, 1) {
+			writeQuotaExceeded(c, quota)
+			return
+		}
+
+		// 目标目录可能在上传期间被删除
+		if err := checkFolderOwner(db, session.FolderID, session.OwnerID); err != nil {
+			writeFolderError(c, err)
+			return
+		}
+
+		partPath := sessionPartPath(cfg, session.UploadID)
+		part, err := os.Open(partPath)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		defer part.Close()
+
+		in := &uploadInput{
+			OwnerID:     session.OwnerID,
+			FolderID:    session.FolderID,
+			Filename:    session.Filename,
+			MimeType:    session.MimeType,
+			Description: session.Description,
+			Content:     part,
+			MaxBytes:    quota.remainingBytes(),
+		}
+		if !prepareUploadContent(c, cfg, in) {
+			return
+		}
+		f, err := persistUpload(c.Request.Context(), db, cfg, store, *in)
+		if err != nil {
+			if errors.Is(err, errQuotaExceeded) {
+				writeQuotaExceeded(c, quota)
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		removeUploadSession(db, cfg, session)
+		c.JSON(http.StatusOK, gin.H{"id": f.ID, "filename": f.Filename, "digest": f.Digest})
+	}
+}
+
+// AbortUploadSession 主动放弃上传，立即释放暂存空间。
+// @Summary 取消分片上传
+// @Tags uploads
+// @Produce json
+// @Param id path string true "上传会话 ID"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /uploads/{id} [delete]
+func AbortUploadSession(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lock := lockUploadSession(c.Param("id"))
+		defer lock.Unlock()
+
+		session, ok := loadOwnedUploadSession(c, db)
+		if !ok {
+			return
+		}
+		removeUploadSession(db, cfg, session)
+		c.JSON(http.StatusOK, gin.H{"message": "upload aborted"})
+	}
+}
+
+// CleanupUploadSessions 删除已过期的上传会话及其暂存文件，返回清理数量。
+func CleanupUploadSessions(db *gorm.DB, cfg *config.Config, now time.Time) (int, error) {
+	var sessions []models.UploadSession
+	if err := db.Where("expires_at < ?", now).Find(&sessions).Error; err != nil {
+		return 0, err
+	}
+	for i := range sessions {
+		lock := lockUploadSession(sessions[i].UploadID)
+		removeUploadSession(db, cfg, &sessions[i])
+		lock.Unlock()
+	}
+	return len(sessions), nil
+}
+
+// StartUploadSessionJanitor 在后台周期性回收被放弃的上传会话。
+func StartUploadSessionJanitor(db *gorm.DB, cfg *config.Config, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			n, err := CleanupUploadSessions(db, cfg, time.Now())
+			if err != nil {
+				log.Printf("cleanup upload sessions: %v", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("cleaned %d expired upload sessions", n)
+			}
+		}
+	}()
+}
+
+// loadOwnedUploadSession 读取会话并校验归属与有效期，失败时直接写出响应。
+func loadOwnedUploadSession(c *gin.Context, db *gorm.DB) (*models.UploadSession, bool) {
+	userIDVal, _ := c.Get("userID")
+	userID, _ := userIDVal.(uint)
+
+	var session models.UploadSession
+	if err := db.Where("upload_id = ?", c.Param("id")).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "upload session not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	// 不区分“不存在”与“非本人”，避免泄露其他用户的会话 ID
+	if session.OwnerID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "upload session not found"})
+		return nil, false
+	}
+	if session.Expired(time.Now()) {
+		c.JSON(http.StatusGone, gin.H{"error": "upload session expired"})
+		return nil, false
+	}
+	return &session, true
+}
+
+// openUploadSessions 统计用户未过期的上传会话数及其声明的总字节数。
+func openUploadSessions(db *gorm.DB, userID uint, now time.Time) (count, bytes int64, err error) {
+	var row struct {
+		Count int64
+		Bytes int64
+	}
+	err = db.Model(&models.UploadSession{}).Select("COUNT(*) AS count, COALESCE(SUM(total_size), 0) AS bytes").
+		Where("owner_id = ? AND expires_at > ?", userID, now).Scan(&row).Error
+	return row.Count, row.Bytes, err
+}
+
+func removeUploadSession(db *gorm.DB, cfg *config.Config, session *models.UploadSession) {
+	if err := os.Remove(sessionPartPath(cfg, session.UploadID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("remove upload part %s: %v", session.UploadID, err)
+	}
+	if err := db.Unscoped().Delete(session).Error; err != nil {
+		log.Printf("delete upload session %s: %v", session.UploadID, err)
+	}
+	uploadSessionLocks.Delete(session.UploadID)
+}
+
+func lockUploadSession(uploadID string) *sync.Mutex {
+	val, _ := uploadSessionLocks.LoadOrStore(uploadID, &sync.Mutex{})
+	mu := val.(*sync.Mutex)
+	mu.Lock()
+	return mu
+}
+
+func sessionPartPath(cfg *config.Config, uploadID string) string {
+	return filepath.Join(cfg.UploadTempDir, filepath.Base(uploadID)+".part")
+}
+
+func buildUploadSessionResponse(s *models.UploadSession) uploadSessionResponse {
+	return uploadSessionResponse{
+		UploadID:  s.UploadID,
+		Filename:  s.Filename,
+		Size:      s.TotalSize,
+		Offset:    s.ReceivedSize,
+		ExpiresAt: s.ExpiresAt,
+	}
+}
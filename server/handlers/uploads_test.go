@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"content-hub/server/config"
+	"content-hub/server/models"
+	"content-hub/server/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupUploadTestEnv(t *testing.T) (*gorm.DB, *config.Config, storage.Driver, models.User) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "uploads.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.File{}, &models.UploadSession{}, &models.Blob{}, &models.Folder{}, &models.FileAccess{}, &models.FileVersion{}, &models.FileText{}, &models.Tag{}, &models.FileTag{}, &models.FilePreview{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	owner := models.User{Username: "uploader", Role: models.RoleUser, PasswordHash: "x"}
+	if err := db.Create(&owner).Error; err != nil {
+		t.Fatalf("create owner: %v", err)
+	}
+	uploadDir := t.TempDir()
+	cfg := &config.Config{
+		UploadDir:        uploadDir,
+		UploadTempDir:    filepath.Join(uploadDir, ".sessions"),
+		UploadSessionTTL: time.Hour,
+	}
+	return db, cfg, storage.NewLocal(uploadDir), owner
+}
+
+// callUpload 以指定用户身份调用分片上传相关 handler，返回响应记录器。
+func callUpload(h gin.HandlerFunc, userID uint, method, target, uploadID, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, target, strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	if uploadID != "" {
+		c.Params = gin.Params{{Key: "id", Value: uploadID}}
+	}
+	c.Set("userID", userID)
+	c.Set("role", models.RoleUser)
+	h(c)
+	return w
+}
+
+func TestChunkedUploadResume(t *testing.T) {
+	db, cfg, store, owner := setupUploadTestEnv(t)
+
+	w := callUpload(CreateUploadSession(db, cfg), owner.ID, http.MethodPost, "/api/uploads", "", `{"filename":"big.bin","size":10}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("create session status = %d, body=%s", w.Code, w.Body.String())
+	}
+	var created uploadSessionResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	// 第一个分片
+	w = callUpload(PutUploadChunk(db, cfg), owner.ID, http.MethodPut, "/api/uploads/x?offset=0", created.UploadID, "01234")
+	if w.Code != http.StatusOK {
+		t.Fatalf("first chunk status = %d, body=%s", w.Code, w.Body.String())
+	}
+
+	// 偏移量不一致时返回 409，并告知服务端进度
+	w = callUpload(PutUploadChunk(db, cfg), owner.ID, http.MethodPut, "/api/uploads/x?offset=2", created.UploadID, "xyz")
+	if w.Code != http.StatusConflict {
+		t.Fatalf("mismatched offset should conflict, got %d", w.Code)
+	}
+
+	// 断线重连后查询进度
+	w = callUpload(GetUploadSession(db), owner.ID, http.MethodGet, "/api/uploads/x", created.UploadID, "")
+	var progress uploadSessionResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &progress)
+	if progress.Offset != 5 {
+		t.Fatalf("expected offset 5, got %d", progress.Offset)
+	}
+
+	// 未上传完整时不允许完成
+	w = callUpload(CompleteUploadSession(db, cfg, store), owner.ID, http.MethodPost, "/api/uploads/x/complete", created.UploadID, "")
+	if w.Code != http.StatusConflict {
+		t.Fatalf("incomplete upload should not finalize, got %d", w.Code)
+	}
+
+	// 超出声明大小的分片被拒绝
+	w = callUpload(PutUploadChunk(db, cfg), owner.ID, http.MethodPut, "/api/uploads/x?offset=5", created.UploadID, "56789EXTRA")
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized chunk should be rejected, got %d", w.Code)
+	}
+
+	w = callUpload(PutUploadChunk(db, cfg), owner.ID, http.MethodPut, "/api/uploads/x?offset=5", created.UploadID, "56789")
+	if w.Code != http.StatusOK {
+		t.Fatalf("second chunk status = %d, body=%s", w.Code, w.Body.String())
+	}
+
+	// 其他用户无法操作该会话
+	w = callUpload(CompleteUploadSession(db, cfg, store), owner.ID+1, http.MethodPost, "/api/uploads/x/complete", created.UploadID, "")
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("foreign user should not see session, got %d", w.Code)
+	}
+
+	w = callUpload(CompleteUploadSession(db, cfg, store), owner.ID, http.MethodPost, "/api/uploads/x/complete", created.UploadID, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("complete status = %d, body=%s", w.Code, w.Body.String())
+	}
+
+	var f models.File
+	if err := db.Where("filename = ?", "big.bin").First(&f).Error; err != nil {
+		t.Fatalf("file record not created: %v", err)
+	}
+	if f.OwnerID != owner.ID || f.Size != 10 {
+		t.Fatalf("unexpected file record: %+v", f)
+	}
+	rc, err := store.Get(t.Context(), f.Path)
+	if err != nil {
+		t.Fatalf("read stored file: %v", err)
+	}
+	defer rc.Close()
+	data, _ := io.ReadAll(rc)
+	if string(data) != "0123456789" {
+		t.Fatalf("unexpected assembled content %q", data)
+	}
+
+	var remaining int64
+	db.Model(&models.UploadSession{}).Count(&remaining)
+	if remaining != 0 {
+		t.Fatalf("session should be removed after completion")
+	}
+}
+
+func TestUploadSessionLimits(t *testing.T) {
+	db, cfg, _, owner := setupUploadTestEnv(t)
+	create := func(size int) *httptest.ResponseRecorder {
+		return callUpload(CreateUploadSession(db, cfg), owner.ID, http.MethodPost, "/api/uploads", "", `{"filename":"big.bin","size":`+strconv.Itoa(size)+`}`)
+	}
+
+	// 未完成会话的声明大小计入配额
+	db.Model(&owner).Update("quota_bytes", 20)
+	w := create(15)
+	if w.Code != http.StatusOK {
+		t.Fatalf("first session status = %d body=%s", w.Code, w.Body.String())
+	}
+	var first uploadSessionResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &first)
+	if w := create(10); w.Code != http.StatusRequestEntityTooLarge || !strings.Contains(w.Body.String(), `"reserved_bytes":15`) {
+		t.Fatalf("over-reserved session status = %d body=%s", w.Code, w.Body.String())
+	}
+	if w := callUpload(AbortUploadSession(db, cfg), owner.ID, http.MethodDelete, "/api/uploads/x", first.UploadID, ""); w.Code != http.StatusOK {
+		t.Fatalf("abort status = %d", w.Code)
+	}
+	if w := create(10); w.Code != http.StatusOK {
+		t.Fatalf("session after abort status = %d body=%s", w.Code, w.Body.String())
+	}
+
+	// 不限配额的用户同样限制同时打开的会话数，过期会话不再占用名额
+	db.Model(&owner).Update("quota_bytes", 0)
+	for i := 1; i < maxOpenUploadSessions; i++ {
+		if w := create(1 << 30); w.Code != http.StatusOK {
+			t.Fatalf("session %d status = %d body=%s", i+1, w.Code, w.Body.String())
+		}
+	}
+	if w := create(1); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("session over limit status = %d, want 429", w.Code)
+	}
+	var oldest models.UploadSession
+	db.Where("owner_id = ?", owner.ID).Order("id").First(&oldest)
+	db.Model(&oldest).Update("expires_at", time.Now().Add(-time.Minute))
+	if w := create(1); w.Code != http.StatusOK {
+		t.Fatalf("session after expiry status = %d body=%s", w.Code, w.Body.String())
+	}
+	if w := create(1); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("session over limit after expiry status = %d, want 429", w.Code)
+	}
+}
+
+func TestCleanupUploadSessions(t *testing.T) {
+	db, cfg, _, owner := setupUploadTestEnv(t)
+
+	w := callUpload(CreateUploadSession(db, cfg), owner.ID, http.MethodPost, "/api/uploads", "", `{"filename":"stale.bin","size":4}`)
+	var created uploadSessionResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+
+	n, err := CleanupUploadSessions(db, cfg, time.Now())
+	if err != nil || n != 0 {
+		t.Fatalf("fresh session should be kept, n=%d err=%v", n, err)
+	}
+
+	n, err = CleanupUploadSessions(db, cfg, time.Now().Add(2*time.Hour))
+	if err != nil || n != 1 {
+		t.Fatalf("expired session should be removed, n=%d err=%v", n, err)
+	}
+	if _, err := os.Stat(sessionPartPath(cfg, created.UploadID)); !os.IsNotExist(err) {
+		t.Fatalf("part file should be deleted, stat err=%v", err)
+	}
+}
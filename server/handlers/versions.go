@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"content-hub/server/config"
+	"content-hub/server/models"
+	"content-hub/server/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errVersionConflict 表示写入新版本时文件已被其他请求更新。
+var errVersionConflict = errors.New("文件正在被其他请求更新，请重试")
+
+type fileVersionResponse struct {
+	Version    uint      `json:"version"`
+	Filename   string    `json:"filename"`
+	Size       int64     `json:"size"`
+	MimeType   string    `json:"mime_type"`
+	Digest     string    `json:"digest"`
+	UploadedAt time.Time `json:"uploaded_at"`
+	Current    bool      `json:"current"`
+}
+
+// UploadFileVersion 上传文件的新版本：内容替换为新上传的内容，旧内容保存为历史版本，文件 ID 与已有分享链接保持不变。
+// @Summary 上传新版本
+// @Tags files
+// @Accept mpfd
+// @Produce json
+// @Param id path int true "文件ID"
+// @Param file formData file false "新版本文件"
+// @Param text formData string false "新版本文字内容"
+// @Security BearerAuth
+// @Router /files/{id}/versions [post]
+func UploadFileVersion(db *gorm.DB, cfg *config.Config, store storage.Driver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f, ok := loadOwnedFile(c, db)
+		if !ok {
+			return
+		}
+		quota, err := loadUserQuota(db, cfg, f.OwnerID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		// 历史版本计入空间配额但不计入文件数
+		in, cleanup, ok := readUploadForm(c, quota, 0)
+		if !ok {
+			return
+		}
+		defer cleanup()
+
+		if in.MimeType == "" {
+			in.MimeType = f.MimeType
+		}
+		if !prepareUploadContent(c, cfg, in) {
+			return
+		}
+		err = persistContent(c.Request.Context(), db, cfg, store, in.Content, in.MaxBytes, func(tx *gorm.DB, digest, key string, size int64) error {
+			return replaceFileContent(tx, f, key, size, in.Types, digest, initialScanStatus(cfg))
+		})
+		if err != nil {
+			if errors.Is(err, errQuotaExceeded) {
+				writeQuotaExceeded(c, quota)
+				return
+			}
+			writeVersionError(c, err)
+			return
+		}
+		indexFileText(c.Request.Context(), db, store, f)
+		schedulePreview(db, f)
+		scheduleScan(f)
+		c.JSON(http.StatusOK, buildFileResponse(f))
+	}
+}
+
+// ListFileVersions 按版本号倒序列出文件的当前版本与全部历史版本。
+// @Summary 版本列表
+// @Tags files
+// @Produce json
+// @Param id path int true "文件ID"
+// @Security BearerAuth
+// @Router /files/{id}/versions [get]
+func ListFileVersions(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f, ok := loadVisibleFile(c, db)
+		if !ok {
+			return
+		}
+		uploadedAt, err := models.CurrentVersionUploadedAt(db, f)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		var versions []models.FileVersion
+		if err := db.Where("file_id = ?", f.ID).Order("version desc").Find(&versions).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		resp := make([]fileVersionResponse, 0, len(versions)+1)
+		resp = append(resp, fileVersionResponse{
+			Version:    f.Version,
+			Filename:   f.Filename,
+			Size:       f.Size,
+			MimeType:   f.MimeType,
+			Digest:     f.Digest,
+			UploadedAt: uploadedAt,
+			Current:    true,
+		})
+		for _, v := range versions {
+			resp = append(resp, fileVersionResponse{
+				Version:    v.Version,
+				Filename:   v.Filename,
+				Size:       v.Size,
+				MimeType:   v.MimeType,
+				Digest:     v.Digest,
+				UploadedAt: v.UploadedAt,
+			})
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// DownloadFileVersion 以附件形式下载指定版本的内容，与下载当前文件一样支持 Range 与条件请求。
+// @Summary 下载指定版本
+// @Tags files
+// @Produce octet-stream
+// @Param id path int true "文件ID"
+// @Param version path int true "版本号"
+// @Security BearerAuth
+// @Router /files/{id}/versions/{version}/download [get]
+func DownloadFileVersion(db *gorm.DB, store storage.Driver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f, ok := loadVisibleFile(c, db)
+		if !ok {
+			return
+		}
+		version, ok := loadFileVersion(c, db, f)
+		if !ok {
+			return
+		}
+		if version == nil {
+			serveStoredFile(c, store, f, "attachment")
+			return
+		}
+		snapshot := *f
+		snapshot.Filename = version.Filename
+		snapshot.Path = version.Path
+		snapshot.Size = version.Size
+		snapshot.MimeType = version.MimeType
+		snapshot.DeclaredMimeType = version.DeclaredMimeType
+		snapshot.DetectedMimeType = version.DetectedMimeType
+		snapshot.Digest = version.Digest
+		snapshot.ScanStatus = version.ScanStatus
+		snapshot.CreatedAt = version.UploadedAt
+		serveStoredFile(c, store, &snapshot, "attachment")
+	}
+}
+
+// RestoreFileVersion 将历史版本恢复为当前版本：以该版本的内容生成一个新版本，原有历史保持不变。
+// @Summary 恢复历史版本
+// @Tags files
+// @Produce json
+// @Param id path int true "文件ID"
+// @Param version path int true "版本号"
+// @Security BearerAuth
+// @Router /files/{id}/versions/{version}/restore [post]
+func RestoreFileVersion(db *gorm.DB, cfg *config.Config, store storage.Driver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f, ok := loadOwnedFile(c, db)
+		if !ok {
+			return
+		}
+		version, ok := loadFileVersion(c, db, f)
+		if !ok {
+			return
+		}
+		if version == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "该版本已是当前版本"})
+			return
+		}
+
+		quota, err := loadUserQuota(db, cfg, f.OwnerID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !quota.allows(version.Size, 0) {
+			writeQuotaExceeded(c, quota)
+			return
+		}
+
+		types := contentTypes{Mime: version.MimeType, Declared: version.DeclaredMimeType, Detected: version.DetectedMimeType}
+		if version.Digest != "" {
+			err = db.Transaction(func(tx *gorm.DB) error {
+				if _, err := models.AcquireBlob(tx, version.Digest, version.Path, version.Size); err != nil {
+					return err
+				}
+				return replaceFileContent(tx, f, version.Path, version.Size, types, version.Digest, initialScanStatus(cfg))
+			})
+		} else {
+			// 去重存储之前的旧内容没有引用计数，需重新读入去重存储后再作为新版本
+			content, getErr := store.Get(c.Request.Context(), version.Path)
+			if getErr != nil {
+				writeStorageError(c, getErr)
+				return
+			}
+			defer content.Close()
+			err = persistContent(c.Request.Context(), db, cfg, store, content, -1, func(tx *gorm.DB, digest, key string, size int64) error {
+				return replaceFileContent(tx, f, key, size, types, digest, initialScanStatus(cfg))
+			})
+		}
+		if err != nil {
+			writeVersionError(c, err)
+			return
+		}
+		indexFileText(c.Request.Context(), db, store, f)
+		schedulePreview(db, f)
+		scheduleScan(f)
+		c.JSON(http.StatusOK, buildFileResponse(f))
+	}
+}
+
+// writeVersionError 写入新版本失败的响应：版本冲突返回 409，其余为 500。
+func writeVersionError(c *gin.Context, err error) {
+	if errors.Is(err, errVersionConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// replaceFileContent 将当前内容转存为历史版本，再把文件指向新内容并递增版本号；新内容的 Blob 引用需已由调用方获取。
+// 新内容需重新扫描，scanStatus 通常为 initialScanStatus 的结果。
+// 调用方在事务内先获取 Blob 引用（写入），SQLite 的写锁使同一时刻只有一个事务能走到这里，因此在事务内重新读取文件即可拿到
+// 最新的版本号，并发上传新版本时依次递增；版本号的条件更新再兜底一次，冲突时返回 errVersionConflict。
+func replaceFileContent(tx *gorm.DB, f *models.File, key string, size int64, types contentTypes, digest, scanStatus string) error {
+	var current models.File
+	if err := tx.First(&current, f.ID).Error; err != nil {
+		return err
+	}
+	current.Owner = f.Owner
+	*f = current
+	if err := models.ArchiveCurrentVersion(tx, f); err != nil {
+		return err
+	}
+	next := f.Version + 1
+	updates := map[string]any{
+		"path":               key,
+		"size":               size,
+		"mime_type":          types.Mime,
+		"declared_mime_type": types.Declared,
+		"detected_mime_type": types.Detected,
+		"digest":             digest,
+		"version":            next,
+		"scan_status":        scanStatus,
+		"scan_result":        "",
+		"scanned_at":         nil,
+		"scan_attempts":      0,
+	}
+	// 新内容不是文本时清除语言；恢复历史版本等未识别语言的情况保留原值
+	lang := f.Language
+	if !isTextMime(types.Mime) {
+		lang = ""
+	} else if types.Language != "" {
+		lang = types.Language
+	}
+	updates["language"] = lang
+	res := tx.Model(&models.File{}).Where("id = ? AND version = ?", f.ID, f.Version).Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errVersionConflict
+	}
+	f.Path, f.Size, f.MimeType, f.Digest, f.Version = key, size, types.Mime, digest, next
+	f.DeclaredMimeType, f.DetectedMimeType, f.Language = types.Declared, types.Detected, lang
+	f.ScanStatus, f.ScanResult, f.ScannedAt, f.ScanAttempts = scanStatus, "", nil, 0
+	return nil
+}
+
+// loadFileVersion 解析路径中的版本号，返回对应的历史版本；版本号等于当前版本时返回 nil。失败时已写入响应。
+func loadFileVersion(c *gin.Context, db *gorm.DB, f *models.File) (*models.FileVersion, bool) {
+	number, err := strconv.ParseUint(c.Param("version"), 10, 32)
+	if err != nil || number == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的版本号"})
+		return nil, false
+	}
+	if uint(number) == f.Version {
+		return nil, true
+	}
+	var version models.FileVersion
+	if err := db.Where("file_id = ? AND version = ?", f.ID, number).First(&version).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "版本不存在"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return &version, true
+}
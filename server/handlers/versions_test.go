@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"content-hub/server/config"
+	"content-hub/server/models"
+	"content-hub/server/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// uploadVersion 以 multipart 表单调用 UploadFileVersion。
+func uploadVersion(t *testing.T, db *gorm.DB, cfg *config.Config, store storage.Driver, user models.User, fileID uint, content string) *httptest.ResponseRecorder {
+	t.Helper()
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	part, _ := mw.CreateFormFile("file", "ignored.txt")
+	_, _ = part.Write([]byte(content))
+	_ = mw.Close()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/files/x/versions", body)
+	c.Request.Header.Set("Content-Type", mw.FormDataContentType())
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(fileID)}}
+	c.Set("userID", user.ID)
+	c.Set("role", user.Role)
+	UploadFileVersion(db, cfg, store)(c)
+	return w
+}
+
+func callVersion(h gin.HandlerFunc, user models.User, method string, fileID uint, version uint) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, "/api/files/x/versions/y", nil)
+	c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(fileID)}, {Key: "version", Value: fmt.Sprint(version)}}
+	c.Set("userID", user.ID)
+	c.Set("role", user.Role)
+	h(c)
+	return w
+}
+
+func TestFileVersions(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	owner := createUser(t, db, "owner", models.RoleUser)
+	other := createUser(t, db, "other", models.RoleUser)
+	admin := createUser(t, db, "root", models.RoleAdmin)
+
+	w := uploadMultipart(t, db, cfg, store, owner.ID, "report.txt", []byte("first draft"))
+	var created struct {
+		ID uint `json:"id"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	share := models.Share{Token: "versioned", FileID: created.ID, CreatorID: owner.ID}
+	if err := db.Create(&share).Error; err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+
+	if w := uploadVersion(t, db, cfg, store, other, created.ID, "hijack"); w.Code != http.StatusNotFound {
+		t.Fatalf("non-owner should not upload versions, got %d", w.Code)
+	}
+	w = uploadVersion(t, db, cfg, store, owner, created.ID, "second draft")
+	if w.Code != http.StatusOK {
+		t.Fatalf("upload version status = %d body=%s", w.Code, w.Body.String())
+	}
+	var updated FileResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &updated)
+	if updated.ID != created.ID || updated.Version != 2 || updated.Filename != "report.txt" || updated.Size != int64(len("second draft")) {
+		t.Fatalf("unexpected file after new version %+v", updated)
+	}
+
+	// 分享链接沿用同一文件 ID，直接看到新内容
+	sw := httptest.NewRecorder()
+	sc, _ := gin.CreateTestContext(sw)
+	sc.Request = httptest.NewRequest(http.MethodGet, "/api/shares/versioned/stream", nil)
+	sc.Params = gin.Params{{Key: "token", Value: share.Token}}
+	StreamShare(db, &config.Config{JWTSecret: "test"}, store)(sc)
+	if sw.Code != http.StatusOK || sw.Body.String() != "second draft" {
+		t.Fatalf("share should serve latest content, got %d %q", sw.Code, sw.Body.String())
+	}
+
+	w = callVersion(ListFileVersions(db), owner, http.MethodGet, created.ID, 0)
+	var versions []fileVersionResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &versions)
+	if len(versions) != 2 || !versions[0].Current || versions[0].Version != 2 || versions[1].Version != 1 {
+		t.Fatalf("unexpected version list %+v", versions)
+	}
+
+	w = callVersion(DownloadFileVersion(db, store), owner, http.MethodGet, created.ID, 1)
+	if w.Code != http.StatusOK || w.Body.String() != "first draft" {
+		t.Fatalf("download v1 got %d %q", w.Code, w.Body.String())
+	}
+	if w := callVersion(DownloadFileVersion(db, store), owner, http.MethodGet, created.ID, 9); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown version should be 404, got %d", w.Code)
+	}
+
+	// 恢复 v1 生成 v3，内容与 v1 共享同一个 Blob
+	w = callVersion(RestoreFileVersion(db, cfg, store), owner, http.MethodPost, created.ID, 1)
+	if w.Code != http.StatusOK {
+		t.Fatalf("restore status = %d body=%s", w.Code, w.Body.String())
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &updated)
+	if updated.Version != 3 || updated.Size != int64(len("first draft")) {
+		t.Fatalf("unexpected file after restore %+v", updated)
+	}
+	var blob models.Blob
+	db.Where("digest = ?", updated.Digest).First(&blob)
+	if blob.RefCount != 2 {
+		t.Fatalf("restored content should be referenced twice, got %d", blob.RefCount)
+	}
+
+	// 历史版本计入空间占用
+	usage, _ := models.UsageByOwner(db, owner.ID)
+	if want := int64(len("first draft")*2 + len("second draft")); usage[owner.ID].UsedBytes != want || usage[owner.ID].FileCount != 1 {
+		t.Fatalf("usage should include versions: %+v, want %d bytes", usage[owner.ID], want)
+	}
+
+	// 永久删除时释放所有版本的内容
+	var paths []string
+	db.Model(&models.FileVersion{}).Pluck("path", &paths)
+	if w := deleteFileAs(db, store, created.ID, admin.ID, models.RoleAdmin); w.Code != http.StatusOK {
+		t.Fatalf("purge status = %d", w.Code)
+	}
+	for _, p := range paths {
+		if _, err := store.Stat(t.Context(), p); !errors.Is(err, storage.ErrNotExist) {
+			t.Fatalf("version content %s should be removed, stat err=%v", p, err)
+		}
+	}
+	var remaining int64
+	db.Model(&models.Blob{}).Count(&remaining)
+	if remaining != 0 {
+		t.Fatalf("all blobs should be released, %d remain", remaining)
+	}
+}
+
+func TestConcurrentFileVersions(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	owner := createUser(t, db, "owner", models.RoleUser)
+	f := persistTestFile(t, db, cfg, store, owner.ID, "notes.txt", "text/plain", []byte("v1"))
+
+	// 并发上传新版本时依次递增版本号，不会争用同一个历史版本号
+	const uploads = 4
+	codes := make(chan int, uploads)
+	var wg sync.WaitGroup
+	for i := range uploads {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- uploadVersion(t, db, cfg, store, owner, f.ID, fmt.Sprintf("v%d", i+2)).Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+	for code := range codes {
+		if code != http.StatusOK {
+			t.Fatalf("concurrent version upload status = %d, want 200", code)
+		}
+	}
+	var current models.File
+	db.First(&current, f.ID)
+	var versions []uint
+	db.Model(&models.FileVersion{}).Where("file_id = ?", f.ID).Order("version").Pluck("version", &versions)
+	if current.Version != uploads+1 || fmt.Sprint(versions) != "[1 2 3 4]" {
+		t.Fatalf("current version = %d, archived = %v", current.Version, versions)
+	}
+}
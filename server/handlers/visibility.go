@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"content-hub/server/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxFileGrantees 限制单个文件可单独授权的用户数。
+const maxFileGrantees = 100
+
+type updateVisibilityRequest struct {
+	Visibility string   `json:"visibility" binding:"required"`
+	Usernames  []string `json:"usernames"` // visibility 为 users 时可查看的用户
+}
+
+// UpdateFileVisibility 设置文件的可见范围：private 仅自己、users 指定用户、public 所有登录用户，仅所有者与管理员可修改。
+// @Summary 设置文件可见性
+// @Tags files
+// @Accept json
+// @Produce json
+// @Param id path int true "文件ID"
+// @Param payload body updateVisibilityRequest true "可见性与授权用户"
+// @Security BearerAuth
+// @Router /files/{id}/visibility [put]
+func UpdateFileVisibility(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		f, ok := loadOwnedFile(c, db)
+		if !ok {
+			return
+		}
+
+		var req updateVisibilityRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		visibility := strings.TrimSpace(req.Visibility)
+		if !models.ValidVisibility(visibility) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "visibility 仅支持 private / users / public"})
+			return
+		}
+
+		var grantees []models.User
+		if visibility == models.VisibilityUsers {
+			names := make([]string, 0, len(req.Usernames))
+			seen := map[string]bool{}
+			for _, name := range req.Usernames {
+				name = strings.TrimSpace(name)
+				if name == "" || seen[name] {
+					continue
+				}
+				seen[name] = true
+				names = append(names, name)
+			}
+			if len(names) == 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "visibility 为 users 时需指定 usernames"})
+				return
+			}
+			if len(names) > maxFileGrantees {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "授权用户过多"})
+				return
+			}
+			if err := db.Where("username IN ?", names).Find(&grantees).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if len(grantees) != len(names) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "指定的用户不存在"})
+				return
+			}
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(f).Update("visibility", visibility).Error; err != nil {
+				return err
+			}
+			// 授权名单整体替换，切换为 private / public 时清空
+			if err := tx.Where("file_id = ?", f.ID).Delete(&models.FileAccess{}).Error; err != nil {
+				return err
+			}
+			for _, u := range grantees {
+				if u.ID == f.OwnerID {
+					continue
+				}
+				if err := tx.Create(&models.FileAccess{FileID: f.ID, UserID: u.ID}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		f.Visibility = visibility
+
+		resp := buildFileResponse(f)
+		if resp.SharedWith, err = fileGrantees(db, f.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// loadVisibleFile 按可见性读取路径参数中的文件，无权查看与不存在一样返回 404，避免泄露文件是否存在。失败时已写入响应。
+func loadVisibleFile(c *gin.Context, db *gorm.DB) (*models.File, bool) {
+	userID, role := currentUser(c)
+	var f models.File
+	err := db.Preload("Owner").Scopes(models.VisibleTo(userID, role)).Where("files.id = ?", c.Param("id")).First(&f).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return &f, true
+}
+
+// fileGrantees 返回被单独授权查看文件的用户名列表。
+func fileGrantees(db *gorm.DB, fileID uint) ([]string, error) {
+	names := []string{}
+	err := db.Model(&models.User{}).
+		Joins("JOIN file_accesses ON file_accesses.user_id = users.id").
+		Where("file_accesses.file_id = ?", fileID).
+		Order("users.username").
+		Pluck("users.username", &names).Error
+	return names, err
+}
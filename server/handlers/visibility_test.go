@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"content-hub/server/models"
+	"github.com/gin-gonic/gin"
+)
+
+// callFileAs 以指定用户身份调用带 :id 参数的文件 handler。
+func callFileAs(h gin.HandlerFunc, user models.User, method string, fileID uint, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, "/api/files/x", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	if fileID != 0 {
+		c.Params = gin.Params{{Key: "id", Value: fmt.Sprint(fileID)}}
+	}
+	c.Set("userID", user.ID)
+	c.Set("role", user.Role)
+	h(c)
+	return w
+}
+
+// listedFiles 返回 ListFiles 对该用户可见的文件名集合。
+func listedFiles(t *testing.T, h gin.HandlerFunc, user models.User) map[string]bool {
+	t.Helper()
+	w := callFileAs(h, user, http.MethodGet, 0, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("list files status = %d body=%s", w.Code, w.Body.String())
+	}
+	var resp FileListResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	names := map[string]bool{}
+	for _, f := range resp.Items {
+		names[f.Filename] = true
+	}
+	return names
+}
+
+func TestFileVisibility(t *testing.T) {
+	db, cfg, store := setupFileTestEnv(t)
+	owner := createUser(t, db, "owner", models.RoleUser)
+	friend := createUser(t, db, "friend", models.RoleUser)
+	stranger := createUser(t, db, "stranger", models.RoleUser)
+	admin := createUser(t, db, "root", models.RoleAdmin)
+
+	ids := map[string]uint{}
+	for _, name := range []string{"private.txt", "users.txt", "public.txt"} {
+		w := uploadMultipart(t, db, cfg, store, owner.ID, name, []byte(name))
+		var resp struct {
+			ID uint `json:"id"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		ids[name] = resp.ID
+	}
+
+	// 只有所有者能修改可见性
+	if w := callFileAs(UpdateFileVisibility(db), stranger, http.MethodPut, ids["public.txt"], `{"visibility":"public"}`); w.Code != http.StatusNotFound {
+		t.Fatalf("stranger should not change visibility, got %d", w.Code)
+	}
+	if w := callFileAs(UpdateFileVisibility(db), owner, http.MethodPut, ids["public.txt"], `{"visibility":"everyone"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown visibility should be rejected, got %d", w.Code)
+	}
+	if w := callFileAs(UpdateFileVisibility(db), owner, http.MethodPut, ids["public.txt"], `{"visibility":"public"}`); w.Code != http.StatusOK {
+		t.Fatalf("set public status = %d body=%s", w.Code, w.Body.String())
+	}
+	w := callFileAs(UpdateFileVisibility(db), owner, http.MethodPut, ids["users.txt"], `{"visibility":"users","usernames":["friend"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("set users status = %d body=%s", w.Code, w.Body.String())
+	}
+	var updated FileResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &updated)
+	if updated.Visibility != models.VisibilityUsers || len(updated.SharedWith) != 1 || updated.SharedWith[0] != "friend" {
+		t.Fatalf("unexpected visibility response %+v", updated)
+	}
+
+	cases := []struct {
+		user    models.User
+		visible map[string]bool
+	}{
+		{owner, map[string]bool{"private.txt": true, "users.txt": true, "public.txt": true}},
+		{friend, map[string]bool{"private.txt": false, "users.txt": true, "public.txt": true}},
+		{stranger, map[string]bool{"private.txt": false, "users.txt": false, "public.txt": true}},
+		{admin, map[string]bool{"private.txt": true, "users.txt": true, "public.txt": true}},
+	}
+	for _, tc := range cases {
+		listed := listedFiles(t, ListFiles(db), tc.user)
+		for name, want := range tc.visible {
+			if listed[name] != want {
+				t.Errorf("%s list %s: visible=%v, want %v", tc.user.Username, name, listed[name], want)
+			}
+			wantCode := http.StatusNotFound
+			if want {
+				wantCode = http.StatusOK
+			}
+			endpoints := map[string]gin.HandlerFunc{
+				"info":     GetFileInfo(db),
+				"download": DownloadFile(db, store),
+				"stream":   StreamFile(db, store),
+			}
+			for label, h := range endpoints {
+				if w := callFileAs(h, tc.user, http.MethodGet, ids[name], ""); w.Code != wantCode {
+					t.Errorf("%s %s %s: status %d, want %d", tc.user.Username, label, name, w.Code, wantCode)
+				}
+			}
+		}
+	}
+
+	// 授权名单仅向所有者与管理员展示
+	w = callFileAs(GetFileInfo(db), friend, http.MethodGet, ids["users.txt"], "")
+	var info FileResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &info)
+	if len(info.SharedWith) != 0 {
+		t.Fatalf("grantee list should be hidden from non-owners: %+v", info.SharedWith)
+	}
+
+	// 改回私有后授权被撤销
+	if w := callFileAs(UpdateFileVisibility(db), owner, http.MethodPut, ids["users.txt"], `{"visibility":"private"}`); w.Code != http.StatusOK {
+		t.Fatalf("set private status = %d", w.Code)
+	}
+	if w := callFileAs(GetFileInfo(db), friend, http.MethodGet, ids["users.txt"], ""); w.Code != http.StatusNotFound {
+		t.Fatalf("revoked grantee should lose access, got %d", w.Code)
+	}
+}
@@ -0,0 +1,157 @@
+package highlight
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DetectLen 是识别语言时读取的内容长度。
+const DetectLen = 8 << 10
+
+// extensions 按扩展名识别语言；.txt 与无扩展名的文件（如粘贴的文字）按内容识别。
+var extensions = map[string]string{
+	".go":       "go",
+	".py":       "python",
+	".pyw":      "python",
+	".js":       "javascript",
+	".mjs":      "javascript",
+	".cjs":      "javascript",
+	".jsx":      "javascript",
+	".ts":       "typescript",
+	".tsx":      "typescript",
+	".java":     "java",
+	".c":        "c",
+	".h":        "c",
+	".cc":       "cpp",
+	".cpp":      "cpp",
+	".cxx":      "cpp",
+	".hpp":      "cpp",
+	".cs":       "csharp",
+	".rs":       "rust",
+	".rb":       "ruby",
+	".php":      "php",
+	".sh":       "shell",
+	".bash":     "shell",
+	".zsh":      "shell",
+	".sql":      "sql",
+	".css":      "css",
+	".json":     "json",
+	".yaml":     "yaml",
+	".yml":      "yaml",
+	".html":     "html",
+	".htm":      "html",
+	".xml":      "xml",
+	".svg":      "xml",
+	".md":       "markdown",
+	".markdown": "markdown",
+}
+
+// interpreters 按 #! 行中的解释器识别脚本语言。
+var interpreters = map[string]string{
+	"python":  "python",
+	"python3": "python",
+	"sh":      "shell",
+	"bash":    "shell",
+	"zsh":     "shell",
+	"node":    "javascript",
+	"ruby":    "ruby",
+	"php":     "php",
+}
+
+// contentRule 以正则匹配内容特征，按顺序取第一个命中的规则。
+type contentRule struct {
+	lang    string
+	pattern *regexp.Regexp
+}
+
+var contentRules = []contentRule{
+	{"go", regexp.MustCompile(`(?m)^package \w+\s*$[\s\S]*\bfunc\b`)},
+	{"rust", regexp.MustCompile(`(?m)^\s*(pub )?fn \w+[<(]|^use \w+(::\w+)+;`)},
+	{"cpp", regexp.MustCompile(`(?m)^#include\s*[<"][\s\S]*(std::|\bnamespace\b|\bclass\b|\btemplate\s*<)`)},
+	{"c", regexp.MustCompile(`(?m)^#include\s*[<"]`)},
+	{"java", regexp.MustCompile(`(?m)^\s*(public|private|protected)?\s*(final\s+)?class \w+[\s\S]*\b(public|private) \w`)},
+	{"csharp", regexp.MustCompile(`(?m)^using System(\.\w+)*;`)},
+	{"python", regexp.MustCompile(`(?m)^(def \w+\(.*\)\s*(->\s*[\w\[\], .]+)?:\s*$|class \w+(\(.*\))?:\s*$|from [\w.]+ import \w|if __name__ == .__main__.:)`)},
+	{"typescript", regexp.MustCompile(`(?m)^\s*(export )?(interface \w+ \{|type \w+ = )|:\s*(string|number|boolean)\b[;,)=]`)},
+	{"javascript", regexp.MustCompile(`(?m)^\s*(import .+ from ['"]|export (default|const|function)\b|const \w+ = require\(|module\.exports\b)|\bfunction\s*\w*\s*\(.*\)\s*\{|\bconsole\.log\(|=>\s*[{(]`)},
+	{"sql", regexp.MustCompile(`(?im)^\s*(select\s[\s\S]+\sfrom\s|insert\s+into\s|update\s+\w+\s+set\s|create\s+(table|index|view)\s|delete\s+from\s|alter\s+table\s)`)},
+	{"css", regexp.MustCompile(`(?m)^\s*([.#]?[\w-]+(\s*[,>+~]?\s*[.#:]?[\w-]+)*)\s*\{\s*$[\s\S]*^\s*[\w-]+\s*:\s*[^;]+;`)},
+	{"markdown", regexp.MustCompile("(?m)^(#{1,6} \\S|```|\\* \\S|- \\[[ x]\\] )")},
+}
+
+var (
+	yamlLine = regexp.MustCompile(`^\s*(- |[\w.-]+:(\s|$)|#|---\s*$)`)
+	// jsonStart 用于超过 DetectLen 而无法整体校验的 JSON
+	jsonStart = regexp.MustCompile(`^(\{\s*"[^"\n]*"\s*:|\[\s*[{\["\d])`)
+)
+
+// Detect 按文件扩展名与内容开头识别语言，无法识别时返回 Text。content 只需包含开头的 DetectLen 字节。
+func Detect(filename string, content []byte) string {
+	if lang, ok := extensions[strings.ToLower(filepath.Ext(filename))]; ok {
+		return lang
+	}
+	if len(content) > DetectLen {
+		content = content[:DetectLen]
+	}
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) == 0 {
+		return Text
+	}
+
+	if line, ok := bytes.CutPrefix(trimmed, []byte("#!")); ok {
+		if end := bytes.IndexByte(line, '\n'); end >= 0 {
+			line = line[:end]
+		}
+		fields := strings.Fields(string(line))
+		for i := len(fields) - 1; i >= 0; i-- {
+			if lang, ok := interpreters[filepath.Base(fields[i])]; ok {
+				return lang
+			}
+		}
+	}
+	lower := bytes.ToLower(trimmed[:min(len(trimmed), 64)])
+	switch {
+	case bytes.HasPrefix(lower, []byte("<?php")):
+		return "php"
+	case bytes.HasPrefix(lower, []byte("<!doctype html")), bytes.HasPrefix(lower, []byte("<html")):
+		return "html"
+	case bytes.HasPrefix(lower, []byte("<?xml")):
+		return "xml"
+	}
+	if json.Valid(trimmed) && (trimmed[0] == '{' || trimmed[0] == '[') || len(content) == DetectLen && jsonStart.Match(trimmed) {
+		return "json"
+	}
+
+	for _, rule := range contentRules {
+		if rule.pattern.Match(content) {
+			return rule.lang
+		}
+	}
+	if looksLikeYAML(trimmed) {
+		return "yaml"
+	}
+	return Text
+}
+
+// looksLikeYAML 要求至少两行、每个非空行都是键值、列表项、注释或文档分隔符，且存在嵌套或列表结构，
+// 避免把“备注: xxx”这类普通文字误判为 YAML。
+func looksLikeYAML(content []byte) bool {
+	lines, nested := 0, false
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indented := strings.HasPrefix(line, "  ")
+		if !yamlLine.MatchString(line) && !indented {
+			return false
+		}
+		if indented || strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "---") {
+			nested = true
+		}
+		lines++
+	}
+	return lines >= 2 && nested
+}
@@ -0,0 +1,312 @@
+// Package highlight 为文本分享提供语言识别与服务端语法高亮。
+// 不依赖第三方词法库：各语言以关键字、注释与字符串规则描述，由同一个扫描器切分为少量几类记号，
+// 输出只包含转义后的文本与 class 属性，不会把内容中的任何标记带入页面。
+package highlight
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 记号对应的 CSS class。
+const (
+	classKeyword = "k"
+	classString  = "s"
+	classComment = "c"
+	classNumber  = "n"
+	classTag     = "t"
+	classAttr    = "a"
+)
+
+// Stylesheet 为高亮结果的默认配色，Page 会内联该样式。
+const Stylesheet = `body{margin:0;background:#fafafa;color:#1e293b}
+pre.highlight{margin:0;padding:16px;font:13px/1.55 ui-monospace,SFMono-Regular,Menlo,Consolas,monospace;white-space:pre-wrap;word-break:break-all;tab-size:4}
+.highlight .k{color:#7c3aed;font-weight:600}
+.highlight .s{color:#047857}
+.highlight .c{color:#94a3b8;font-style:italic}
+.highlight .n{color:#c2410c}
+.highlight .t{color:#2563eb}
+.highlight .a{color:#b45309}`
+
+// HTML 将 src 按 lang 着色为 <pre class="highlight"> 片段；不支持的语言按纯文本输出。无效的 UTF-8 字节会被替换。
+func HTML(lang string, src string) string {
+	name, ok := Normalize(lang)
+	if !ok {
+		name = Text
+	}
+	src = strings.ToValidUTF8(src, "�")
+
+	var b strings.Builder
+	b.Grow(len(src) * 2)
+	b.WriteString(`<pre class="highlight" data-language="`)
+	b.WriteString(name)
+	b.WriteString(`"><code>`)
+	s := &scanner{lang: languages[name], src: src, out: &b}
+	if s.lang.markup {
+		s.scanMarkup()
+	} else {
+		s.scanCode()
+	}
+	b.WriteString("</code></pre>")
+	return b.String()
+}
+
+// Page 返回包含内联样式的完整 HTML 文档，可直接在浏览器中打开或嵌入 iframe。
+func Page(title, lang, src string) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><meta name=\"viewport\" content=\"width=device-width, initial-scale=1\"><title>")
+	b.WriteString(html.EscapeString(title))
+	b.WriteString("</title><style>")
+	b.WriteString(Stylesheet)
+	b.WriteString("</style></head><body>")
+	b.WriteString(HTML(lang, src))
+	b.WriteString("</body></html>\n")
+	return b.String()
+}
+
+type scanner struct {
+	lang *language
+	src  string
+	pos  int
+	out  *strings.Builder
+}
+
+// emit 输出 src[start:end]，class 为空时不加 span。
+func (s *scanner) emit(class string, start, end int) {
+	if start >= end {
+		return
+	}
+	if class == "" {
+		s.out.WriteString(html.EscapeString(s.src[start:end]))
+		return
+	}
+	s.out.WriteString(`<span class="`)
+	s.out.WriteString(class)
+	s.out.WriteString(`">`)
+	s.out.WriteString(html.EscapeString(s.src[start:end]))
+	s.out.WriteString("</span>")
+}
+
+func (s *scanner) scanCode() {
+	lang := s.lang
+	plain := 0
+	flush := func() { s.emit("", plain, s.pos) }
+	for s.pos < len(s.src) {
+		rest := s.src[s.pos:]
+		start := s.pos
+
+		if end, ok := s.matchComment(rest); ok {
+			flush()
+			s.pos += end
+			s.emit(classComment, start, s.pos)
+			plain = s.pos
+			continue
+		}
+		if end, ok := s.matchString(rest); ok {
+			flush()
+			s.pos += end
+			class := classString
+			if lang.objectKeys && strings.HasPrefix(strings.TrimLeft(s.src[s.pos:], " \t"), ":") {
+				class = classAttr
+			}
+			s.emit(class, start, s.pos)
+			plain = s.pos
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(rest)
+		prevIdent := start > 0 && s.isIdent(lastRune(s.src[:start]))
+		switch {
+		case !prevIdent && unicode.IsDigit(r):
+			flush()
+			s.pos += scanWhile(rest, func(r rune) bool {
+				return unicode.IsDigit(r) || unicode.IsLetter(r) || r == '.' || r == '_'
+			})
+			s.emit(classNumber, start, s.pos)
+			plain = s.pos
+		case !prevIdent && s.isIdentStart(r):
+			end := scanWhile(rest, s.isIdent)
+			word := rest[:end]
+			key := word
+			if lang.ignoreCase {
+				key = strings.ToLower(word)
+			}
+			class := ""
+			if lang.keywords[key] {
+				class = classKeyword
+			} else if lang.literals[key] {
+				class = classNumber
+			}
+			if class != "" {
+				flush()
+			}
+			s.pos += end
+			if class != "" {
+				s.emit(class, start, s.pos)
+				plain = s.pos
+			}
+		default:
+			s.pos += size
+		}
+	}
+	flush()
+}
+
+// matchComment 判断 rest 是否以注释开头，返回注释的长度；块注释未闭合时延续到末尾。
+func (s *scanner) matchComment(rest string) (int, bool) {
+	for _, pair := range s.lang.blockComments {
+		if strings.HasPrefix(rest, pair[0]) {
+			if end := strings.Index(rest[len(pair[0]):], pair[1]); end >= 0 {
+				return len(pair[0]) + end + len(pair[1]), true
+			}
+			return len(rest), true
+		}
+	}
+	for _, prefix := range s.lang.lineComments {
+		if strings.HasPrefix(rest, prefix) {
+			// shell 等语言中 $# 不是注释
+			if prefix == "#" && s.pos > 0 && s.src[s.pos-1] == '$' {
+				continue
+			}
+			if end := strings.IndexByte(rest, '\n'); end >= 0 {
+				return end, true
+			}
+			return len(rest), true
+		}
+	}
+	return 0, false
+}
+
+// matchString 判断 rest 是否以字符串开头，返回字符串的长度；未闭合的单行字符串截止到行尾。
+func (s *scanner) matchString(rest string) (int, bool) {
+	if rest == "" {
+		return 0, false
+	}
+	q := rest[0]
+	if s.lang.tripleQuotes && (strings.HasPrefix(rest, `"""`) || strings.HasPrefix(rest, `'''`)) {
+		if end := strings.Index(rest[3:], rest[:3]); end >= 0 {
+			return 3 + end + 3, true
+		}
+		return len(rest), true
+	}
+	if strings.IndexByte(s.lang.rawQuotes, q) >= 0 {
+		if end := strings.IndexByte(rest[1:], q); end >= 0 {
+			return end + 2, true
+		}
+		return len(rest), true
+	}
+	if strings.IndexByte(s.lang.quotes, q) < 0 {
+		return 0, false
+	}
+	for i := 1; i < len(rest); i++ {
+		switch rest[i] {
+		case '\\':
+			i++
+		case q:
+			return i + 1, true
+		case '\n':
+			if !s.lang.multiline {
+				return i, true
+			}
+		}
+	}
+	return len(rest), true
+}
+
+func (s *scanner) isIdentStart(r rune) bool {
+	return unicode.IsLetter(r) || r == '_' || strings.ContainsRune(s.lang.identChars, r)
+}
+
+func (s *scanner) isIdent(r rune) bool {
+	return s.isIdentStart(r) || unicode.IsDigit(r)
+}
+
+// scanMarkup 为 HTML / XML 着色：注释、标签名、属性名与属性值，标签之外的文本原样输出。
+func (s *scanner) scanMarkup() {
+	plain := 0
+	for s.pos < len(s.src) {
+		rest := s.src[s.pos:]
+		start := s.pos
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			s.emit("", plain, start)
+			end := strings.Index(rest, "-->")
+			if end < 0 {
+				s.pos = len(s.src)
+			} else {
+				s.pos += end + 3
+			}
+			s.emit(classComment, start, s.pos)
+			plain = s.pos
+		case len(rest) > 1 && rest[0] == '<' && (rest[1] == '/' || rest[1] == '!' || rest[1] == '?' || isASCIILetter(rest[1])):
+			s.emit("", plain, start)
+			s.scanTag()
+			plain = s.pos
+		default:
+			_, size := utf8.DecodeRuneInString(rest)
+			s.pos += size
+		}
+	}
+	s.emit("", plain, s.pos)
+}
+
+// scanTag 从 < 开始扫描一个标签直到 >（或内容结束）。
+func (s *scanner) scanTag() {
+	start := s.pos
+	s.pos++
+	s.pos += scanWhile(s.src[s.pos:], func(r rune) bool {
+		return r == '/' || r == '!' || r == '?' || unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == ':' || r == '_'
+	})
+	s.emit(classTag, start, s.pos)
+	for s.pos < len(s.src) {
+		rest := s.src[s.pos:]
+		start := s.pos
+		switch c := rest[0]; {
+		case c == '>':
+			s.pos++
+			s.emit(classTag, start, s.pos)
+			return
+		case strings.HasPrefix(rest, "/>"), strings.HasPrefix(rest, "?>"):
+			s.pos += 2
+			s.emit(classTag, start, s.pos)
+			return
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(rest[1:], c)
+			if end < 0 {
+				s.pos = len(s.src)
+			} else {
+				s.pos += end + 2
+			}
+			s.emit(classString, start, s.pos)
+		case isASCIILetter(c) || c == '_' || c == ':' || c == '@':
+			s.pos += scanWhile(rest, func(r rune) bool {
+				return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == ':' || r == '_' || r == '.' || r == '@'
+			})
+			s.emit(classAttr, start, s.pos)
+		default:
+			_, size := utf8.DecodeRuneInString(rest)
+			s.pos += size
+			s.emit("", start, s.pos)
+		}
+	}
+}
+
+func scanWhile(s string, ok func(rune) bool) int {
+	for i, r := range s {
+		if !ok(r) {
+			return i
+		}
+	}
+	return len(s)
+}
+
+func lastRune(s string) rune {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return r
+}
+
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,98 @@
+package highlight
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	cases := []struct {
+		filename string
+		content  string
+		want     string
+	}{
+		{"main.go", "", "go"},
+		{"App.TSX", "", "typescript"},
+		{"text-1.txt", "package main\n\nfunc main() {}\n", "go"},
+		{"paste.txt", "#!/usr/bin/env python3\nprint('hi')\n", "python"},
+		{"paste.txt", "#!/bin/bash\necho hi\n", "shell"},
+		{"paste.txt", "def add(a, b):\n    return a + b\n", "python"},
+		{"paste.txt", `{"name": "hub", "tags": [1, 2]}`, "json"},
+		{"paste.txt", "<!DOCTYPE html>\n<html><body></body></html>", "html"},
+		{"paste.txt", "<?php echo 'hi';", "php"},
+		{"paste.txt", "#include <stdio.h>\nint main(void) { return 0; }\n", "c"},
+		{"paste.txt", "#include <vector>\nint main() { std::vector<int> v; }\n", "cpp"},
+		{"paste.txt", "SELECT id, name\nFROM users\nWHERE id = 1;\n", "sql"},
+		{"paste.txt", "const fs = require('fs')\nconsole.log(fs)\n", "javascript"},
+		{"paste.txt", "fn main() {\n    println!(\"hi\");\n}\n", "rust"},
+		{"paste.txt", "server:\n  port: 8080\n  host: localhost\n", "yaml"},
+		{"paste.txt", "# 标题\n\n正文段落\n", "markdown"},
+		{"paste.txt", "备注: 明天开会\n时间: 下午三点\n", Text},
+		{"paste.txt", "just some words", Text},
+		{"", "", Text},
+	}
+	for _, tc := range cases {
+		if got := Detect(tc.filename, []byte(tc.content)); got != tc.want {
+			t.Errorf("Detect(%q, %q) = %q, want %q", tc.filename, tc.content, got, tc.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	for in, want := range map[string]string{"Go": "go", "js": "javascript", " bash ": "shell", "C++": "cpp", "plaintext": Text} {
+		if got, ok := Normalize(in); !ok || got != want {
+			t.Errorf("Normalize(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+	if _, ok := Normalize("brainfuck"); ok {
+		t.Error("unsupported language accepted")
+	}
+}
+
+func TestHTMLEscapesAndClassifies(t *testing.T) {
+	src := "// <b>note</b>\nfunc main() {\n\ts := \"<script>\" + `x`\n\tn := 42\n\treturn nil\n}\n"
+	out := HTML("go", src)
+	for _, want := range []string{
+		`<pre class="highlight" data-language="go"><code>`,
+		`<span class="c">// &lt;b&gt;note&lt;/b&gt;</span>`,
+		`<span class="k">func</span> main`,
+		`<span class="s">&#34;&lt;script&gt;&#34;</span>`,
+		"<span class=\"s\">`x`</span>",
+		`<span class="n">42</span>`,
+		`<span class="n">nil</span>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "<script>") || strings.Contains(out, "<b>") {
+		t.Fatalf("unescaped markup in output:\n%s", out)
+	}
+}
+
+func TestHTMLLanguages(t *testing.T) {
+	cases := []struct {
+		lang, src, want string
+	}{
+		{"python", "x = '''a\nb'''  # done", `<span class="s">&#39;&#39;&#39;a` + "\n" + `b&#39;&#39;&#39;</span>  <span class="c"># done</span>`},
+		{"sql", "select 1 from t", `<span class="k">select</span> <span class="n">1</span> <span class="k">from</span> t`},
+		{"json", `{"a": "b"}`, `{<span class="a">&#34;a&#34;</span>: <span class="s">&#34;b&#34;</span>}`},
+		{"shell", "echo $# # count", `<span class="k">echo</span> $# <span class="c"># count</span>`},
+		{"html", `<a href="/x">hi</a><!-- c -->`, `<span class="t">&lt;a</span> <span class="a">href</span>=<span class="s">&#34;/x&#34;</span><span class="t">&gt;</span>hi<span class="t">&lt;/a</span><span class="t">&gt;</span><span class="c">&lt;!-- c --&gt;</span>`},
+		{"unknown", "if x", "if x"},
+		{"go", "v2 := x1", "v2 := x1"},
+	}
+	for _, tc := range cases {
+		out := HTML(tc.lang, tc.src)
+		if !strings.Contains(out, tc.want) {
+			t.Errorf("HTML(%q, %q) = %s\nwant substring %s", tc.lang, tc.src, out, tc.want)
+		}
+	}
+}
+
+func TestPage(t *testing.T) {
+	page := Page("<x>.go", "go", "package x\xff")
+	if !strings.Contains(page, "<title>&lt;x&gt;.go</title>") || !strings.Contains(page, Stylesheet) || !strings.Contains(page, "x�") {
+		t.Fatalf("page = %s", page)
+	}
+}
@@ -0,0 +1,214 @@
+package highlight
+
+import "strings"
+
+// Text 表示无法识别或无需着色的纯文本。
+const Text = "text"
+
+// language 描述一种语言的词法规则，供通用的扫描器使用。
+type language struct {
+	keywords      map[string]bool
+	literals      map[string]bool // true / false / null 等字面量，按数字着色
+	lineComments  []string
+	blockComments [][2]string
+	quotes        string // 字符串定界符
+	rawQuotes     string // 不处理转义的定界符，如 Go 的反引号
+	tripleQuotes  bool   // 支持 """ 与 ''' 多行字符串
+	multiline     bool   // 普通字符串可跨行
+	ignoreCase    bool   // 关键字不区分大小写
+	identChars    string // 标识符中除字母、数字与下划线外允许的字符
+	markup        bool   // HTML / XML：按标签、属性与注释着色
+	objectKeys    bool   // 后跟冒号的字符串视为键名（JSON）
+}
+
+func words(s string) map[string]bool {
+	m := map[string]bool{}
+	for _, w := range strings.Fields(s) {
+		m[w] = true
+	}
+	return m
+}
+
+var (
+	cStyleComments = [][2]string{{"/*", "*/"}}
+	cLiterals      = words("true false NULL nullptr")
+	jsKeywords     = "async await break case catch class const continue debugger default delete do else export extends finally for from function if import in instanceof let new of return static super switch this throw try typeof var void while with yield"
+)
+
+var languages = map[string]*language{
+	"go": {
+		keywords:      words("break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var"),
+		literals:      words("true false nil iota"),
+		lineComments:  []string{"//"},
+		blockComments: cStyleComments,
+		quotes:        `"'`,
+		rawQuotes:     "`",
+	},
+	"python": {
+		keywords:     words("and as assert async await break class continue def del elif else except finally for from global if import in is lambda nonlocal not or pass raise return try while with yield match case"),
+		literals:     words("True False None"),
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		tripleQuotes: true,
+	},
+	"javascript": {
+		keywords:      words(jsKeywords),
+		literals:      words("true false null undefined NaN Infinity"),
+		lineComments:  []string{"//"},
+		blockComments: cStyleComments,
+		quotes:        `"'`,
+		rawQuotes:     "`",
+		identChars:    "$",
+	},
+	"typescript": {
+		keywords:      words(jsKeywords + " abstract as declare enum implements interface keyof namespace private protected public readonly type"),
+		literals:      words("true false null undefined NaN Infinity"),
+		lineComments:  []string{"//"},
+		blockComments: cStyleComments,
+		quotes:        `"'`,
+		rawQuotes:     "`",
+		identChars:    "$",
+	},
+	"java": {
+		keywords:      words("abstract assert boolean break byte case catch char class const continue default do double else enum extends final finally float for goto if implements import instanceof int interface long native new package private protected public return short static strictfp super switch synchronized this throw throws transient try var void volatile while record"),
+		literals:      words("true false null"),
+		lineComments:  []string{"//"},
+		blockComments: cStyleComments,
+		quotes:        `"'`,
+	},
+	"c": {
+		keywords:      words("auto break case char const continue default do double else enum extern float for goto if inline int long register restrict return short signed sizeof static struct switch typedef union unsigned void volatile while #include #define #ifdef #ifndef #endif #if #else #elif #pragma"),
+		literals:      cLiterals,
+		lineComments:  []string{"//"},
+		blockComments: cStyleComments,
+		quotes:        `"'`,
+		identChars:    "#",
+	},
+	"cpp": {
+		keywords:      words("auto bool break case catch char class const constexpr continue default delete do double else enum explicit extern float for friend goto if inline int long mutable namespace new noexcept operator private protected public register return short signed sizeof static struct switch template this throw try typedef typename union unsigned using virtual void volatile while #include #define #ifdef #ifndef #endif #if #else #elif #pragma"),
+		literals:      cLiterals,
+		lineComments:  []string{"//"},
+		blockComments: cStyleComments,
+		quotes:        `"'`,
+		identChars:    "#",
+	},
+	"csharp": {
+		keywords:      words("abstract as async await base bool break byte case catch char class const continue decimal default delegate do double else enum event explicit extern finally fixed float for foreach goto if implicit in int interface internal is lock long namespace new object operator out override params private protected public readonly ref return sealed short static string struct switch this throw try typeof uint ulong unsafe ushort using var virtual void volatile while"),
+		literals:      words("true false null"),
+		lineComments:  []string{"//"},
+		blockComments: cStyleComments,
+		quotes:        `"'`,
+	},
+	"rust": {
+		keywords:      words("as async await break const continue crate dyn else enum extern fn for if impl in let loop match mod move mut pub ref return self Self static struct super trait type unsafe use where while"),
+		literals:      words("true false None Some Ok Err"),
+		lineComments:  []string{"//"},
+		blockComments: cStyleComments,
+		quotes:        `"`,
+		multiline:     true,
+	},
+	"ruby": {
+		keywords:     words("alias and begin break case class def defined? do else elsif end ensure for if in module next not or redo rescue retry return self super then undef unless until when while yield require"),
+		literals:     words("true false nil"),
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		identChars:   "?!",
+	},
+	"php": {
+		keywords:      words("abstract and array as break callable case catch class clone const continue declare default do echo else elseif empty enddeclare endfor endforeach endif endswitch endwhile extends final finally fn for foreach function global goto if implements include include_once instanceof insteadof interface isset list match namespace new or print private protected public require require_once return static switch throw trait try unset use var while yield"),
+		literals:      words("true false null TRUE FALSE NULL"),
+		lineComments:  []string{"//", "#"},
+		blockComments: cStyleComments,
+		quotes:        `"'`,
+		identChars:    "$",
+	},
+	"shell": {
+		keywords:     words("if then else elif fi case esac for select while until do done in function return local export readonly declare unset shift exit break continue source alias echo set"),
+		literals:     words("true false"),
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		multiline:    true,
+	},
+	"sql": {
+		keywords:      words("select from where and or not insert into values update set delete create table drop alter add column index primary key foreign references join inner left right outer full on as group by order having limit offset union all distinct case when then else end is in like between exists view begin commit rollback transaction default unique constraint returning with asc desc"),
+		literals:      words("null true false"),
+		lineComments:  []string{"--"},
+		blockComments: cStyleComments,
+		quotes:        `'"`,
+		multiline:     true,
+		ignoreCase:    true,
+	},
+	"css": {
+		keywords:      words("@media @import @font-face @keyframes @supports !important"),
+		blockComments: cStyleComments,
+		quotes:        `"'`,
+		identChars:    "-@!",
+	},
+	"json": {
+		literals:   words("true false null"),
+		quotes:     `"`,
+		objectKeys: true,
+	},
+	"yaml": {
+		literals:     words("true false null yes no on off ~"),
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+	},
+	"html": {
+		markup: true,
+	},
+	"xml": {
+		markup: true,
+	},
+	"markdown": {},
+	Text:       {},
+}
+
+// aliases 将常见的别名与扩展名映射到规范名称。
+var aliases = map[string]string{
+	"golang":    "go",
+	"py":        "python",
+	"python3":   "python",
+	"js":        "javascript",
+	"jsx":       "javascript",
+	"node":      "javascript",
+	"ts":        "typescript",
+	"tsx":       "typescript",
+	"c++":       "cpp",
+	"cc":        "cpp",
+	"c#":        "csharp",
+	"cs":        "csharp",
+	"rs":        "rust",
+	"rb":        "ruby",
+	"sh":        "shell",
+	"bash":      "shell",
+	"zsh":       "shell",
+	"yml":       "yaml",
+	"htm":       "html",
+	"svg":       "xml",
+	"md":        "markdown",
+	"txt":       Text,
+	"plain":     Text,
+	"plaintext": Text,
+}
+
+// Normalize 返回语言的规范名称（不区分大小写，支持 js、py、sh 等别名），不支持的语言返回 false。
+func Normalize(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := aliases[name]; ok {
+		name = alias
+	}
+	if _, ok := languages[name]; !ok {
+		return "", false
+	}
+	return name, true
+}
+
+// Languages 返回支持的语言名称。
+func Languages() []string {
+	names := make([]string, 0, len(languages))
+	for name := range languages {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Blob 是按 SHA-256 摘要寻址的文件内容，多个 File 记录可共享同一个 Blob，RefCount 记录引用数量。
+type Blob struct {
+	gorm.Model
+	Digest   string `gorm:"uniqueIndex;size:64" json:"digest"`
+	Path     string `json:"path"` // 存储驱动中的 key
+	Size     int64  `json:"size"`
+	RefCount int64  `json:"ref_count"`
+}
+
+// BlobKey 返回摘要对应的存储 key，按前两位分目录避免单目录文件过多。
+func BlobKey(digest string) string {
+	return "blobs/" + digest[:2] + "/" + digest
+}
+
+// AcquireBlob 为摘要增加一次引用；不存在时创建引用数为 1 的记录。需在事务中与 File 的创建一并执行。
+func AcquireBlob(tx *gorm.DB, digest, path string, size int64) (*Blob, error) {
+	blob := Blob{Digest: digest, Path: path, Size: size, RefCount: 1}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "digest"}},
+		DoUpdates: clause.Assignments(map[string]any{"ref_count": gorm.Expr("ref_count + 1")}),
+	}).Create(&blob).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("digest = ?", digest).First(&blob).Error; err != nil {
+		return nil, err
+	}
+	return &blob, nil
+}
+
+// ReleaseBlob 减少一次引用，返回是否为最后一个引用；最后一个引用释放时删除 Blob 记录，调用方据此删除实际内容。
+// 递减使用条件更新，避免并发释放时读到相同的引用数而漏删。
+func ReleaseBlob(tx *gorm.DB, digest string) (*Blob, bool, error) {
+	var blob Blob
+	if err := tx.Where("digest = ?", digest).First(&blob).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	res := tx.Model(&Blob{}).Where("digest = ? AND ref_count > 1", digest).UpdateColumn("ref_count", gorm.Expr("ref_count - 1"))
+	if res.Error != nil {
+		return nil, false, res.Error
+	}
+	if res.RowsAffected > 0 {
+		return &blob, false, nil
+	}
+	if err := tx.Unscoped().Where("digest = ?", digest).Delete(&Blob{}).Error; err != nil {
+		return nil, false, err
+	}
+	return &blob, true, nil
+}
@@ -1,15 +1,97 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 文件可见性：private 仅所有者可见，users 对 FileAccess 中列出的用户可见，public 对所有登录用户可见。
+const (
+	VisibilityPrivate = "private"
+	VisibilityUsers   = "users"
+	VisibilityPublic  = "public"
+)
+
+// 恶意软件扫描状态：只有 clean 的文件允许下载、预览与分享访问。未启用扫描时上传即为 clean。
+const (
+	ScanPending  = "pending"
+	ScanClean    = "clean"
+	ScanInfected = "infected"
+	ScanError    = "error"
+)
 
 type File struct {
 	gorm.Model
-	OwnerID     uint   `json:"owner_id"`
-	Owner       User   `gorm:"constraint:OnDelete:CASCADE" json:"owner"`
-	Filename    string `json:"filename"`
-	Path        string `json:"path"`
-	Size        int64  `json:"size"`
-	MimeType    string `json:"mime_type"`
-	Description string `json:"description"`
-	PublicLink  string `json:"public_link"` // optional share token path
+	OwnerID          uint   `json:"owner_id"`
+	Owner            User   `gorm:"constraint:OnDelete:CASCADE" json:"owner"`
+	FolderID         *uint  `gorm:"index" json:"folder_id"` // 所在目录，为空表示根目录
+	Filename         string `json:"filename"`
+	Path             string `json:"path"`
+	Size             int64  `json:"size"`
+	MimeType         string `json:"mime_type"`                   // 保存并用于响应的类型，以按内容识别的结果为准
+	DeclaredMimeType string `json:"declared_mime_type"`          // 客户端上传时声明的类型
+	DetectedMimeType string `json:"detected_mime_type"`          // 按文件头识别出的类型，类型识别上线前上传的文件为空
+	Digest           string `gorm:"index;size:64" json:"digest"` // 内容的 SHA-256，空值表示去重存储之前上传的旧文件
+	Description      string `json:"description"`
+	PublicLink       string `json:"public_link"` // optional share token path
+	Visibility       string `gorm:"size:16;default:private;index" json:"visibility"`
+	Version          uint   `gorm:"default:1" json:"version"` // 当前版本号，历史版本见 FileVersion
+	// ScanStatus 为当前版本的扫描状态，扫描功能上线前的文件迁移后视为 clean；ScanResult 保存命中的病毒名或扫描错误
+	ScanStatus string     `gorm:"size:16;not null;default:clean;index" json:"scan_status"`
+	ScanResult string     `json:"scan_result,omitempty"`
+	ScannedAt  *time.Time `json:"scanned_at,omitempty"`
+	// ScanAttempts 为当前内容连续扫描出错的次数，达到上限后停止自动重试，需管理员手动重新扫描
+	ScanAttempts uint `gorm:"not null;default:0" json:"-"`
+	// TrashBatch 为随目录一起删除时的批次，单独删除的文件为空；见 Folder.TrashBatch
+	TrashBatch string `gorm:"size:36;index" json:"-"`
+	// DropID 为通过上传链接收集的文件所属的链接，链接撤销后仍保留以便追溯来源
+	DropID *uint `gorm:"index" json:"drop_id,omitempty"`
+	// Language 为文本内容的语言，上传时指定或自动识别，非文本为空
+	Language string `gorm:"size:32" json:"language,omitempty"`
+}
+
+// FileAccess 记录 visibility 为 users 的文件额外授权给哪些用户查看。
+type FileAccess struct {
+	ID     uint `gorm:"primaryKey" json:"id"`
+	FileID uint `gorm:"uniqueIndex:idx_file_access;not null" json:"file_id"`
+	File   File `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	UserID uint `gorm:"uniqueIndex:idx_file_access;index;not null" json:"user_id"`
+	User   User `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// ValidVisibility 判断可见性取值是否受支持。
+func ValidVisibility(v string) bool {
+	switch v {
+	case VisibilityPrivate, VisibilityUsers, VisibilityPublic:
+		return true
+	}
+	return false
+}
+
+// VisibleTo 返回按可见性过滤文件的查询作用域：管理员可见全部，其他用户可见自己的、公开的以及被单独授权的文件。
+func VisibleTo(userID uint, role string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if role == RoleAdmin {
+			return db
+		}
+		return db.Where(
+			"files.owner_id = ? OR files.visibility = ? OR (files.visibility = ? AND files.id IN (?))",
+			userID, VisibilityPublic, VisibilityUsers,
+			db.Session(&gorm.Session{NewDB: true}).Model(&FileAccess{}).Select("file_id").Where("user_id = ?", userID),
+		)
+	}
+}
+
+// CanView 判断用户能否查看指定文件，用于已加载记录后的单条校验。
+func (f *File) CanView(db *gorm.DB, userID uint, role string) (bool, error) {
+	if role == RoleAdmin || f.OwnerID == userID || f.Visibility == VisibilityPublic {
+		return true, nil
+	}
+	if f.Visibility != VisibilityUsers {
+		return false, nil
+	}
+	var count int64
+	err := db.Model(&FileAccess{}).Where("file_id = ? AND user_id = ?", f.ID, userID).Count(&count).Error
+	return count > 0, err
 }
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FileDrop 是收集文件的上传链接：持有 Token 的访客无需登录即可在限制内上传，文件归入 Owner 的空间并记录来源链接。
+type FileDrop struct {
+	gorm.Model
+	Token        string `gorm:"uniqueIndex;size:191" json:"token"`
+	OwnerID      uint   `gorm:"index" json:"owner_id"`
+	Owner        User   `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	FolderID     *uint  `json:"folder_id"` // 上传的目标目录，为空或目录已删除时放入根目录
+	Title        string `gorm:"size:255" json:"title"`
+	Instructions string `json:"instructions"`
+	// MaxFiles 为可上传的文件总数，为空表示不限；MaxFileSize 为单个文件的字节上限，0 表示仅受所有者配额限制
+	MaxFiles    *uint `json:"max_files"`
+	MaxFileSize int64 `json:"max_file_size"`
+	// AllowedTypes 为逗号分隔的 MIME 类型（支持 image/*），为空表示只受全局上传策略限制
+	AllowedTypes string     `json:"allowed_types"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	UploadCount  uint       `json:"upload_count"`
+}
+
+// Expired 判断上传链接是否已过期。
+func (d *FileDrop) Expired(now time.Time) bool {
+	return d.ExpiresAt != nil && now.After(*d.ExpiresAt)
+}
+
+// Full 判断上传链接是否已达到文件数上限。
+func (d *FileDrop) Full() bool {
+	return d.MaxFiles != nil && d.UploadCount >= *d.MaxFiles
+}
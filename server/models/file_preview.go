@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 预览生成状态：pending 等待后台生成，ready 可用，unsupported 表示该类型不生成预览，failed 表示生成出错。
+const (
+	PreviewPending     = "pending"
+	PreviewReady       = "ready"
+	PreviewUnsupported = "unsupported"
+	PreviewFailed      = "failed"
+)
+
+// FilePreview 保存文件当前版本的预览：图片生成 JPEG 缩略图写入存储驱动，文本保存开头的摘录。
+// Version 对应生成时的 File.Version，上传新版本后重新置为 pending。
+type FilePreview struct {
+	FileID          uint      `gorm:"primaryKey;autoIncrement:false" json:"file_id"`
+	File            File      `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Version         uint      `json:"version"`
+	Status          string    `gorm:"size:16;index" json:"status"`
+	ThumbnailKey    string    `json:"-"`
+	ThumbnailSize   int64     `json:"thumbnail_size"`
+	ThumbnailWidth  int       `json:"thumbnail_width"`
+	ThumbnailHeight int       `json:"thumbnail_height"`
+	Excerpt         string    `json:"excerpt"`
+	Error           string    `json:"error,omitempty"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// QueueFilePreview 将文件当前版本标记为待生成预览，已有的缩略图保留到新预览生成后再替换。
+func QueueFilePreview(db *gorm.DB, f *File) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "file_id"}},
+		DoUpdates: clause.Assignments(map[string]any{"version": f.Version, "status": PreviewPending, "error": ""}),
+	}).Create(&FilePreview{FileID: f.ID, Version: f.Version, Status: PreviewPending}).Error
+}
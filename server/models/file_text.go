@@ -0,0 +1,99 @@
+package models
+
+import (
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// FileText 保存文本文件的内容副本，供全文检索使用。SQLite 编译了 FTS5（构建标签 sqlite_fts5）时，
+// file_texts_fts 虚拟表通过触发器与本表保持同步；否则退化为对本表的 LIKE 匹配。
+type FileText struct {
+	FileID  uint   `gorm:"primaryKey;autoIncrement:false" json:"file_id"`
+	Content string `json:"content"`
+}
+
+const fileTextFTSTable = "file_texts_fts"
+
+var fileTextTriggers = []string{
+	`CREATE TRIGGER IF NOT EXISTS file_texts_ai AFTER INSERT ON file_texts BEGIN
+		INSERT INTO file_texts_fts(rowid, content) VALUES (new.file_id, new.content);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS file_texts_ad AFTER DELETE ON file_texts BEGIN
+		INSERT INTO file_texts_fts(file_texts_fts, rowid, content) VALUES ('delete', old.file_id, old.content);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS file_texts_au AFTER UPDATE ON file_texts BEGIN
+		INSERT INTO file_texts_fts(file_texts_fts, rowid, content) VALUES ('delete', old.file_id, old.content);
+		INSERT INTO file_texts_fts(rowid, content) VALUES (new.file_id, new.content);
+	END`,
+}
+
+// SetupFullTextIndex 在 AutoMigrate 之后调用，尝试创建 FTS5 索引并返回是否可用。
+// 当前 SQLite 不支持 FTS5 时移除同步触发器（否则写入 file_texts 会失败），检索退化为 LIKE；
+// 重新启用 FTS5 后会根据 file_texts 重建索引。
+func SetupFullTextIndex(db *gorm.DB) bool {
+	err := db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS file_texts_fts USING fts5(content, content='file_texts', content_rowid='file_id')`).Error
+	if err != nil {
+		if !strings.Contains(err.Error(), "fts5") {
+			log.Printf("create full-text index: %v", err)
+		}
+		for _, name := range []string{"file_texts_ai", "file_texts_ad", "file_texts_au"} {
+			db.Exec("DROP TRIGGER IF EXISTS " + name)
+		}
+		return false
+	}
+
+	var triggers int64
+	db.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name IN ('file_texts_ai', 'file_texts_ad', 'file_texts_au')").Scan(&triggers)
+	if triggers == int64(len(fileTextTriggers)) {
+		return true
+	}
+	// 首次创建或之前在无 FTS5 的环境中运行过，触发器缺失期间的写入需要重建索引补齐
+	for _, stmt := range fileTextTriggers {
+		if err := db.Exec(stmt).Error; err != nil {
+			log.Printf("create full-text trigger: %v", err)
+			return false
+		}
+	}
+	if err := db.Exec("INSERT INTO file_texts_fts(file_texts_fts) VALUES ('rebuild')").Error; err != nil {
+		log.Printf("rebuild full-text index: %v", err)
+		return false
+	}
+	return true
+}
+
+// HasFullTextIndex 判断当前数据库是否已启用 FTS5 索引。
+func HasFullTextIndex(db *gorm.DB) bool {
+	var count int64
+	db.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name = 'file_texts_ai'").Scan(&count)
+	return count > 0
+}
+
+// FullTextQuery 将用户输入转换为 FTS5 查询：每个词按短语加引号，词之间为 AND，避免特殊语法字符导致查询出错。
+func FullTextQuery(input string) string {
+	terms := strings.Fields(input)
+	quoted := make([]string, 0, len(terms))
+	for _, t := range terms {
+		quoted = append(quoted, `"`+strings.ReplaceAll(t, `"`, `""`)+`"`)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// MatchingFileTexts 返回内容匹配 query 的文件 ID 子查询，FTS5 不可用时使用 LIKE 逐词匹配。
+func MatchingFileTexts(db *gorm.DB, query string) *gorm.DB {
+	sub := db.Session(&gorm.Session{NewDB: true})
+	if HasFullTextIndex(db) {
+		return sub.Table(fileTextFTSTable).Select("rowid").Where(fileTextFTSTable+" MATCH ?", FullTextQuery(query))
+	}
+	q := sub.Model(&FileText{}).Select("file_id")
+	for _, term := range strings.Fields(query) {
+		q = q.Where(`content LIKE ? ESCAPE '\'`, "%"+EscapeLike(term)+"%")
+	}
+	return q
+}
+
+// EscapeLike 转义 LIKE 模式中的通配符，配合 ESCAPE '\' 使用。
+func EscapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FileVersion 保存文件被新版本替换前的历史内容，当前版本始终记录在 File 上，因此文件 ID 与分享链接保持不变。
+// 每条历史版本各自持有一次 Blob 引用；CreatedAt 即该版本被替换的时间。
+type FileVersion struct {
+	gorm.Model
+	FileID           uint      `gorm:"uniqueIndex:idx_file_version;not null" json:"file_id"`
+	File             File      `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Version          uint      `gorm:"uniqueIndex:idx_file_version;not null" json:"version"`
+	Filename         string    `json:"filename"`
+	Path             string    `json:"path"`
+	Size             int64     `json:"size"`
+	MimeType         string    `json:"mime_type"`
+	DeclaredMimeType string    `json:"declared_mime_type"`
+	DetectedMimeType string    `json:"detected_mime_type"`
+	Digest           string    `gorm:"size:64" json:"digest"`
+	ScanStatus       string    `gorm:"size:16;not null;default:clean" json:"scan_status"` // 该版本被替换时的扫描状态
+	UploadedAt       time.Time `json:"uploaded_at"`
+}
+
+// CurrentVersionUploadedAt 返回文件当前版本的上传时间：即上一个版本被替换的时间，没有历史版本时为文件创建时间。
+func CurrentVersionUploadedAt(db *gorm.DB, f *File) (time.Time, error) {
+	var latest FileVersion
+	err := db.Where("file_id = ?", f.ID).Order("version desc").Limit(1).Find(&latest).Error
+	if err != nil {
+		return time.Time{}, err
+	}
+	if latest.ID == 0 {
+		return f.CreatedAt, nil
+	}
+	return latest.CreatedAt, nil
+}
+
+// ArchiveCurrentVersion 将文件当前内容转存为历史版本，原 File 持有的 Blob 引用随之转移到历史版本上。
+func ArchiveCurrentVersion(tx *gorm.DB, f *File) error {
+	uploadedAt, err := CurrentVersionUploadedAt(tx, f)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&FileVersion{
+		FileID:           f.ID,
+		Version:          f.Version,
+		Filename:         f.Filename,
+		Path:             f.Path,
+		Size:             f.Size,
+		MimeType:         f.MimeType,
+		DeclaredMimeType: f.DeclaredMimeType,
+		DetectedMimeType: f.DetectedMimeType,
+		Digest:           f.Digest,
+		ScanStatus:       f.ScanStatus,
+		UploadedAt:       uploadedAt,
+	}).Error
+}
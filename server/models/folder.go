@@ -0,0 +1,83 @@
+package models
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// maxFolderDepth 限制目录层级，防止脏数据形成环时无限向上查找。
+const maxFolderDepth = 64
+
+// ErrFolderCycle 表示将目录移动到自身或其子目录下。
+var ErrFolderCycle = errors.New("不能将目录移动到自身或其子目录下")
+
+// Folder 是用户私有的目录，ParentID 为空表示位于根目录。
+type Folder struct {
+	gorm.Model
+	OwnerID  uint    `gorm:"index" json:"owner_id"`
+	Owner    User    `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	ParentID *uint   `gorm:"index" json:"parent_id"`
+	Parent   *Folder `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Name     string  `gorm:"size:255" json:"name"`
+	// TrashBatch 标识随同一次目录删除进入回收站的目录与文件，恢复目录时只恢复同一批次的条目
+	TrashBatch string `gorm:"size:36;index" json:"-"`
+}
+
+// FolderPath 返回从根目录到 folder（含自身）的目录链，用于生成面包屑。
+func FolderPath(db *gorm.DB, folder *Folder) ([]Folder, error) {
+	path := []Folder{*folder}
+	current := folder
+	for current.ParentID != nil {
+		if len(path) > maxFolderDepth {
+			return nil, ErrFolderCycle
+		}
+		var parent Folder
+		if err := db.First(&parent, *current.ParentID).Error; err != nil {
+			return nil, err
+		}
+		path = append(path, parent)
+		current = &parent
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, nil
+}
+
+// DescendantFolderIDs 按层序返回 rootID 及其全部子目录的 ID；db 是否 Unscoped 决定是否包含已删除的目录。
+func DescendantFolderIDs(db *gorm.DB, rootID uint) ([]uint, error) {
+	ids := []uint{rootID}
+	frontier := []uint{rootID}
+	for depth := 0; len(frontier) > 0; depth++ {
+		if depth > maxFolderDepth {
+			return nil, ErrFolderCycle
+		}
+		var children []uint
+		if err := db.Model(&Folder{}).Where("parent_id IN ?", frontier).Pluck("id", &children).Error; err != nil {
+			return nil, err
+		}
+		ids = append(ids, children...)
+		frontier = children
+	}
+	return ids, nil
+}
+
+// IsFolderDescendant 判断 candidateID 是否为 ancestorID 自身或其子目录，用于移动目录前的环检测。
+func IsFolderDescendant(db *gorm.DB, ancestorID, candidateID uint) (bool, error) {
+	current := candidateID
+	for depth := 0; depth <= maxFolderDepth; depth++ {
+		if current == ancestorID {
+			return true, nil
+		}
+		var folder Folder
+		if err := db.Select("id", "parent_id").First(&folder, current).Error; err != nil {
+			return false, err
+		}
+		if folder.ParentID == nil {
+			return false, nil
+		}
+		current = *folder.ParentID
+	}
+	return false, ErrFolderCycle
+}
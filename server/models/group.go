@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserGroup 是由管理员维护的用户组，可作为分享的接收范围。
+type UserGroup struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"size:64;uniqueIndex;not null" json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// UserGroupMember 记录用户组的成员关系。
+type UserGroupMember struct {
+	ID      uint      `gorm:"primaryKey" json:"id"`
+	GroupID uint      `gorm:"uniqueIndex:idx_group_member;not null" json:"group_id"`
+	Group   UserGroup `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	UserID  uint      `gorm:"uniqueIndex:idx_group_member;index;not null" json:"user_id"`
+	User    User      `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// InAnyGroup 判断用户是否属于给定用户组中的任意一个。
+func InAnyGroup(db *gorm.DB, userID uint, groupIDs []uint) (bool, error) {
+	if len(groupIDs) == 0 {
+		return false, nil
+	}
+	var count int64
+	err := db.Model(&UserGroupMember{}).Where("user_id = ? AND group_id IN ?", userID, groupIDs).Count(&count).Error
+	return count > 0, err
+}
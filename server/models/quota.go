@@ -0,0 +1,46 @@
+package models
+
+import "gorm.io/gorm"
+
+// StorageUsage 汇总用户当前占用的存储，按文件记录的逻辑大小计算（去重共享的内容也分别计入），
+// 包含文件的历史版本，不含已软删除的文件。FileCount 只统计文件数，历史版本不计入。
+type StorageUsage struct {
+	OwnerID   uint  `json:"-"`
+	UsedBytes int64 `json:"used_bytes"`
+	FileCount int64 `json:"file_count"`
+}
+
+// UsageByOwner 一次查询多个用户的占用情况；不传 ownerIDs 时返回所有用户。
+func UsageByOwner(db *gorm.DB, ownerIDs ...uint) (map[uint]StorageUsage, error) {
+	var rows []StorageUsage
+	query := db.Model(&File{}).Select("owner_id, COALESCE(SUM(size), 0) AS used_bytes, COUNT(*) AS file_count").Group("owner_id")
+	if len(ownerIDs) > 0 {
+		query = query.Where("owner_id IN ?", ownerIDs)
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	usage := make(map[uint]StorageUsage, len(rows))
+	for _, r := range rows {
+		usage[r.OwnerID] = r
+	}
+
+	var versionRows []StorageUsage
+	versionQuery := db.Model(&FileVersion{}).
+		Joins("JOIN files ON files.id = file_versions.file_id AND files.deleted_at IS NULL").
+		Select("files.owner_id AS owner_id, COALESCE(SUM(file_versions.size), 0) AS used_bytes").
+		Group("files.owner_id")
+	if len(ownerIDs) > 0 {
+		versionQuery = versionQuery.Where("files.owner_id IN ?", ownerIDs)
+	}
+	if err := versionQuery.Scan(&versionRows).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range versionRows {
+		u := usage[r.OwnerID]
+		u.OwnerID = r.OwnerID
+		u.UsedBytes += r.UsedBytes
+		usage[r.OwnerID] = u
+	}
+	return usage, nil
+}
@@ -3,23 +3,121 @@ package models
 import (
 	"time"
 
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
-// Share 表示一个受控的文件分享链接，支持登录约束、限定用户、次数与有效期。
+// Share 表示一个受控的文件分享链接，支持登录约束、限定接收人、次数与有效期。
 type Share struct {
 	gorm.Model
-	Token        string     `gorm:"uniqueIndex;size:191" json:"token"`
-	FileID       uint       `json:"file_id"`
+	Token string `gorm:"uniqueIndex;size:191" json:"token"`
+	// ShortCode 与 Slug 为可选的短链接别名（随机 base62 短码与所有者自定义的链接），与 Token 一样可用于访问；
+	// 未设置时为空，分享只能通过 UUID 访问
+	ShortCode    *string    `gorm:"uniqueIndex;size:16" json:"short_code,omitempty"`
+	Slug         *string    `gorm:"uniqueIndex;size:64" json:"slug,omitempty"`
+	FileID       uint       `json:"file_id"` // 打包分享中指向第一个文件，权限与统计归属以此为准
 	File         File       `gorm:"constraint:OnDelete:CASCADE" json:"file"`
 	CreatorID    uint       `json:"creator_id"`
 	Creator      User       `gorm:"constraint:OnDelete:SET NULL" json:"creator"`
 	RequireLogin bool       `json:"require_login"`
-	AllowUserID  *uint      `json:"allow_user_id"`
+	AllowUserID  *uint      `json:"allow_user_id"` // 早期版本的单一接收人，新分享改用 Recipients
 	AllowUser    *User      `gorm:"constraint:OnDelete:SET NULL" json:"allow_user"`
 	MaxViews     *uint      `json:"max_views"`
 	ViewCount    uint       `json:"view_count"`
 	ExpiresAt    *time.Time `json:"expires_at"`
+	// NotBefore 为分享的生效时间，之前的访问一律拒绝；为空表示创建后立即生效
+	NotBefore *time.Time `json:"not_before"`
+	// RestrictRecipients 为 true 时仅 Recipients 中的用户与用户组成员可访问；接收人被删除后分享仍保持受限
+	RestrictRecipients bool             `json:"restrict_recipients"`
+	Recipients         []ShareRecipient `json:"recipients,omitempty"`
+	// PasswordHash 为访问密码的 bcrypt 哈希，为空表示无需密码；UnlockFailures / UnlockLockedUntil 记录连续输错次数与锁定截止时间
+	PasswordHash      string     `json:"-"`
+	UnlockFailures    uint       `json:"-"`
+	UnlockLockedUntil *time.Time `json:"-"`
+	// Bundle 为 true 时分享包含 Items 中的多个文件，下载时打包为 Name.zip
+	Bundle bool        `json:"bundle"`
+	Name   string      `gorm:"size:255" json:"name,omitempty"`
+	Items  []ShareItem `json:"items,omitempty"`
+	// BurnAfterReading 为阅后即焚：固定只能查看 1 次（MaxViews 为 1），且计数后不再放行免计数的续读
+	BurnAfterReading bool `json:"burn_after_reading"`
+}
+
+// RetiredShareAlias 记录修改分享时移除或替换掉的短码与自定义链接（统一存为小写），这些别名不再分配给其他分享，
+// 避免旧链接指向新的内容；原分享可以重新启用。已撤销分享的别名仍保留在 Share 中，无需另行记录。
+type RetiredShareAlias struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Alias     string    `gorm:"uniqueIndex;size:64;not null" json:"alias"`
+	ShareID   uint      `gorm:"index;not null" json:"share_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ShareRecipient 是分享的一个接收人：UserID 与 GroupID 二选一。
+type ShareRecipient struct {
+	ID      uint       `gorm:"primaryKey" json:"id"`
+	ShareID uint       `gorm:"index;not null" json:"share_id"`
+	Share   Share      `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	UserID  *uint      `gorm:"index" json:"user_id,omitempty"`
+	User    *User      `gorm:"constraint:OnDelete:CASCADE" json:"user,omitempty"`
+	GroupID *uint      `gorm:"index" json:"group_id,omitempty"`
+	Group   *UserGroup `gorm:"constraint:OnDelete:CASCADE" json:"group,omitempty"`
+}
+
+// ShareItem 是打包分享中的一个文件，Path 为其在 ZIP 中的相对路径，分享目录时保留子目录结构。
+type ShareItem struct {
+	ID      uint   `gorm:"primaryKey" json:"id"`
+	ShareID uint   `gorm:"index;not null" json:"share_id"`
+	Share   Share  `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	FileID  uint   `gorm:"index;not null" json:"file_id"`
+	File    File   `gorm:"constraint:OnDelete:CASCADE" json:"file"`
+	Path    string `gorm:"size:1024" json:"path"`
+}
+
+// Restricted 判断分享是否限定了接收人。
+func (s *Share) Restricted() bool {
+	return s.AllowUserID != nil || s.RestrictRecipients
+}
+
+// AllowsUser 判断用户是否在分享的接收范围内：命中早期的单一接收人、接收人名单，或属于任一接收用户组。需已预加载 Recipients。
+func (s *Share) AllowsUser(db *gorm.DB, userID uint) (bool, error) {
+	if !s.Restricted() {
+		return true, nil
+	}
+	if s.AllowUserID != nil && *s.AllowUserID == userID {
+		return true, nil
+	}
+	var groupIDs []uint
+	for _, r := range s.Recipients {
+		if r.UserID != nil && *r.UserID == userID {
+			return true, nil
+		}
+		if r.GroupID != nil {
+			groupIDs = append(groupIDs, *r.GroupID)
+		}
+	}
+	return InAnyGroup(db, userID, groupIDs)
+}
+
+// HasPassword 判断分享是否设置了访问密码。
+func (s *Share) HasPassword() bool {
+	return s.PasswordHash != ""
+}
+
+// SetPassword 设置访问密码，传入空字符串表示取消密码。
+func (s *Share) SetPassword(pw string) error {
+	if pw == "" {
+		s.PasswordHash = ""
+		return nil
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	s.PasswordHash = string(hash)
+	return nil
+}
+
+func (s *Share) CheckPassword(pw string) bool {
+	return s.HasPassword() && bcrypt.CompareHashAndPassword([]byte(s.PasswordHash), []byte(pw)) == nil
 }
 
 // Expired 判断分享是否已过期。
@@ -29,3 +127,8 @@ func (s *Share) Expired(now time.Time) bool {
 	}
 	return now.After(*s.ExpiresAt)
 }
+
+// Pending 判断分享是否尚未到生效时间。
+func (s *Share) Pending(now time.Time) bool {
+	return s.NotBefore != nil && now.Before(*s.NotBefore)
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// ShareAccess 记录一次分享内容请求（预览或下载），包括被拒绝的请求，用于分享与文件的访问统计。
+// FileID 冗余保存，分享被撤销后仍可按文件汇总；打包分享整体下载 ZIP 时为 0。
+type ShareAccess struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ShareID     uint      `gorm:"index;not null" json:"share_id"`
+	FileID      uint      `gorm:"index;not null" json:"file_id"`
+	UserID      *uint     `gorm:"index" json:"user_id"`
+	IP          string    `gorm:"size:64" json:"ip"`
+	UserAgent   string    `gorm:"size:512" json:"user_agent"`
+	Disposition string    `gorm:"size:16" json:"disposition"` // inline 或 attachment
+	BytesSent   int64     `json:"bytes_sent"`
+	Status      int       `json:"status"`
+	CreatedAt   time.Time `gorm:"index" json:"created_at"`
+}
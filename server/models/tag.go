@@ -0,0 +1,116 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// MaxTagLength 限制标签名的字符数。
+const MaxTagLength = 64
+
+// ErrInvalidTag 表示标签名为空、过长或包含逗号。
+var ErrInvalidTag = errors.New("标签名需为 1-64 个字符且不能包含逗号")
+
+// Tag 是用于按项目等维度整理文件的标签：OwnerID 为空表示管理员维护的全局标签，否则为该用户的个人标签。
+type Tag struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	OwnerID   *uint     `gorm:"index" json:"owner_id"`
+	Owner     *User     `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Name      string    `gorm:"size:64;index;not null" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FileTag 记录文件与标签的多对多关系。
+type FileTag struct {
+	ID     uint `gorm:"primaryKey" json:"id"`
+	FileID uint `gorm:"uniqueIndex:idx_file_tag;not null" json:"file_id"`
+	File   File `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	TagID  uint `gorm:"uniqueIndex:idx_file_tag;index;not null" json:"tag_id"`
+	Tag    Tag  `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// NormalizeTagName 去除首尾空白、合并连续空白并转为小写，使 "Project X" 与 "project  x" 视为同一标签。
+func NormalizeTagName(name string) (string, error) {
+	name = strings.ToLower(strings.Join(strings.Fields(name), " "))
+	if name == "" || utf8.RuneCountInString(name) > MaxTagLength || strings.Contains(name, ",") {
+		return "", ErrInvalidTag
+	}
+	return name, nil
+}
+
+// TagsUsableBy 返回用户可使用的标签作用域：自己的个人标签与全局标签。
+func TagsUsableBy(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("tags.owner_id = ? OR tags.owner_id IS NULL", userID)
+	}
+}
+
+// ResolveTag 查找或创建 ownerID 名下可用的同名标签：已存在同名全局标签时优先复用，否则使用个人标签；global 为 true 时只查找或创建全局标签。
+func ResolveTag(tx *gorm.DB, ownerID uint, name string, global bool) (*Tag, error) {
+	var tag Tag
+	err := tx.Where("owner_id IS NULL AND name = ?", name).First(&tag).Error
+	if err == nil {
+		return &tag, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if global {
+		tag = Tag{Name: name}
+		return &tag, tx.Create(&tag).Error
+	}
+	err = tx.Where("owner_id = ? AND name = ?", ownerID, name).First(&tag).Error
+	if err == nil {
+		return &tag, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	tag = Tag{OwnerID: &ownerID, Name: name}
+	return &tag, tx.Create(&tag).Error
+}
+
+// TagNamesByFile 批量返回文件的标签名，按名称排序。
+func TagNamesByFile(db *gorm.DB, fileIDs []uint) (map[uint][]string, error) {
+	names := make(map[uint][]string, len(fileIDs))
+	if len(fileIDs) == 0 {
+		return names, nil
+	}
+	var rows []struct {
+		FileID uint
+		Name   string
+	}
+	err := db.Model(&FileTag{}).
+		Select("file_tags.file_id AS file_id, tags.name AS name").
+		Joins("JOIN tags ON tags.id = file_tags.tag_id").
+		Where("file_tags.file_id IN ?", fileIDs).
+		Order("tags.name").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		// 个人标签与全局标签可能同名，只展示一次
+		if list := names[r.FileID]; len(list) > 0 && list[len(list)-1] == r.Name {
+			continue
+		}
+		names[r.FileID] = append(names[r.FileID], r.Name)
+	}
+	return names, nil
+}
+
+// TaggedFileIDs 返回打了指定标签的文件 ID 子查询；userID 为 0 表示不限标签归属（管理员），否则只匹配该用户的个人标签与全局标签。
+func TaggedFileIDs(db *gorm.DB, name string, userID uint) *gorm.DB {
+	query := db.Session(&gorm.Session{NewDB: true}).Model(&FileTag{}).
+		Select("file_tags.file_id").
+		Joins("JOIN tags ON tags.id = file_tags.tag_id").
+		Where("tags.name = ?", name)
+	if userID != 0 {
+		query = query.Scopes(TagsUsableBy(userID))
+	}
+	return query
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UploadSession 记录一次可续传的分片上传：分片按偏移顺序追加到本地暂存文件，全部到齐后再整体写入存储驱动。
+type UploadSession struct {
+	gorm.Model
+	UploadID     string    `gorm:"uniqueIndex;size:64" json:"upload_id"`
+	OwnerID      uint      `gorm:"index" json:"owner_id"`
+	Filename     string    `json:"filename"`
+	MimeType     string    `json:"mime_type"`
+	Description  string    `json:"description"`
+	FolderID     *uint     `json:"folder_id"`
+	TotalSize    int64     `json:"total_size"`
+	ReceivedSize int64     `json:"received_size"`
+	ExpiresAt    time.Time `gorm:"index" json:"expires_at"`
+}
+
+// Expired 判断会话是否已超过保留时间，过期会话由后台任务回收。
+func (s *UploadSession) Expired(now time.Time) bool {
+	return now.After(s.ExpiresAt)
+}
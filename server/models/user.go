@@ -18,6 +18,21 @@ type User struct {
 	Username     string `gorm:"uniqueIndex;size:64" json:"username"`
 	PasswordHash string `json:"-"`
 	Role         string `json:"role"`
+	// QuotaBytes / QuotaFiles 为空时使用全局默认配额，0 表示不限制。
+	QuotaBytes *int64 `json:"quota_bytes"`
+	QuotaFiles *int64 `json:"quota_files"`
+}
+
+// EffectiveQuota 返回用户实际生效的空间与文件数配额，0 表示不限制。
+func (u *User) EffectiveQuota(defaultBytes, defaultFiles int64) (int64, int64) {
+	bytes, files := defaultBytes, defaultFiles
+	if u.QuotaBytes != nil {
+		bytes = *u.QuotaBytes
+	}
+	if u.QuotaFiles != nil {
+		files = *u.QuotaFiles
+	}
+	return bytes, files
 }
 
 func (u *User) SetPassword(pw string) error {
@@ -1,14 +1,19 @@
 package routes
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"net/http"
+	"time"
 
 	"content-hub/server/config"
 	"content-hub/server/frontend"
 	"content-hub/server/handlers"
 	"content-hub/server/middleware"
 	"content-hub/server/models"
+	"content-hub/server/scanner"
+	"content-hub/server/storage"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -19,12 +24,22 @@ import (
 // SetupRouter wires API routes and the embedded frontend bundle so the built
 // binary can serve both surfaces without extra assets.
 func SetupRouter(db *gorm.DB, cfg *config.Config) (*gin.Engine, error) {
+	store, err := storage.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("init storage: %w", err)
+	}
+	sc, err := scanner.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("init scanner: %w", err)
+	}
+
 	r := gin.Default()
 
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{cfg.AllowOrigin, "http://localhost:5173"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Authorization", "Content-Type", "X-API-Key"},
+		AllowMethods:     []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Authorization", "Content-Type", "X-API-Key", "X-Share-Access", "Range", "If-None-Match", "If-Modified-Since", "If-Range"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Range", "Content-Disposition", "Accept-Ranges", "ETag", "Last-Modified"},
 		AllowCredentials: true,
 	}))
 
@@ -34,37 +49,114 @@ func SetupRouter(db *gorm.DB, cfg *config.Config) (*gin.Engine, error) {
 		api.POST("/apikeys/verify", handlers.VerifyAPIKey(db))
 		// 分享预览接口：根据分享策略可选登录
 		api.GET("/shares/:token", handlers.GetShareMeta(db, cfg))
-		api.GET("/shares/:token/stream", handlers.StreamShare(db, cfg))
-		api.GET("/shares/:token/download", handlers.DownloadShare(db, cfg))
+		api.POST("/shares/:token/unlock", handlers.UnlockShare(db, cfg))
+		api.GET("/shares/:token/stream", handlers.StreamShare(db, cfg, store))
+		api.HEAD("/shares/:token/stream", handlers.StreamShare(db, cfg, store))
+		api.GET("/shares/:token/download", handlers.DownloadShare(db, cfg, store))
+		api.HEAD("/shares/:token/download", handlers.DownloadShare(db, cfg, store))
+		api.GET("/shares/:token/thumbnail", handlers.GetShareThumbnail(db, cfg, store))
+		api.GET("/shares/:token/raw", handlers.StreamShareRaw(db, cfg, store))
+		api.HEAD("/shares/:token/raw", handlers.StreamShareRaw(db, cfg, store))
+		api.GET("/shares/:token/highlight", handlers.GetShareHighlight(db, cfg, store))
+		api.GET("/shares/:token/items/:item", handlers.StreamShareItem(db, cfg, store))
+		api.HEAD("/shares/:token/items/:item", handlers.StreamShareItem(db, cfg, store))
+		// 上传链接：持有链接的访客无需登录即可上传到所有者空间
+		api.GET("/drops/:token", handlers.GetFileDropMeta(db))
+		api.POST("/drops/:token/files", handlers.UploadToFileDrop(db, cfg, store))
 
 		// 文件上传支持 JWT 或 API Key 两种鉴权方式，便于未来按 scope 扩展到更多接口
-		api.POST("/files", middleware.APIKeyOrAuth(db, cfg, models.ScopeFilesUpload), handlers.UploadFile(db, cfg))
+		api.POST("/files", middleware.APIKeyOrAuth(db, cfg, models.ScopeFilesUpload), handlers.UploadFile(db, cfg, store))
+
+		// 分片上传会话与普通上传共用鉴权方式与 scope，支持大文件断点续传
+		uploads := api.Group("/uploads")
+		uploads.Use(middleware.APIKeyOrAuth(db, cfg, models.ScopeFilesUpload))
+		uploads.POST("", handlers.CreateUploadSession(db, cfg))
+		uploads.GET("/:id", handlers.GetUploadSession(db))
+		uploads.PUT("/:id", handlers.PutUploadChunk(db, cfg))
+		uploads.POST("/:id/complete", handlers.CompleteUploadSession(db, cfg, store))
+		uploads.DELETE("/:id", handlers.AbortUploadSession(db, cfg))
 
 		authorized := api.Group("")
 		authorized.Use(middleware.AuthRequired(cfg))
 
 		// file operations
 		authorized.GET("/files", handlers.ListFiles(db))
+		authorized.GET("/files/search", handlers.SearchFiles(db))
 		authorized.GET("/files/:id", handlers.GetFileInfo(db))
-		authorized.GET("/files/:id/download", handlers.DownloadFile(db))
-		authorized.GET("/files/:id/stream", handlers.StreamFile(db))
-		authorized.DELETE("/files/:id", handlers.DeleteFile(db))
+		authorized.GET("/files/:id/download", handlers.DownloadFile(db, store))
+		authorized.HEAD("/files/:id/download", handlers.DownloadFile(db, store))
+		authorized.GET("/files/:id/stream", handlers.StreamFile(db, store))
+		authorized.HEAD("/files/:id/stream", handlers.StreamFile(db, store))
+		authorized.GET("/files/:id/thumbnail", handlers.GetFileThumbnail(db, store))
+		authorized.PATCH("/files/:id", handlers.UpdateFile(db))
+		authorized.DELETE("/files/:id", handlers.DeleteFile(db, store))
+		authorized.GET("/files/:id/versions", handlers.ListFileVersions(db))
+		authorized.POST("/files/:id/versions", handlers.UploadFileVersion(db, cfg, store))
+		authorized.GET("/files/:id/versions/:version/download", handlers.DownloadFileVersion(db, store))
+		authorized.HEAD("/files/:id/versions/:version/download", handlers.DownloadFileVersion(db, store))
+		authorized.POST("/files/:id/versions/:version/restore", handlers.RestoreFileVersion(db, cfg, store))
+		authorized.PUT("/files/:id/visibility", handlers.UpdateFileVisibility(db))
 		authorized.POST("/files/:id/share", handlers.CreateShare(db, cfg))
+		authorized.GET("/files/:id/share-analytics", handlers.GetFileShareAnalytics(db))
+		authorized.POST("/files/:id/tags", handlers.AddFileTags(db))
+		authorized.DELETE("/files/:id/tags/:tag", handlers.RemoveFileTag(db))
+
+		// shares：分享创建者与文件所有者自助管理，管理员可见全部
+		authorized.GET("/shares", handlers.ListMyShares(db))
+		authorized.POST("/shares", handlers.CreateBundleShare(db, cfg))
+		authorized.POST("/shares/cleanup", handlers.CleanMyShares(db, store))
+		authorized.PATCH("/shares/:token", handlers.UpdateShare(db, cfg))
+		authorized.DELETE("/shares/:token", handlers.RevokeMyShare(db))
+		authorized.GET("/shares/:token/analytics", handlers.GetShareAnalytics(db))
+
+		// drops：文件收集链接，所有者与管理员可管理
+		authorized.GET("/drops", handlers.ListFileDrops(db))
+		authorized.POST("/drops", handlers.CreateFileDrop(db, cfg))
+		authorized.DELETE("/drops/:token", handlers.RevokeFileDrop(db))
+
+		// tags
+		authorized.GET("/tags", handlers.ListTags(db))
+
+		// groups：所有登录用户可查看，用于选择分享接收范围
+		authorized.GET("/groups", handlers.ListGroups(db))
+
+		// folders
+		authorized.GET("/folders", handlers.ListFolder(db))
+		authorized.POST("/folders", handlers.CreateFolder(db))
+		authorized.GET("/folders/:id", handlers.ListFolder(db))
+		authorized.PATCH("/folders/:id", handlers.UpdateFolder(db))
+		authorized.DELETE("/folders/:id", handlers.DeleteFolder(db, store))
+
+		// trash
+		trash := authorized.Group("/trash")
+		trash.GET("", handlers.ListTrash(db, cfg))
+		trash.DELETE("", handlers.EmptyTrash(db, store))
+		trash.POST("/files/:id/restore", handlers.RestoreTrashFile(db, cfg))
+		trash.DELETE("/files/:id", handlers.PurgeTrashFile(db, store))
+		trash.POST("/folders/:id/restore", handlers.RestoreTrashFolder(db, cfg))
+		trash.DELETE("/folders/:id", handlers.PurgeTrashFolder(db, store))
 
 		// admin
 		admin := authorized.Group("/admin")
 		admin.Use(middleware.RequireAdmin())
 		admin.POST("/users", handlers.CreateUser(db))
-		admin.GET("/users", handlers.ListUsers(db))
+		admin.GET("/users", handlers.ListUsers(db, cfg))
 		admin.DELETE("/users/:id", handlers.DeleteUser(db))
 		admin.PATCH("/users/:id/role", handlers.UpdateUserRole(db))
 		admin.POST("/users/:id/reset-password", handlers.ResetPassword(db))
+		admin.PUT("/users/:id/quota", handlers.UpdateUserQuota(db, cfg))
 		admin.GET("/apikeys", handlers.ListAPIKeys(db))
 		admin.POST("/apikeys", handlers.CreateAPIKey(db))
 		admin.DELETE("/apikeys/:id", handlers.RevokeAPIKey(db))
+		admin.POST("/groups", handlers.CreateGroup(db))
+		admin.PATCH("/groups/:id", handlers.UpdateGroup(db))
+		admin.DELETE("/groups/:id", handlers.DeleteGroup(db))
 		admin.GET("/shares", handlers.ListShares(db))
-		admin.POST("/shares/cleanup", handlers.CleanShares(db))
+		admin.POST("/shares/cleanup", handlers.CleanShares(db, store))
 		admin.DELETE("/shares/:token", handlers.RevokeShare(db))
+		admin.POST("/trash/purge", handlers.RunTrashPurge(db, cfg, store))
+		admin.POST("/files/:id/rescan", handlers.RescanFile(db, cfg))
+		admin.POST("/files/:id/release", handlers.ReleaseScannedFile(db))
 	}
 
 	// Swagger UI：单一路由，兼容 /swagger 与 /swagger/ 入口
@@ -89,5 +181,34 @@ func SetupRouter(db *gorm.DB, cfg *config.Config) (*gin.Engine, error) {
 	}
 	frontend.Register(r, assets)
 
+	// 后台回收超时未完成的分片上传会话
+	handlers.StartUploadSessionJanitor(db, cfg, time.Hour)
+	// 为升级前上传的文本文件补建全文索引
+	go func() {
+		if n, err := handlers.IndexMissingFileTexts(context.Background(), db, store); err != nil {
+			log.Printf("index file texts: %v", err)
+		} else if n > 0 {
+			log.Printf("indexed %d text files for search", n)
+		}
+	}()
+	// 后台永久清理超过保留期的回收站条目
+	handlers.StartTrashJanitor(db, cfg, store, time.Hour)
+	// 后台生成图片缩略图与文本摘录
+	handlers.StartPreviewWorker(db, store, time.Minute)
+	// 后台扫描新上传的内容，未启用扫描时上传即为 clean
+	if sc != nil {
+		handlers.StartScanWorker(db, store, sc, time.Minute)
+	} else if released, blocked, err := handlers.ReleaseUnscannedFiles(db); err != nil {
+		log.Printf("release unscanned files: %v", err)
+	} else {
+		// 扫描启用期间上传、尚未扫描的文件在关闭扫描后直接放行；扫描出错的文件保持拦截，等待管理员处理
+		if released > 0 {
+			log.Printf("scanning disabled: marked %d pending files as clean without scanning", released)
+		}
+		if blocked > 0 {
+			log.Printf("scanning disabled: %d files with failed scans stay blocked until an admin releases them (POST /api/admin/files/:id/release)", blocked)
+		}
+	}
+
 	return r, nil
 }
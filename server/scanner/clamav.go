@@ -0,0 +1,152 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamdChunkSize 为 INSTREAM 每个数据块的大小，clamd 对单块长度没有额外限制，64KB 兼顾吞吐与内存。
+const clamdChunkSize = 64 << 10
+
+// ClamAV 通过 clamd 的 INSTREAM 命令扫描内容，不要求 clamd 能访问本服务的存储目录。
+type ClamAV struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClamAV 解析 clamd 地址：tcp://host:port、host:port 或 unix:/path/clamd.sock。timeout 作用于每次读写。
+func NewClamAV(address string, timeout time.Duration) (*ClamAV, error) {
+	address = strings.TrimSpace(address)
+	c := &ClamAV{network: "tcp", address: address, timeout: timeout}
+	switch {
+	case strings.HasPrefix(address, "unix:"):
+		c.network = "unix"
+		c.address = strings.TrimPrefix(strings.TrimPrefix(address, "unix:"), "//")
+	case strings.HasPrefix(address, "tcp://"):
+		c.address = strings.TrimPrefix(address, "tcp://")
+	}
+	if c.address == "" {
+		return nil, errors.New("clamav: address is required")
+	}
+	if c.timeout <= 0 {
+		c.timeout = time.Minute
+	}
+	return c, nil
+}
+
+// Ping 检查 clamd 是否可用。
+func (c *ClamAV) Ping(ctx context.Context) error {
+	reply, err := c.command(ctx, "PING", nil)
+	if err != nil {
+		return err
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("clamav: unexpected ping reply %q", reply)
+	}
+	return nil
+}
+
+// Scan 以 INSTREAM 协议发送内容：每块前带 4 字节大端长度，以长度为 0 的块结束，clamd 随后返回结论。
+func (c *ClamAV) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	reply, err := c.command(ctx, "INSTREAM", r)
+	if err != nil {
+		return Result{}, err
+	}
+	return parseClamdReply(reply)
+}
+
+// command 发送以 z 前缀（NUL 结尾）的命令并读取单条回复；body 不为空时按 INSTREAM 分块发送。
+func (c *ClamAV) command(ctx context.Context, name string, body io.Reader) (string, error) {
+	dialer := net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return "", fmt.Errorf("clamav: connect: %w", err)
+	}
+	defer conn.Close()
+	// 上下文取消时关闭连接，使阻塞中的读写立即返回
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	writeErr := c.write(conn, []byte("z"+name+"\x00"))
+	if writeErr == nil && body != nil {
+		writeErr = c.stream(conn, body)
+	}
+	// clamd 在超出 StreamMaxLength 等情况下会提前回复并关闭连接，写入失败时仍尝试读取回复以获得具体原因
+	reply, readErr := c.readReply(conn)
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	if readErr != nil {
+		if writeErr != nil {
+			return "", fmt.Errorf("clamav: send: %w", writeErr)
+		}
+		return "", fmt.Errorf("clamav: read reply: %w", readErr)
+	}
+	return reply, nil
+}
+
+func (c *ClamAV) stream(conn net.Conn, body io.Reader) error {
+	buf := make([]byte, 4+clamdChunkSize)
+	for {
+		n, err := io.ReadFull(body, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if werr := c.write(conn, buf[:4+n]); werr != nil {
+				return werr
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return c.write(conn, []byte{0, 0, 0, 0})
+}
+
+func (c *ClamAV) write(conn net.Conn, p []byte) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(c.timeout)); err != nil {
+		return err
+	}
+	_, err := conn.Write(p)
+	return err
+}
+
+func (c *ClamAV) readReply(conn net.Conn) (string, error) {
+	if err := conn.SetReadDeadline(time.Now().Add(c.timeout)); err != nil {
+		return "", err
+	}
+	reply, err := bufio.NewReader(conn).ReadBytes(0)
+	if err != nil && !(errors.Is(err, io.EOF) && len(reply) > 0) {
+		return "", err
+	}
+	return string(bytes.TrimRight(reply, "\x00\n")), nil
+}
+
+// parseClamdReply 解析 "stream: OK"、"stream: <病毒名> FOUND" 与 "... ERROR" 三种回复。
+func parseClamdReply(reply string) (Result, error) {
+	_, status, ok := strings.Cut(reply, ": ")
+	if !ok {
+		status = reply
+	}
+	switch {
+	case status == "OK":
+		return Result{}, nil
+	case strings.HasSuffix(status, " FOUND"):
+		return Result{Infected: true, Signature: strings.TrimSuffix(status, " FOUND")}, nil
+	case strings.HasSuffix(status, " ERROR"):
+		return Result{}, fmt.Errorf("clamav: %s", strings.TrimSuffix(status, " ERROR"))
+	default:
+		return Result{}, fmt.Errorf("clamav: unexpected reply %q", reply)
+	}
+}
@@ -0,0 +1,152 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClamd 实现 clamd 的 zPING 与 zINSTREAM，内容包含 signature 时报告感染，超过 maxStream 时模拟 StreamMaxLength 报错。
+type fakeClamd struct {
+	signature []byte
+	maxStream int
+	received  chan []byte
+}
+
+func startFakeClamd(t *testing.T, f *fakeClamd) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.handle(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func (f *fakeClamd) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	cmd, err := r.ReadString(0)
+	if err != nil {
+		return
+	}
+	switch cmd {
+	case "zPING\x00":
+		conn.Write([]byte("PONG\x00"))
+	case "zINSTREAM\x00":
+		var data []byte
+		for {
+			var size uint32
+			if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+				return
+			}
+			if size == 0 {
+				break
+			}
+			chunk := make([]byte, size)
+			if _, err := io.ReadFull(r, chunk); err != nil {
+				return
+			}
+			data = append(data, chunk...)
+			if f.maxStream > 0 && len(data) > f.maxStream {
+				conn.Write([]byte("INSTREAM size limit exceeded. ERROR\x00"))
+				return
+			}
+		}
+		if f.received != nil {
+			f.received <- data
+		}
+		if bytes.Contains(data, f.signature) {
+			conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+			return
+		}
+		conn.Write([]byte("stream: OK\x00"))
+	default:
+		conn.Write([]byte("UNKNOWN COMMAND\x00"))
+	}
+}
+
+func TestClamAVScan(t *testing.T) {
+	fake := &fakeClamd{signature: []byte("EICAR"), maxStream: 1 << 20, received: make(chan []byte, 4)}
+	addr := startFakeClamd(t, fake)
+	clam, err := NewClamAV("tcp://"+addr, 5*time.Second)
+	if err != nil {
+		t.Fatalf("new clamav: %v", err)
+	}
+	ctx := context.Background()
+	if err := clam.Ping(ctx); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+
+	// 跨多个数据块的内容需完整送达
+	clean := bytes.Repeat([]byte("0123456789abcdef"), clamdChunkSize/8+3)
+	res, err := clam.Scan(ctx, bytes.NewReader(clean))
+	if err != nil || res.Infected {
+		t.Fatalf("clean scan = %+v, %v", res, err)
+	}
+	if got := <-fake.received; !bytes.Equal(got, clean) {
+		t.Fatalf("clamd received %d bytes, want %d", len(got), len(clean))
+	}
+
+	res, err = clam.Scan(ctx, strings.NewReader("X5O!P%@AP EICAR test"))
+	if err != nil || !res.Infected || res.Signature != "Eicar-Test-Signature" {
+		t.Fatalf("infected scan = %+v, %v", res, err)
+	}
+	<-fake.received
+
+	_, err = clam.Scan(ctx, bytes.NewReader(make([]byte, 2<<20)))
+	if err == nil || !strings.Contains(err.Error(), "size limit exceeded") {
+		t.Fatalf("oversized scan error = %v, want size limit", err)
+	}
+}
+
+func TestClamAVUnavailable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	clam, _ := NewClamAV(addr, time.Second)
+	if _, err := clam.Scan(context.Background(), strings.NewReader("data")); err == nil {
+		t.Fatal("scan should fail when clamd is not reachable")
+	}
+	if _, err := NewClamAV("", time.Second); err == nil {
+		t.Fatal("empty address should be rejected")
+	}
+}
+
+func TestParseClamdReply(t *testing.T) {
+	cases := []struct {
+		reply    string
+		infected bool
+		wantErr  bool
+	}{
+		{"stream: OK", false, false},
+		{"stream: Win.Test.EICAR_HDB-1 FOUND", true, false},
+		{"INSTREAM size limit exceeded. ERROR", false, true},
+		{"garbage", false, true},
+	}
+	for _, tc := range cases {
+		res, err := parseClamdReply(tc.reply)
+		if res.Infected != tc.infected || (err != nil) != tc.wantErr {
+			t.Fatalf("parse %q = %+v, %v", tc.reply, res, err)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+// Package scanner 在上传内容可被下载前检查恶意软件，handler 只依赖 Scanner 接口，
+// 便于接入 ClamAV 之外的扫描服务或在测试中替换。
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"content-hub/server/config"
+)
+
+// Result 描述一次扫描的结论；Infected 为 true 时 Signature 为命中的病毒名。
+type Result struct {
+	Infected  bool
+	Signature string
+}
+
+// Scanner 是所有扫描器需要实现的能力：读取完整内容并给出结论，无法完成扫描时返回 error。
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (Result, error)
+}
+
+// New 根据配置创建扫描器，未启用扫描时返回 nil。
+func New(cfg *config.Config) (Scanner, error) {
+	if !cfg.ScanEnabled() {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(cfg.ScanDriver)) {
+	case "clamav", "clamd":
+		return NewClamAV(cfg.ClamdAddress, cfg.ScanTimeout)
+	default:
+		return nil, fmt.Errorf("unsupported scan driver %q", cfg.ScanDriver)
+	}
+}
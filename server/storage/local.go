@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Local 将对象保存在本地目录中，是默认的存储驱动。
+type Local struct {
+	root string
+}
+
+// NewLocal 以 root 作为根目录创建本地驱动，目录会在首次写入时自动创建。
+func NewLocal(root string) *Local {
+	return &Local{root: root}
+}
+
+// resolve 将 key 映射为磁盘路径。
+// 早期版本直接把 UploadDir 拼接后的路径写入 models.File.Path，这里对绝对路径与已带根目录前缀的 key 原样返回以兼容旧数据。
+func (l *Local) resolve(key string) string {
+	if filepath.IsAbs(key) {
+		return filepath.Clean(key)
+	}
+	cleaned := filepath.Clean(key)
+	if l.root != "" {
+		root := filepath.Clean(l.root)
+		if cleaned == root || strings.HasPrefix(cleaned, root+string(filepath.Separator)) {
+			return cleaned
+		}
+	}
+	return filepath.Join(l.root, cleaned)
+}
+
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	path := l.resolve(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("mkdir upload dir: %w", err)
+	}
+	// 先写临时文件再重命名，避免中途失败留下半截文件被当作完整对象读取
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	written, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && size >= 0 && written != size {
+		err = fmt.Errorf("short write: expected %d bytes, got %d", size, written)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return l.ReadRange(ctx, key, 0, -1)
+}
+
+func (l *Local) ReadRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	path := l.resolve(key)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, mapLocalErr(err)
+	}
+	if info.IsDir() {
+		return nil, ErrNotExist
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, mapLocalErr(err)
+	}
+	if offset > 0 {
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return newLimitReadCloser(f, length), nil
+}
+
+func (l *Local) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	info, err := os.Stat(l.resolve(key))
+	if err != nil {
+		return ObjectInfo{}, mapLocalErr(err)
+	}
+	if info.IsDir() {
+		return ObjectInfo{}, ErrNotExist
+	}
+	return ObjectInfo{Key: key, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	if err := os.Remove(l.resolve(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func mapLocalErr(err error) error {
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotExist
+	}
+	return err
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalPutGetRangeDelete(t *testing.T) {
+	ctx := context.Background()
+	root := filepath.Join(t.TempDir(), "uploads")
+	store := NewLocal(root)
+
+	if err := store.Put(ctx, "a/b.txt", strings.NewReader("hello world"), 11); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "a", "b.txt")); err != nil {
+		t.Fatalf("object should be written under root: %v", err)
+	}
+
+	info, err := store.Stat(ctx, "a/b.txt")
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if info.Size != 11 {
+		t.Fatalf("expected size 11, got %d", info.Size)
+	}
+
+	rc, err := store.ReadRange(ctx, "a/b.txt", 6, 3)
+	if err != nil {
+		t.Fatalf("read range: %v", err)
+	}
+	got, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(got) != "wor" {
+		t.Fatalf("unexpected range content %q", got)
+	}
+
+	if err := store.Delete(ctx, "a/b.txt"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := store.Get(ctx, "a/b.txt"); !errors.Is(err, ErrNotExist) {
+		t.Fatalf("expected ErrNotExist after delete, got %v", err)
+	}
+	// 删除不存在的对象保持幂等
+	if err := store.Delete(ctx, "a/b.txt"); err != nil {
+		t.Fatalf("second delete should succeed: %v", err)
+	}
+}
+
+// 旧版本的 models.File.Path 保存的是 UploadDir 拼接后的路径，驱动需要原样识别。
+func TestLocalResolvesLegacyPaths(t *testing.T) {
+	store := NewLocal("uploads")
+	if got := store.resolve("uploads/1-a.txt"); got != filepath.Join("uploads", "1-a.txt") {
+		t.Fatalf("legacy relative path resolved to %q", got)
+	}
+	if got := store.resolve("1-a.txt"); got != filepath.Join("uploads", "1-a.txt") {
+		t.Fatalf("plain key resolved to %q", got)
+	}
+	abs := filepath.Join(t.TempDir(), "x.txt")
+	if got := store.resolve(abs); got != abs {
+		t.Fatalf("absolute path resolved to %q", got)
+	}
+}
+
+func TestLocalPutSizeMismatch(t *testing.T) {
+	store := NewLocal(t.TempDir())
+	err := store.Put(context.Background(), "short.txt", strings.NewReader("abc"), 10)
+	if err == nil {
+		t.Fatal("expected size mismatch error")
+	}
+	if _, err := store.Stat(context.Background(), "short.txt"); !errors.Is(err, ErrNotExist) {
+		t.Fatalf("partial upload should not be visible, got %v", err)
+	}
+}
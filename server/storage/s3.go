@@ -0,0 +1,330 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	// emptyPayloadHash 为空请求体的 SHA256，GET/HEAD/DELETE 使用它参与签名。
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// S3Options 描述连接 S3 兼容服务（AWS S3、MinIO 等）所需的参数。
+type S3Options struct {
+	Endpoint  string // 例如 https://s3.amazonaws.com 或 http://127.0.0.1:9000
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	Prefix    string // 可选的 key 前缀，便于多个实例共用同一个 bucket
+	PathStyle bool   // true 时使用 endpoint/bucket/key，MinIO 通常需要开启
+	Client    *http.Client
+}
+
+// S3 通过 REST 接口与 AWS Signature V4 访问对象存储，不依赖额外 SDK。
+type S3 struct {
+	opts     S3Options
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewS3 校验必要配置并创建驱动。
+func NewS3(opts S3Options) (*S3, error) {
+	if opts.Endpoint == "" || opts.Bucket == "" {
+		return nil, errors.New("s3 storage requires endpoint and bucket")
+	}
+	if opts.AccessKey == "" || opts.SecretKey == "" {
+		return nil, errors.New("s3 storage requires access key and secret key")
+	}
+	u, err := url.Parse(strings.TrimRight(opts.Endpoint, "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", opts.Endpoint)
+	}
+	if opts.Region == "" {
+		opts.Region = "us-east-1"
+	}
+	client := opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &S3{opts: opts, endpoint: u, client: client, now: time.Now}, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	if size < 0 {
+		// S3 的 PutObject 需要明确的 Content-Length，长度未知时先落到临时文件
+		tmp, err := os.CreateTemp("", "s3-put-*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		if size, err = io.Copy(tmp, r); err != nil {
+			return err
+		}
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		r = tmp
+	}
+
+	req, err := s.newRequest(ctx, http.MethodPut, key, io.NopCloser(r))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	resp, err := s.do(req, unsignedPayload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return s.responseError(req, resp)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return s.ReadRange(ctx, key, 0, -1)
+}
+
+func (s *S3) ReadRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	if length == 0 {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 || length > 0 {
+		rng := fmt.Sprintf("bytes=%d-", offset)
+		if length > 0 {
+			rng += strconv.FormatInt(offset+length-1, 10)
+		}
+		req.Header.Set("Range", rng)
+	}
+	resp, err := s.do(req, emptyPayloadHash)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		return resp.Body, nil
+	case http.StatusOK:
+		// 部分兼容实现会忽略 Range 头，此时在客户端跳过前缀并截断
+		if offset > 0 {
+			if _, err := io.CopyN(io.Discard, resp.Body, offset); err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
+		}
+		return newLimitReadCloser(resp.Body, length), nil
+	default:
+		defer resp.Body.Close()
+		return nil, s.responseError(req, resp)
+	}
+}
+
+func (s *S3) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	req, err := s.newRequest(ctx, http.MethodHead, key, nil)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	resp, err := s.do(req, emptyPayloadHash)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ObjectInfo{}, s.responseError(req, resp)
+	}
+	info := ObjectInfo{Key: key, Size: resp.ContentLength}
+	if lm := resp.Header.Get("Last-Modified"); lm != "" {
+		if t, err := http.ParseTime(lm); err == nil {
+			info.ModTime = t
+		}
+	}
+	return info, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req, emptyPayloadHash)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 || resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return s.responseError(req, resp)
+}
+
+// objectURL 根据寻址模式拼出对象地址，路径按 SigV4 规则逐段编码。
+func (s *S3) objectURL(key string) (*url.URL, error) {
+	fullKey := strings.TrimLeft(strings.Trim(s.opts.Prefix, "/")+"/"+strings.TrimLeft(key, "/"), "/")
+	if fullKey == "" {
+		return nil, errors.New("empty object key")
+	}
+	host := s.endpoint.Host
+	path := strings.TrimRight(s.endpoint.Path, "/")
+	if s.opts.PathStyle {
+		path += "/" + s.opts.Bucket
+	} else {
+		host = s.opts.Bucket + "." + host
+	}
+	path += "/" + fullKey
+	return url.Parse(s.endpoint.Scheme + "://" + host + uriEncode(path, false))
+}
+
+func (s *S3) newRequest(ctx context.Context, method, key string, body io.ReadCloser) (*http.Request, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (s *S3) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	s.sign(req, payloadHash, s.now().UTC())
+	return s.client.Do(req)
+}
+
+func (s *S3) responseError(req *http.Request, resp *http.Response) error {
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotExist
+	}
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("s3 %s %s: %s %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(snippet)))
+}
+
+// sign 按 AWS Signature Version 4 为请求添加 Authorization 头，
+// 参与签名的头包括 host、range 以及所有 x-amz-* 头。
+func (s *S3) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "range" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.opts.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hexSHA256(canonicalRequest),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.opts.SecretKey), date)
+	key = hmacSHA256(key, s.opts.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.opts.AccessKey, scope, signedHeaders, signature,
+	))
+}
+
+func canonicalQuery(values url.Values) string {
+	if len(values) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		vs := append([]string(nil), values[k]...)
+		sort.Strings(vs)
+		for _, v := range vs {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode 实现 SigV4 要求的编码：仅保留 A-Z a-z 0-9 - _ . ~，encodeSlash 为 false 时保留 /。
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case 'A' <= ch && ch <= 'Z', 'a' <= ch && ch <= 'z', '0' <= ch && ch <= '9',
+			ch == '-', ch == '_', ch == '.', ch == '~':
+			b.WriteByte(ch)
+		case ch == '/' && !encodeSlash:
+			b.WriteByte(ch)
+		default:
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hexSHA256(data string) string {
+	digest := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(digest[:])
+}
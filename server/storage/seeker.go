@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ReadSeeker 把驱动的 ReadRange 能力包装成 io.ReadSeeker，便于直接交给 http.ServeContent
+// 处理 Range、多段 Range 与条件请求；每次 Seek 后的首次 Read 才会向后端发起新的范围读取。
+type ReadSeeker struct {
+	ctx    context.Context
+	driver Driver
+	key    string
+	size   int64
+	offset int64
+	rc     io.ReadCloser
+}
+
+// NewReadSeeker 基于已知大小的对象创建 ReadSeeker，调用方需在使用后 Close。
+func NewReadSeeker(ctx context.Context, driver Driver, key string, size int64) *ReadSeeker {
+	return &ReadSeeker{ctx: ctx, driver: driver, key: key, size: size}
+}
+
+func (r *ReadSeeker) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.rc == nil {
+		rc, err := r.driver.ReadRange(r.ctx, r.key, r.offset, -1)
+		if err != nil {
+			return 0, err
+		}
+		r.rc = rc
+	}
+	n, err := r.rc.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+func (r *ReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	var next int64
+	switch whence {
+	case io.SeekStart:
+		next = offset
+	case io.SeekCurrent:
+		next = r.offset + offset
+	case io.SeekEnd:
+		next = r.size + offset
+	default:
+		return 0, errors.New("storage: invalid whence")
+	}
+	if next < 0 {
+		return 0, errors.New("storage: negative position")
+	}
+	if next != r.offset {
+		r.closeReader()
+		r.offset = next
+	}
+	return next, nil
+}
+
+// Close 释放当前打开的后端读取流。
+func (r *ReadSeeker) Close() error {
+	return r.closeReader()
+}
+
+func (r *ReadSeeker) closeReader() error {
+	if r.rc == nil {
+		return nil
+	}
+	err := r.rc.Close()
+	r.rc = nil
+	return err
+}
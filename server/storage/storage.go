@@ -0,0 +1,70 @@
+// Package storage 抽象文件内容的持久化位置，handler 只依赖 Driver 接口，
+// 便于在本地磁盘与 S3 兼容对象存储之间切换而无需改动业务逻辑。
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"content-hub/server/config"
+)
+
+// ErrNotExist 表示对象不存在，调用方据此返回 404 而不是 500。
+var ErrNotExist = errors.New("storage: object not found")
+
+// ObjectInfo 描述已存储对象的基础元数据。
+type ObjectInfo struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Driver 是所有存储后端需要实现的最小能力集合，key 为 models.File.Path 中保存的相对标识。
+type Driver interface {
+	// Put 写入完整对象；size 为 -1 时表示长度未知，由实现自行处理。
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Get 返回整个对象的读取流，调用方负责关闭。
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// ReadRange 从 offset 开始读取 length 字节，length 小于 0 表示读到末尾。
+	ReadRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+	// Delete 删除对象，对象不存在时视为成功，保持幂等。
+	Delete(ctx context.Context, key string) error
+}
+
+// New 根据配置选择存储驱动，默认使用本地磁盘以兼容历史部署。
+func New(cfg *config.Config) (Driver, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.StorageDriver)) {
+	case "", "local":
+		return NewLocal(cfg.UploadDir), nil
+	case "s3":
+		return NewS3(S3Options{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			Prefix:    cfg.S3Prefix,
+			PathStyle: cfg.S3PathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported storage driver %q", cfg.StorageDriver)
+	}
+}
+
+// limitReadCloser 在限制读取长度的同时保留底层的 Close。
+type limitReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func newLimitReadCloser(rc io.ReadCloser, length int64) io.ReadCloser {
+	if length < 0 {
+		return rc
+	}
+	return limitReadCloser{Reader: io.LimitReader(rc, length), Closer: rc}
+}
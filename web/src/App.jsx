@@ -7,6 +7,8 @@ import ApiKeyManage from './views/ApiKeyManage'
 import Shell from './views/Shell'
 import { useAuthStore } from './store/auth'
 import SharePreview from './views/SharePreview'
+import DropManage from './views/DropManage'
+import DropUpload from './views/DropUpload'
 
 const ProtectedRoute = ({ children }) => {
   const isAuthenticated = useAuthStore((state) => state.isAuthenticated)
@@ -52,14 +54,8 @@ function App() {
               </AdminRoute>
             }
           />
-          <Route
-            path="/shares"
-            element={
-              <AdminRoute>
-                <ShareManage />
-              </AdminRoute>
-            }
-          />
+          <Route path="/shares" element={<ShareManage />} />
+          <Route path="/drops" element={<DropManage />} />
           <Route
             path="/apikeys"
             element={
@@ -78,6 +74,9 @@ function App() {
           }
         />
         <Route path="/preview/:token" element={<SharePreview />} />
+        {/* 短链接：token 为分享的短码或自定义链接 */}
+        <Route path="/s/:token" element={<SharePreview />} />
+        <Route path="/drop/:token" element={<DropUpload />} />
         <Route path="*" element={<Navigate to="/" replace />} />
       </Routes>
     </BrowserRouter>
@@ -1,7 +1,8 @@
-import api from './client'
+import api, { fetchAllPages } from './client'
 
 // 获取 API Key 列表，后台用于管理和审计
-export const listApiKeys = () => api.get('/admin/apikeys')
+export const listApiKeys = (params) => fetchAllPages('/admin/apikeys', params)
+export const listApiKeysPage = (params) => api.get('/admin/apikeys', { params })
 
 // 创建新的 API Key，返回仅本次展示的明文 key
 export const createApiKey = (payload) => api.post('/admin/apikeys', payload)
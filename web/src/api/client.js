@@ -33,4 +33,17 @@ api.interceptors.response.use(
   }
 )
 
+// 列表接口统一返回 { items, next_cursor, has_more, total? }，按 next_cursor 逐页拉取直至结束，
+// 返回形如 { data: items } 的结果，便于需要完整列表的页面沿用原有写法
+export const fetchAllPages = async (path, params = {}) => {
+  const items = []
+  let cursor
+  do {
+    const { data } = await api.get(path, { params: { limit: 200, ...params, cursor } })
+    items.push(...(data.items || []))
+    cursor = data.has_more ? data.next_cursor : undefined
+  } while (cursor)
+  return { data: items }
+}
+
 export default api
@@ -0,0 +1,14 @@
+import api, { fetchAllPages } from './client'
+
+// 上传链接管理：本人创建的链接（管理员可见全部），撤销后已收集的文件保留
+export const listDrops = (params) => fetchAllPages('/drops', params)
+export const createDrop = (payload) => api.post('/drops', payload)
+export const revokeDrop = (token) => api.delete(`/drops/${token}`)
+
+// 公开上传页：无需登录即可获取链接信息并上传文件
+export const getDropMeta = (token) => api.get(`/drops/${token}`)
+export const uploadToDrop = (token, formData, onUploadProgress) =>
+  api.post(`/drops/${token}/files`, formData, {
+    headers: { 'Content-Type': 'multipart/form-data' },
+    onUploadProgress,
+  })
@@ -1,6 +1,9 @@
-import api from './client'
+import api, { fetchAllPages } from './client'
 
-export const fetchFiles = () => api.get('/files')
+// 拉取全部可见文件；params 可传 owner / mime / folder_id / visibility / sort / order 等过滤与排序条件
+export const fetchFiles = (params) => fetchAllPages('/files', params)
+// 单页拉取，返回 { items, next_cursor, has_more, total? }
+export const fetchFilesPage = (params) => api.get('/files', { params })
 
 // 允许透传 onUploadProgress 以便前端展示实时上传进度；默认保持 multipart 提交头
 export const uploadFile = (formData, onUploadProgress) =>
@@ -15,3 +18,21 @@ export const deleteFile = (id) => api.delete(`/files/${id}`)
 export const streamFile = (id, options = {}) => api.get(`/files/${id}/stream`, { responseType: 'blob', ...options })
 // 以附件形式下载文件，统一透传鉴权头，避免新窗口缺失 Authorization 导致 401
 export const downloadFile = (id, options = {}) => api.get(`/files/${id}/download`, { responseType: 'blob', ...options })
+// 设置文件可见性：private / users（配合 usernames）/ public
+export const updateFileVisibility = (id, visibility, usernames = []) =>
+  api.put(`/files/${id}/visibility`, { visibility, usernames })
+// 文件版本：上传新版本（保留文件 ID 与分享链接）、列出、下载与恢复历史版本
+export const uploadFileVersion = (id, formData, onUploadProgress) =>
+  api.post(`/files/${id}/versions`, formData, {
+    headers: { 'Content-Type': 'multipart/form-data' },
+    onUploadProgress,
+  })
+export const fetchFileVersions = (id) => api.get(`/files/${id}/versions`)
+export const downloadFileVersion = (id, version, options = {}) =>
+  api.get(`/files/${id}/versions/${version}/download`, { responseType: 'blob', ...options })
+export const restoreFileVersion = (id, version) => api.post(`/files/${id}/versions/${version}/restore`)
+// 文件标签：添加（支持一次多个）与移除，标签名不区分大小写
+export const addFileTags = (id, tags, global = false) => api.post(`/files/${id}/tags`, { tags, global })
+export const removeFileTag = (id, tag) => api.delete(`/files/${id}/tags/${encodeURIComponent(tag)}`)
+// 获取缩略图（图片为 JPEG，文本为摘录）；后台生成中返回 202，可稍后重试
+export const fetchFileThumbnail = (id, options = {}) => api.get(`/files/${id}/thumbnail`, { responseType: 'blob', ...options })
@@ -0,0 +1,7 @@
+import api from './client'
+
+// 用户组：所有登录用户可查看（用于选择分享接收范围），增删改仅管理员可用
+export const listGroups = (q) => api.get('/groups', { params: q ? { q } : undefined })
+export const createGroup = (payload) => api.post('/admin/groups', payload)
+export const updateGroup = (id, payload) => api.patch(`/admin/groups/${id}`, payload)
+export const deleteGroup = (id) => api.delete(`/admin/groups/${id}`)
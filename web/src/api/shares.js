@@ -1,18 +1,62 @@
-import api from './client'
+import api, { fetchAllPages } from './client'
+
+// 密码分享解锁后的访问令牌按分享保存在 sessionStorage，关闭标签页即失效
+const accessKey = (token) => `share-access:${token}`
+export const getShareAccess = (token) => sessionStorage.getItem(accessKey(token)) || ''
+export const clearShareAccess = (token) => sessionStorage.removeItem(accessKey(token))
+
+const withShareAccess = (token, options = {}) => {
+  const access = getShareAccess(token)
+  if (!access) return options
+  return { ...options, headers: { ...(options.headers || {}), 'X-Share-Access': access } }
+}
+
+// 提交分享密码换取短期访问令牌，连续输错会被临时锁定（429）；服务端同时下发 HttpOnly Cookie，<video>/<img> 等直接加载的地址无需再拼接令牌
+export const unlockShare = async (token, password) => {
+  const res = await api.post(`/shares/${token}/unlock`, { password })
+  sessionStorage.setItem(accessKey(token), res.data.access_token)
+  return res
+}
 
 // 获取分享预览元信息，后端会做权限校验
-export const getShareMeta = (token) => api.get(`/shares/${token}`)
+export const getShareMeta = (token) => api.get(`/shares/${token}`, withShareAccess(token))
 
 // 以内联方式获取分享文件内容，用于预览展示；保留 responseType 以便按需渲染
 export const streamShare = (token, options = {}) =>
-  api.get(`/shares/${token}/stream`, { responseType: 'blob', ...options })
+  api.get(`/shares/${token}/stream`, withShareAccess(token, { responseType: 'blob', ...options }))
+
+// 获取文本分享的语法高亮页面（完整 HTML，计入浏览次数）；language 可覆盖识别出的语言，超过 1 MB 返回 413
+export const fetchShareHighlight = (token, language) =>
+  api.get(`/shares/${token}/highlight`, withShareAccess(token, { responseType: 'text', params: language ? { language } : undefined }))
 
 // 以附件形式下载分享文件，后端会计入同一套浏览/下载次数限制
 export const downloadShare = (token, options = {}) =>
-  api.get(`/shares/${token}/download`, { responseType: 'blob', ...options })
+  api.get(`/shares/${token}/download`, withShareAccess(token, { responseType: 'blob', ...options }))
+
+// 打包分享：file_ids / folder_ids 指定成员，其余参数同单文件分享
+export const createBundleShare = (payload) => api.post('/shares', payload)
+
+// 预览打包分享中的单个文件，与整体下载共用浏览次数
+export const streamShareItem = (token, itemId, options = {}) =>
+  api.get(`/shares/${token}/items/${itemId}`, withShareAccess(token, { responseType: 'blob', ...options }))
+
+// 获取分享缩略图或文本摘录，不计入浏览次数；后台生成中返回 202
+export const fetchShareThumbnail = (token, options = {}) =>
+  api.get(`/shares/${token}/thumbnail`, withShareAccess(token, { responseType: 'blob', ...options }))
+
+// 访问统计：单个分享（创建者 / 文件所有者 / 管理员）与文件下全部分享（文件所有者 / 管理员）
+export const getShareAnalytics = (token, params) => api.get(`/shares/${token}/analytics`, { params })
+export const getFileShareAnalytics = (fileId, params) => api.get(`/files/${fileId}/share-analytics`, { params })
+
+// 我的分享：本人创建或针对本人文件的分享（管理员可见全部），支持修改、撤销与清理
+export const listMyShares = (params) => fetchAllPages('/shares', params)
+export const updateShare = (token, payload) => api.patch(`/shares/${token}`, payload)
+export const revokeMyShare = (token) => api.delete(`/shares/${token}`)
+export const cleanMyShares = (payload) => api.post('/shares/cleanup', payload)
 
 // 管理端：列出所有分享（仅管理员可调用）
-export const listShares = () => api.get('/admin/shares')
+export const listShares = (params) => fetchAllPages('/admin/shares', params)
+export const listSharesPage = (params) => api.get('/admin/shares', { params })
 
 // 管理端：撤销某个分享
 export const revokeShare = (token) => api.delete(`/admin/shares/${token}`)
@@ -0,0 +1,4 @@
+import api from './client'
+
+// 标签联想：按前缀返回可用的个人标签与全局标签
+export const suggestTags = (q, limit = 10) => api.get('/tags', { params: { q, limit } })
@@ -1,6 +1,7 @@
-import api from './client'
+import api, { fetchAllPages } from './client'
 
-export const fetchUsers = () => api.get('/admin/users')
+export const fetchUsers = (params) => fetchAllPages('/admin/users', params)
+export const fetchUsersPage = (params) => api.get('/admin/users', { params })
 export const createUser = (payload) => api.post('/admin/users', payload)
 export const deleteUser = (id) => api.delete(`/admin/users/${id}`)
 export const resetUserPassword = (id, password) => api.post(`/admin/users/${id}/reset-password`, password ? { password } : {})
@@ -0,0 +1,13 @@
+// 以 B / KB / MB / GB 展示字节数
+export const formatSize = (size) => {
+  if (!size) return '0 B'
+  if (size < 1024) return `${size} B`
+  const units = ['KB', 'MB', 'GB']
+  let value = size
+  let idx = -1
+  do {
+    value /= 1024
+    idx++
+  } while (value >= 1024 && idx < units.length - 1)
+  return `${value.toFixed(1)} ${units[idx]}`
+}
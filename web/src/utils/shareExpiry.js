@@ -0,0 +1,13 @@
+import dayjs from 'dayjs'
+
+// 将有效期选项转换为接口参数：数字为天数，custom 使用 expires_in，at 使用截止时间，never 表示永不过期
+export const shareExpiryPayload = (mode, expiresIn, expiresAt) => {
+  if (mode === 'custom') return { expires_in: expiresIn.trim() }
+  if (mode === 'at') return { expires_at: dayjs(expiresAt).format() }
+  if (mode === 'never') return { never_expires: true }
+  if (mode) return { expires_in_days: Number(mode) }
+  return {}
+}
+
+// datetime-local 输入框使用的本地时间格式
+export const toLocalInput = (value) => (value ? dayjs(value).format('YYYY-MM-DDTHH:mm') : '')
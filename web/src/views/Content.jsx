@@ -16,6 +16,7 @@ import {
   Clock,
   Lock,
   Users,
+  Package,
 } from 'lucide-react'
 import { Button } from '../components/ui/button'
 import { Card, CardContent, CardDescription, CardHeader, CardTitle } from '../components/ui/card'
@@ -31,11 +32,14 @@ import {
 import { Textarea } from '../components/ui/textarea'
 import { Label } from '../components/ui/label'
 import { deleteFile, downloadFile, fetchFiles, shareFile, uploadFile } from '../api/files'
-import { fetchUsers } from '../api/users'
+import { createBundleShare } from '../api/shares'
+import { listGroups } from '../api/groups'
 import { useAuthStore } from '../store/auth'
 import { toast } from 'sonner'
 import PreviewDialog from '../components/preview/PreviewDialog'
 import DownloadProgress from '../components/DownloadProgress'
+import { shareExpiryPayload } from '../utils/shareExpiry'
+import { formatSize } from '../utils/format'
 
 const typeOfFile = (mime, filename = '') => {
   if (!mime) return 'other'
@@ -48,23 +52,36 @@ const typeOfFile = (mime, filename = '') => {
   return 'file'
 }
 
-const formatSize = (size) => {
-  if (!size) return '0 B'
-  if (size < 1024) return `${size} B`
-  const units = ['KB', 'MB', 'GB']
-  let value = size
-  let idx = -1
-  do {
-    value /= 1024
-    idx++
-  } while (value >= 1024 && idx < units.length - 1)
-  return `${value.toFixed(1)} ${units[idx]}`
-}
+// 上传时可指定的文本语言，值与后端 highlight 包的规范名称一致；auto 表示由服务端识别
+const textLanguages = [
+  ['auto', '自动识别'],
+  ['text', '纯文本'],
+  ['go', 'Go'],
+  ['python', 'Python'],
+  ['javascript', 'JavaScript'],
+  ['typescript', 'TypeScript'],
+  ['java', 'Java'],
+  ['c', 'C'],
+  ['cpp', 'C++'],
+  ['csharp', 'C#'],
+  ['rust', 'Rust'],
+  ['ruby', 'Ruby'],
+  ['php', 'PHP'],
+  ['shell', 'Shell'],
+  ['sql', 'SQL'],
+  ['css', 'CSS'],
+  ['html', 'HTML'],
+  ['xml', 'XML'],
+  ['json', 'JSON'],
+  ['yaml', 'YAML'],
+  ['markdown', 'Markdown'],
+]
 
 const UploadModal = ({ open, onClose, onUploaded }) => {
   const [file, setFile] = useState(null)
   const [text, setText] = useState('')
   const [description, setDescription] = useState('')
+  const [language, setLanguage] = useState('auto')
   const [uploading, setUploading] = useState(false)
   const [uploadProgress, setUploadProgress] = useState(null)
   const [isDraggingFile, setIsDraggingFile] = useState(false)
@@ -112,6 +129,7 @@ const UploadModal = ({ open, onClose, onUploaded }) => {
     if (file) form.append('file', file)
     if (text) form.append('text', text)
     if (description) form.append('description', description)
+    if (language !== 'auto') form.append('language', language)
     try {
       await uploadFile(form, (event) => {
         // axios 在提供 total 时才计算百分比，否则保持 null 以展示“计算中”占位
@@ -127,6 +145,7 @@ const UploadModal = ({ open, onClose, onUploaded }) => {
       setFile(null)
       setText('')
       setDescription('')
+      setLanguage('auto')
       toast.success('上传成功', {
         description: file ? `文件 ${file.name} 已加入列表` : '文字内容已保存',
       })
@@ -179,6 +198,20 @@ const UploadModal = ({ open, onClose, onUploaded }) => {
             <Textarea rows={3} placeholder="若不选文件，可直接输入文字" value={text} onChange={(e) => setText(e.target.value)} />
           </div>
           <Input placeholder="描述 (可选)" value={description} onChange={(e) => setDescription(e.target.value)} />
+          <div className="space-y-2">
+            <Label className="text-xs uppercase tracking-wide text-slate-500">文本语言</Label>
+            <Select value={language} onValueChange={setLanguage}>
+              <SelectTrigger className="w-full">
+                <SelectValue />
+              </SelectTrigger>
+              <SelectContent>
+                {textLanguages.map(([value, label]) => (
+                  <SelectItem key={value} value={value}>{label}</SelectItem>
+                ))}
+              </SelectContent>
+            </Select>
+            <p className="text-xs text-slate-500">仅对文本内容生效，分享时用于语法高亮。</p>
+          </div>
           {uploading && (
             <DownloadProgress
               percent={uploadProgress}
@@ -223,42 +256,59 @@ const ConfirmDialog = ({ open, title, description, confirmText = '确认', onCon
 }
 
 
-const ShareDialog = ({ open, file, onClose, onCreate, isAdmin }) => {
+const ShareDialog = ({ open, file, onClose, onCreate }) => {
   const [requireLogin, setRequireLogin] = useState(true)
-  const [allowUsername, setAllowUsername] = useState('')
+  const [allowUsernames, setAllowUsernames] = useState('')
+  const [allowGroups, setAllowGroups] = useState([])
   const [maxViews, setMaxViews] = useState('20')
   const [expiresInDays, setExpiresInDays] = useState('7')
+  const [expiresIn, setExpiresIn] = useState('')
+  const [expiresAt, setExpiresAt] = useState('')
+  const [notBefore, setNotBefore] = useState('')
+  const [password, setPassword] = useState('')
+  const [burnAfterReading, setBurnAfterReading] = useState(false)
+  // 短链接：随机短码与自定义链接均为可选，默认仍只生成不可猜测的 UUID 链接
+  const [shortCode, setShortCode] = useState(false)
+  const [slug, setSlug] = useState('')
   const [submitting, setSubmitting] = useState(false)
-  const [userSearch, setUserSearch] = useState('')
-  const [users, setUsers] = useState([])
-  const [loadingUsers, setLoadingUsers] = useState(false)
+  const [groups, setGroups] = useState([])
+  const [loadingGroups, setLoadingGroups] = useState(false)
 
   useEffect(() => {
     if (open) {
       // 打开弹窗时重置默认值，保证配置可重复使用
       setRequireLogin(true)
-      setAllowUsername('')
+      setAllowUsernames('')
+      setAllowGroups([])
       setMaxViews('20')
       setExpiresInDays('7')
-      setUserSearch('')
-      if (isAdmin) {
-        loadUsers()
-      }
+      setExpiresIn('')
+      setExpiresAt('')
+      setNotBefore('')
+      setPassword('')
+      setBurnAfterReading(false)
+      setShortCode(false)
+      setSlug('')
+      loadGroups()
     }
   }, [open, file?.id])
 
-  const loadUsers = async () => {
-    setLoadingUsers(true)
+  const loadGroups = async () => {
+    setLoadingGroups(true)
     try {
-      const { data } = await fetchUsers()
-      setUsers(data || [])
+      const { data } = await listGroups()
+      setGroups(data || [])
     } catch (err) {
-      toast.error(err.response?.data?.error || err.message, { description: '无法获取用户列表' })
+      toast.error(err.response?.data?.error || err.message, { description: '无法获取用户组列表' })
     } finally {
-      setLoadingUsers(false)
+      setLoadingGroups(false)
     }
   }
 
+  const toggleGroup = (name) => {
+    setAllowGroups((prev) => (prev.includes(name) ? prev.filter((g) => g !== name) : [...prev, name]))
+  }
+
   if (!open || !file) return null
 
   const submit = async (e) => {
@@ -267,9 +317,19 @@ const ShareDialog = ({ open, file, onClose, onCreate, isAdmin }) => {
     try {
       const payload = {
         require_login: requireLogin,
-        allow_username: isAdmin ? allowUsername.trim() || undefined : undefined,
-        max_views: maxViews ? Number(maxViews) : undefined,
-        expires_in_days: Number(expiresInDays),
+        allow_usernames: allowUsernames
+          .split(/[,，\s]+/)
+          .map((u) => u.trim())
+          .filter(Boolean),
+        allow_groups: allowGroups,
+        // 阅后即焚由后端固定为 1 次
+        max_views: !burnAfterReading && maxViews ? Number(maxViews) : undefined,
+        burn_after_reading: burnAfterReading || undefined,
+        short_code: shortCode || undefined,
+        slug: slug.trim() || undefined,
+        ...shareExpiryPayload(expiresInDays, expiresIn, expiresAt),
+        not_before: notBefore ? dayjs(notBefore).format() : undefined,
+        password: password || undefined,
       }
       await onCreate(payload)
     } catch (err) {
@@ -309,8 +369,25 @@ const ShareDialog = ({ open, file, onClose, onCreate, isAdmin }) => {
                   <SelectItem value="1">1 天内仅可访问</SelectItem>
                   <SelectItem value="7">7 天内仅可访问</SelectItem>
                   <SelectItem value="30">30 天内仅可访问</SelectItem>
+                  <SelectItem value="custom">自定义时长</SelectItem>
+                  <SelectItem value="at">指定截止时间</SelectItem>
+                  <SelectItem value="never">永不过期</SelectItem>
                 </SelectContent>
               </Select>
+              {expiresInDays === 'custom' && (
+                <Input value={expiresIn} onChange={(e) => setExpiresIn(e.target.value)} placeholder="如 90m、36h、10d" required />
+              )}
+              {expiresInDays === 'at' && (
+                <Input type="datetime-local" value={expiresAt} onChange={(e) => setExpiresAt(e.target.value)} required />
+              )}
+              {expiresInDays === 'never' && (
+                <p className="text-xs text-slate-500">是否允许永不过期取决于管理员配置的分享策略。</p>
+              )}
+            </div>
+            <div className="space-y-2">
+              <Label className="text-xs uppercase tracking-wide text-slate-500">生效时间</Label>
+              <Input type="datetime-local" value={notBefore} onChange={(e) => setNotBefore(e.target.value)} />
+              <p className="text-xs text-slate-500">留空表示立即生效，生效前访问链接会被拒绝。</p>
             </div>
             <div className="space-y-2">
               <Label className="text-xs uppercase tracking-wide text-slate-500">访问次数</Label>
@@ -318,11 +395,46 @@ const ShareDialog = ({ open, file, onClose, onCreate, isAdmin }) => {
                 type="number"
                 min={1}
                 max={1000}
-                value={maxViews}
+                value={burnAfterReading ? '1' : maxViews}
                 onChange={(e) => setMaxViews(e.target.value)}
+                disabled={burnAfterReading}
                 placeholder="最多可查看次数 (可为空代表不限)"
               />
               <p className="text-xs text-slate-500">留空表示不限次数，1-1000 之间将强制限制。</p>
+              <label className="flex cursor-pointer items-center gap-2 text-sm text-slate-700">
+                <input
+                  type="checkbox"
+                  checked={burnAfterReading}
+                  onChange={(e) => setBurnAfterReading(e.target.checked)}
+                  className="h-4 w-4 text-primary focus:ring-primary"
+                />
+                阅后即焚：只能查看一次，不提供缩略图
+              </label>
+            </div>
+            <div className="space-y-2">
+              <Label className="text-xs uppercase tracking-wide text-slate-500">访问密码</Label>
+              <Input
+                type="password"
+                autoComplete="new-password"
+                value={password}
+                onChange={(e) => setPassword(e.target.value)}
+                placeholder="可选，4-72 个字符"
+              />
+              <p className="text-xs text-slate-500">设置后访问者需输入密码，适合发给没有账号的外部接收者。</p>
+            </div>
+            <div className="space-y-2">
+              <Label className="text-xs uppercase tracking-wide text-slate-500">短链接</Label>
+              <label className="flex cursor-pointer items-center gap-2 text-sm text-slate-700">
+                <input
+                  type="checkbox"
+                  checked={shortCode}
+                  onChange={(e) => setShortCode(e.target.checked)}
+                  className="h-4 w-4 text-primary focus:ring-primary"
+                />
+                生成 8 位随机短码
+              </label>
+              <Input value={slug} onChange={(e) => setSlug(e.target.value)} placeholder="自定义链接 (可选)，如 q3-report" />
+              <p className="text-xs text-slate-500">短链接更易读写但更容易被猜到，建议配合登录或密码使用。</p>
             </div>
           </div>
           <div className="space-y-3">
@@ -364,58 +476,43 @@ const ShareDialog = ({ open, file, onClose, onCreate, isAdmin }) => {
               </div>
             </div>
 
-            {isAdmin && (
-              <div className="space-y-2">
-                <Label className="text-xs uppercase tracking-wide text-slate-500">限定接收人 (管理员可选)</Label>
-                <div className="space-y-2 rounded-xl border border-slate-200 p-3">
-                  <Input
-                    placeholder="筛选用户名"
-                    value={userSearch}
-                    onChange={(e) => setUserSearch(e.target.value)}
-                    className="w-full"
-                  />
-                  <div className="max-h-48 overflow-y-auto rounded-lg border border-slate-100 bg-slate-50">
-                    <label className="flex cursor-pointer items-center gap-2 px-3 py-2 text-sm text-slate-700 hover:bg-white">
-                      <input
-                        type="radio"
-                        name="allow-user"
-                        value=""
-                        checked={!allowUsername}
-                        onChange={() => setAllowUsername('')}
-                        className="h-4 w-4 text-primary"
-                      />
-                      不限定接收人
-                    </label>
-                    {loadingUsers ? (
-                      <p className="px-3 py-2 text-xs text-slate-500">加载用户...</p>
-                    ) : (
-                      users
-                        .filter((u) => u.username.toLowerCase().includes(userSearch.toLowerCase()))
-                        .map((u) => (
-                          <label
-                            key={u.id}
-                            className="flex cursor-pointer items-center gap-2 px-3 py-2 text-sm text-slate-700 hover:bg-white"
-                          >
-                            <input
-                              type="radio"
-                              name="allow-user"
-                              value={u.username}
-                              checked={allowUsername === u.username}
-                              onChange={() => setAllowUsername(u.username)}
-                              className="h-4 w-4 text-primary"
-                            />
-                            <span className="font-medium">{u.username}</span>
-                            <Badge variant="secondary" className="text-[11px]">{u.role}</Badge>
-                          </label>
-                        ))
-                    )}
-                  </div>
-                  <p className="text-xs text-slate-500 flex items-center gap-1">
-                    <Users className="h-4 w-4" /> 仅管理员可指定接收人，选择后将强制登录校验。
-                  </p>
+            <div className="space-y-2">
+              <Label className="text-xs uppercase tracking-wide text-slate-500">限定接收人 (可选)</Label>
+              <div className="space-y-2 rounded-xl border border-slate-200 p-3">
+                <Input
+                  placeholder="用户名，多个以逗号分隔"
+                  value={allowUsernames}
+                  onChange={(e) => setAllowUsernames(e.target.value)}
+                  className="w-full"
+                />
+                <div className="max-h-48 overflow-y-auto rounded-lg border border-slate-100 bg-slate-50">
+                  {loadingGroups ? (
+                    <p className="px-3 py-2 text-xs text-slate-500">加载用户组...</p>
+                  ) : groups.length === 0 ? (
+                    <p className="px-3 py-2 text-xs text-slate-500">暂无用户组</p>
+                  ) : (
+                    groups.map((g) => (
+                      <label
+                        key={g.id}
+                        className="flex cursor-pointer items-center gap-2 px-3 py-2 text-sm text-slate-700 hover:bg-white"
+                      >
+                        <input
+                          type="checkbox"
+                          checked={allowGroups.includes(g.name)}
+                          onChange={() => toggleGroup(g.name)}
+                          className="h-4 w-4 text-primary"
+                        />
+                        <span className="font-medium">{g.name}</span>
+                        <Badge variant="secondary" className="text-[11px]">{g.member_count} 人</Badge>
+                      </label>
+                    ))
+                  )}
                 </div>
+                <p className="text-xs text-slate-500 flex items-center gap-1">
+                  <Users className="h-4 w-4" /> 指定用户或用户组后仅其成员可访问，并强制登录校验。
+                </p>
               </div>
-            )}
+            </div>
 
             <div className="flex flex-wrap gap-2 text-xs text-slate-500">
               <span className="flex items-center gap-1"><Clock className="h-4 w-4" /> 访问时间范围：可选 1/7/30 天</span>
@@ -444,6 +541,8 @@ const Content = () => {
 	const [shareLink, setShareLink] = useState('')
 	const [shareTarget, setShareTarget] = useState(null)
 	const [shareDialogOpen, setShareDialogOpen] = useState(false)
+	// 勾选的文件 ID，用于打包分享
+	const [selectedIds, setSelectedIds] = useState([])
 	const [pendingDelete, setPendingDelete] = useState(null)
   // 控制当前正在预览的文件，兼顾移动端全屏弹层体验
   const [previewing, setPreviewing] = useState(null)
@@ -490,6 +589,7 @@ const Content = () => {
 			const { data } = await fetchFiles()
 			setFiles(data)
 			setShareLink('')
+			setSelectedIds([])
 		} catch (err) {
 			console.error(err)
 		} finally {
@@ -506,11 +606,24 @@ const Content = () => {
 		setShareDialogOpen(true)
 	}
 
+	const toggleSelected = (id) => {
+		setSelectedIds((prev) => (prev.includes(id) ? prev.filter((v) => v !== id) : [...prev, id]))
+	}
+
+	// 多个文件打包为一个分享，访问者可逐个预览或下载 ZIP
+	const startBundleShare = () => {
+		setShareTarget({ bundle: true, fileIds: selectedIds, filename: `${selectedIds.length} 个文件（打包为 ZIP）` })
+		setShareDialogOpen(true)
+	}
+
 	const handleCreateShare = async (options) => {
 		if (!shareTarget) return
 		try {
-			const { data } = await shareFile(shareTarget.id, options)
-			const link = `${window.location.origin}${data.preview_path}`
+			const { data } = shareTarget.bundle
+				? await createBundleShare({ ...options, file_ids: shareTarget.fileIds })
+				: await shareFile(shareTarget.id, options)
+			// 设置了短链接时优先分发短链接
+			const link = `${window.location.origin}${data.short_path || data.preview_path}`
 			setShareLink(link)
 			setShareDialogOpen(false)
 			const copied = await copyToClipboard(link)
@@ -695,6 +808,11 @@ const Content = () => {
           </SelectContent>
         </Select>
         <div className="flex-1" />
+        {selectedIds.length > 1 && (
+          <Button variant="outline" onClick={startBundleShare} className="gap-2">
+            <Package className="h-4 w-4" /> 打包分享 {selectedIds.length} 个文件
+          </Button>
+        )}
         <Button variant="outline" onClick={loadFiles} disabled={loading} className="gap-2">
           <RefreshCw className="h-4 w-4" /> 刷新
         </Button>
@@ -748,15 +866,26 @@ const Content = () => {
                         </CardDescription>
                       </div>
                       {/* 将类型徽章固定在卡片右侧，避免随标题长度波动导致布局抖动 */}
-                      <Badge variant="secondary" className="flex-shrink-0 self-start">
-                        {getTypeLabel(type)}
-                      </Badge>
+                      <div className="flex flex-shrink-0 items-center gap-2 self-start">
+                        <Badge variant="secondary">{getTypeLabel(type)}</Badge>
+                        {canShare && (
+                          <input
+                            type="checkbox"
+                            title="选择后可打包分享"
+                            checked={selectedIds.includes(f.id)}
+                            onChange={() => toggleSelected(f.id)}
+                            className="h-4 w-4 accent-slate-700"
+                          />
+                        )}
+                      </div>
                     </div>
                   </CardHeader>
                   <CardContent className="space-y-3">
                     <div className="text-sm text-slate-600 flex flex-wrap gap-3">
                       <span>上传者：{f.owner}</span>
                       <span>时间：{dayjs(f.created_at).format('MM/DD HH:mm')}</span>
+                      {f.drop_id && <span className="text-primary">来自上传链接 #{f.drop_id}</span>}
+                      {f.language && f.language !== 'text' && <span>语言：{f.language}</span>}
                     </div>
                     <div className="text-sm text-slate-600">大小：{formatSize(f.size)}</div>
                     {/* 动作按钮使用紧凑尺寸，桌面端保持单行，移动端可自动换行避免溢出 */}
@@ -848,7 +977,6 @@ const Content = () => {
 		file={shareTarget}
 		onClose={() => setShareDialogOpen(false)}
 		onCreate={handleCreateShare}
-		isAdmin={isAdmin}
 	/>
 	<ConfirmDialog
 		open={confirmConfig.open}
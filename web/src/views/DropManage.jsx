@@ -0,0 +1,221 @@
+import { useEffect, useState } from 'react'
+import dayjs from 'dayjs'
+import { Copy, Inbox, Loader2, Plus, RefreshCw, Trash2 } from 'lucide-react'
+import { toast } from 'sonner'
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from '../components/ui/card'
+import { Button } from '../components/ui/button'
+import { Input } from '../components/ui/input'
+import { Label } from '../components/ui/label'
+import { Textarea } from '../components/ui/textarea'
+import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from '../components/ui/select'
+import { createDrop, listDrops, revokeDrop } from '../api/drops'
+import { shareExpiryPayload } from '../utils/shareExpiry'
+import { formatSize } from '../utils/format'
+
+const emptyForm = { title: '', instructions: '', maxFiles: '', maxFileSizeMB: '', allowedTypes: '', expiry: '7', expiresIn: '', expiresAt: '' }
+
+const dropUrl = (drop) => `${window.location.origin}${drop.upload_path}`
+
+const DropManage = () => {
+  const [drops, setDrops] = useState([])
+  const [loading, setLoading] = useState(true)
+  const [creating, setCreating] = useState(false)
+  const [revoking, setRevoking] = useState('')
+  const [form, setForm] = useState(emptyForm)
+
+  const setField = (key) => (e) => setForm((prev) => ({ ...prev, [key]: e.target.value }))
+
+  const load = async () => {
+    setLoading(true)
+    try {
+      const { data } = await listDrops()
+      setDrops(data || [])
+    } catch (err) {
+      toast.error(err.response?.data?.error || '获取上传链接失败')
+    } finally {
+      setLoading(false)
+    }
+  }
+
+  useEffect(() => {
+    load()
+  }, [])
+
+  const copyLink = async (drop) => {
+    try {
+      await navigator.clipboard.writeText(dropUrl(drop))
+      toast.success('链接已复制')
+    } catch (err) {
+      toast.error('复制失败，请手动保存')
+    }
+  }
+
+  const submit = async (e) => {
+    e.preventDefault()
+    setCreating(true)
+    try {
+      const payload = {
+        title: form.title.trim(),
+        instructions: form.instructions.trim(),
+        max_files: form.maxFiles ? Number(form.maxFiles) : undefined,
+        max_file_size: form.maxFileSizeMB ? Math.round(Number(form.maxFileSizeMB) * 1024 * 1024) : 0,
+        allowed_types: form.allowedTypes
+          .split(/[,，\s]+/)
+          .map((t) => t.trim())
+          .filter(Boolean),
+        ...shareExpiryPayload(form.expiry, form.expiresIn, form.expiresAt),
+      }
+      const { data } = await createDrop(payload)
+      setDrops((prev) => [data, ...prev])
+      setForm(emptyForm)
+      copyLink(data)
+    } catch (err) {
+      toast.error(err.response?.data?.error || '创建失败')
+    } finally {
+      setCreating(false)
+    }
+  }
+
+  const revoke = async (drop) => {
+    setRevoking(drop.token)
+    try {
+      await revokeDrop(drop.token)
+      toast.success('已撤销上传链接', { description: '已收集的文件仍保留在空间中' })
+      setDrops((prev) => prev.filter((d) => d.token !== drop.token))
+    } catch (err) {
+      toast.error(err.response?.data?.error || '撤销失败')
+    } finally {
+      setRevoking('')
+    }
+  }
+
+  return (
+    <div className="space-y-5">
+      <Card>
+        <CardHeader className="flex flex-col gap-3 md:flex-row md:items-center md:justify-between">
+          <div>
+            <CardTitle className="flex items-center gap-2 text-lg">
+              <Inbox className="h-5 w-5 text-primary" /> 文件收集
+            </CardTitle>
+            <CardDescription>生成上传链接，对方无需账号即可把文件传到你的空间，并计入你的配额。</CardDescription>
+          </div>
+          <Button variant="outline" size="sm" onClick={load} disabled={loading} className="gap-2">
+            <RefreshCw className={`h-4 w-4 ${loading ? 'animate-spin' : ''}`} /> 刷新
+          </Button>
+        </CardHeader>
+        <CardContent>
+          <form className="grid gap-4 md:grid-cols-2" onSubmit={submit}>
+            <div className="space-y-3">
+              <div className="space-y-2">
+                <Label>标题</Label>
+                <Input placeholder="如：第三周作业提交" value={form.title} onChange={setField('title')} />
+              </div>
+              <div className="space-y-2">
+                <Label>说明（可选）</Label>
+                <Textarea rows={3} placeholder="展示在上传页面，告诉对方需要上传什么" value={form.instructions} onChange={setField('instructions')} />
+              </div>
+            </div>
+            <div className="space-y-3">
+              <div className="grid gap-3 sm:grid-cols-2">
+                <div className="space-y-2">
+                  <Label>文件数上限</Label>
+                  <Input type="number" min={1} max={1000} placeholder="不限" value={form.maxFiles} onChange={setField('maxFiles')} />
+                </div>
+                <div className="space-y-2">
+                  <Label>单个文件上限（MB）</Label>
+                  <Input type="number" min={0} step="0.1" placeholder="仅受配额限制" value={form.maxFileSizeMB} onChange={setField('maxFileSizeMB')} />
+                </div>
+              </div>
+              <div className="space-y-2">
+                <Label>允许的类型</Label>
+                <Input placeholder="如 application/pdf, image/*，留空不限" value={form.allowedTypes} onChange={setField('allowedTypes')} />
+              </div>
+              <div className="space-y-2">
+                <Label>有效期</Label>
+                <Select value={form.expiry} onValueChange={(v) => setForm((prev) => ({ ...prev, expiry: v }))}>
+                  <SelectTrigger className="w-full">
+                    <SelectValue />
+                  </SelectTrigger>
+                  <SelectContent>
+                    <SelectItem value="1">1 天</SelectItem>
+                    <SelectItem value="7">7 天</SelectItem>
+                    <SelectItem value="30">30 天</SelectItem>
+                    <SelectItem value="custom">自定义时长</SelectItem>
+                    <SelectItem value="at">指定截止时间</SelectItem>
+                    <SelectItem value="never">永不过期</SelectItem>
+                  </SelectContent>
+                </Select>
+                {form.expiry === 'custom' && (
+                  <Input value={form.expiresIn} onChange={setField('expiresIn')} placeholder="如 90m、36h、10d" required />
+                )}
+                {form.expiry === 'at' && <Input type="datetime-local" value={form.expiresAt} onChange={setField('expiresAt')} required />}
+              </div>
+            </div>
+            <div className="flex justify-end md:col-span-2">
+              <Button type="submit" disabled={creating} className="gap-2">
+                <Plus className="h-4 w-4" /> {creating ? '创建中...' : '创建上传链接'}
+              </Button>
+            </div>
+          </form>
+        </CardContent>
+      </Card>
+
+      <Card>
+        <CardHeader>
+          <CardTitle className="text-lg">已创建的链接</CardTitle>
+          <CardDescription>收集到的文件会标记来源链接，可在内容页按来源查看。</CardDescription>
+        </CardHeader>
+        <CardContent className="space-y-3">
+          {loading && (
+            <div className="flex items-center justify-center gap-2 text-sm text-slate-500">
+              <Loader2 className="h-4 w-4 animate-spin" /> 正在加载...
+            </div>
+          )}
+          {!loading && drops.length === 0 && (
+            <div className="rounded-xl border border-dashed border-slate-300 px-4 py-6 text-center text-sm text-slate-500">暂无上传链接</div>
+          )}
+          {!loading &&
+            drops.map((d) => (
+              <div key={d.token} className={`rounded-xl border border-slate-200 bg-white p-4 ${d.expired ? 'opacity-60' : ''}`}>
+                <div className="flex flex-col gap-2 md:flex-row md:items-center md:justify-between">
+                  <div className="min-w-0">
+                    <p className="font-semibold text-slate-900">
+                      #{d.id} {d.title}
+                      {d.expired && <span className="ml-2 rounded-full bg-rose-50 px-2 py-0.5 text-xs text-rose-600">已失效</span>}
+                    </p>
+                    <p className="truncate text-xs text-slate-500">{dropUrl(d)}</p>
+                  </div>
+                  <div className="flex gap-2">
+                    <Button variant="outline" size="sm" className="gap-1" onClick={() => copyLink(d)}>
+                      <Copy className="h-4 w-4" /> 复制
+                    </Button>
+                    <Button
+                      variant="outline"
+                      size="sm"
+                      className="gap-1 text-rose-600 hover:text-rose-700"
+                      onClick={() => revoke(d)}
+                      disabled={revoking === d.token}
+                    >
+                      <Trash2 className="h-4 w-4" /> {revoking === d.token ? '撤销中' : '撤销'}
+                    </Button>
+                  </div>
+                </div>
+                <div className="mt-2 flex flex-wrap gap-3 text-xs text-slate-500">
+                  <span>
+                    已收集 {d.upload_count}
+                    {d.max_files ? `/${d.max_files}` : ''} 个（现存 {d.file_count}）
+                  </span>
+                  <span>单个上限：{d.max_file_size ? formatSize(d.max_file_size) : '仅受配额限制'}</span>
+                  <span>类型：{d.allowed_types?.length ? d.allowed_types.join(', ') : '不限'}</span>
+                  <span>{d.expires_at ? `有效期至 ${dayjs(d.expires_at).format('YYYY-MM-DD HH:mm')}` : '永久有效'}</span>
+                  {d.owner && <span>所有者：{d.owner}</span>}
+                </div>
+              </div>
+            ))}
+        </CardContent>
+      </Card>
+    </div>
+  )
+}
+
+export default DropManage
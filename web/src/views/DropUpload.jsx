@@ -0,0 +1,135 @@
+import { useEffect, useState } from 'react'
+import { useParams } from 'react-router-dom'
+import dayjs from 'dayjs'
+import { AlertTriangle, CheckCircle2, Inbox, RefreshCw, Upload } from 'lucide-react'
+import { toast } from 'sonner'
+import { Button } from '../components/ui/button'
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from '../components/ui/card'
+import { Input } from '../components/ui/input'
+import { getDropMeta, uploadToDrop } from '../api/drops'
+import DownloadProgress from '../components/DownloadProgress'
+import { formatSize } from '../utils/format'
+
+// 公开的文件收集页：持有链接即可上传，无需登录
+const DropUpload = () => {
+  const { token } = useParams()
+  const [meta, setMeta] = useState(null)
+  const [error, setError] = useState('')
+  const [loading, setLoading] = useState(true)
+  const [file, setFile] = useState(null)
+  const [percent, setPercent] = useState(null)
+  const [uploaded, setUploaded] = useState([])
+
+  const fetchMeta = async () => {
+    setLoading(true)
+    setError('')
+    try {
+      const { data } = await getDropMeta(token)
+      setMeta(data)
+    } catch (err) {
+      setError(err.response?.status === 404 ? '上传链接不存在或已被撤销' : err.response?.data?.error || '加载失败')
+    } finally {
+      setLoading(false)
+    }
+  }
+
+  useEffect(() => {
+    fetchMeta()
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [token])
+
+  const submit = async (e) => {
+    e.preventDefault()
+    if (!file) return
+    if (meta.max_file_size && file.size > meta.max_file_size) {
+      toast.error(`文件不能超过 ${formatSize(meta.max_file_size)}`)
+      return
+    }
+    const formData = new FormData()
+    formData.append('file', file)
+    setPercent(0)
+    try {
+      const { data } = await uploadToDrop(token, formData, (evt) => {
+        if (evt.total) setPercent(Math.round((evt.loaded / evt.total) * 100))
+      })
+      setUploaded((prev) => [...prev, data])
+      setFile(null)
+      e.target.reset()
+      toast.success('上传成功')
+      if (data.remaining_files !== null && data.remaining_files !== undefined) {
+        setMeta((prev) => ({ ...prev, remaining_files: data.remaining_files }))
+      }
+    } catch (err) {
+      toast.error(err.response?.data?.error || '上传失败')
+      if (err.response?.status === 410) fetchMeta()
+    } finally {
+      setPercent(null)
+    }
+  }
+
+  const full = meta?.remaining_files === 0
+
+  return (
+    <div className="min-h-screen bg-gradient-to-br from-slate-50 via-white to-slate-100 px-4 py-8">
+      <div className="mx-auto flex w-full max-w-xl flex-col gap-4">
+        {loading ? (
+          <Card className="border-dashed">
+            <CardHeader>
+              <CardTitle className="flex items-center gap-2"><RefreshCw className="h-4 w-4 animate-spin" /> 正在加载</CardTitle>
+            </CardHeader>
+          </Card>
+        ) : error ? (
+          <Card className="border-rose-200">
+            <CardHeader>
+              <CardTitle className="flex items-center gap-2 text-rose-600">
+                <AlertTriangle className="h-5 w-5" /> 无法上传
+              </CardTitle>
+              <CardDescription className="text-rose-500">{error}</CardDescription>
+            </CardHeader>
+          </Card>
+        ) : (
+          <Card>
+            <CardHeader>
+              <CardTitle className="flex items-center gap-2 text-lg">
+                <Inbox className="h-5 w-5 text-primary" /> {meta.title}
+              </CardTitle>
+              <CardDescription className="flex flex-wrap gap-3">
+                <span>收件人：{meta.owner}</span>
+                {meta.max_files && <span>剩余 {meta.remaining_files}/{meta.max_files} 个</span>}
+                {meta.max_file_size > 0 && <span>单个不超过 {formatSize(meta.max_file_size)}</span>}
+                {meta.allowed_types?.length > 0 && <span>类型：{meta.allowed_types.join(', ')}</span>}
+                <span>{meta.expires_at ? `截止 ${dayjs(meta.expires_at).format('YYYY-MM-DD HH:mm')}` : '长期有效'}</span>
+              </CardDescription>
+            </CardHeader>
+            <CardContent className="space-y-4">
+              {meta.instructions && <p className="whitespace-pre-wrap rounded-xl bg-slate-50 p-3 text-sm text-slate-700">{meta.instructions}</p>}
+              <form onSubmit={submit} className="flex flex-col gap-3">
+                <Input
+                  type="file"
+                  accept={meta.allowed_types?.join(',') || undefined}
+                  onChange={(e) => setFile(e.target.files?.[0] || null)}
+                  disabled={full}
+                />
+                {percent !== null && <DownloadProgress percent={percent} label="上传中" />}
+                <Button type="submit" disabled={!file || full || percent !== null} className="gap-2">
+                  <Upload className="h-4 w-4" /> {full ? '已收满' : '上传'}
+                </Button>
+              </form>
+              {uploaded.length > 0 && (
+                <div className="space-y-1 text-sm text-slate-600">
+                  {uploaded.map((u, i) => (
+                    <p key={i} className="flex items-center gap-2">
+                      <CheckCircle2 className="h-4 w-4 text-emerald-500" /> {u.filename}（{formatSize(u.size)}）
+                    </p>
+                  ))}
+                </div>
+              )}
+            </CardContent>
+          </Card>
+        )}
+      </div>
+    </div>
+  )
+}
+
+export default DropUpload
@@ -1,12 +1,14 @@
 import { useEffect, useMemo, useState } from 'react'
 import dayjs from 'dayjs'
 import relativeTime from 'dayjs/plugin/relativeTime'
-import { AlertTriangle, Copy, Lock, RefreshCw, ShieldCheck, Trash2, Users } from 'lucide-react'
+import { AlertTriangle, BarChart3, Copy, Lock, Pencil, RefreshCw, ShieldCheck, Trash2, Users, X } from 'lucide-react'
 import { Table, TableBody, TableCell, TableHead, TableHeader, TableRow } from '../components/ui/table'
 import { Button } from '../components/ui/button'
 import { Badge } from '../components/ui/badge'
-import { cleanShares, listShares, revokeShare } from '../api/shares'
+import { Input } from '../components/ui/input'
+import { cleanMyShares, getShareAnalytics, listMyShares, revokeMyShare, updateShare } from '../api/shares'
 import { toast } from 'sonner'
+import { shareExpiryPayload, toLocalInput } from '../utils/shareExpiry'
 
 dayjs.extend(relativeTime)
 
@@ -22,6 +24,10 @@ const ShareManage = () => {
     exhausted: false,
   })
   const [error, setError] = useState('')
+  const [stats, setStats] = useState(null)
+  const [loadingStats, setLoadingStats] = useState('')
+  const [editing, setEditing] = useState(null)
+  const [saving, setSaving] = useState(false)
   const shareBase = useMemo(() => (typeof window !== 'undefined' ? window.location.origin : ''), [])
 
   // 通用复制工具，兼容桌面端与移动端的异步剪贴板及回退方案
@@ -56,7 +62,7 @@ const ShareManage = () => {
     setLoading(true)
     setError('')
     try {
-      const { data } = await listShares()
+      const { data } = await listMyShares()
       setShares(data)
     } catch (err) {
       setError(err.response?.data?.error || err.message)
@@ -69,10 +75,71 @@ const ShareManage = () => {
     load()
   }, [])
 
+  const showStats = async (token) => {
+    setLoadingStats(token)
+    try {
+      const { data } = await getShareAnalytics(token, { limit: 10 })
+      setStats({ token, ...data })
+    } catch (err) {
+      toast.error(err.response?.data?.error || err.message, { description: '无法获取访问统计' })
+    } finally {
+      setLoadingStats('')
+    }
+  }
+
+  const startEdit = (s) => {
+    setEditing({
+      token: s.token,
+      requireLogin: s.require_login,
+      maxViews: s.max_views ? String(s.max_views) : '',
+      expiresInDays: '',
+      expiresIn: '',
+      expiresAt: '',
+      notBefore: toLocalInput(s.not_before),
+      resetViews: false,
+      usernames: (s.allow_usernames || []).join(', '),
+      groups: (s.allow_groups || []).join(', '),
+      shortCode: Boolean(s.short_code),
+      slug: s.slug || '',
+    })
+  }
+
+  const splitNames = (value) =>
+    value
+      .split(/[,，\s]+/)
+      .map((v) => v.trim())
+      .filter(Boolean)
+
+  // 仅提交有效字段：次数留空表示不限，有效期留空表示不修改
+  const saveEdit = async (e) => {
+    e.preventDefault()
+    setSaving(true)
+    try {
+      const { data } = await updateShare(editing.token, {
+        require_login: editing.requireLogin,
+        max_views: editing.maxViews ? Number(editing.maxViews) : 0,
+        ...shareExpiryPayload(editing.expiresInDays, editing.expiresIn, editing.expiresAt),
+        not_before: editing.notBefore ? dayjs(editing.notBefore).format() : '',
+        reset_views: editing.resetViews,
+        allow_usernames: splitNames(editing.usernames),
+        allow_groups: splitNames(editing.groups),
+        short_code: editing.shortCode,
+        slug: editing.slug.trim(),
+      })
+      setShares((prev) => prev.map((s) => (s.token === data.token ? data : s)))
+      setEditing(null)
+      toast.success('分享已更新')
+    } catch (err) {
+      toast.error(err.response?.data?.error || err.message, { description: '更新失败' })
+    } finally {
+      setSaving(false)
+    }
+  }
+
   const revoke = async (token) => {
     setRevoking(token)
     try {
-      await revokeShare(token)
+      await revokeMyShare(token)
       toast.success('已撤销分享')
       setShares((prev) => prev.filter((s) => s.token !== token))
     } catch (err) {
@@ -95,7 +162,7 @@ const ShareManage = () => {
     }
     setCleaning(true)
     try {
-      const { data } = await cleanShares({
+      const { data } = await cleanMyShares({
         remove_expired: cleanupOptions.expired,
         remove_missing_file: cleanupOptions.missingFile,
         remove_exhausted: cleanupOptions.exhausted,
@@ -110,11 +177,12 @@ const ShareManage = () => {
     }
   }
 
-  // 构造预览地址并复制，提供快捷“复制链接”操作，方便后台一键分发
-  const copyShareLink = async (token) => {
-    if (!token || !shareBase) return
-    setCopying(token)
-    const link = `${shareBase}/preview/${token}`
+  // 构造预览地址并复制，提供快捷“复制链接”操作，方便后台一键分发；设置了短链接时优先复制短链接
+  const copyShareLink = async (s) => {
+    if (!s?.token || !shareBase) return
+    setCopying(s.token)
+    const alias = s.slug || s.short_code
+    const link = alias ? `${shareBase}/s/${alias}` : `${shareBase}/preview/${s.token}`
     const ok = await copyToClipboard(link)
     setCopying('')
     if (ok) {
@@ -132,7 +200,7 @@ const ShareManage = () => {
         <h1 className="text-lg font-semibold text-slate-900 flex items-center gap-2">
           <ShieldCheck className="h-5 w-5 text-primary" /> 分享管理
         </h1>
-        <p className="text-sm text-slate-500">仅超级管理员可见，可撤销失效或违规的分享链接。</p>
+        <p className="text-sm text-slate-500">查看本人创建或针对本人文件的分享并撤销失效链接，管理员可见全部分享。</p>
         <div className="flex-1" />
         <Button variant="outline" size="sm" onClick={load} disabled={loading} className="gap-2">
           <RefreshCw className={`h-4 w-4 ${loading ? 'animate-spin' : ''}`} /> 刷新
@@ -223,14 +291,28 @@ const ShareManage = () => {
                 <TableRow key={s.token}>
                   <TableCell className="space-y-1">
                     <p className="font-medium text-slate-900 break-words">{s.filename}</p>
+                    {s.bundle && <p className="text-xs text-slate-500">打包分享 · {s.item_count} 个文件</p>}
+                    {s.burn_after_reading && <p className="text-xs text-orange-600">阅后即焚</p>}
                     <p className="text-xs text-slate-500 break-all">Token: {s.token}</p>
+                    {(s.slug || s.short_code) && (
+                      <p className="text-xs text-primary break-all">短链接：/s/{s.slug || s.short_code}</p>
+                    )}
                   </TableCell>
                   <TableCell className="text-sm text-slate-700">{s.creator}</TableCell>
                   <TableCell className="text-sm text-slate-700">
-                    {s.allow_username ? (
-                      <Badge variant="secondary" className="gap-1">
-                        <Users className="h-3 w-3" /> 仅 {s.allow_username}
-                      </Badge>
+                    {s.allow_usernames?.length || s.allow_groups?.length ? (
+                      <div className="flex flex-wrap gap-1">
+                        {(s.allow_usernames || []).map((name) => (
+                          <Badge key={`u-${name}`} variant="secondary" className="gap-1">
+                            <Users className="h-3 w-3" /> {name}
+                          </Badge>
+                        ))}
+                        {(s.allow_groups || []).map((name) => (
+                          <Badge key={`g-${name}`} variant="secondary" className="gap-1">
+                            <Users className="h-3 w-3" /> 组 {name}
+                          </Badge>
+                        ))}
+                      </div>
                     ) : (
                       <span className="text-slate-500">未指定</span>
                     )}
@@ -250,7 +332,12 @@ const ShareManage = () => {
                         {dayjs(s.expires_at).format('MM/DD HH:mm')} · {dayjs(s.expires_at).fromNow()}
                       </span>
                     ) : (
-                      '默认 7 天'
+                      '永久有效'
+                    )}
+                    {s.not_before && dayjs(s.not_before).isAfter(dayjs()) && (
+                      <span className="block whitespace-nowrap text-xs text-amber-600">
+                        {dayjs(s.not_before).format('MM/DD HH:mm')} 起生效
+                      </span>
                     )}
                   </TableCell>
                   <TableCell className="text-sm text-slate-700 whitespace-nowrap">
@@ -262,12 +349,25 @@ const ShareManage = () => {
                         variant="secondary"
                         size="sm"
                         className="gap-2"
-                        onClick={() => copyShareLink(s.token)}
+                        onClick={() => copyShareLink(s)}
                         disabled={copying === s.token}
                       >
                         <Copy className="h-4 w-4" />
                         {copying === s.token ? '复制中' : '复制链接'}
                       </Button>
+                      <Button variant="outline" size="sm" className="gap-2" onClick={() => startEdit(s)}>
+                        <Pencil className="h-4 w-4" /> 编辑
+                      </Button>
+                      <Button
+                        variant="outline"
+                        size="sm"
+                        className="gap-2"
+                        onClick={() => showStats(s.token)}
+                        disabled={loadingStats === s.token}
+                      >
+                        <BarChart3 className="h-4 w-4" />
+                        {loadingStats === s.token ? '加载中' : '统计'}
+                      </Button>
                       <Button
                         variant="destructive"
                         size="sm"
@@ -286,6 +386,147 @@ const ShareManage = () => {
           </TableBody>
         </Table>
       </div>
+
+      {editing && (
+        <form className="rounded-2xl border border-slate-200 bg-white p-4 shadow-sm space-y-3" onSubmit={saveEdit}>
+          <div className="flex items-center justify-between">
+            <p className="text-sm font-semibold text-slate-900 flex items-center gap-2">
+              <Pencil className="h-4 w-4 text-primary" /> 编辑分享 · <span className="break-all font-normal">{editing.token}</span>
+            </p>
+            <button type="button" className="rounded-full p-1 text-slate-500 hover:bg-slate-100" onClick={() => setEditing(null)}>
+              <X className="h-4 w-4" />
+            </button>
+          </div>
+          <div className="grid gap-3 text-sm text-slate-700 md:grid-cols-2">
+            <label className="flex items-center gap-2">
+              <input
+                type="checkbox"
+                checked={editing.requireLogin}
+                onChange={(e) => setEditing({ ...editing, requireLogin: e.target.checked })}
+                className="h-4 w-4 accent-slate-700"
+              />
+              需要登录（限定接收人时始终需要）
+            </label>
+            <label className="flex items-center gap-2">
+              <input
+                type="checkbox"
+                checked={editing.resetViews}
+                onChange={(e) => setEditing({ ...editing, resetViews: e.target.checked })}
+                className="h-4 w-4 accent-slate-700"
+              />
+              已用次数清零
+            </label>
+            <Input
+              type="number"
+              min="1"
+              placeholder="浏览次数上限，留空不限"
+              value={editing.maxViews}
+              onChange={(e) => setEditing({ ...editing, maxViews: e.target.value })}
+            />
+            <select
+              value={editing.expiresInDays}
+              onChange={(e) => setEditing({ ...editing, expiresInDays: e.target.value })}
+              className="h-11 rounded-xl border border-input bg-white px-3 text-sm"
+            >
+              <option value="">有效期不变</option>
+              <option value="1">从现在起 1 天</option>
+              <option value="7">从现在起 7 天</option>
+              <option value="30">从现在起 30 天</option>
+              <option value="custom">自定义时长</option>
+              <option value="at">指定截止时间</option>
+              <option value="never">永不过期</option>
+            </select>
+            {editing.expiresInDays === 'custom' && (
+              <Input
+                placeholder="如 90m、36h、10d"
+                value={editing.expiresIn}
+                onChange={(e) => setEditing({ ...editing, expiresIn: e.target.value })}
+                required
+              />
+            )}
+            {editing.expiresInDays === 'at' && (
+              <Input
+                type="datetime-local"
+                value={editing.expiresAt}
+                onChange={(e) => setEditing({ ...editing, expiresAt: e.target.value })}
+                required
+              />
+            )}
+            <Input
+              type="datetime-local"
+              title="生效时间，留空表示立即生效"
+              value={editing.notBefore}
+              onChange={(e) => setEditing({ ...editing, notBefore: e.target.value })}
+            />
+            <Input
+              placeholder="接收用户，多个以逗号分隔"
+              value={editing.usernames}
+              onChange={(e) => setEditing({ ...editing, usernames: e.target.value })}
+            />
+            <Input
+              placeholder="接收用户组，多个以逗号分隔"
+              value={editing.groups}
+              onChange={(e) => setEditing({ ...editing, groups: e.target.value })}
+            />
+            <label className="flex items-center gap-2">
+              <input
+                type="checkbox"
+                checked={editing.shortCode}
+                onChange={(e) => setEditing({ ...editing, shortCode: e.target.checked })}
+                className="h-4 w-4 accent-slate-700"
+              />
+              随机短码链接
+            </label>
+            <Input
+              placeholder="自定义链接，如 q3-report，留空表示不使用"
+              value={editing.slug}
+              onChange={(e) => setEditing({ ...editing, slug: e.target.value })}
+            />
+          </div>
+          <div className="flex justify-end">
+            <Button type="submit" size="sm" disabled={saving}>
+              {saving ? '保存中...' : '保存'}
+            </Button>
+          </div>
+        </form>
+      )}
+
+      {stats && (
+        <div className="rounded-2xl border border-slate-200 bg-white p-4 shadow-sm space-y-3">
+          <div className="flex items-center justify-between">
+            <p className="text-sm font-semibold text-slate-900 flex items-center gap-2">
+              <BarChart3 className="h-4 w-4 text-primary" /> 访问统计 · <span className="break-all font-normal">{stats.token}</span>
+            </p>
+            <button className="rounded-full p-1 text-slate-500 hover:bg-slate-100" onClick={() => setStats(null)}>
+              <X className="h-4 w-4" />
+            </button>
+          </div>
+          <div className="flex flex-wrap gap-2 text-xs">
+            <Badge variant="secondary">总请求 {stats.total_accesses}</Badge>
+            <Badge variant="secondary">预览 {stats.previews}</Badge>
+            <Badge variant="secondary">下载 {stats.downloads}</Badge>
+            <Badge variant="secondary">被拒绝 {stats.denied}</Badge>
+            <Badge variant="secondary">独立访客 {stats.unique_visitors}</Badge>
+            <Badge variant="secondary">传输 {(stats.bytes_sent / 1024).toFixed(1)} KB</Badge>
+          </div>
+          {stats.recent.length === 0 ? (
+            <p className="text-sm text-slate-500">暂无访问记录</p>
+          ) : (
+            <ul className="divide-y divide-slate-100 text-xs text-slate-600">
+              {stats.recent.map((a, i) => (
+                <li key={i} className="flex flex-wrap gap-x-3 py-1.5">
+                  <span className="whitespace-nowrap">{dayjs(a.accessed_at).format('MM/DD HH:mm:ss')}</span>
+                  <span className="font-medium text-slate-800">{a.username || '匿名'}</span>
+                  <span>{a.ip}</span>
+                  <span>{a.disposition === 'attachment' ? '下载' : '预览'}</span>
+                  <span>{a.status}</span>
+                  <span className="break-all text-slate-400">{a.user_agent}</span>
+                </li>
+              ))}
+            </ul>
+          )}
+        </div>
+      )}
     </div>
   )
 }
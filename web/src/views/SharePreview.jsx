@@ -2,12 +2,13 @@ import { useEffect, useState } from 'react'
 import { useNavigate, useParams } from 'react-router-dom'
 import dayjs from 'dayjs'
 import relativeTime from 'dayjs/plugin/relativeTime'
-import { AlertTriangle, ArrowLeft, Clock, Download, Eye, Lock, RefreshCw, ShieldCheck, Users } from 'lucide-react'
+import { AlertTriangle, ArrowLeft, Clock, Download, Eye, FileText, Flame, Lock, Package, RefreshCw, ShieldCheck, Users } from 'lucide-react'
 import { Button } from '../components/ui/button'
 import { Card, CardContent, CardDescription, CardHeader, CardTitle } from '../components/ui/card'
 import { Badge } from '../components/ui/badge'
+import { Input } from '../components/ui/input'
 import { useAuthStore } from '../store/auth'
-import { downloadShare, getShareMeta, streamShare } from '../api/shares'
+import { clearShareAccess, downloadShare, fetchShareHighlight, getShareMeta, streamShare, streamShareItem, unlockShare } from '../api/shares'
 import { toast } from 'sonner'
 import DownloadProgress from '../components/DownloadProgress'
 
@@ -29,6 +30,10 @@ const SharePreview = () => {
   const [meta, setMeta] = useState(null)
   const [previewUrl, setPreviewUrl] = useState('')
   const [textContent, setTextContent] = useState('')
+  // 文本分享由后端渲染的语法高亮页面，放入无脚本权限的 iframe 展示
+  const [highlightHtml, setHighlightHtml] = useState('')
+  // 阅后即焚：需确认后才加载内容，查看过一次即失效
+  const [burnConfirmed, setBurnConfirmed] = useState(false)
   const [loading, setLoading] = useState(true)
   const [previewLoading, setPreviewLoading] = useState(false)
   // 控制下载按钮的加载态，避免重复触发后端计数
@@ -36,20 +41,38 @@ const SharePreview = () => {
   // 记录下载百分比，方便大文件下载时给出实时提示
   const [downloadPercent, setDownloadPercent] = useState(null)
   const [error, setError] = useState(null)
+  // 密码分享：未解锁或访问令牌过期时展示密码输入框
+  const [passwordRequired, setPasswordRequired] = useState(false)
+  const [password, setPassword] = useState('')
+  const [unlocking, setUnlocking] = useState(false)
+  // 打包分享中正在预览的成员；每次预览都会占用一次浏览次数，因此不自动加载
+  const [activeItem, setActiveItem] = useState(null)
+  // 单文件分享预览自身，打包分享预览选中的成员
+  const previewTarget = meta?.bundle ? activeItem : meta
 
   // 拉取元信息：受限于登录或指定用户会返回对应错误
   const fetchMeta = async () => {
     setLoading(true)
     setError(null)
+    setPasswordRequired(false)
     try {
       const { data } = await getShareMeta(token)
+      setActiveItem(null)
+      setBurnConfirmed(false)
       setMeta(data)
     } catch (err) {
       const status = err.response?.status
-      if (status === 401) {
+      if (status === 401 && err.response?.data?.password_required) {
+        clearShareAccess(token)
+        setPasswordRequired(true)
+      } else if (status === 401) {
         setError('该分享需要登录后查看')
+      } else if (status === 403 && err.response?.data?.not_before) {
+        setError(`该分享将于 ${dayjs(err.response.data.not_before).format('YYYY-MM-DD HH:mm')} 生效，请稍后再访问`)
       } else if (status === 403) {
         setError('您不是被允许的访问者，无法查看该分享')
+      } else if (status === 410 && err.response?.data?.burned) {
+        setError('该分享为阅后即焚，已被查看过，内容不再可见')
       } else if (status === 410 || status === 404) {
         setError('分享已失效或不存在')
       } else {
@@ -63,21 +86,34 @@ const SharePreview = () => {
 
   // 获取预览流并生成 blob URL，附带鉴权头避免被浏览器直接下载
   const fetchPreview = async () => {
-    if (!meta) return
+    if (!previewTarget) return
     setPreviewLoading(true)
     setTextContent('')
+    setHighlightHtml('')
     // 每次重新获取前释放旧的 blob URL，避免多次预览导致内存泄漏
     if (previewUrl) {
       URL.revokeObjectURL(previewUrl)
       setPreviewUrl('')
     }
     try {
-      const { data } = await streamShare(token, { responseType: 'blob' })
+      // 单个文本分享优先使用服务端高亮；文本过大（413）时退回原始内容
+      if (!meta.bundle && meta.highlight_path) {
+        try {
+          const { data } = await fetchShareHighlight(token)
+          setHighlightHtml(data)
+          return
+        } catch (err) {
+          if (err.response?.status !== 413) throw err
+        }
+      }
+      const { data } = meta.bundle
+        ? await streamShareItem(token, activeItem.id, { responseType: 'blob' })
+        : await streamShare(token, { responseType: 'blob' })
       const url = URL.createObjectURL(data)
       setPreviewUrl(url)
 
       // 文本类文件直接解码为字符串，移动端也方便查看与复制
-      if (detectType(meta.mime_type, meta.filename) === 'text') {
+      if (detectType(previewTarget.mime_type, previewTarget.filename) === 'text') {
         const text = await data.text()
         setTextContent(text)
       }
@@ -88,7 +124,7 @@ const SharePreview = () => {
       } else if (status === 403) {
         setError('您不是被允许的访问者，无法查看该分享')
       } else if (status === 410) {
-        setError('分享已失效或次数已用尽')
+        setError(meta.burn_after_reading ? '该分享为阅后即焚，已被查看过，内容不再可见' : '分享已失效或次数已用尽')
       } else if (status === 404) {
         // 文件已被删除或路径失效时给出明确提示，避免裸露底层错误
         setError('分享文件已被删除或移动，预览不可用')
@@ -165,11 +201,34 @@ const SharePreview = () => {
   }, [previewUrl])
 
   useEffect(() => {
-    if (meta) {
+    if (previewTarget && (!meta.burn_after_reading || burnConfirmed)) {
       fetchPreview()
     }
     // eslint-disable-next-line react-hooks/exhaustive-deps
-  }, [meta])
+  }, [meta, activeItem, burnConfirmed])
+
+  const handleUnlock = async (event) => {
+    event.preventDefault()
+    if (!password) return
+    setUnlocking(true)
+    try {
+      await unlockShare(token, password)
+      setPassword('')
+      await fetchMeta()
+    } catch (err) {
+      const status = err.response?.status
+      const data = err.response?.data || {}
+      if (status === 429) {
+        toast.error('尝试次数过多', { description: `请于 ${dayjs(data.locked_until).format('HH:mm')} 后再试` })
+      } else if (status === 401) {
+        toast.error('密码错误', { description: `还可尝试 ${data.remaining_attempts ?? 0} 次` })
+      } else {
+        toast.error('解锁失败', { description: data.error || err.message })
+      }
+    } finally {
+      setUnlocking(false)
+    }
+  }
 
   const gotoLogin = () => {
     navigate(`/login?redirect=/preview/${token}`)
@@ -177,19 +236,47 @@ const SharePreview = () => {
 
   const renderPreview = () => {
     if (!meta) return null
-    const fileType = detectType(meta.mime_type, meta.filename)
+    if (meta.bundle && !activeItem) {
+      return (
+        <div className="flex h-[200px] w-full items-center justify-center bg-slate-50 text-sm text-slate-500">
+          从下方列表选择文件预览，或直接下载全部文件的压缩包。
+        </div>
+      )
+    }
+    const fileType = detectType(previewTarget.mime_type, previewTarget.filename)
 
+    if (meta.burn_after_reading && !burnConfirmed) {
+      return (
+        <div className="flex h-[240px] w-full flex-col items-center justify-center gap-3 bg-orange-50 px-4 text-center text-sm text-orange-700">
+          <Flame className="h-6 w-6" />
+          <p>该分享为阅后即焚，内容只能查看一次，关闭或刷新页面后将无法再次打开。</p>
+          <Button onClick={() => setBurnConfirmed(true)} className="gap-2">
+            <Eye className="h-4 w-4" /> 查看内容
+          </Button>
+        </div>
+      )
+    }
+    if (highlightHtml) {
+      return (
+        <iframe
+          title="text-preview"
+          sandbox=""
+          srcDoc={highlightHtml}
+          className="h-[60vh] w-full"
+        />
+      )
+    }
     if (!previewUrl) {
       return <p className="text-sm text-slate-500">点击下方重试或稍后再试。</p>
     }
 
     if (fileType === 'image') {
-      return <img src={previewUrl} alt={meta.filename} className="max-h-[60vh] w-full rounded-xl object-contain" />
+      return <img src={previewUrl} alt={previewTarget.filename} className="max-h-[60vh] w-full rounded-xl object-contain" />
     }
     if (fileType === 'video') {
       return (
         <video controls className="max-h-[60vh] w-full rounded-xl bg-black">
-          <source src={previewUrl} type={meta.mime_type} />
+          <source src={previewUrl} type={previewTarget.mime_type} />
         </video>
       )
     }
@@ -210,11 +297,19 @@ const SharePreview = () => {
     )
   }
 
+  const recipients = [
+    ...(meta?.allow_usernames || []),
+    ...(meta?.allow_groups || []).map((g) => `${g} 组成员`),
+  ].join('、')
+
   const securityItems = [
     meta?.requires_login ? '访问需要登录' : '允许未登录访问',
-    meta?.allow_username ? `仅 ${meta.allow_username} 可查看` : '未限制接收人',
+    ...(meta?.requires_password ? ['访问需要密码'] : []),
+    recipients ? `仅 ${recipients} 可查看` : '未限制接收人',
     meta?.max_views ? `剩余 ${meta.remaining_views ?? 0}/${meta.max_views} 次浏览` : '浏览次数不限',
-    meta?.expires_at ? `有效期至 ${dayjs(meta.expires_at).format('YYYY-MM-DD HH:mm')}` : '默认 7 天有效',
+    ...(meta?.bundle ? ['打包分享：预览单个文件与下载压缩包均计入浏览次数'] : []),
+    ...(meta?.burn_after_reading ? ['阅后即焚：查看或下载一次后立即失效'] : []),
+    meta?.expires_at ? `有效期至 ${dayjs(meta.expires_at).format('YYYY-MM-DD HH:mm')}` : '永久有效',
   ]
 
   return (
@@ -235,6 +330,29 @@ const SharePreview = () => {
               <CardDescription>请稍候，这不会触发下载。</CardDescription>
             </CardHeader>
           </Card>
+        ) : passwordRequired ? (
+          <Card className="mx-auto w-full max-w-md">
+            <CardHeader>
+              <CardTitle className="flex items-center gap-2">
+                <Lock className="h-5 w-5 text-primary" /> 该分享需要密码
+              </CardTitle>
+              <CardDescription>请输入分享者提供的访问密码。</CardDescription>
+            </CardHeader>
+            <CardContent>
+              <form onSubmit={handleUnlock} className="flex flex-col gap-3">
+                <Input
+                  type="password"
+                  autoFocus
+                  value={password}
+                  onChange={(e) => setPassword(e.target.value)}
+                  placeholder="访问密码"
+                />
+                <Button type="submit" disabled={unlocking || !password} className="gap-2">
+                  <ShieldCheck className="h-4 w-4" /> {unlocking ? '正在验证' : '查看分享'}
+                </Button>
+              </form>
+            </CardContent>
+          </Card>
         ) : error ? (
           <Card className="border-rose-200">
             <CardHeader>
@@ -269,13 +387,13 @@ const SharePreview = () => {
                       onClick={handleDownload}
                       disabled={previewLoading || downloading}
                     >
-                      <Download className="h-4 w-4" /> {downloading ? '正在下载' : '下载文件'}
+                      <Download className="h-4 w-4" /> {downloading ? '正在下载' : meta.bundle ? '下载全部（ZIP）' : '下载文件'}
                     </Button>
                     <Button
                       variant="outline"
                       className="flex-1 gap-2 sm:flex-none"
                       onClick={fetchPreview}
-                      disabled={previewLoading}
+                      disabled={previewLoading || !previewTarget || meta.burn_after_reading}
                     >
                       <RefreshCw className="h-4 w-4" /> {previewLoading ? '重新加载中' : '重新加载'}
                     </Button>
@@ -292,21 +410,23 @@ const SharePreview = () => {
                   <span>分享者：{meta.owner}</span>
                   <span>大小：{(meta.size / 1024).toFixed(1)} KB</span>
                   <span>类型：{meta.mime_type}</span>
+                  {meta.language && meta.language !== 'text' && <span>语言：{meta.language}</span>}
+                  {meta.bundle && <span>共 {meta.items?.length || 0} 个文件</span>}
                 </CardDescription>
               </CardHeader>
               <CardContent>
                 <div className="mb-3 flex flex-wrap gap-2 text-xs text-slate-500">
                   <Badge variant="secondary" className="bg-slate-100 text-slate-700">
-                    <Clock className="mr-1 h-3 w-3" /> {meta.expires_at ? `有效期 ${dayjs(meta.expires_at).fromNow()}` : '默认 7 天'}
+                    <Clock className="mr-1 h-3 w-3" /> {meta.expires_at ? `有效期 ${dayjs(meta.expires_at).fromNow()}` : '永久有效'}
                   </Badge>
                   {meta.max_views && (
                     <Badge variant="secondary" className="bg-slate-100 text-slate-700">
                       <Eye className="mr-1 h-3 w-3" /> 剩余 {meta.remaining_views ?? 0}/{meta.max_views} 次
                     </Badge>
                   )}
-                  {meta.allow_username && (
+                  {recipients && (
                     <Badge variant="secondary" className="bg-slate-100 text-slate-700">
-                      <Users className="mr-1 h-3 w-3" /> 仅 {meta.allow_username}
+                      <Users className="mr-1 h-3 w-3" /> 仅 {recipients}
                     </Badge>
                   )}
                   {meta.requires_login && (
@@ -314,6 +434,11 @@ const SharePreview = () => {
                       <Lock className="mr-1 h-3 w-3" /> 需登录
                     </Badge>
                   )}
+                  {meta.burn_after_reading && (
+                    <Badge variant="secondary" className="bg-orange-100 text-orange-700">
+                      <Flame className="mr-1 h-3 w-3" /> 阅后即焚
+                    </Badge>
+                  )}
                 </div>
 
                 <div className="overflow-hidden rounded-2xl border border-slate-200 bg-white">
@@ -325,6 +450,35 @@ const SharePreview = () => {
                     renderPreview()
                   )}
                 </div>
+
+                {meta.bundle && (
+                  <div className="mt-4 divide-y divide-slate-100 rounded-2xl border border-slate-200">
+                    {(meta.items || []).map((item) => (
+                      <div key={item.id} className="flex items-center justify-between gap-3 px-4 py-2 text-sm">
+                        <span className="flex min-w-0 items-center gap-2 text-slate-700" title={item.path}>
+                          {activeItem?.id === item.id ? (
+                            <Package className="h-4 w-4 flex-shrink-0 text-primary" />
+                          ) : (
+                            <FileText className="h-4 w-4 flex-shrink-0 text-slate-400" />
+                          )}
+                          <span className="truncate">{item.path}</span>
+                        </span>
+                        <span className="flex flex-shrink-0 items-center gap-2">
+                          <span className="text-xs text-slate-500">{(item.size / 1024).toFixed(1)} KB</span>
+                          <Button
+                            variant="outline"
+                            size="sm"
+                            className="h-7 gap-1 px-2 text-xs"
+                            onClick={() => setActiveItem(item)}
+                            disabled={!item.preview_available || previewLoading}
+                          >
+                            <Eye className="h-3 w-3" /> 预览
+                          </Button>
+                        </span>
+                      </div>
+                    ))}
+                  </div>
+                )}
               </CardContent>
             </Card>
 